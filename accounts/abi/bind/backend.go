@@ -104,6 +104,14 @@ type DeployBackend interface {
 	CodeAt(ctx context.Context, account common.Address, blockNumber *big.Int) ([]byte, error)
 }
 
+// PendingReceiptReader is implemented by backends executing the transactions as
+// soon as they are sent, which know the receipt of a transaction before it is
+// mined. DeployContract uses it to learn the address of a new contract without
+// waiting for the deployment to be mined.
+type PendingReceiptReader interface {
+	PendingTransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error)
+}
+
 // ContractBackend defines the methods needed to work with contracts on a read-write basis.
 type ContractBackend interface {
 	ContractCaller
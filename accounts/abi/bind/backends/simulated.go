@@ -23,9 +23,8 @@ import (
 	"sync"
 	"time"
 
-	"github.com/sero-cash/go-sero/zero/txs/assets"
-
 	"github.com/sero-cash/go-sero"
+	"github.com/sero-cash/go-sero/accounts/abi/bind"
 	"github.com/sero-cash/go-sero/common"
 	"github.com/sero-cash/go-sero/common/math"
 	"github.com/sero-cash/go-sero/consensus/ethash"
@@ -40,10 +39,13 @@ import (
 	"github.com/sero-cash/go-sero/rpc"
 	"github.com/sero-cash/go-sero/sero/filters"
 	"github.com/sero-cash/go-sero/serodb"
+	"github.com/sero-cash/go-sero/zero/txs/assets"
+	"github.com/sero-cash/go-sero/zero/utils"
 )
 
 var errBlockNumberUnsupported = errors.New("SimulatedBackend cannot access blocks other than the latest block")
 var errGasEstimationFailed = errors.New("gas required exceeds allowance or always failing transaction")
+var errUnknownTransaction = errors.New("transaction not sent through the simulated backend")
 
 // SimulatedBackend implements bind.ContractBackend, simulating a blockchain in
 // the background. Its main purpose is to allow easily testing contract bindings.
@@ -55,8 +57,12 @@ type SimulatedBackend struct {
 	pendingBlock *types.Block   // Currently pending block that will be imported on request
 	pendingState *state.StateDB // Currently pending state that will be the active on on request
 
+	pendingReceipts map[common.Hash]*types.Receipt // Receipts of the transactions of the pending block
+
 	events *filters.EventSystem // Event system for filtering log events live
 
+	systemCalls map[common.Hash][]*bind.SystemCall // System calls made by the executed transactions
+
 	config *params.ChainConfig
 }
 
@@ -73,6 +79,8 @@ func NewSimulatedBackend(alloc core.GenesisAlloc) *SimulatedBackend {
 		blockchain: blockchain,
 		config:     genesis.Config,
 		events:     filters.NewEventSystem(new(event.TypeMux), &filterBackend{database, blockchain}, false),
+
+		systemCalls: make(map[common.Hash][]*bind.SystemCall),
 	}
 	backend.rollback()
 	return backend
@@ -104,6 +112,7 @@ func (b *SimulatedBackend) rollback() {
 
 	b.pendingBlock = blocks[0]
 	b.pendingState, _ = state.New(statedb.Database(), b.pendingBlock.Header())
+	b.pendingReceipts = make(map[common.Hash]*types.Receipt)
 }
 
 // CodeAt returns the code associated with a certain account in the blockchain.
@@ -118,8 +127,14 @@ func (b *SimulatedBackend) CodeAt(ctx context.Context, contract common.Address,
 	return statedb.GetCode(contract), nil
 }
 
-// BalanceAt returns the wei balance of a certain account in the blockchain.
+// BalanceAt returns the SERO balance of a certain account in the blockchain.
 func (b *SimulatedBackend) BalanceAt(ctx context.Context, contract common.Address, blockNumber *big.Int) (*big.Int, error) {
+	return b.TokenBalanceAt(ctx, contract, "SERO", blockNumber)
+}
+
+// TokenBalanceAt returns the balance of a certain account in the blockchain for
+// the given token currency.
+func (b *SimulatedBackend) TokenBalanceAt(ctx context.Context, contract common.Address, currency string, blockNumber *big.Int) (*big.Int, error) {
 	b.mu.Lock()
 	defer b.mu.Unlock()
 
@@ -127,7 +142,20 @@ func (b *SimulatedBackend) BalanceAt(ctx context.Context, contract common.Addres
 		return nil, errBlockNumberUnsupported
 	}
 	statedb, _ := b.blockchain.State()
-	return statedb.GetBalance(contract, "sero"), nil
+	return statedb.GetBalance(contract, currency), nil
+}
+
+// BalancesAt returns the balances of all the tokens held by a certain account
+// in the blockchain.
+func (b *SimulatedBackend) BalancesAt(ctx context.Context, contract common.Address, blockNumber *big.Int) (map[string]*big.Int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if blockNumber != nil && blockNumber.Cmp(b.blockchain.CurrentBlock().Number()) != 0 {
+		return nil, errBlockNumberUnsupported
+	}
+	statedb, _ := b.blockchain.State()
+	return statedb.Balances(contract), nil
 }
 
 // NonceAt returns the nonce of a certain account in the blockchain.
@@ -161,6 +189,19 @@ func (b *SimulatedBackend) TransactionReceipt(ctx context.Context, txHash common
 	return receipt, nil
 }
 
+// PendingTransactionReceipt implements bind.PendingReceiptReader, returning the
+// receipt of a transaction of the pending block.
+func (b *SimulatedBackend) PendingTransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	receipt, ok := b.pendingReceipts[txHash]
+	if !ok {
+		return nil, errUnknownTransaction
+	}
+	return receipt, nil
+}
+
 // PendingCodeAt returns the code associated with an account in the pending state.
 func (b *SimulatedBackend) PendingCodeAt(ctx context.Context, contract common.Address) ([]byte, error) {
 	b.mu.Lock()
@@ -275,7 +316,7 @@ func (b *SimulatedBackend) callContract(ctx context.Context, call sero.CallMsg,
 	}
 	// Set infinite balance to the fake caller account.
 	from := statedb.GetOrNewStateObject(call.From)
-	from.SetBalance("sero", math.MaxBig256)
+	from.SetBalance("SERO", math.MaxBig256)
 	// Execute the call.
 	msg := callmsg{call}
 
@@ -288,6 +329,43 @@ func (b *SimulatedBackend) callContract(ctx context.Context, call sero.CallMsg,
 	return core.NewStateTransition(vmenv, msg, gaspool).TransitionDb()
 }
 
+// SendTransaction updates the pending block to include the given transaction.
+// It panics if the transaction is invalid.
+func (b *SimulatedBackend) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	blocks, receipts := core.GenerateChain(b.config, b.blockchain.CurrentBlock(), ethash.NewFaker(), b.database, 1, func(number int, block *core.BlockGen) {
+		for _, tx := range b.pendingBlock.Transactions() {
+			block.AddTxWithChain(b.blockchain, tx)
+		}
+		recorder := newSystemCallRecorder(tx.Hash())
+		block.AddTxWithConfig(b.blockchain, tx, vm.Config{Debug: true, Tracer: recorder})
+		b.systemCalls[tx.Hash()] = recorder.calls
+	})
+	statedb, _ := b.blockchain.State()
+
+	b.pendingBlock = blocks[0]
+	b.pendingState, _ = state.New(statedb.Database(), b.pendingBlock.Header())
+	for _, receipt := range receipts[0] {
+		b.pendingReceipts[receipt.TxHash] = receipt
+	}
+	return nil
+}
+
+// SystemCalls implements bind.SystemCallReader, returning the SERO system calls
+// made by a transaction sent through the simulated backend.
+func (b *SimulatedBackend) SystemCalls(ctx context.Context, txHash common.Hash) ([]*bind.SystemCall, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	calls, ok := b.systemCalls[txHash]
+	if !ok {
+		return nil, errUnknownTransaction
+	}
+	return calls, nil
+}
+
 // FilterLogs executes a log filter operation, blocking during execution and
 // returning all the results in one batch.
 //
@@ -389,10 +467,26 @@ func (m callmsg) Gas() uint64                    { return m.CallMsg.Gas }
 func (m callmsg) Value() *big.Int                { return m.CallMsg.Value }
 func (m callmsg) Data() []byte                   { return m.CallMsg.Data }
 func (m callmsg) Fee() assets.Token {
-	return assets.Token{}
+	fee := new(big.Int).Mul(m.CallMsg.GasPrice, new(big.Int).SetUint64(m.CallMsg.Gas))
+	return assets.Token{
+		Currency: utils.CurrencyToUint256("SERO"),
+		Value:    utils.U256(*fee),
+	}
+}
+func (m callmsg) Asset() *assets.Asset {
+	if m.CallMsg.Asset != nil {
+		return m.CallMsg.Asset
+	}
+	asset := &assets.Asset{}
+	if m.CallMsg.Value != nil && m.CallMsg.Value.Sign() > 0 {
+		asset.Tkn = &assets.Token{
+			Currency: utils.CurrencyToUint256("SERO"),
+			Value:    utils.U256(*m.CallMsg.Value),
+		}
+	}
+	return asset
 }
-func (m callmsg) Asset() *assets.Asset { return &assets.Asset{} }
-func (m callmsg) TxHash() common.Hash  { return common.Hash{} }
+func (m callmsg) TxHash() common.Hash { return common.Hash{} }
 
 // filterBackend implements filters.Backend to support filtering for logs without
 // taking bloom-bits acceleration structures into account.
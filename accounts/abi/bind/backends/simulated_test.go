@@ -0,0 +1,73 @@
+package backends_test
+
+import (
+	"context"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/sero-cash/go-czero-import/keys"
+	"github.com/sero-cash/go-sero/accounts/abi"
+	"github.com/sero-cash/go-sero/accounts/abi/bind"
+	"github.com/sero-cash/go-sero/accounts/abi/bind/backends"
+	"github.com/sero-cash/go-sero/common"
+	"github.com/sero-cash/go-sero/core"
+	"github.com/sero-cash/go-sero/core/types"
+	"github.com/sero-cash/go-sero/zero/txs/stx"
+	"github.com/sero-cash/go-sero/zero/txs/tx"
+)
+
+// answerABI and answerBin describe a contract answering 42 to every call.
+const (
+	answerABI = `[{"constant":true,"inputs":[],"name":"answer","outputs":[{"name":"","type":"uint256"}],"type":"function"}]`
+	answerBin = "600a600c600039600a6000f3" + "602a60005260206000f3"
+)
+
+// testEncrypter builds the public transaction carrying the call, without
+// spending any input.
+func testEncrypter(from common.Address, rawTx *types.Transaction, txt *tx.T) (*types.Transaction, error) {
+	st := &stx.T{Ehash: txt.Ehash, From: *from.ToPKr(), Fee: txt.Fee}
+	for _, out := range txt.Outs {
+		st.Desc_O.Outs = append(st.Desc_O.Outs, stx.Out_O{Addr: out.Addr, Asset: out.Asset})
+	}
+	return rawTx.WithEncrypt(st)
+}
+
+func TestSimulatedDeployContract(t *testing.T) {
+	sim := backends.NewSimulatedBackend(core.GenesisAlloc{})
+	parsed, err := abi.JSON(strings.NewReader(answerABI))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkr := keys.PKr{1}
+	from := common.BytesToAddress(pkr[:])
+	opts := &bind.TransactOpts{From: from, Encrypter: testEncrypter, GasLimit: 1000000}
+
+	address, deployTx, contract, err := bind.DeployContract(opts, parsed, common.FromHex(answerBin), sim)
+	if err != nil {
+		t.Fatalf("deploy: %v", err)
+	}
+	if address == (common.Address{}) {
+		t.Fatalf("deploy returned an empty address")
+	}
+	receipt, err := sim.PendingTransactionReceipt(context.Background(), deployTx.Hash())
+	if err != nil || receipt.ContractAddress != address {
+		t.Fatalf("deployed at %v, pending receipt %v, %v", address, receipt, err)
+	}
+	if code, _ := sim.PendingCodeAt(context.Background(), address); len(code) == 0 {
+		t.Fatalf("no code at the deployed address")
+	}
+
+	var answer *big.Int
+	if err := contract.Call(&bind.CallOpts{Pending: true, From: from}, &answer, "answer"); err != nil {
+		t.Fatalf("call the deployed contract: %v", err)
+	}
+	if answer.Int64() != 42 {
+		t.Fatalf("answer %v, want 42", answer)
+	}
+
+	sim.Rollback()
+	if _, err := sim.PendingTransactionReceipt(context.Background(), deployTx.Hash()); err == nil {
+		t.Fatalf("got the pending receipt of a rolled back transaction")
+	}
+}
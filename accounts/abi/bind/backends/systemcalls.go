@@ -0,0 +1,80 @@
+// copyright 2018 The sero.cash Authors
+// This file is part of the go-sero library.
+//
+// The go-sero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-sero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-sero library. If not, see <http://www.gnu.org/licenses/>.
+
+package backends
+
+import (
+	"time"
+
+	"github.com/sero-cash/go-sero/accounts/abi/bind"
+	"github.com/sero-cash/go-sero/common"
+	"github.com/sero-cash/go-sero/core/vm"
+	"github.com/sero-cash/go-sero/zero/txs/assets"
+)
+
+// systemCallRecorder is a vm.Tracer collecting the SERO system calls issued by
+// a transaction. The EVM consumes these LOG instructions without producing a
+// log, so tracing is the only way to observe them.
+type systemCallRecorder struct {
+	txHash common.Hash
+	calls  []*bind.SystemCall
+}
+
+func newSystemCallRecorder(txHash common.Hash) *systemCallRecorder {
+	return &systemCallRecorder{txHash: txHash}
+}
+
+func (r *systemCallRecorder) CaptureStart(from common.Address, to common.Address, call bool, input []byte, gas uint64, asset *assets.Asset) error {
+	return nil
+}
+
+func (r *systemCallRecorder) CaptureState(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, memory *vm.Memory, stack *vm.Stack, contract *vm.Contract, depth int, err error) error {
+	if err != nil || op < vm.LOG1 || op > vm.LOG4 || len(stack.Data()) < 3 {
+		return nil
+	}
+	topic := common.BigToHash(stack.Back(2))
+	if !bind.IsSystemTopic(topic) {
+		return nil
+	}
+	mStart, mSize := stack.Back(0), stack.Back(1)
+	if !mStart.IsInt64() || !mSize.IsInt64() {
+		return nil
+	}
+	data := memory.Get(mStart.Int64(), mSize.Int64())
+	resolve := func(addr common.ContractAddress) common.Address {
+		return contract.GetNonceAddress(env.StateDB, addr)
+	}
+	call, e := bind.DecodeSystemCall(contract.Address(), topic, data, memory.Data(), resolve)
+	if e != nil {
+		// Malformed payloads are rejected by the interpreter itself
+		return nil
+	}
+	call.TxHash = r.txHash
+	r.calls = append(r.calls, call)
+	return nil
+}
+
+func (r *systemCallRecorder) CaptureFault(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, memory *vm.Memory, stack *vm.Stack, contract *vm.Contract, depth int, err error) error {
+	return nil
+}
+
+func (r *systemCallRecorder) CaptureEnd(output []byte, gasUsed uint64, t time.Duration, err error) error {
+	if err != nil {
+		// A reverted transaction rolls back every system call it made
+		r.calls = nil
+	}
+	return nil
+}
@@ -18,16 +18,26 @@ package bind
 
 import (
 	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
 	"math/big"
 
+	"github.com/sero-cash/go-czero-import/keys"
 	sero "github.com/sero-cash/go-sero"
 	"github.com/sero-cash/go-sero/accounts/abi"
 	"github.com/sero-cash/go-sero/common"
 	"github.com/sero-cash/go-sero/core/types"
 	"github.com/sero-cash/go-sero/event"
+	"github.com/sero-cash/go-sero/zero/txs/assets"
 	"github.com/sero-cash/go-sero/zero/txs/tx"
+	"github.com/sero-cash/go-sero/zero/utils"
 )
 
+// ErrNoDeployReceipt is returned by DeployContract when the backend can't report
+// the address of the deployed contract.
+var ErrNoDeployReceipt = errors.New("no receipt for the contract deployment")
+
 // SignerFn is a abi function callback when a contract requires a method to
 // sign the transaction before submission.
 type EncrypterFn func(common.Address, *types.Transaction, *tx.T) (*types.Transaction, error)
@@ -36,6 +46,7 @@ type EncrypterFn func(common.Address, *types.Transaction, *tx.T) (*types.Transac
 type CallOpts struct {
 	Pending bool           // Whether to operate on the pending state or the last known one
 	From    common.Address // Optional the sender address, otherwise the first account is used
+	Asset   *assets.Asset  // Optional token and ticket attached to the call (nil = no value)
 
 	Context context.Context // Network context to support cancellation and timeouts (nil = no timeout)
 }
@@ -47,13 +58,29 @@ type TransactOpts struct {
 	Nonce     *big.Int       // Nonce to use for the transaction execution (nil = use pending state)
 	Encrypter EncrypterFn    // Method to use for signing the transaction (mandatory)
 
-	Value    *big.Int // Funds to transfer along along the transaction (nil = 0 = no funds)
-	GasPrice *big.Int // Gas price to use for the transaction execution (nil = gas price oracle)
-	GasLimit uint64   // Gas limit to set for the transaction execution (0 = estimate)
+	Value    *big.Int      // SERO funds to transfer along along the transaction (nil = 0 = no funds)
+	Asset    *assets.Asset // Token and ticket to transfer along the transaction, overrides Value
+	GasPrice *big.Int      // Gas price to use for the transaction execution (nil = gas price oracle)
+	GasLimit uint64        // Gas limit to set for the transaction execution (0 = estimate)
 
 	Context context.Context // Network context to support cancellation and timeouts (nil = no timeout)
 }
 
+// asset returns the value attached to the transaction, built from Value when no
+// explicit Asset is given.
+func (opts *TransactOpts) asset() assets.Asset {
+	if opts.Asset != nil {
+		return opts.Asset.Clone()
+	}
+	if opts.Value != nil && opts.Value.Sign() > 0 {
+		return assets.Asset{Tkn: &assets.Token{
+			Currency: utils.CurrencyToUint256("SERO"),
+			Value:    utils.U256(*opts.Value),
+		}}
+	}
+	return assets.Asset{}
+}
+
 // FilterOpts is the collection of options to fine tune filtering for events
 // within a bound contract.
 type FilterOpts struct {
@@ -81,6 +108,58 @@ type BoundContract struct {
 	filterer   ContractFilterer   // Event filtering to interact with the blockchain
 }
 
+// DeployContract deploys a contract onto the Sero blockchain and binds the
+// deployment address with a Go wrapper. The address of a SERO contract depends
+// on the contract nonce at execution time, so it's read back from the receipt
+// of the deployment: the pending one if the backend implements
+// PendingReceiptReader, otherwise the mined one, waiting for it until the
+// context of opts is canceled.
+func DeployContract(opts *TransactOpts, abi abi.ABI, bytecode []byte, backend ContractBackend, params ...interface{}) (common.Address, *types.Transaction, *BoundContract, error) {
+	c := NewBoundContract(common.Address{}, abi, backend, backend, backend)
+
+	input, err := c.abi.Pack("", params...)
+	if err != nil {
+		return common.Address{}, nil, nil, err
+	}
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return common.Address{}, nil, nil, err
+	}
+	tx, err := c.transact(opts, nil, contractInput(salt, append(bytecode, input...)))
+	if err != nil {
+		return common.Address{}, nil, nil, err
+	}
+	address, err := deployedAddress(ensureContext(opts.Context), backend, tx)
+	if err != nil {
+		return common.Address{}, tx, nil, err
+	}
+	c.address = address
+	return address, tx, c, nil
+}
+
+// deployedAddress returns the address of the contract created by tx.
+func deployedAddress(ctx context.Context, backend ContractBackend, tx *types.Transaction) (common.Address, error) {
+	var (
+		receipt *types.Receipt
+		err     error
+	)
+	switch b := backend.(type) {
+	case PendingReceiptReader:
+		receipt, err = b.PendingTransactionReceipt(ctx, tx.Hash())
+	case DeployBackend:
+		receipt, err = WaitMined(ctx, b, tx)
+	default:
+		return common.Address{}, ErrNoDeployReceipt
+	}
+	if err != nil {
+		return common.Address{}, err
+	}
+	if receipt == nil || receipt.ContractAddress == (common.Address{}) {
+		return common.Address{}, ErrNoDeployReceipt
+	}
+	return receipt.ContractAddress, nil
+}
+
 // NewBoundContract creates a low level contract interface through which calls
 // and transactions may be made through.
 func NewBoundContract(address common.Address, abi abi.ABI, caller ContractCaller, transactor ContractTransactor, filterer ContractFilterer) *BoundContract {
@@ -93,6 +172,27 @@ func NewBoundContract(address common.Address, abi abi.ABI, caller ContractCaller
 	}
 }
 
+// pack packs the call of the method with params as the input of a call made by
+// an account to the bound contract.
+func (c *BoundContract) pack(method string, params ...interface{}) ([]byte, error) {
+	input, err := c.abi.Pack(method, params...)
+	if err != nil {
+		return nil, err
+	}
+	return contractInput(c.address[:16], input), nil
+}
+
+// contractInput prepends to data the header the EVM expects in the input of
+// the calls made by accounts: 16 bytes salting the address of a new contract,
+// or the first 16 bytes of the called one, then the size of the table of the
+// addresses referenced by the call. The table is left empty, the ABI values
+// only carry the contract addresses the EVM resolves from its state.
+func contractInput(salt []byte, data []byte) []byte {
+	input := make([]byte, 18, 18+len(data))
+	copy(input[:16], salt)
+	return append(input, data...)
+}
+
 // Call invokes the (constant) contract method with params as input values and
 // sets the output to result. The result type might be a single field for simple
 // returns, a slice of interfaces for anonymous returns and a struct for named
@@ -103,12 +203,12 @@ func (c *BoundContract) Call(opts *CallOpts, result interface{}, method string,
 		opts = new(CallOpts)
 	}
 	// Pack the input, call and unpack the results
-	input, err := c.abi.Pack(method, params...)
+	input, err := c.pack(method, params...)
 	if err != nil {
 		return err
 	}
 	var (
-		msg    = sero.CallMsg{From: opts.From, To: &c.address, Data: input}
+		msg    = sero.CallMsg{From: opts.From, To: &c.address, Asset: opts.Asset, Data: input}
 		ctx    = ensureContext(opts.Context)
 		code   []byte
 		output []byte
@@ -144,6 +244,84 @@ func (c *BoundContract) Call(opts *CallOpts, result interface{}, method string,
 	return c.abi.Unpack(result, method, output)
 }
 
+// Transact invokes the (paid) contract method with params as input values.
+func (c *BoundContract) Transact(opts *TransactOpts, method string, params ...interface{}) (*types.Transaction, error) {
+	input, err := c.pack(method, params...)
+	if err != nil {
+		return nil, err
+	}
+	return c.transact(opts, &c.address, input)
+}
+
+// Transfer initiates a plain transaction to move funds to the contract, calling
+// its default method if one is available.
+func (c *BoundContract) Transfer(opts *TransactOpts) (*types.Transaction, error) {
+	return c.transact(opts, &c.address, nil)
+}
+
+// transact executes an actual transaction invocation, first deriving any missing
+// authorization fields, and then scheduling the transaction for execution. The
+// attached asset is carried as the single public output of the transaction.
+func (c *BoundContract) transact(opts *TransactOpts, contract *common.Address, input []byte) (*types.Transaction, error) {
+	var err error
+
+	if opts.Encrypter == nil {
+		return nil, errors.New("no encrypter to authorize the transaction with")
+	}
+	asset := opts.asset()
+
+	gasPrice := opts.GasPrice
+	if gasPrice == nil {
+		gasPrice, err = c.transactor.SuggestGasPrice(ensureContext(opts.Context))
+		if err != nil {
+			return nil, fmt.Errorf("failed to suggest gas price: %v", err)
+		}
+	}
+	gasLimit := opts.GasLimit
+	if gasLimit == 0 {
+		// Gas estimation cannot succeed without code for method invocations
+		if contract != nil {
+			if code, err := c.transactor.PendingCodeAt(ensureContext(opts.Context), c.address); err != nil {
+				return nil, err
+			} else if len(code) == 0 {
+				return nil, ErrNoCode
+			}
+		}
+		// If the contract surely has code (or code is not needed), estimate the transaction
+		msg := sero.CallMsg{From: opts.From, To: contract, Value: opts.Value, Asset: &asset, Data: input}
+		gasLimit, err = c.transactor.EstimateGas(ensureContext(opts.Context), msg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to estimate gas needed: %v", err)
+		}
+	}
+
+	fromRnd := keys.Uint256{}
+	var pkr keys.PKr
+	if contract == nil {
+		copy(fromRnd[:16], input[:16])
+	} else {
+		copy(fromRnd[:16], contract[:16])
+		pkr = *contract.ToPKr()
+	}
+
+	rawTx := types.NewTransaction(gasPrice, gasLimit, input)
+	fee := assets.Token{
+		Currency: utils.CurrencyToUint256("SERO"),
+		Value:    utils.U256(*new(big.Int).Mul(gasPrice, new(big.Int).SetUint64(gasLimit))),
+	}
+	out := &tx.Out{Addr: pkr, Asset: asset, IsZ: false}
+	txt := types.NewTxt(&fromRnd, rawTx.Ehash(), fee, out, nil, nil, nil)
+
+	encryptedTx, err := opts.Encrypter(opts.From, rawTx, txt)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.transactor.SendTransaction(ensureContext(opts.Context), encryptedTx); err != nil {
+		return nil, err
+	}
+	return encryptedTx, nil
+}
+
 // FilterLogs filters contract logs for past blocks, returning the necessary
 // channels to construct a strongly typed bound iterator on top of them.
 func (c *BoundContract) FilterLogs(opts *FilterOpts, name string, query ...[]interface{}) (chan types.Log, event.Subscription, error) {
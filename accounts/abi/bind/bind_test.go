@@ -0,0 +1,116 @@
+package bind
+
+import (
+	"go/parser"
+	"go/token"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/sero-cash/go-sero/accounts/abi"
+	"github.com/sero-cash/go-sero/common"
+	"github.com/sero-cash/go-sero/core/vm"
+)
+
+const tokenABI = `[
+	{"constant":false,"inputs":[{"name":"to","type":"address"},{"name":"value","type":"uint256"}],"name":"transfer","outputs":[{"name":"","type":"bool"}],"type":"function"},
+	{"constant":true,"inputs":[{"name":"owner","type":"address"}],"name":"balanceOf","outputs":[{"name":"","type":"uint256"}],"type":"function"},
+	{"anonymous":false,"inputs":[{"indexed":true,"name":"from","type":"address"},{"indexed":false,"name":"value","type":"uint256"}],"name":"Transfer","type":"event"}
+]`
+
+func TestBindGo(t *testing.T) {
+	code, err := Bind([]string{"Token"}, []string{tokenABI}, []string{"600a600c600039600a6000f3602a60005260206000f3"}, "token", LangGo)
+	if err != nil {
+		t.Fatalf("bind: %v", err)
+	}
+	if _, err := parser.ParseFile(token.NewFileSet(), "token.go", code, 0); err != nil {
+		t.Fatalf("generated binding doesn't parse: %v\n%s", err, code)
+	}
+	for _, want := range []string{
+		"func DeployToken(auth *bind.TransactOpts, backend bind.ContractBackend) (common.Address, *types.Transaction, *Token, error)",
+		"func NewToken(address common.Address, backend bind.ContractBackend) (*Token, error)",
+		"Transfer(opts *bind.TransactOpts, to common.ContractAddress, value *big.Int) (*types.Transaction, error)",
+		"BalanceOf(opts *bind.CallOpts, owner common.ContractAddress) (*big.Int, error)",
+		"FilterTransfer(opts *bind.FilterOpts, from []common.ContractAddress) (*TokenTransferIterator, error)",
+		"SystemCalls(reader bind.SystemCallReader, txHash common.Hash) ([]*bind.SystemCall, error)",
+	} {
+		if !strings.Contains(code, want) {
+			t.Errorf("generated binding misses %q", want)
+		}
+	}
+}
+
+func TestContractAddressTopics(t *testing.T) {
+	parsed, err := abi.JSON(strings.NewReader(tokenABI))
+	if err != nil {
+		t.Fatal(err)
+	}
+	from := common.BytesToContractAddress([]byte{1, 2, 3})
+	topics, err := makeTopics([]interface{}{from})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if topics[0][0] != common.BytesToHash(from[:]) {
+		t.Fatalf("topic %x, want the left padded address %x", topics[0][0], from)
+	}
+
+	var event struct {
+		From  common.ContractAddress
+		Value *big.Int
+	}
+	var indexed abi.Arguments
+	for _, arg := range parsed.Events["Transfer"].Inputs {
+		if arg.Indexed {
+			indexed = append(indexed, arg)
+		}
+	}
+	if err := parseTopics(&event, indexed, topics[0]); err != nil {
+		t.Fatal(err)
+	}
+	if event.From != from {
+		t.Fatalf("parsed %x, want %x", event.From, from)
+	}
+}
+
+func TestContractInput(t *testing.T) {
+	address := common.Address{1, 2, 3}
+	c := NewBoundContract(address, abi.ABI{}, nil, nil, nil)
+	input := contractInput(address[:16], []byte{0xaa})
+	if len(input) != 19 || input[0] != 1 || input[16] != 0 || input[17] != 0 || input[18] != 0xaa {
+		t.Fatalf("input %x", input)
+	}
+	if _, err := c.pack("missing"); err == nil {
+		t.Fatalf("packed an unknown method")
+	}
+}
+
+func TestDecodeSystemCallResolvesRecipient(t *testing.T) {
+	var topic common.Hash
+	for hash, name := range vm.SystemTopics {
+		if name == "send" {
+			topic = hash
+		}
+	}
+	// memory holds the "sero" currency and the empty category
+	memory := make([]byte, 128)
+	memory[31] = 4
+	copy(memory[32:], "sero")
+	data := make([]byte, 160)
+	data[31] = 7
+	data[63] = 0  // currency at 0
+	data[95] = 10 // amount
+	data[127] = 64
+	to := common.Address{9}
+	call, err := DecodeSystemCall(common.Address{1}, topic, data, memory, func(addr common.ContractAddress) common.Address {
+		if addr != common.BytesToContractAddress([]byte{7}) {
+			t.Fatalf("resolving %x", addr)
+		}
+		return to
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if call.To != to || call.Currency != "SERO" || call.Amount.Int64() != 10 {
+		t.Fatalf("decoded %+v", call)
+	}
+}
@@ -0,0 +1,167 @@
+// copyright 2018 The sero.cash Authors
+// This file is part of the go-sero library.
+//
+// The go-sero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-sero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-sero library. If not, see <http://www.gnu.org/licenses/>.
+
+package bind
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/sero-cash/go-sero/common"
+	"github.com/sero-cash/go-sero/core/vm"
+)
+
+// ErrNotSystemCall is returned when decoding a LOG whose first topic is not
+// one of the SERO system call topics.
+var ErrNotSystemCall = errors.New("not a sero system call")
+
+// SystemCall is a decoded SERO system call. Contracts trigger these through
+// LOG instructions with a reserved first topic, which the EVM intercepts
+// instead of recording a log, so they never show up in receipts.
+type SystemCall struct {
+	TxHash   common.Hash    // Transaction during which the call was made
+	Contract common.Address // Contract issuing the system call
+	Topic    common.Hash    // Reserved topic selecting the call
+	Name     string         // Name of the call (issueToken, send, ...)
+
+	To       common.Address // Recipient of send and allotTicket
+	Currency string         // Token currency involved, if any
+	Amount   *big.Int       // Token amount issued or sent
+	Category string         // Ticket category involved, if any
+	Ticket   common.Hash    // Ticket value involved, if any
+}
+
+// AddressResolver maps the 20 bytes contract address seen by the EVM to the
+// SERO address it stands for.
+type AddressResolver func(common.ContractAddress) common.Address
+
+// SystemCallReader is implemented by backends able to report the system calls
+// executed by a transaction.
+type SystemCallReader interface {
+	SystemCalls(ctx context.Context, txHash common.Hash) ([]*SystemCall, error)
+}
+
+// IsSystemTopic reports whether topic selects a SERO system call.
+func IsSystemTopic(topic common.Hash) bool {
+	_, ok := vm.SystemTopics[topic]
+	return ok
+}
+
+// DecodeSystemCall decodes the arguments of a system call. data is the LOG
+// payload and memory the full memory of the calling frame, which holds the
+// string arguments referenced by offset from data. resolve maps the recipient
+// of the call to its SERO address.
+func DecodeSystemCall(contract common.Address, topic common.Hash, data []byte, memory []byte, resolve AddressResolver) (*SystemCall, error) {
+	name, ok := vm.SystemTopics[topic]
+	if !ok {
+		return nil, ErrNotSystemCall
+	}
+	call := &SystemCall{Contract: contract, Topic: topic, Name: name}
+
+	var err error
+	switch name {
+	case "issueToken":
+		if len(data) != 64 {
+			return nil, errSystemCallLength(name, len(data))
+		}
+		if call.Currency, err = memoryString(memory, data[0:32]); err != nil {
+			return nil, err
+		}
+		call.Amount = new(big.Int).SetBytes(data[32:64])
+	case "send":
+		if len(data) != 160 {
+			return nil, errSystemCallLength(name, len(data))
+		}
+		call.To = resolve(common.BytesToContractAddress(data[12:32]))
+		if call.Currency, err = memoryString(memory, data[32:64]); err != nil {
+			return nil, err
+		}
+		call.Amount = new(big.Int).SetBytes(data[64:96])
+		if call.Category, err = memoryString(memory, data[96:128]); err != nil {
+			return nil, err
+		}
+		call.Ticket = common.BytesToHash(data[128:160])
+	case "allotTicket":
+		if len(data) != 96 {
+			return nil, errSystemCallLength(name, len(data))
+		}
+		call.Ticket = common.BytesToHash(data[0:32])
+		call.To = resolve(common.BytesToContractAddress(data[44:64]))
+		if call.Category, err = memoryString(memory, data[64:96]); err != nil {
+			return nil, err
+		}
+	case "setCallValues":
+		if len(data) != 128 {
+			return nil, errSystemCallLength(name, len(data))
+		}
+		if call.Currency, err = memoryString(memory, data[0:32]); err != nil {
+			return nil, err
+		}
+		call.Amount = new(big.Int).SetBytes(data[32:64])
+		if call.Category, err = memoryString(memory, data[64:96]); err != nil {
+			return nil, err
+		}
+		call.Ticket = common.BytesToHash(data[96:128])
+	case "balanceOf":
+		if len(data) != 32 {
+			return nil, errSystemCallLength(name, len(data))
+		}
+		if call.Currency, err = memoryString(memory, data[0:32]); err != nil {
+			return nil, err
+		}
+	}
+	call.Currency = strings.ToUpper(call.Currency)
+	call.Category = strings.ToUpper(call.Category)
+	return call, nil
+}
+
+// memoryString reads an ABI encoded string located at the offset stored in
+// word.
+func memoryString(memory []byte, word []byte) (string, error) {
+	offset := new(big.Int).SetBytes(word)
+	if !offset.IsUint64() || offset.Uint64()+32 > uint64(len(memory)) {
+		return "", fmt.Errorf("string offset %v out of memory bounds", offset)
+	}
+	start := offset.Uint64()
+	length := new(big.Int).SetBytes(memory[start : start+32])
+	if !length.IsUint64() || start+32+length.Uint64() > uint64(len(memory)) {
+		return "", fmt.Errorf("string length %v out of memory bounds", length)
+	}
+	return string(memory[start+32 : start+32+length.Uint64()]), nil
+}
+
+func errSystemCallLength(name string, length int) error {
+	return fmt.Errorf("invalid %s system call payload length %d", name, length)
+}
+
+// SystemCalls retrieves the system calls issued by the bound contract during
+// the execution of the given transaction.
+func (c *BoundContract) SystemCalls(reader SystemCallReader, txHash common.Hash) ([]*SystemCall, error) {
+	calls, err := reader.SystemCalls(context.Background(), txHash)
+	if err != nil {
+		return nil, err
+	}
+	var result []*SystemCall
+	for _, call := range calls {
+		if call.Contract == c.address {
+			result = append(result, call)
+		}
+	}
+	return result, nil
+}
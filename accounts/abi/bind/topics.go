@@ -38,8 +38,8 @@ func makeTopics(query ...[]interface{}) ([][]common.Hash, error) {
 			switch rule := rule.(type) {
 			case common.Hash:
 				copy(topic[:], rule[:])
-			case common.Address:
-				copy(topic[:], rule.Bytes())
+			case common.ContractAddress:
+				copy(topic[common.HashLength-len(rule):], rule[:])
 			case *big.Int:
 				blob := rule.Bytes()
 				copy(topic[common.HashLength-len(blob):], blob)
@@ -99,7 +99,7 @@ func makeTopics(query ...[]interface{}) ([][]common.Hash, error) {
 // Big batch of reflect types for topic reconstruction.
 var (
 	reflectHash    = reflect.TypeOf(common.Hash{})
-	reflectAddress = reflect.TypeOf(common.Address{})
+	reflectAddress = reflect.TypeOf(common.ContractAddress{})
 	reflectBigInt  = reflect.TypeOf(new(big.Int))
 )
 
@@ -164,8 +164,8 @@ func parseTopics(out interface{}, fields abi.Arguments, topics []common.Hash) er
 				field.Set(reflect.ValueOf(topics[0]))
 
 			case reflectAddress:
-				var addr common.Address
-				addr.SetBytes(topics[0][0:])
+				var addr common.ContractAddress
+				addr.SetBytes(topics[0][:])
 				field.Set(reflect.ValueOf(addr))
 			case reflectBigInt:
 				num := new(big.Int).SetBytes(topics[0][:])
@@ -25,6 +25,7 @@ import (
 	"github.com/sero-cash/go-sero/core/types"
 	"github.com/sero-cash/go-sero/event"
 	"github.com/sero-cash/go-sero/zero/txs/tx"
+	"github.com/sero-cash/go-sero/zero/txtool"
 )
 
 // AccountAddress represents an Sero account located at a specific location defined
@@ -108,6 +109,13 @@ type Wallet interface {
 	GetSeedWithPassphrase(passphrase string) (*address.Seed, error)
 }
 
+// TxParamSigner is implemented by the wallets signing the transaction params
+// without giving away their seed, like the external signers.
+type TxParamSigner interface {
+	// SignTxParam requests the wallet to sign a transaction param of account.
+	SignTxParam(account Account, param *txtool.GTxParam) (*txtool.GTx, error)
+}
+
 // Backend is a "wallet provider" that may contain a batch of accounts they can
 // sign transactions with and upon request, do so.
 type Backend interface {
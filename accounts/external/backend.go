@@ -0,0 +1,241 @@
+// Package external implements an account backend delegating the signing to an
+// external signer, the one of cmd/sersigner.
+//
+// The signer keeps the seeds of its accounts, the wallets of the backend only
+// sign transaction params through accounts.TxParamSigner.
+package external
+
+import (
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/sero-cash/go-czero-import/keys"
+	sero "github.com/sero-cash/go-sero"
+	"github.com/sero-cash/go-sero/accounts"
+	"github.com/sero-cash/go-sero/common"
+	"github.com/sero-cash/go-sero/common/address"
+	"github.com/sero-cash/go-sero/core/state"
+	"github.com/sero-cash/go-sero/core/types"
+	"github.com/sero-cash/go-sero/event"
+	"github.com/sero-cash/go-sero/log"
+	"github.com/sero-cash/go-sero/rpc"
+	"github.com/sero-cash/go-sero/zero/txs/tx"
+	"github.com/sero-cash/go-sero/zero/txtool"
+)
+
+// ExternalBackendType is the reflect type of an external signer backend.
+var ExternalBackendType = reflect.TypeOf(&ExternalBackend{})
+
+// refreshCycle is the interval of the refreshes of the accounts of the signer.
+const refreshCycle = 10 * time.Second
+
+// ExternalBackend lists the accounts of an external signer, each in a wallet.
+type ExternalBackend struct {
+	endpoint string
+	client   *rpc.Client
+
+	wallets     []accounts.Wallet
+	updateFeed  event.Feed
+	updateScope event.SubscriptionScope
+
+	quit chan struct{}
+	mu   sync.RWMutex
+}
+
+// NewExternalBackend connects to the signer listening at endpoint, an IPC path
+// or an HTTP URL.
+func NewExternalBackend(endpoint string) (*ExternalBackend, error) {
+	client, err := rpc.Dial(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	backend := &ExternalBackend{
+		endpoint: endpoint,
+		client:   client,
+		quit:     make(chan struct{}),
+	}
+	if err := backend.refreshWallets(); err != nil {
+		client.Close()
+		return nil, err
+	}
+	go backend.updater()
+	return backend, nil
+}
+
+// Wallets implements accounts.Backend, returning a wallet for each account of
+// the signer.
+func (b *ExternalBackend) Wallets() []accounts.Wallet {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	cpy := make([]accounts.Wallet, len(b.wallets))
+	copy(cpy, b.wallets)
+	return cpy
+}
+
+// Subscribe implements accounts.Backend, creating an async subscription to
+// receive notifications on the addition or removal of the accounts of the
+// signer.
+func (b *ExternalBackend) Subscribe(sink chan<- accounts.WalletEvent) event.Subscription {
+	return b.updateScope.Track(b.updateFeed.Subscribe(sink))
+}
+
+// Close stops the refreshes and disconnects from the signer.
+func (b *ExternalBackend) Close() {
+	close(b.quit)
+	b.updateScope.Close()
+	b.client.Close()
+}
+
+func (b *ExternalBackend) updater() {
+	ticker := time.NewTicker(refreshCycle)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := b.refreshWallets(); err != nil {
+				log.Warn("Failed to list the accounts of the external signer", "endpoint", b.endpoint, "err", err)
+			}
+		case <-b.quit:
+			return
+		}
+	}
+}
+
+// refreshWallets lists the accounts of the signer and fires the events of the
+// wallets arrived and dropped since the last refresh.
+func (b *ExternalBackend) refreshWallets() error {
+	var list []accounts.Account
+	if err := b.client.Call(&list, "signer_list"); err != nil {
+		return err
+	}
+	b.mu.Lock()
+	current := make(map[address.AccountAddress]accounts.Wallet)
+	for _, wallet := range b.wallets {
+		current[wallet.Accounts()[0].Address] = wallet
+	}
+	var (
+		wallets = make([]accounts.Wallet, 0, len(list))
+		events  []accounts.WalletEvent
+	)
+	for _, account := range list {
+		// The manager tells the wallets apart by their URLs
+		account.URL = accounts.URL{Scheme: "extapi", Path: b.endpoint + "/" + account.Address.Base58()}
+		if wallet, ok := current[account.Address]; ok {
+			wallets = append(wallets, wallet)
+			delete(current, account.Address)
+			continue
+		}
+		wallet := &externalWallet{account: account, backend: b}
+		wallets = append(wallets, wallet)
+		events = append(events, accounts.WalletEvent{Wallet: wallet, Kind: accounts.WalletArrived})
+	}
+	for _, wallet := range current {
+		events = append(events, accounts.WalletEvent{Wallet: wallet, Kind: accounts.WalletDropped})
+	}
+	b.wallets = wallets
+	b.mu.Unlock()
+
+	for _, ev := range events {
+		b.updateFeed.Send(ev)
+	}
+	return nil
+}
+
+// externalWallet is an account of the signer.
+type externalWallet struct {
+	account accounts.Account
+	backend *ExternalBackend
+}
+
+// URL implements accounts.Wallet, returning the endpoint of the signer followed
+// by the address of the account.
+func (w *externalWallet) URL() accounts.URL {
+	return w.account.URL
+}
+
+// Status implements accounts.Wallet, the signer unlocks its accounts itself.
+func (w *externalWallet) Status() (string, error) {
+	return "Signer", nil
+}
+
+// Open implements accounts.Wallet, but is a noop for the accounts of the
+// signer.
+func (w *externalWallet) Open(passphrase string) error { return nil }
+
+// Close implements accounts.Wallet, but is a noop for the accounts of the
+// signer.
+func (w *externalWallet) Close() error { return nil }
+
+// Accounts implements accounts.Wallet, returning the account of the wallet.
+func (w *externalWallet) Accounts() []accounts.Account {
+	return []accounts.Account{w.account}
+}
+
+// Contains implements accounts.Wallet, returning whether an account is the one
+// of the wallet.
+func (w *externalWallet) Contains(account accounts.Account) bool {
+	return account.Address == w.account.Address && (account.URL == (accounts.URL{}) || account.URL == w.account.URL)
+}
+
+// Derive implements accounts.Wallet, but isn't supported by the signer.
+func (w *externalWallet) Derive(path accounts.DerivationPath, pin bool) (accounts.Account, error) {
+	return accounts.Account{}, accounts.ErrNotSupported
+}
+
+// SelfDerive implements accounts.Wallet, but is a noop for the accounts of
+// the signer.
+func (w *externalWallet) SelfDerive(base accounts.DerivationPath, chain sero.ChainStateReader) {}
+
+// EncryptTx implements accounts.Wallet, but isn't supported, the signer only
+// signs transaction params.
+func (w *externalWallet) EncryptTx(account accounts.Account, tx *types.Transaction, txt *tx.T, state *state.StateDB) (*types.Transaction, error) {
+	return nil, accounts.ErrNotSupported
+}
+
+// EncryptTxWithPassphrase implements accounts.Wallet, but isn't supported,
+// the signer only signs transaction params.
+func (w *externalWallet) EncryptTxWithPassphrase(account accounts.Account, passphrase string, tx *types.Transaction, txt *tx.T, state *state.StateDB) (*types.Transaction, error) {
+	return nil, accounts.ErrNotSupported
+}
+
+// IsMine implements accounts.Wallet, returning whether a one-time address
+// belongs to the account.
+func (w *externalWallet) IsMine(onceAddress common.Address) bool {
+	return keys.IsMyPKr(w.account.Tk.ToUint512(), onceAddress.ToPKr())
+}
+
+// AddressUnlocked implements accounts.Wallet, the account can always sign,
+// the signer asking for its password if needed.
+func (w *externalWallet) AddressUnlocked(account accounts.Account) (bool, error) {
+	if !w.Contains(account) {
+		return false, accounts.ErrUnknownAccount
+	}
+	return true, nil
+}
+
+// GetSeed implements accounts.Wallet, but the seed never leaves the signer.
+func (w *externalWallet) GetSeed() (*address.Seed, error) {
+	return nil, accounts.ErrNotSupported
+}
+
+// GetSeedWithPassphrase implements accounts.Wallet, but the seed never leaves
+// the signer.
+func (w *externalWallet) GetSeedWithPassphrase(passphrase string) (*address.Seed, error) {
+	return nil, accounts.ErrNotSupported
+}
+
+// SignTxParam implements accounts.TxParamSigner, requesting the signer to
+// sign the param.
+func (w *externalWallet) SignTxParam(account accounts.Account, param *txtool.GTxParam) (*txtool.GTx, error) {
+	if !w.Contains(account) {
+		return nil, accounts.ErrUnknownAccount
+	}
+	var gtx txtool.GTx
+	if err := w.backend.client.Call(&gtx, "signer_signTxParam", param); err != nil {
+		return nil, err
+	}
+	return &gtx, nil
+}
@@ -0,0 +1,92 @@
+package external
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sero-cash/go-sero/accounts"
+	"github.com/sero-cash/go-sero/common/address"
+	"github.com/sero-cash/go-sero/rpc"
+)
+
+// FakeSigner lists accounts as the signer does.
+type FakeSigner struct {
+	mu       sync.Mutex
+	accounts []accounts.Account
+}
+
+func (s *FakeSigner) List() []accounts.Account {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.accounts
+}
+
+func (s *FakeSigner) set(list ...accounts.Account) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.accounts = list
+}
+
+func testAccount(b byte) accounts.Account {
+	var addr address.AccountAddress
+	addr[0] = b
+	return accounts.Account{Address: addr, URL: accounts.URL{Scheme: "keystore", Path: "key"}}
+}
+
+// walletAddresses returns the addresses of the wallets of the manager once it
+// holds n of them.
+func walletAddresses(t *testing.T, am *accounts.Manager, n int) []address.AccountAddress {
+	for i := 0; i < 100; i++ {
+		if wallets := am.Wallets(); len(wallets) == n {
+			var addrs []address.AccountAddress
+			for _, wallet := range wallets {
+				addrs = append(addrs, wallet.Accounts()[0].Address)
+			}
+			return addrs
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("manager holding %d wallets, want %d", len(am.Wallets()), n)
+	return nil
+}
+
+// TestWalletsDropped checks that the manager drops the wallet of the account
+// removed from the signer, not another one of the same signer.
+func TestWalletsDropped(t *testing.T) {
+	signer := &FakeSigner{}
+	signer.set(testAccount(1), testAccount(2), testAccount(3))
+	server := rpc.NewServer()
+	if err := server.RegisterName("signer", signer); err != nil {
+		t.Fatal(err)
+	}
+	defer server.Stop()
+
+	b := &ExternalBackend{endpoint: "test", client: rpc.DialInProc(server), quit: make(chan struct{})}
+	if err := b.refreshWallets(); err != nil {
+		t.Fatal(err)
+	}
+	am := accounts.NewManager(b)
+	defer am.Close()
+	defer b.Close()
+
+	urls := make(map[accounts.URL]bool)
+	for _, wallet := range b.Wallets() {
+		urls[wallet.URL()] = true
+	}
+	if len(urls) != 3 {
+		t.Fatalf("%d wallet URLs for 3 accounts", len(urls))
+	}
+	walletAddresses(t, am, 3)
+
+	signer.set(testAccount(1), testAccount(3))
+	if err := b.refreshWallets(); err != nil {
+		t.Fatal(err)
+	}
+	addrs := walletAddresses(t, am, 2)
+	for _, addr := range addrs {
+		if addr == testAccount(2).Address {
+			t.Fatalf("wallet of the removed account kept: %v", addrs)
+		}
+	}
+}
@@ -0,0 +1,220 @@
+package keystore
+
+import (
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/sero-cash/go-sero/accounts"
+	"github.com/sero-cash/go-sero/crypto"
+	bip39 "github.com/tyler-smith/go-bip39"
+)
+
+var (
+	ErrInvalidDerivation = errors.New("derived seed is not a valid key, use another path")
+	ErrNoSeed            = errors.New("account has no seed to derive from")
+)
+
+// derivationKey is the HMAC key of the master node of the derivations.
+var derivationKey = []byte("Sero seed")
+
+// DerivedAccountPath is the path of the index-th account derived from a seed.
+func DerivedAccountPath(index uint32) accounts.DerivationPath {
+	return accounts.DerivationPath{0x80000000 + index}
+}
+
+// ParseDerivedPath parses the index of a derived account or an absolute
+// derivation path starting with m.
+func ParseDerivedPath(path string) (accounts.DerivationPath, error) {
+	path = strings.TrimSpace(path)
+	if index, err := strconv.ParseUint(path, 10, 31); err == nil {
+		return DerivedAccountPath(uint32(index)), nil
+	}
+	if !strings.HasPrefix(path, "m") {
+		return nil, errors.New("derivation path must be an account index or start with m/")
+	}
+	return accounts.ParseDerivationPath(path)
+}
+
+// SeedFromMnemonic returns the seed of a mnemonic, the seed of the account
+// created with it by NewAccountWithMnemonic.
+func SeedFromMnemonic(mnemonic string) ([]byte, error) {
+	seed, err := bip39.EntropyFromMnemonic(mnemonic)
+	if err != nil {
+		return nil, err
+	}
+	if len(seed) != 32 {
+		return nil, errors.New("mnemonic seed isn't 256 bits")
+	}
+	return seed, nil
+}
+
+// DeriveSeed derives the seed of path from the seed of a mnemonic, following
+// BIP-32 from a master node keyed by "Sero seed". The keys of SERO can't be
+// derived from public keys, so every component is hardened whatever its
+// notation. The empty path derives the seed itself.
+func DeriveSeed(seed []byte, path accounts.DerivationPath) ([]byte, error) {
+	if len(path) == 0 {
+		return seed, nil
+	}
+	mac := hmac.New(sha512.New, derivationKey)
+	mac.Write(seed)
+	node := mac.Sum(nil)
+
+	data := make([]byte, 37)
+	for _, component := range path {
+		copy(data[1:33], node[:32])
+		binary.BigEndian.PutUint32(data[33:], component|0x80000000)
+
+		mac := hmac.New(sha512.New, node[32:])
+		mac.Write(data)
+		node = mac.Sum(nil)
+	}
+	if _, err := crypto.ToECDSA(node[:32]); err != nil {
+		return nil, ErrInvalidDerivation
+	}
+	return node[:32], nil
+}
+
+func newKeyFromSeed(seed []byte, path accounts.DerivationPath, at uint64) (*Key, error) {
+	derived, err := DeriveSeed(seed, path)
+	if err != nil {
+		return nil, err
+	}
+	privateKeyECDSA, err := crypto.ToECDSA(derived)
+	if err != nil {
+		return nil, err
+	}
+	return newKeyFromECDSA(privateKeyECDSA, at), nil
+}
+
+// DeriveKey derives the key of path from the seed of a mnemonic.
+func DeriveKey(mnemonic string, path accounts.DerivationPath) (*Key, error) {
+	seed, err := SeedFromMnemonic(mnemonic)
+	if err != nil {
+		return nil, err
+	}
+	return newKeyFromSeed(seed, path, 0)
+}
+
+// masterSeed returns the seed of an account and the block it was created at.
+func (ks *KeyStore) masterSeed(master accounts.Account, passphrase string) ([]byte, uint64, error) {
+	_, key, err := ks.getDecryptedKey(master, passphrase)
+	if err != nil {
+		return nil, 0, err
+	}
+	if key.PrivateKey == nil {
+		return nil, 0, ErrNoSeed
+	}
+	defer zeroKey(key.PrivateKey)
+	return crypto.FromECDSA(key.PrivateKey), key.At, nil
+}
+
+// storeDerived stores a derived key encrypted with passphrase, the account is
+// returned as is if it already exists.
+func (ks *KeyStore) storeDerived(key *Key, passphrase string) (accounts.Account, error) {
+	if account, err := ks.Find(accounts.Account{Address: key.Address}); err == nil {
+		return account, nil
+	}
+	a := accounts.Account{Address: key.Address, Tk: key.Tk, URL: accounts.URL{Scheme: KeyStoreScheme, Path: ks.storage.JoinPath(keyFileName(key.Address))}, At: key.At}
+	if err := ks.storage.StoreKey(a.URL.Path, key, passphrase); err != nil {
+		return accounts.Account{}, err
+	}
+	ks.cache.add(a, true)
+	ks.refreshWallets()
+	return a, nil
+}
+
+// DeriveAccount derives the account of path from the seed of master and stores
+// it encrypted with the passphrase of master. The derived account is dated
+// from the creation of master.
+func (ks *KeyStore) DeriveAccount(master accounts.Account, passphrase string, path accounts.DerivationPath) (accounts.Account, error) {
+	seed, at, err := ks.masterSeed(master, passphrase)
+	if err != nil {
+		return accounts.Account{}, err
+	}
+	key, err := newKeyFromSeed(seed, path, at)
+	if err != nil {
+		return accounts.Account{}, err
+	}
+	defer zeroKey(key.PrivateKey)
+	return ks.storeDerived(key, passphrase)
+}
+
+// DeriveAccountFromMnemonic derives the account of path from the seed of a
+// mnemonic and stores it encrypted with passphrase.
+func (ks *KeyStore) DeriveAccountFromMnemonic(mnemonic, passphrase string, path accounts.DerivationPath, at uint64) (accounts.Account, error) {
+	key, err := DeriveKey(mnemonic, path)
+	if err != nil {
+		return accounts.Account{}, err
+	}
+	defer zeroKey(key.PrivateKey)
+	key.At = at
+	return ks.storeDerived(key, passphrase)
+}
+
+// DerivedUsage tells which of the candidate accounts have been used.
+type DerivedUsage func(candidates []accounts.Account) ([]bool, error)
+
+// ScanDerived derives the accounts of master by DerivedAccountPath until gap
+// consecutive of them are unused, and stores the ones up to the last used.
+// The candidates are given to used by batches, in the order of derivation.
+func (ks *KeyStore) ScanDerived(master accounts.Account, passphrase string, gap int, used DerivedUsage) ([]accounts.Account, error) {
+	if gap <= 0 {
+		return nil, errors.New("gap limit must be positive")
+	}
+	seed, at, err := ks.masterSeed(master, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		derived []*Key
+		last    = -1 // Position of the last used account
+		checked = 0  // Number of accounts given to used
+		index   uint32
+	)
+	defer func() {
+		for _, key := range derived {
+			zeroKey(key.PrivateKey)
+		}
+	}()
+	for len(derived)-last-1 < gap {
+		for len(derived)-last-1 < gap {
+			key, err := newKeyFromSeed(seed, DerivedAccountPath(index), at)
+			index++
+			if err == ErrInvalidDerivation {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			derived = append(derived, key)
+		}
+		candidates := make([]accounts.Account, 0, len(derived)-checked)
+		for _, key := range derived[checked:] {
+			candidates = append(candidates, accounts.Account{Address: key.Address, Tk: key.Tk, At: key.At})
+		}
+		flags, err := used(candidates)
+		if err != nil {
+			return nil, err
+		}
+		for i, flag := range flags {
+			if flag {
+				last = checked + i
+			}
+		}
+		checked = len(derived)
+	}
+	stored := make([]accounts.Account, 0, last+1)
+	for _, key := range derived[:last+1] {
+		account, err := ks.storeDerived(key, passphrase)
+		if err != nil {
+			return stored, err
+		}
+		stored = append(stored, account)
+	}
+	return stored, nil
+}
@@ -0,0 +1,75 @@
+package keystore
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/sero-cash/go-sero/accounts"
+)
+
+func TestDeriveSeed(t *testing.T) {
+	seed := bytes.Repeat([]byte{0x42}, 32)
+
+	master, err := DeriveSeed(seed, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(master, seed) {
+		t.Errorf("empty path derived %x, want the seed", master)
+	}
+	first, err := DeriveSeed(seed, DerivedAccountPath(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, _ := DeriveSeed(seed, DerivedAccountPath(0))
+	if !bytes.Equal(first, again) {
+		t.Errorf("derivation isn't deterministic: %x != %x", first, again)
+	}
+	second, _ := DeriveSeed(seed, DerivedAccountPath(1))
+	if bytes.Equal(first, second) {
+		t.Errorf("accounts 0 and 1 derived the same seed %x", first)
+	}
+	// The components are hardened whatever their notation
+	hardened, _ := DeriveSeed(seed, accounts.DerivationPath{0})
+	if !bytes.Equal(first, hardened) {
+		t.Errorf("m/0 derived %x, want m/0' %x", hardened, first)
+	}
+}
+
+func TestScanDerived(t *testing.T) {
+	dir, ks := tmpKeyStore(t)
+	defer os.RemoveAll(dir)
+
+	master, err := ks.NewAccount("foo", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Accounts 1 and 4 are used, with a gap of 3 the scan stops after 7
+	var scanned int
+	used := func(candidates []accounts.Account) ([]bool, error) {
+		flags := make([]bool, len(candidates))
+		for i := range candidates {
+			flags[i] = scanned == 1 || scanned == 4
+			scanned++
+		}
+		return flags, nil
+	}
+	derived, err := ks.ScanDerived(master, "foo", 3, used)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if scanned != 8 {
+		t.Errorf("scanned %d accounts, want 8", scanned)
+	}
+	if len(derived) != 5 {
+		t.Fatalf("stored %d accounts, want 5", len(derived))
+	}
+	account, err := ks.DeriveAccount(master, "foo", DerivedAccountPath(4))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if account.Address != derived[4].Address {
+		t.Errorf("derived account 4 is %v, scanned %v", account.Address, derived[4].Address)
+	}
+}
@@ -430,11 +430,14 @@ func (ks *KeyStore) ImportECDSA(priv *ecdsa.PrivateKey, passphrase string) (acco
 }
 
 func (ks *KeyStore) ImportTk(tk address.AccountAddress) (accounts.Account, error) {
-	key := newKeyFromTk(tk.ToUint512())
+	return ks.importTk(newKeyFromTk(tk.ToUint512()))
+}
+
+func (ks *KeyStore) importTk(key *Key) (accounts.Account, error) {
 	if ks.cache.hasAddress(key.Address) {
 		return accounts.Account{}, fmt.Errorf("account already exists")
 	}
-	a := accounts.Account{Address: key.Address, Tk: key.Tk, URL: accounts.URL{Scheme: KeyStoreScheme, Path: ks.storage.JoinPath(keyFileName(key.Address))}}
+	a := accounts.Account{Address: key.Address, Tk: key.Tk, URL: accounts.URL{Scheme: KeyStoreScheme, Path: ks.storage.JoinPath(keyFileName(key.Address))}, At: key.At}
 	if err := ks.storage.StoreKey(a.URL.Path, key, ""); err != nil {
 		return accounts.Account{}, err
 	}
@@ -37,7 +37,7 @@ func TestKeyStore(t *testing.T) {
 	dir, ks := tmpKeyStore(t)
 	defer os.RemoveAll(dir)
 
-	a, err := ks.NewAccount("foo")
+	a, err := ks.NewAccount("foo", 0)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -73,7 +73,7 @@ func TestTimedUnlock(t *testing.T) {
 	defer os.RemoveAll(dir)
 
 	pass := "foo"
-	a1, err := ks.NewAccount(pass)
+	a1, err := ks.NewAccount(pass, 0)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -108,7 +108,7 @@ func TestOverrideUnlock(t *testing.T) {
 	defer os.RemoveAll(dir)
 
 	pass := "foo"
-	a1, err := ks.NewAccount(pass)
+	a1, err := ks.NewAccount(pass, 0)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -238,7 +238,7 @@ func TestWalletNotifications(t *testing.T) {
 	for i := 0; i < 1024; i++ {
 		if create := len(live) == 0 || rand.Int()%4 > 0; create {
 			// Add a new account and ensure wallet notifications arrives
-			account, err := ks.NewAccount("")
+			account, err := ks.NewAccount("", 0)
 			if err != nil {
 				t.Fatalf("failed to create test account: %v", err)
 			}
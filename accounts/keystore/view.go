@@ -0,0 +1,187 @@
+package keystore
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/btcsuite/btcutil/base58"
+	"github.com/sero-cash/go-czero-import/keys"
+	"github.com/sero-cash/go-sero/accounts"
+	"github.com/sero-cash/go-sero/common/address"
+	"github.com/sero-cash/go-sero/common/hexutil"
+	"github.com/sero-cash/go-sero/crypto/sha3"
+)
+
+var (
+	ErrInvalidViewSign = errors.New("view bundle isn't signed by the owner of its tk")
+	ErrForeignViewPKr  = errors.New("view bundle restricted to a pkr of another account")
+)
+
+// ViewBundle is a scoped view of an account given to the auditors: its tk,
+// the block its outs are viewable from, the PKrs they may be restricted to and
+// the last block viewable. It is signed with a PKr of the account, proving it
+// was issued by the owner of the seed.
+//
+// The tk can decrypt every out of the account, the scope only restricts what
+// the indexers of the node importing the bundle record.
+type ViewBundle struct {
+	Tk     address.AccountAddress
+	At     uint64
+	PKrs   []keys.PKr // Whitelist of the PKrs viewable, all of them if empty
+	Expiry uint64     // Last block viewable, no limit if 0
+	Signer keys.PKr
+	Sign   keys.Uint512
+}
+
+type viewBundleJSON struct {
+	Tk     address.AccountAddress `json:"tk"`
+	At     hexutil.Uint64         `json:"at"`
+	PKrs   []string               `json:"pkrs,omitempty"`
+	Expiry hexutil.Uint64         `json:"expiry,omitempty"`
+	Signer string                 `json:"signer"`
+	Sign   hexutil.Bytes          `json:"sign"`
+}
+
+func (b *ViewBundle) MarshalJSON() ([]byte, error) {
+	enc := viewBundleJSON{
+		Tk:     b.Tk,
+		At:     hexutil.Uint64(b.At),
+		Expiry: hexutil.Uint64(b.Expiry),
+		Signer: base58.Encode(b.Signer[:]),
+		Sign:   b.Sign[:],
+	}
+	for i := range b.PKrs {
+		enc.PKrs = append(enc.PKrs, base58.Encode(b.PKrs[i][:]))
+	}
+	return json.Marshal(enc)
+}
+
+func (b *ViewBundle) UnmarshalJSON(input []byte) error {
+	var dec viewBundleJSON
+	if err := json.Unmarshal(input, &dec); err != nil {
+		return err
+	}
+	if len(dec.Sign) != len(b.Sign) {
+		return fmt.Errorf("invalid view bundle sign length %d", len(dec.Sign))
+	}
+	b.Tk = dec.Tk
+	b.At = uint64(dec.At)
+	b.Expiry = uint64(dec.Expiry)
+	if err := decodePKr(dec.Signer, &b.Signer); err != nil {
+		return err
+	}
+	copy(b.Sign[:], dec.Sign)
+	b.PKrs = nil
+	for _, s := range dec.PKrs {
+		var pkr keys.PKr
+		if err := decodePKr(s, &pkr); err != nil {
+			return err
+		}
+		b.PKrs = append(b.PKrs, pkr)
+	}
+	return nil
+}
+
+func decodePKr(s string, pkr *keys.PKr) error {
+	data := base58.Decode(s)
+	if len(data) != len(pkr) {
+		return fmt.Errorf("invalid pkr %s", s)
+	}
+	copy(pkr[:], data)
+	return nil
+}
+
+// Hash returns the hash of the bundle signed by its signer.
+func (b *ViewBundle) Hash() (ret keys.Uint256) {
+	var num [8]byte
+	d := sha3.NewKeccak256()
+	d.Write(b.Tk[:])
+	binary.BigEndian.PutUint64(num[:], b.At)
+	d.Write(num[:])
+	for i := range b.PKrs {
+		d.Write(b.PKrs[i][:])
+	}
+	binary.BigEndian.PutUint64(num[:], b.Expiry)
+	d.Write(num[:])
+	d.Write(b.Signer[:])
+	copy(ret[:], d.Sum(nil))
+	return
+}
+
+// Verify checks the bundle is signed by the owner of its tk, and restricted
+// to PKrs of its account.
+func (b *ViewBundle) Verify() error {
+	tk := b.Tk.ToUint512()
+	hash := b.Hash()
+	if !keys.IsMyPKr(tk, &b.Signer) || !keys.VerifyPKr(&hash, &b.Sign, &b.Signer) {
+		return ErrInvalidViewSign
+	}
+	for i := range b.PKrs {
+		if !keys.IsMyPKr(tk, &b.PKrs[i]) {
+			return ErrForeignViewPKr
+		}
+	}
+	return nil
+}
+
+// Allows tells whether an out sent to pkr in the block num is in the view.
+func (b *ViewBundle) Allows(pkr *keys.PKr, num uint64) bool {
+	if num < b.At || (b.Expiry != 0 && num > b.Expiry) {
+		return false
+	}
+	if len(b.PKrs) == 0 {
+		return true
+	}
+	for i := range b.PKrs {
+		if b.PKrs[i] == *pkr {
+			return true
+		}
+	}
+	return false
+}
+
+// ExportViewBundle signs a view bundle of the account a, viewable from the
+// block at to the block expiry, restricted to pkrs if any.
+func (ks *KeyStore) ExportViewBundle(a accounts.Account, passphrase string, at uint64, pkrs []keys.PKr, expiry uint64) (*ViewBundle, error) {
+	a, err := ks.Find(a)
+	if err != nil {
+		return nil, err
+	}
+	if expiry != 0 && expiry < at {
+		return nil, errors.New("view bundle expires before its first block")
+	}
+	seed, err := ks.GetSeedWithPassphrase(a, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	bundle := &ViewBundle{
+		Tk:     a.Tk,
+		At:     at,
+		PKrs:   pkrs,
+		Expiry: expiry,
+		Signer: keys.Addr2PKr(keys.Seed2Addr(seed.SeedToUint256()).NewRef(), keys.RandUint256().NewRef()),
+	}
+	for i := range pkrs {
+		if !keys.IsMyPKr(a.Tk.ToUint512(), &pkrs[i]) {
+			return nil, ErrForeignViewPKr
+		}
+	}
+	hash := bundle.Hash()
+	if bundle.Sign, err = keys.SignPKr(seed.SeedToUint256(), &hash, &bundle.Signer); err != nil {
+		return nil, err
+	}
+	return bundle, nil
+}
+
+// ImportViewBundle verifies a view bundle and stores the watch-only account
+// of its tk, dated from the first block of the bundle.
+func (ks *KeyStore) ImportViewBundle(bundle *ViewBundle) (accounts.Account, error) {
+	if err := bundle.Verify(); err != nil {
+		return accounts.Account{}, err
+	}
+	key := newKeyFromTk(bundle.Tk.ToUint512())
+	key.At = bundle.At
+	return ks.importTk(key)
+}
@@ -0,0 +1,113 @@
+package keystore
+
+import (
+	"encoding/json"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/sero-cash/go-czero-import/keys"
+)
+
+func TestViewBundleJSON(t *testing.T) {
+	bundle := &ViewBundle{At: 10, Expiry: 20, PKrs: []keys.PKr{{1}, {2}}}
+	bundle.Tk[0] = 3
+	bundle.Signer[0] = 4
+	bundle.Sign[0] = 5
+
+	data, err := json.Marshal(bundle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded := new(ViewBundle)
+	if err := json.Unmarshal(data, decoded); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(bundle, decoded) {
+		t.Errorf("decoded %+v, want %+v", decoded, bundle)
+	}
+	if decoded.Hash() != bundle.Hash() {
+		t.Errorf("decoded bundle hashes differently")
+	}
+}
+
+func TestViewBundleAllows(t *testing.T) {
+	bundle := &ViewBundle{At: 10, Expiry: 20}
+	tests := []struct {
+		pkr  keys.PKr
+		num  uint64
+		want bool
+	}{
+		{keys.PKr{1}, 9, false},
+		{keys.PKr{1}, 10, true},
+		{keys.PKr{1}, 20, true},
+		{keys.PKr{1}, 21, false},
+	}
+	for i, test := range tests {
+		if got := bundle.Allows(&test.pkr, test.num); got != test.want {
+			t.Errorf("test %d: allows %v, want %v", i, got, test.want)
+		}
+	}
+
+	bundle.PKrs = []keys.PKr{{2}}
+	bundle.Expiry = 0
+	if bundle.Allows(&keys.PKr{1}, 100) {
+		t.Errorf("allowed a pkr out of the whitelist")
+	}
+	if !bundle.Allows(&keys.PKr{2}, 100) {
+		t.Errorf("refused a whitelisted pkr without expiry")
+	}
+}
+
+func TestViewBundleVerify(t *testing.T) {
+	dir, ks := tmpKeyStore(t)
+	defer os.RemoveAll(dir)
+	account, err := ks.NewAccount("pass", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := ks.NewAccount("pass", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	own := keys.Addr2PKr(account.Address.ToUint512(), nil)
+	foreign := keys.Addr2PKr(other.Address.ToUint512(), nil)
+
+	if _, err := ks.ExportViewBundle(account, "pass", 10, []keys.PKr{own, foreign}, 20); err != ErrForeignViewPKr {
+		t.Fatalf("export restricted to a foreign pkr: have %v, want %v", err, ErrForeignViewPKr)
+	}
+	bundle, err := ks.ExportViewBundle(account, "pass", 10, []keys.PKr{own}, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := bundle.Verify(); err != nil {
+		t.Fatalf("exported bundle invalid: %v", err)
+	}
+
+	// Any change of the scope breaks the sign
+	tampered := *bundle
+	tampered.Expiry = 0
+	if err := tampered.Verify(); err != ErrInvalidViewSign {
+		t.Fatalf("bundle with a changed expiry: have %v, want %v", err, ErrInvalidViewSign)
+	}
+	tampered = *bundle
+	tampered.Tk = other.Tk
+	if err := tampered.Verify(); err != ErrInvalidViewSign {
+		t.Fatalf("bundle of another tk: have %v, want %v", err, ErrInvalidViewSign)
+	}
+
+	// A bundle signed by its owner can't reach the pkrs of other accounts
+	seed, err := ks.GetSeedWithPassphrase(account, "pass")
+	if err != nil {
+		t.Fatal(err)
+	}
+	foreignBundle := *bundle
+	foreignBundle.PKrs = []keys.PKr{own, foreign}
+	hash := foreignBundle.Hash()
+	if foreignBundle.Sign, err = keys.SignPKr(seed.SeedToUint256(), &hash, &foreignBundle.Signer); err != nil {
+		t.Fatal(err)
+	}
+	if err := foreignBundle.Verify(); err != ErrForeignViewPKr {
+		t.Fatalf("bundle restricted to a foreign pkr: have %v, want %v", err, ErrForeignViewPKr)
+	}
+}
@@ -17,9 +17,13 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"strings"
 
+	"github.com/btcsuite/btcutil/base58"
+	"github.com/sero-cash/go-czero-import/keys"
 	"github.com/sero-cash/go-sero/accounts"
 	"github.com/sero-cash/go-sero/accounts/keystore"
 	"github.com/sero-cash/go-sero/cmd/utils"
@@ -182,6 +186,60 @@ Note:
 As you can directly copy your encrypted accounts to another ethereum instance,
 this import mechanism is not needed when you transfer an account between
 nodes.
+`,
+			},
+			{
+				Name:      "derive",
+				Usage:     "Derive accounts from the seed of an existing account",
+				Action:    utils.MigrateFlags(accountDerive),
+				ArgsUsage: "<address> <index|path>...",
+				Flags: []cli.Flag{
+					utils.DataDirFlag,
+					utils.KeyStoreDirFlag,
+					utils.PasswordFileFlag,
+				},
+				Description: `
+    gero account derive <address> <index|path>...
+
+Derives accounts from the seed of an existing account, the seed of its mnemonic.
+Each account is given by its index, the path m/<index>', or by an absolute path
+starting with m/. Without index the account 0 is derived.
+
+The same seed and path always derive the same account, the derived accounts can
+therefore be restored from the mnemonic alone.
+
+The derived accounts are saved in encrypted format with the passphrase of the
+account they derive from, you are prompted for it.
+`,
+			},
+			{
+				Name:      "export-view",
+				Usage:     "Export a signed view bundle of an account for the auditors",
+				Action:    utils.MigrateFlags(accountExportView),
+				ArgsUsage: "<address> [<bundleFile>]",
+				Flags: []cli.Flag{
+					utils.DataDirFlag,
+					utils.KeyStoreDirFlag,
+					utils.PasswordFileFlag,
+					viewAtFlag,
+					viewExpiryFlag,
+					viewPKrsFlag,
+				},
+				Description: `
+    gero account export-view <address> [<bundleFile>]
+
+Exports a view bundle of an account: its tk, the block its outs are viewable
+from (--at, the creation of the account by default), the last block viewable
+(--expiry, none by default) and the PKrs they are restricted to (--pkrs, all
+of them by default). The bundle is signed with the seed of the account, you are
+prompted for its passphrase.
+
+The bundle is written to <bundleFile>, or printed. The auditors import it with
+personal.importViewBundle on a node running the exchange, which indexes the
+watch-only account within the scope of the bundle.
+
+Note the tk itself can decrypt every out of the account, the scope only
+restricts what the importing node indexes.
 `,
 			},
 		},
@@ -331,6 +389,92 @@ func accountUpdate(ctx *cli.Context) error {
 	return nil
 }
 
+// accountDerive derives accounts from the seed of an existing account.
+func accountDerive(ctx *cli.Context) error {
+	if len(ctx.Args()) == 0 {
+		utils.Fatalf("No account specified to derive from")
+	}
+	paths := ctx.Args()[1:]
+	if len(paths) == 0 {
+		paths = []string{"0"}
+	}
+	stack, _ := makeConfigNode(ctx)
+	ks := stack.AccountManager().Backends(keystore.KeyStoreType)[0].(*keystore.KeyStore)
+
+	master, password := unlockAccount(ctx, ks, ctx.Args().First(), 0, utils.MakePasswordList(ctx))
+	for _, path := range paths {
+		derivPath, err := keystore.ParseDerivedPath(path)
+		if err != nil {
+			utils.Fatalf("Invalid derivation path %s: %v", path, err)
+		}
+		acct, err := ks.DeriveAccount(master, password, derivPath)
+		if err != nil {
+			utils.Fatalf("Could not derive the account %s: %v", derivPath, err)
+		}
+		fmt.Printf("%s: {%x}\n", derivPath, acct.Address)
+	}
+	return nil
+}
+
+var (
+	viewAtFlag = cli.Uint64Flag{
+		Name:  "at",
+		Usage: "First block viewable (default = creation of the account)",
+	}
+	viewExpiryFlag = cli.Uint64Flag{
+		Name:  "expiry",
+		Usage: "Last block viewable (default = no limit)",
+	}
+	viewPKrsFlag = cli.StringFlag{
+		Name:  "pkrs",
+		Usage: "Comma separated list of the only PKrs viewable",
+	}
+)
+
+// accountExportView exports a signed view bundle of an account.
+func accountExportView(ctx *cli.Context) error {
+	if len(ctx.Args()) == 0 {
+		utils.Fatalf("No account specified to export")
+	}
+	var pkrs []keys.PKr
+	if list := ctx.String(viewPKrsFlag.Name); list != "" {
+		for _, s := range strings.Split(list, ",") {
+			data := base58.Decode(strings.TrimSpace(s))
+			var pkr keys.PKr
+			if len(data) != len(pkr) {
+				utils.Fatalf("Invalid PKr %s", s)
+			}
+			copy(pkr[:], data)
+			pkrs = append(pkrs, pkr)
+		}
+	}
+	stack, _ := makeConfigNode(ctx)
+	ks := stack.AccountManager().Backends(keystore.KeyStoreType)[0].(*keystore.KeyStore)
+
+	account, password := unlockAccount(ctx, ks, ctx.Args().First(), 0, utils.MakePasswordList(ctx))
+	at := account.At
+	if ctx.IsSet(viewAtFlag.Name) {
+		at = ctx.Uint64(viewAtFlag.Name)
+	}
+	bundle, err := ks.ExportViewBundle(account, password, at, pkrs, ctx.Uint64(viewExpiryFlag.Name))
+	if err != nil {
+		utils.Fatalf("Could not export the view bundle: %v", err)
+	}
+	data, err := json.MarshalIndent(bundle, "", "  ")
+	if err != nil {
+		utils.Fatalf("Could not encode the view bundle: %v", err)
+	}
+	if file := ctx.Args().Get(1); file != "" {
+		if err := ioutil.WriteFile(file, data, 0600); err != nil {
+			utils.Fatalf("Could not write the view bundle: %v", err)
+		}
+		fmt.Printf("View bundle of {%x} written to %s\n", account.Address, file)
+	} else {
+		fmt.Println(string(data))
+	}
+	return nil
+}
+
 func importWallet(ctx *cli.Context) error {
 	keyfile := ctx.Args().First()
 	if len(keyfile) == 0 {
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/sero-cash/go-sero/cmd/utils"
+	"github.com/sero-cash/go-sero/common/hexutil"
+	"github.com/sero-cash/go-sero/core/rawdb"
+	"github.com/sero-cash/go-sero/core/state"
+	"github.com/sero-cash/go-sero/crypto"
+	"github.com/sero-cash/go-sero/log"
+	"github.com/sero-cash/go-sero/zero/checkpoint"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var (
+	checkpointCommand = cli.Command{
+		Action:    utils.MigrateFlags(makeCheckpoint),
+		Name:      "checkpoint",
+		Usage:     "Sign a zero checkpoint of a block",
+		ArgsUsage: "<blockNum> <keyfile>",
+		Flags: []cli.Flag{
+			utils.DataDirFlag,
+			utils.CacheFlag,
+		},
+		Category: "BLOCKCHAIN COMMANDS",
+		Description: `
+The checkpoint command signs the hash, the state root, the zero state root and
+the stake state hash of a block with the hex encoded private key of keyfile.
+The state of the block must be on disk, see --snapshot. The output can be saved
+as a json file of the checkpoint directory of the nodes trusting the key, the
+public key of the signer is logged.`,
+	}
+)
+
+func makeCheckpoint(ctx *cli.Context) error {
+	if len(ctx.Args()) < 2 {
+		utils.Fatalf("This command requires two arguments.")
+	}
+	number, err := strconv.ParseUint(ctx.Args().First(), 10, 64)
+	if err != nil {
+		utils.Fatalf("Invalid block number: %v", err)
+	}
+	key, err := crypto.LoadECDSA(ctx.Args().Get(1))
+	if err != nil {
+		utils.Fatalf("Failed to load the private key: %v", err)
+	}
+	stack := makeFullNode(ctx)
+	db := utils.MakeChainDatabase(ctx, stack)
+	defer db.Close()
+
+	header := rawdb.ReadHeader(db, rawdb.ReadCanonicalHash(db, number), number)
+	if header == nil {
+		utils.Fatalf("Block %d not found", number)
+	}
+	statedb, err := state.New(state.NewDatabase(db), header)
+	if err != nil {
+		utils.Fatalf("State of block %d not found: %v", number, err)
+	}
+	cp := checkpoint.New(header, statedb)
+	if err := cp.Sign(key); err != nil {
+		utils.Fatalf("Failed to sign the checkpoint: %v", err)
+	}
+	out, _ := json.MarshalIndent([]*checkpoint.Checkpoint{cp}, "", "  ")
+	log.Info("Signed checkpoint", "number", number, "signer", hexutil.Encode(crypto.FromECDSAPub(&key.PublicKey)))
+	fmt.Println(string(out))
+	return nil
+}
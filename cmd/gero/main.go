@@ -68,6 +68,7 @@ var (
 		utils.BootnodesV5Flag,
 		utils.DataDirFlag,
 		utils.KeyStoreDirFlag,
+		utils.ExternalSignerFlag,
 		utils.NoUSBFlag,
 		utils.DashboardEnabledFlag,
 		utils.DashboardAddrFlag,
@@ -80,7 +81,10 @@ var (
 		utils.EthashDatasetsInMemoryFlag,
 		utils.EthashDatasetsOnDiskFlag,
 		utils.TxPoolNoLocalsFlag,
+		utils.TxPoolJournalFlag,
+		utils.TxPoolRejournalFlag,
 		utils.TxPoolPriceLimitFlag,
+		utils.TxPoolPriceBumpFlag,
 		utils.TxPoolAccountSlotsFlag,
 		utils.TxPoolGlobalSlotsFlag,
 		utils.TxPoolAccountQueueFlag,
@@ -89,6 +93,7 @@ var (
 		utils.SyncModeFlag,
 		utils.MiningModeFlag,
 		utils.GCModeFlag,
+		utils.ZeroKeepFlag,
 		utils.CacheFlag,
 		utils.CacheDatabaseFlag,
 		utils.CacheGCFlag,
@@ -101,6 +106,10 @@ var (
 		utils.VThreadsFlag,
 		utils.PThreadsFlag,
 		utils.MinerThreadsFlag,
+		utils.LotteryDeadlineFlag,
+		utils.LotteryFallbackFlag,
+		utils.StratumFlag,
+		utils.StratumDifficultyFlag,
 		utils.MiningEnabledFlag,
 		utils.TargetGasLimitFlag,
 		utils.NATFlag,
@@ -118,10 +127,12 @@ var (
 		utils.AutoMergeFlag,
 		utils.ConfirmedBlockFlag,
 		utils.LightNodeFlag,
+		utils.WitnessFlag,
 		utils.ResetBlockNumber,
 
 		utils.DeveloperFlag,
 		utils.SnapshotFlag,
+		utils.CheckpointSignersFlag,
 		utils.VMEnableDebugFlag,
 		utils.NetworkIdFlag,
 		utils.RPCCORSDomainFlag,
@@ -178,6 +189,10 @@ func init() {
 		copydbCommand,
 		removedbCommand,
 		//dumpCommand,
+		// See snapshotcmd.go:
+		snapshotCommand,
+		// See checkpointcmd.go:
+		checkpointCommand,
 		// See monitorcmd.go:
 		monitorCommand,
 		// See accountcmd.go:
@@ -58,6 +58,8 @@ var (
 The Gero monitor is a tool to collect and visualize various internal metrics
 gathered by the node, supporting different chart types as well as the capacity
 to display multiple metrics simultaneously.
+
+The generation of the ethash caches and DAGs is followed with "gero monitor ethash".
 `,
 		Flags: []cli.Flag{
 			monitorCommandAttachFlag,
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sero-cash/go-sero/cmd/utils"
+	"github.com/sero-cash/go-sero/core"
+	"github.com/sero-cash/go-sero/serodb"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var (
+	snapshotCommand = cli.Command{
+		Name:      "snapshot",
+		Usage:     "Export and import state snapshots",
+		ArgsUsage: "",
+		Category:  "BLOCKCHAIN COMMANDS",
+		Description: `
+A snapshot holds the state and the zero state of a block, a new node imports it
+to start from that block instead of processing the chain from the genesis.`,
+		Subcommands: []cli.Command{
+			{
+				Name:      "export",
+				Usage:     "Export the state of a block into a snapshot archive",
+				ArgsUsage: "<filename> <blockNum>",
+				Action:    utils.MigrateFlags(exportSnapshot),
+				Category:  "BLOCKCHAIN COMMANDS",
+				Flags: []cli.Flag{
+					utils.DataDirFlag,
+					utils.CacheFlag,
+				},
+				Description: `
+The state of the block must be on disk, which the node only guarantees for the
+height given with --snapshot. The node must be stopped. If the file ends with
+.gz, the output will be gzipped.`,
+			},
+			{
+				Name:      "import",
+				Usage:     "Start a new node from a snapshot archive",
+				ArgsUsage: "<filename>",
+				Action:    utils.MigrateFlags(importSnapshot),
+				Category:  "BLOCKCHAIN COMMANDS",
+				Flags: []cli.Flag{
+					utils.DataDirFlag,
+					utils.CacheFlag,
+				},
+				Description: `
+The chain database must not hold any block but the genesis, which is written
+first if missing. The headers, the nodes of the state and the checksum of the
+archive are checked, so are the zero state records against the chain and the
+zero state of the block. The block only becomes the head of the chain once the
+whole state is found under its root.`,
+			},
+		},
+	}
+)
+
+func exportSnapshot(ctx *cli.Context) error {
+	if len(ctx.Args()) < 2 {
+		utils.Fatalf("This command requires two arguments.")
+	}
+	number, err := strconv.ParseUint(ctx.Args().Get(1), 10, 64)
+	if err != nil {
+		utils.Fatalf("Export error in parsing parameters: block number not an integer\n")
+	}
+	stack := makeFullNode(ctx)
+	db := utils.MakeChainDatabase(ctx, stack).(*serodb.LDBDatabase)
+	defer db.Close()
+
+	fn := ctx.Args().First()
+	fh, err := os.OpenFile(fn, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.ModePerm)
+	if err != nil {
+		utils.Fatalf("Export error: %v\n", err)
+	}
+	defer fh.Close()
+
+	var writer io.Writer = fh
+	if strings.HasSuffix(fn, ".gz") {
+		writer = gzip.NewWriter(writer)
+		defer writer.(*gzip.Writer).Close()
+	}
+	start := time.Now()
+	if err := core.ExportSnapshot(db, number, writer); err != nil {
+		utils.Fatalf("Export error: %v\n", err)
+	}
+	fmt.Printf("Export done in %v\n", time.Since(start))
+	return nil
+}
+
+func importSnapshot(ctx *cli.Context) error {
+	if len(ctx.Args()) < 1 {
+		utils.Fatalf("This command requires an argument.")
+	}
+	// Set up the chain to write the genesis, the snapshot goes on top of it
+	stack := makeFullNode(ctx)
+	chain, chainDb := utils.MakeChain(ctx, stack)
+	chain.Stop()
+	defer chainDb.Close()
+	db := chainDb.(*serodb.LDBDatabase)
+
+	fn := ctx.Args().First()
+	fh, err := os.Open(fn)
+	if err != nil {
+		utils.Fatalf("Import error: %v\n", err)
+	}
+	defer fh.Close()
+
+	var reader io.Reader = fh
+	if strings.HasSuffix(fn, ".gz") {
+		if reader, err = gzip.NewReader(reader); err != nil {
+			utils.Fatalf("Import error: %v\n", err)
+		}
+	}
+	start := time.Now()
+	block, err := core.ImportSnapshot(db, reader)
+	if err != nil {
+		utils.Fatalf("Import error: %v\n", err)
+	}
+	fmt.Printf("Imported block %d (%x) in %v\n", block.NumberU64(), block.Hash(), time.Since(start))
+	return nil
+}
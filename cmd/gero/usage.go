@@ -74,6 +74,8 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.AlphanetFlag,
 			utils.DeveloperFlag,
 			utils.SyncModeFlag,
+			utils.CheckpointSignersFlag,
+			utils.WitnessFlag,
 			utils.SeroStatsURLFlag,
 			utils.IdentityFlag,
 		},
@@ -99,7 +101,10 @@ var AppHelpFlagGroups = []flagGroup{
 		Name: "TRANSACTION POOL",
 		Flags: []cli.Flag{
 			utils.TxPoolNoLocalsFlag,
+			utils.TxPoolJournalFlag,
+			utils.TxPoolRejournalFlag,
 			utils.TxPoolPriceLimitFlag,
+			utils.TxPoolPriceBumpFlag,
 			utils.TxPoolAccountSlotsFlag,
 			utils.TxPoolGlobalSlotsFlag,
 			utils.TxPoolAccountQueueFlag,
@@ -114,6 +119,7 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.CacheDatabaseFlag,
 			utils.CacheGCFlag,
 			utils.TrieCacheGenFlag,
+			utils.ZeroKeepFlag,
 		},
 	},
 	{
@@ -121,6 +127,7 @@ var AppHelpFlagGroups = []flagGroup{
 		Flags: []cli.Flag{
 			utils.UnlockedAccountFlag,
 			utils.PasswordFileFlag,
+			utils.ExternalSignerFlag,
 		},
 	},
 	{
@@ -173,6 +180,10 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.TargetGasLimitFlag,
 			utils.GasPriceFlag,
 			utils.ExtraDataFlag,
+			utils.LotteryDeadlineFlag,
+			utils.LotteryFallbackFlag,
+			utils.StratumFlag,
+			utils.StratumDifficultyFlag,
 		},
 	},
 	{
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/sero-cash/go-sero/accounts/keystore"
+	"github.com/sero-cash/go-sero/cmd/utils"
+	"github.com/sero-cash/go-sero/console"
+	"gopkg.in/urfave/cli.v1"
+)
+
+type outputDerive struct {
+	Path    string
+	Address string
+	Tk      string
+}
+
+var commandDerive = cli.Command{
+	Name:      "derive",
+	Usage:     "derive a keyfile from a mnemonic",
+	ArgsUsage: "[ <keyfile> ]",
+	Description: `
+Derive the keyfile of an account from a mnemonic.
+
+The account is given by --path, either an account index or an absolute path
+starting with m/, the index 0 by default. The same mnemonic and path always
+derive the same account, the one of gero account derive.
+
+The mnemonic is read from the file given by --mnemonicfile or prompted.
+`,
+	Flags: []cli.Flag{
+		passphraseFlag,
+		jsonFlag,
+		cli.StringFlag{
+			Name:  "mnemonicfile",
+			Usage: "file containing the mnemonic to derive from",
+		},
+		cli.StringFlag{
+			Name:  "path",
+			Usage: "index or absolute path of the derived account",
+			Value: "0",
+		},
+	},
+	Action: func(ctx *cli.Context) error {
+		keyfilepath := ctx.Args().First()
+		if keyfilepath == "" {
+			keyfilepath = defaultKeyfileName
+		}
+		if _, err := os.Stat(keyfilepath); err == nil {
+			utils.Fatalf("Keyfile already exists at %s.", keyfilepath)
+		} else if !os.IsNotExist(err) {
+			utils.Fatalf("Error checking if keyfile exists: %v", err)
+		}
+		path, err := keystore.ParseDerivedPath(ctx.String("path"))
+		if err != nil {
+			utils.Fatalf("Invalid derivation path: %v", err)
+		}
+
+		var mnemonic string
+		if file := ctx.String("mnemonicfile"); file != "" {
+			content, err := ioutil.ReadFile(file)
+			if err != nil {
+				utils.Fatalf("Failed to read mnemonic file '%s': %v", file, err)
+			}
+			mnemonic = string(content)
+		} else {
+			if mnemonic, err = console.Stdin.PromptPassword("Mnemonic: "); err != nil {
+				utils.Fatalf("Failed to read mnemonic: %v", err)
+			}
+		}
+		key, err := keystore.DeriveKey(strings.Join(strings.Fields(mnemonic), " "), path)
+		if err != nil {
+			utils.Fatalf("Failed to derive the key: %v", err)
+		}
+
+		// Encrypt key with passphrase.
+		var passphrase string
+		if ctx.String(passphraseFlag.Name) != "" {
+			passphrase = getPassphrase(ctx)
+		} else {
+			passphrase = promptPassphrase(true)
+		}
+		keyjson, err := keystore.EncryptKey(key, passphrase, keystore.StandardScryptN, keystore.StandardScryptP)
+		if err != nil {
+			utils.Fatalf("Error encrypting key: %v", err)
+		}
+
+		// Store the file to disk.
+		if err := os.MkdirAll(filepath.Dir(keyfilepath), 0700); err != nil {
+			utils.Fatalf("Could not create directory %s", filepath.Dir(keyfilepath))
+		}
+		if err := ioutil.WriteFile(keyfilepath, keyjson, 0600); err != nil {
+			utils.Fatalf("Failed to write keyfile to %s: %v", keyfilepath, err)
+		}
+
+		out := outputDerive{
+			Path:    path.String(),
+			Address: key.Address.Base58(),
+			Tk:      key.Tk.Base58(),
+		}
+		if ctx.Bool(jsonFlag.Name) {
+			mustPrintJSON(out)
+		} else {
+			fmt.Println("Path:", out.Path)
+			fmt.Println("Address:", out.Address)
+			fmt.Println("Tk:", out.Tk)
+		}
+		return nil
+	},
+}
@@ -39,6 +39,7 @@ func init() {
 	app = utils.NewApp(gitCommit, "an Sero key manager")
 	app.Commands = []cli.Command{
 		commandGenerate,
+		commandDerive,
 		commandInspect,
 		commandChangePassphrase,
 		commandRegister,
@@ -0,0 +1,195 @@
+// sersigner is a standalone signer of SERO transaction params.
+//
+// It holds a keystore outside of gero, and signs over IPC or HTTP the params
+// respecting its rules once approved on its terminal. gero delegates the
+// signing to it with --signer.
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/sero-cash/go-czero-import/cpt"
+	"github.com/sero-cash/go-sero/accounts"
+	"github.com/sero-cash/go-sero/accounts/keystore"
+	"github.com/sero-cash/go-sero/cmd/utils"
+	"github.com/sero-cash/go-sero/common/address"
+	"github.com/sero-cash/go-sero/console"
+	"github.com/sero-cash/go-sero/log"
+	"github.com/sero-cash/go-sero/node"
+	"github.com/sero-cash/go-sero/rpc"
+	"github.com/sero-cash/go-sero/signer"
+	"gopkg.in/urfave/cli.v1"
+)
+
+// Git SHA1 commit hash of the release (set via linker flags)
+var gitCommit = ""
+
+var (
+	configDirFlag = cli.StringFlag{
+		Name:  "configdir",
+		Usage: "Directory of the signer ledger and IPC endpoint",
+		Value: filepath.Join(node.DefaultDataDir(), "sersigner"),
+	}
+	keystoreFlag = cli.StringFlag{
+		Name:  "keystore",
+		Usage: "Directory for the keystore",
+		Value: filepath.Join(node.DefaultDataDir(), "keystore"),
+	}
+	lightKDFFlag = cli.BoolFlag{
+		Name:  "lightkdf",
+		Usage: "Reduce key-derivation RAM & CPU usage at some expense of KDF strength",
+	}
+	rulesFlag = cli.StringFlag{
+		Name:  "rules",
+		Usage: "JSON file of the rules enforced on the transactions (default = approve every transaction)",
+	}
+	unlockFlag = cli.StringFlag{
+		Name:  "unlock",
+		Usage: "Comma separated list of accounts to unlock, for the approval by the rules",
+	}
+	ipcDisabledFlag = cli.BoolFlag{
+		Name:  "ipcdisable",
+		Usage: "Disable the IPC-RPC server",
+	}
+	ipcPathFlag = cli.StringFlag{
+		Name:  "ipcpath",
+		Usage: "Filename for IPC socket/pipe within the configdir (explicit paths escape it)",
+		Value: "sersigner.ipc",
+	}
+	httpEnabledFlag = cli.BoolFlag{
+		Name:  "http",
+		Usage: "Enable the HTTP-RPC server",
+	}
+	httpAddrFlag = cli.StringFlag{
+		Name:  "http.addr",
+		Usage: "HTTP-RPC server listening interface",
+		Value: node.DefaultHTTPHost,
+	}
+	httpPortFlag = cli.IntFlag{
+		Name:  "http.port",
+		Usage: "HTTP-RPC server listening port",
+		Value: 8550,
+	}
+	logLevelFlag = cli.IntFlag{
+		Name:  "loglevel",
+		Usage: "log level to emit to the screen",
+		Value: int(log.LvlInfo),
+	}
+)
+
+var app = utils.NewApp(gitCommit, "the SERO transaction signer")
+
+func init() {
+	app.Flags = []cli.Flag{
+		configDirFlag,
+		keystoreFlag,
+		lightKDFFlag,
+		rulesFlag,
+		unlockFlag,
+		ipcDisabledFlag,
+		ipcPathFlag,
+		httpEnabledFlag,
+		httpAddrFlag,
+		httpPortFlag,
+		logLevelFlag,
+	}
+	app.Action = sersigner
+}
+
+func main() {
+	cpt.ZeroInit_OnlyInOuts()
+	if err := app.Run(os.Args); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func sersigner(ctx *cli.Context) error {
+	log.Root().SetHandler(log.LvlFilterHandler(log.Lvl(ctx.Int(logLevelFlag.Name)), log.StreamHandler(os.Stderr, log.TerminalFormat(true))))
+
+	scryptN, scryptP := keystore.StandardScryptN, keystore.StandardScryptP
+	if ctx.Bool(lightKDFFlag.Name) {
+		scryptN, scryptP = keystore.LightScryptN, keystore.LightScryptP
+	}
+	ks := keystore.NewKeyStore(ctx.String(keystoreFlag.Name), scryptN, scryptP)
+
+	var rules *signer.Rules
+	if file := ctx.String(rulesFlag.Name); file != "" {
+		var err error
+		if rules, err = signer.LoadRules(file); err != nil {
+			utils.Fatalf("Failed to load the rules: %v", err)
+		}
+		log.Info("Loaded the rules", "file", file)
+	} else {
+		log.Warn("No rules, every transaction is submitted for approval")
+	}
+	if list := ctx.String(unlockFlag.Name); list != "" {
+		for _, addr := range strings.Split(list, ",") {
+			unlockAccount(ks, strings.TrimSpace(addr))
+		}
+	}
+
+	configDir := ctx.String(configDirFlag.Name)
+	s, err := signer.New(ks, signer.NewCommandlineUI(), rules, filepath.Join(configDir, "ledger.json"))
+	if err != nil {
+		utils.Fatalf("Failed to create the signer: %v", err)
+	}
+
+	if !ctx.Bool(ipcDisabledFlag.Name) {
+		ipcPath := ctx.String(ipcPathFlag.Name)
+		if !filepath.IsAbs(ipcPath) && filepath.Base(ipcPath) == ipcPath {
+			if err := os.MkdirAll(configDir, 0700); err != nil {
+				utils.Fatalf("Failed to create the config directory: %v", err)
+			}
+			ipcPath = filepath.Join(configDir, ipcPath)
+		}
+		listener, _, err := rpc.StartIPCEndpoint(ipcPath, s.APIs(true))
+		if err != nil {
+			utils.Fatalf("Could not start the IPC endpoint: %v", err)
+		}
+		defer listener.Close()
+		log.Info("IPC endpoint opened", "url", ipcPath)
+	}
+	if ctx.Bool(httpEnabledFlag.Name) {
+		endpoint := fmt.Sprintf("%s:%d", ctx.String(httpAddrFlag.Name), ctx.Int(httpPortFlag.Name))
+		// Any local process reaches the HTTP endpoint, its requests are
+		// always submitted to the UI
+		listener, _, err := rpc.StartHTTPEndpoint(endpoint, s.APIs(false), []string{"signer"}, nil, []string{"localhost"}, rpc.DefaultHTTPTimeouts)
+		if err != nil {
+			utils.Fatalf("Could not start the HTTP endpoint: %v", err)
+		}
+		defer listener.Close()
+		log.Info("HTTP endpoint opened", "url", "http://"+endpoint)
+	}
+
+	sigc := make(chan os.Signal, 1)
+	signal.Notify(sigc, syscall.SIGINT, syscall.SIGTERM)
+	<-sigc
+	log.Info("Got interrupt, shutting down...")
+	return nil
+}
+
+// unlockAccount unlocks an account of the keystore with a prompted password.
+func unlockAccount(ks *keystore.KeyStore, addr string) {
+	account, err := ks.Find(accounts.Account{Address: address.Base58ToAccount(addr)})
+	if err != nil {
+		utils.Fatalf("Could not find the account %s: %v", addr, err)
+	}
+	for trials := 0; trials < 3; trials++ {
+		password, err := console.Stdin.PromptPassword(fmt.Sprintf("Password of %s: ", addr))
+		if err != nil {
+			utils.Fatalf("Failed to read the password: %v", err)
+		}
+		if err = ks.Unlock(account, password); err == nil {
+			log.Info("Unlocked account", "address", addr)
+			return
+		}
+		log.Warn("Failed to unlock the account", "address", addr, "err", err)
+	}
+	utils.Fatalf("Failed to unlock the account %s (too many attempts)", addr)
+}
@@ -33,6 +33,10 @@ var sk = ""
 var tk = ""
 var out = ""
 var key = ""
+var num uint64
+var chaindata = ""
+var endpoint = ""
+var net = ""
 
 func init() {
 	flag.StringVar(&method, "method", "", "tx method")
@@ -40,6 +44,10 @@ func init() {
 	flag.StringVar(&sk, "sk", "", "sk for sign")
 	flag.StringVar(&tk, "tk", "", "tk for dec")
 	flag.StringVar(&out, "out", "", "out for dec")
+	flag.Uint64Var(&num, "num", 0, "block number of the rules for verify, the latest fork by default without -chaindata and -rpc")
+	flag.StringVar(&chaindata, "chaindata", "", "chain database of a stopped node for verify")
+	flag.StringVar(&endpoint, "rpc", "", "rpc endpoint of a node for verify")
+	flag.StringVar(&net, "net", "", "network of the rules for verify [beta,alpha,dev], taken from the genesis with -chaindata")
 }
 
 func OUTPUT_RESULT(result interface{}) {
@@ -72,5 +80,15 @@ func main() {
 		Confirm(key, out)
 		return
 	}
-	OUTPUT_ERROR("METHOD-MUST-[sign,dec,confirm]", nil)
+	if method == "verify" {
+		var at *uint64
+		flag.Visit(func(f *flag.Flag) {
+			if f.Name == "num" {
+				at = &num
+			}
+		})
+		Verify(txParam, net, at, chaindata, endpoint)
+		return
+	}
+	OUTPUT_ERROR("METHOD-MUST-[sign,dec,confirm,verify]", nil)
 }
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/sero-cash/go-czero-import/cpt"
+	"github.com/sero-cash/go-czero-import/seroparam"
+	"github.com/sero-cash/go-sero/common"
+	"github.com/sero-cash/go-sero/core/rawdb"
+	"github.com/sero-cash/go-sero/core/state"
+	"github.com/sero-cash/go-sero/core/types"
+	"github.com/sero-cash/go-sero/params"
+	"github.com/sero-cash/go-sero/rpc"
+	"github.com/sero-cash/go-sero/serodb"
+	"github.com/sero-cash/go-sero/zero/txtool"
+	"github.com/sero-cash/go-sero/zero/txtool/verify"
+)
+
+// Verify checks a signed transaction before it is broadcast. The static checks
+// and the proofs are run under the rules of the block num, the state checks
+// need the chain database of a stopped node or the RPC endpoint of a node.
+// With a chain database the network is taken from its genesis and num, when
+// given, selects the state of the parent of block num instead of the head.
+// Without either, num defaults to the height of the latest fork.
+func Verify(txParam string, net string, num *uint64, chaindata string, endpoint string) {
+	if len(txParam) == 0 {
+		stdin := bufio.NewReader(os.Stdin)
+		fmt.Println("input tx:")
+		var err error
+		txParam, err = stdin.ReadString('\n')
+		if err != nil {
+			OUTPUT_ERROR("TX READ ERROR", nil)
+			return
+		}
+		txParam = strings.Trim(txParam, "\n")
+	}
+	txParam = strings.Trim(txParam, "'")
+
+	var gtx txtool.GTx
+	if e := json.Unmarshal([]byte(txParam), &gtx); e != nil {
+		OUTPUT_ERROR("Unmarshal-", e)
+		return
+	}
+
+	var report *verify.Report
+	if len(endpoint) > 0 {
+		if num != nil {
+			OUTPUT_ERROR("Num-", errors.New("-num can not be used with -rpc, the node verifies at its head"))
+			return
+		}
+		client, e := rpc.Dial(endpoint)
+		if e != nil {
+			OUTPUT_ERROR("Dial-", e)
+			return
+		}
+		defer client.Close()
+		if e := client.Call(&report, "sero_verifyTx", &gtx); e != nil {
+			OUTPUT_ERROR("VerifyTx-", e)
+			return
+		}
+	} else if len(chaindata) > 0 {
+		db, e := serodb.NewLDBDatabaseReadOnly(chaindata, 16, 16)
+		if e != nil {
+			OUTPUT_ERROR("OpenDB-", e)
+			return
+		}
+		defer db.Close()
+		netType, e := genesisNet(db, net)
+		if e != nil {
+			OUTPUT_ERROR("Net-", e)
+			return
+		}
+		zeroInit(netType)
+		statedb, parent, e := openState(db, num)
+		if e != nil {
+			OUTPUT_ERROR("OpenState-", e)
+			return
+		}
+		tx := types.NewTxWithGTx(uint64(gtx.Gas), gtx.GasPrice.ToInt(), &gtx.Tx)
+		ehash := tx.Ehash()
+		report = verify.Inspect(&ehash, tx.GetZZSTX(), parent.Number.Uint64()+1)
+		report.CheckState(tx.GetZZSTX(), statedb.NextZState())
+	} else {
+		netType, e := flagNet(net)
+		if e != nil {
+			OUTPUT_ERROR("Net-", e)
+			return
+		}
+		zeroInit(netType)
+		at := latestFork()
+		if num != nil {
+			at = *num
+		}
+		tx := types.NewTxWithGTx(uint64(gtx.Gas), gtx.GasPrice.ToInt(), &gtx.Tx)
+		ehash := tx.Ehash()
+		report = verify.Inspect(&ehash, tx.GetZZSTX(), at)
+	}
+
+	if jreport, e := json.Marshal(report); e != nil {
+		OUTPUT_ERROR("Marshal-", e)
+	} else {
+		OUTPUT_RESULT(string(jreport))
+	}
+}
+
+// latestFork returns the height of the last fork of the network, from which on
+// the latest rules apply.
+func latestFork() (at uint64) {
+	for _, fork := range []uint64{seroparam.SIP1(), seroparam.SIP2(), seroparam.SIP3(), seroparam.SIP4(), seroparam.VP0()} {
+		if fork > at {
+			at = fork
+		}
+	}
+	return
+}
+
+func zeroInit(netType cpt.NetType) {
+	if netType == cpt.NET_Dev {
+		seroparam.Init_Dev(true)
+	}
+	cpt.ZeroInit("", netType)
+}
+
+// flagNet returns the network named by the -net flag, beta by default as gero.
+func flagNet(net string) (cpt.NetType, error) {
+	switch net {
+	case "", "beta":
+		return cpt.NET_Beta, nil
+	case "alpha":
+		return cpt.NET_Alpha, nil
+	case "dev":
+		return cpt.NET_Dev, nil
+	}
+	return cpt.NET_Beta, fmt.Errorf("unknown network %v, must be one of [beta,alpha,dev]", net)
+}
+
+// genesisNet returns the network of a chain database by its genesis hash, an
+// unknown genesis is a dev chain. A -net flag that names another network is
+// refused rather than verifying under the wrong rules.
+func genesisNet(db serodb.Database, net string) (cpt.NetType, error) {
+	genesis := rawdb.ReadCanonicalHash(db, 0)
+	if genesis == (common.Hash{}) {
+		return cpt.NET_Beta, errors.New("genesis block not found")
+	}
+	netType := cpt.NET_Dev
+	switch genesis {
+	case params.MainnetGenesisHash:
+		netType = cpt.NET_Beta
+	case params.AlphanetGenesisHash:
+		netType = cpt.NET_Alpha
+	}
+	if len(net) > 0 {
+		flagType, err := flagNet(net)
+		if err != nil {
+			return netType, err
+		}
+		if flagType != netType {
+			return netType, fmt.Errorf("-net %v does not match the genesis %v of the chain database", net, genesis.Hex())
+		}
+	}
+	return netType, nil
+}
+
+// openState opens the state a transaction of block num is checked against,
+// the state of the head block when num is nil.
+func openState(db serodb.Database, num *uint64) (*state.StateDB, *types.Header, error) {
+	hash := rawdb.ReadHeadBlockHash(db)
+	number := rawdb.ReadHeaderNumber(db, hash)
+	if number == nil {
+		return nil, nil, errors.New("head block not found")
+	}
+	if num != nil {
+		if *num == 0 || *num > *number+1 {
+			return nil, nil, fmt.Errorf("block %v is out of the chain, head is %v", *num, *number)
+		}
+		parent := *num - 1
+		hash = rawdb.ReadCanonicalHash(db, parent)
+		number = &parent
+	}
+	parent := rawdb.ReadHeader(db, hash, *number)
+	if parent == nil {
+		return nil, nil, fmt.Errorf("header %v not found", *number)
+	}
+	statedb, err := state.New(state.NewDatabase(db), parent)
+	if err != nil {
+		return nil, nil, err
+	}
+	return statedb, parent, nil
+}
@@ -42,6 +42,7 @@ import (
 	"github.com/sero-cash/go-sero/common/fdlimit"
 	"github.com/sero-cash/go-sero/consensus"
 
+	"github.com/sero-cash/go-sero/consensus/dev"
 	"github.com/sero-cash/go-sero/consensus/ethash"
 	"github.com/sero-cash/go-sero/core"
 	"github.com/sero-cash/go-sero/core/state"
@@ -128,6 +129,10 @@ var (
 		Name:  "keystore",
 		Usage: "Directory for the keystore (default = inside the datadir)",
 	}
+	ExternalSignerFlag = cli.StringFlag{
+		Name:  "signer",
+		Usage: "External signer of the exchange transactions (IPC path or HTTP URL of sersigner)",
+	}
 	NoUSBFlag = cli.BoolFlag{
 		Name:  "nousb",
 		Usage: "Disables monitoring for and managing USB hardware wallets",
@@ -183,6 +188,10 @@ var (
 		Usage: `Blockchain garbage collection mode ("full", "archive")`,
 		Value: "full",
 	}
+	ZeroKeepFlag = cli.Uint64Flag{
+		Name:  "gcmode.zkeep",
+		Usage: "Number of recent blocks whose zero state shortcuts are kept (0 = keep all)",
+	}
 	DashboardAddrFlag = cli.StringFlag{
 		Name:  "dashboard.addr",
 		Usage: "Dashboard listening interface",
@@ -233,11 +242,26 @@ var (
 		Name:  "txpool.nolocals",
 		Usage: "Disables price exemptions for locally submitted transactions",
 	}
+	TxPoolJournalFlag = cli.StringFlag{
+		Name:  "txpool.journal",
+		Usage: "Disk journal for local transaction to survive node restarts",
+		Value: sero.DefaultConfig.TxPool.Journal,
+	}
+	TxPoolRejournalFlag = cli.DurationFlag{
+		Name:  "txpool.rejournal",
+		Usage: "Time interval to regenerate the local transaction journal",
+		Value: sero.DefaultConfig.TxPool.Rejournal,
+	}
 	TxPoolPriceLimitFlag = cli.Uint64Flag{
 		Name:  "txpool.pricelimit",
 		Usage: "Minimum gas price limit to enforce for acceptance into the pool",
 		Value: sero.DefaultConfig.TxPool.PriceLimit,
 	}
+	TxPoolPriceBumpFlag = cli.Uint64Flag{
+		Name:  "txpool.pricebump",
+		Usage: "Price bump percentage to replace a transaction spending the same nil",
+		Value: sero.DefaultConfig.TxPool.PriceBump,
+	}
 	TxPoolAccountSlotsFlag = cli.Uint64Flag{
 		Name:  "txpool.accountslots",
 		Usage: "Minimum number of executable transaction slots guaranteed per account",
@@ -316,6 +340,16 @@ var (
 		Usage: "start light node",
 	}
 
+	WitnessFlag = cli.BoolFlag{
+		Name:  "witness",
+		Usage: "maintain the witnesses of registered roots",
+	}
+
+	CheckpointSignersFlag = cli.StringFlag{
+		Name:  "checkpoint.signers",
+		Usage: "Comma separated public keys trusted to sign zero checkpoints",
+	}
+
 	ConfirmedBlockFlag = cli.Uint64Flag{
 		Name:  "confirmedBlock",
 		Usage: "The balance will be confirmed after the current block of number,default is 12",
@@ -359,6 +393,25 @@ var (
 		Name:  "extradata",
 		Usage: "Block extra data set by the miner (default = client version)",
 	}
+	LotteryDeadlineFlag = cli.DurationFlag{
+		Name:  "lottery.deadline",
+		Usage: "Longest wait for the votes of a mined block",
+		Value: sero.DefaultConfig.LotteryDeadline,
+	}
+	LotteryFallbackFlag = cli.StringFlag{
+		Name:  "lottery.fallback",
+		Usage: `What is done with a mined block missing votes at the deadline ("drop", "minimum")`,
+		Value: sero.DefaultConfig.LotteryFallback,
+	}
+	StratumFlag = cli.StringFlag{
+		Name:  "stratum",
+		Usage: "Stratum server listening address for pool miners (e.g. :8008)",
+	}
+	StratumDifficultyFlag = cli.Uint64Flag{
+		Name:  "stratum.diff",
+		Usage: "Initial share difficulty of the stratum workers",
+		Value: sero.DefaultConfig.StratumDifficulty,
+	}
 	// AccountAddress settings
 	UnlockedAccountFlag = cli.StringFlag{
 		Name:  "unlock",
@@ -994,6 +1047,9 @@ func SetNodeConfig(ctx *cli.Context, cfg *node.Config) {
 	if ctx.GlobalIsSet(KeyStoreDirFlag.Name) {
 		cfg.KeyStoreDir = ctx.GlobalString(KeyStoreDirFlag.Name)
 	}
+	if ctx.GlobalIsSet(ExternalSignerFlag.Name) {
+		cfg.ExternalSigner = ctx.GlobalString(ExternalSignerFlag.Name)
+	}
 }
 
 func setGPO(ctx *cli.Context, cfg *gasprice.Config) {
@@ -1009,9 +1065,18 @@ func setTxPool(ctx *cli.Context, cfg *core.TxPoolConfig) {
 	if ctx.GlobalIsSet(TxPoolNoLocalsFlag.Name) {
 		cfg.NoLocals = ctx.GlobalBool(TxPoolNoLocalsFlag.Name)
 	}
+	if ctx.GlobalIsSet(TxPoolJournalFlag.Name) {
+		cfg.Journal = ctx.GlobalString(TxPoolJournalFlag.Name)
+	}
+	if ctx.GlobalIsSet(TxPoolRejournalFlag.Name) {
+		cfg.Rejournal = ctx.GlobalDuration(TxPoolRejournalFlag.Name)
+	}
 	if ctx.GlobalIsSet(TxPoolPriceLimitFlag.Name) {
 		cfg.PriceLimit = ctx.GlobalUint64(TxPoolPriceLimitFlag.Name)
 	}
+	if ctx.GlobalIsSet(TxPoolPriceBumpFlag.Name) {
+		cfg.PriceBump = ctx.GlobalUint64(TxPoolPriceBumpFlag.Name)
+	}
 	if ctx.GlobalIsSet(TxPoolAccountSlotsFlag.Name) {
 		cfg.AccountSlots = ctx.GlobalUint64(TxPoolAccountSlotsFlag.Name)
 	}
@@ -1115,6 +1180,14 @@ func SetSeroConfig(ctx *cli.Context, stack *node.Node, cfg *sero.Config) {
 	}
 	cfg.NoPruning = ctx.GlobalString(GCModeFlag.Name) == "archive"
 
+	if ctx.GlobalIsSet(ZeroKeepFlag.Name) {
+		cfg.ZeroKeep = ctx.GlobalUint64(ZeroKeepFlag.Name)
+		if cfg.ZeroKeep > 0 && cfg.ZeroKeep < sero.MinZeroKeep {
+			log.Warn("Sanitizing zero state pruning", "provided", cfg.ZeroKeep, "updated", sero.MinZeroKeep)
+			cfg.ZeroKeep = sero.MinZeroKeep
+		}
+	}
+
 	if ctx.GlobalIsSet(CacheFlag.Name) || ctx.GlobalIsSet(CacheGCFlag.Name) {
 		cfg.TrieCache = ctx.GlobalInt(CacheFlag.Name) * ctx.GlobalInt(CacheGCFlag.Name) / 100
 	}
@@ -1131,6 +1204,18 @@ func SetSeroConfig(ctx *cli.Context, stack *node.Node, cfg *sero.Config) {
 	if ctx.GlobalIsSet(ExtraDataFlag.Name) {
 		cfg.ExtraData = []byte(ctx.GlobalString(ExtraDataFlag.Name))
 	}
+	if ctx.GlobalIsSet(LotteryDeadlineFlag.Name) {
+		cfg.LotteryDeadline = ctx.GlobalDuration(LotteryDeadlineFlag.Name)
+	}
+	if ctx.GlobalIsSet(LotteryFallbackFlag.Name) {
+		cfg.LotteryFallback = ctx.GlobalString(LotteryFallbackFlag.Name)
+	}
+	if ctx.GlobalIsSet(StratumFlag.Name) {
+		cfg.StratumAddr = ctx.GlobalString(StratumFlag.Name)
+	}
+	if ctx.GlobalIsSet(StratumDifficultyFlag.Name) {
+		cfg.StratumDifficulty = ctx.GlobalUint64(StratumDifficultyFlag.Name)
+	}
 	if ctx.GlobalIsSet(GasPriceFlag.Name) {
 		cfg.GasPrice = GlobalBig(ctx, GasPriceFlag.Name)
 	}
@@ -1159,6 +1244,14 @@ func SetSeroConfig(ctx *cli.Context, stack *node.Node, cfg *sero.Config) {
 		cfg.StartLight = true
 	}
 
+	if ctx.GlobalIsSet(WitnessFlag.Name) {
+		cfg.StartWitness = true
+	}
+
+	if ctx.GlobalIsSet(CheckpointSignersFlag.Name) {
+		cfg.CheckpointSigners = strings.Split(ctx.GlobalString(CheckpointSignersFlag.Name), ",")
+	}
+
 	// Override any default configs for hard coded networks.
 	switch {
 	case ctx.GlobalBool(AlphanetFlag.Name):
@@ -1172,6 +1265,7 @@ func SetSeroConfig(ctx *cli.Context, stack *node.Node, cfg *sero.Config) {
 		}
 
 		cfg.Genesis = core.DeveloperGenesisBlock()
+		cfg.Dev = &dev.Config{Period: uint64(ctx.GlobalInt(DeveloperPeriodFlag.Name))}
 	}
 	// TODO(fjl): move trie cache generations into config
 	if gen := ctx.GlobalInt(TrieCacheGenFlag.Name); gen > 0 {
@@ -0,0 +1,124 @@
+// Package dev implements the consensus engine of the development network, it
+// seals the blocks without proof of work and votes with the local shares so
+// that the staking flows run in seconds.
+package dev
+
+import (
+	"math/big"
+	"time"
+
+	"github.com/sero-cash/go-czero-import/keys"
+	"github.com/sero-cash/go-sero/accounts"
+	"github.com/sero-cash/go-sero/common"
+	"github.com/sero-cash/go-sero/common/address"
+	"github.com/sero-cash/go-sero/consensus"
+	"github.com/sero-cash/go-sero/consensus/ethash"
+	"github.com/sero-cash/go-sero/core/types"
+	"github.com/sero-cash/go-sero/log"
+	"github.com/sero-cash/go-sero/voter"
+	"github.com/sero-cash/go-sero/zero/stake"
+)
+
+// Config are the settings of the dev engine.
+type Config struct {
+	Period uint64 // Seconds between the blocks, 0 seals a block as soon as there are transactions
+}
+
+// Dev is an ethash engine in fake mode, the headers are checked by the ethash
+// rules but any seal is valid.
+type Dev struct {
+	*ethash.Ethash
+
+	config Config
+	am     *accounts.Manager
+}
+
+// New creates a dev engine voting with the unlocked accounts of am.
+func New(config Config, am *accounts.Manager) *Dev {
+	return &Dev{
+		Ethash: ethash.NewFaker(),
+		config: config,
+		am:     am,
+	}
+}
+
+// Period returns the seconds between the blocks.
+func (self *Dev) Period() uint64 {
+	return self.config.Period
+}
+
+// Prepare implements consensus.Engine, the block is timed a period after its
+// parent.
+func (self *Dev) Prepare(chain consensus.ChainReader, header *types.Header) error {
+	parent := chain.GetHeader(header.ParentHash, header.Number.Uint64()-1)
+	if parent == nil {
+		return consensus.ErrUnknownAncestor
+	}
+	if self.config.Period > 0 {
+		header.Time = new(big.Int).Add(parent.Time, new(big.Int).SetUint64(self.config.Period))
+		if now := big.NewInt(time.Now().Unix()); header.Time.Cmp(now) < 0 {
+			header.Time = now
+		}
+	}
+	return self.Ethash.Prepare(chain, header)
+}
+
+// Seal implements consensus.Engine, the block is sealed at its time without
+// searching a nonce. Without period the blocks without transactions aren't
+// sealed.
+func (self *Dev) Seal(chain consensus.ChainReader, block *types.Block, stop <-chan struct{}) (*types.Block, error) {
+	if self.config.Period == 0 && len(block.Transactions()) == 0 {
+		log.Trace("Dev sealing paused, waiting for transactions")
+		return nil, nil
+	}
+	header := block.Header()
+	delay := time.Until(time.Unix(header.Time.Int64(), 0))
+	select {
+	case <-stop:
+		return nil, nil
+	case <-time.After(delay):
+	}
+	header.Nonce, header.MixDigest = types.BlockNonce{}, common.Hash{}
+	return block.WithSeal(header), nil
+}
+
+// Votes signs the votes of the selected shares of a block held by the unlocked
+// local accounts, the vote of a pool is preferred to the vote of the share.
+func (self *Dev) Votes(state *stake.StakeState, block, parent *types.Block, idxs []uint32, shares []*stake.Share) (votes []*types.Vote) {
+	posHash := block.HashPos()
+	parentPos := parent.HashPos()
+	wallets := self.am.Wallets()
+	for i, share := range shares {
+		isPool := false
+		votePKr := share.VotePKr
+		var seed *address.Seed
+		if share.PoolId != nil {
+			if pool := state.GetStakePool(*share.PoolId); pool != nil && pool.CanBeVote() {
+				if seed = voter.GetSeedByVotePkr(wallets, pool.VotePKr); seed != nil {
+					isPool = true
+					votePKr = pool.VotePKr
+				}
+			}
+		}
+		if seed == nil {
+			if seed = voter.GetSeedByVotePkr(wallets, share.VotePKr); seed == nil {
+				continue
+			}
+		}
+		stakeHash := types.StakeHash(&posHash, &parentPos, isPool)
+		sign, err := keys.SignPKr(seed.SeedToUint256(), stakeHash.HashToUint256(), &votePKr)
+		if err != nil {
+			log.Error("Dev vote sign failed", "share", common.BytesToHash(share.Id()), "err", err)
+			continue
+		}
+		votes = append(votes, &types.Vote{
+			Idx:       idxs[i],
+			ParentNum: parent.NumberU64(),
+			ShareId:   common.BytesToHash(share.Id()),
+			PosHash:   posHash,
+			IsPool:    isPool,
+			Sign:      sign,
+		})
+	}
+	return
+}
@@ -0,0 +1,213 @@
+package dev
+
+import (
+	"io/ioutil"
+	"math/big"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/sero-cash/go-czero-import/cpt"
+	"github.com/sero-cash/go-czero-import/keys"
+	"github.com/sero-cash/go-czero-import/seroparam"
+	"github.com/sero-cash/go-sero/accounts"
+	"github.com/sero-cash/go-sero/accounts/keystore"
+	"github.com/sero-cash/go-sero/common"
+	"github.com/sero-cash/go-sero/core"
+	"github.com/sero-cash/go-sero/core/state"
+	"github.com/sero-cash/go-sero/core/types"
+	"github.com/sero-cash/go-sero/core/vm"
+	"github.com/sero-cash/go-sero/params"
+	"github.com/sero-cash/go-sero/serodb"
+	"github.com/sero-cash/go-sero/zero/stake"
+	"github.com/sero-cash/go-sero/zero/txtool/prepare"
+)
+
+// newTestManager returns an account manager holding an unlocked account.
+func newTestManager(t *testing.T) (*accounts.Manager, accounts.Account, func()) {
+	dir, err := ioutil.TempDir("", "dev")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ks := keystore.NewKeyStore(dir, keystore.LightScryptN, keystore.LightScryptP)
+	account, err := ks.NewAccount("", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ks.Unlock(account, ""); err != nil {
+		t.Fatal(err)
+	}
+	am := accounts.NewManager(ks)
+	return am, account, func() {
+		am.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+func TestPrepareAndSeal(t *testing.T) {
+	engine := New(Config{Period: 2}, nil)
+	db := serodb.NewMemDatabase()
+	genesis := (&core.Genesis{Config: params.TestChainConfig, Timestamp: uint64(time.Now().Unix())}).MustCommit(db)
+	chain, err := core.NewBlockChain(db, nil, params.TestChainConfig, engine, vm.Config{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer chain.Stop()
+
+	header := &types.Header{ParentHash: genesis.Hash(), Number: big.NewInt(1), GasLimit: genesis.GasLimit(), Time: new(big.Int)}
+	if err := engine.Prepare(chain, header); err != nil {
+		t.Fatal(err)
+	}
+	if want := new(big.Int).Add(genesis.Time(), big.NewInt(2)); header.Time.Cmp(want) != 0 {
+		t.Fatalf("block time mismatch: have %v, want %v", header.Time, want)
+	}
+	start := time.Now()
+	sealed, err := engine.Seal(chain, types.NewBlockWithHeader(header), nil)
+	if err != nil || sealed == nil {
+		t.Fatalf("block not sealed: %v", err)
+	}
+	if time.Now().Unix() < header.Time.Int64() || time.Since(start) > 3*time.Second {
+		t.Fatalf("block sealed at %v, timed %v", time.Now().Unix(), header.Time)
+	}
+	if err := engine.VerifyHeader(chain, sealed.Header(), true); err != nil {
+		t.Fatalf("sealed block invalid: %v", err)
+	}
+
+	// Without period only the blocks with transactions are sealed
+	engine = New(Config{}, nil)
+	if sealed, err := engine.Seal(chain, types.NewBlockWithHeader(header), nil); sealed != nil || err != nil {
+		t.Fatalf("empty block sealed without period: %v", err)
+	}
+}
+
+// shareProcessor adds the shares bought in the block 1 as the processing of
+// the transactions buying them would.
+type shareProcessor struct {
+	core.Processor
+	shares []*stake.Share
+}
+
+func (p *shareProcessor) Process(block *types.Block, statedb *state.StateDB, cfg vm.Config) (types.Receipts, []*types.Log, uint64, error) {
+	if block.NumberU64() == 1 {
+		addShares(stake.NewStakeState(statedb), p.shares)
+	}
+	return p.Processor.Process(block, statedb, cfg)
+}
+
+func addShares(stakeState *stake.StakeState, shares []*stake.Share) {
+	for _, share := range shares {
+		stakeState.AddPendingShare(share.CopyTo().(*stake.Share))
+	}
+}
+
+// makeBlock finalizes an empty block on parent as the miner does, processing
+// the stake state before the transactions.
+func makeBlock(t *testing.T, chain *core.BlockChain, engine *Dev, parent *types.Block, shares []*stake.Share) *types.Block {
+	header := &types.Header{
+		ParentHash: parent.Hash(),
+		Number:     new(big.Int).Add(parent.Number(), common.Big1),
+		GasLimit:   parent.GasLimit(),
+		Time:       new(big.Int).Add(parent.Time(), big.NewInt(10)),
+	}
+	if err := engine.Prepare(chain, header); err != nil {
+		t.Fatal(err)
+	}
+	statedb, err := chain.StateAt(parent.Header())
+	if err != nil {
+		t.Fatal(err)
+	}
+	stakeState := stake.NewStakeState(statedb)
+	if err := stakeState.ProcessBeforeApply(chain, header); err != nil {
+		t.Fatal(err)
+	}
+	if header.Number.Uint64() == 1 {
+		addShares(stakeState, shares)
+	}
+	block, err := engine.Finalize(chain, header, statedb, nil, nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return block
+}
+
+// TestStakingFlow buys shares voted by a local account and by a foreign one on
+// a dev chain, the dev engine signs the votes of the local shares selected for
+// a block and the chain accepts them.
+func TestStakingFlow(t *testing.T) {
+	seroparam.Init_Dev(true)
+	cpt.ZeroInit("", cpt.NET_Dev)
+	am, account, closeAm := newTestManager(t)
+	defer closeAm()
+	engine := New(Config{}, am)
+
+	local := &stake.Share{BlockNumber: 1, InitNum: 2, Value: big.NewInt(1), Income: new(big.Int), Profit: new(big.Int)}
+	local.VotePKr = prepare.CreatePkr(account.Address.ToUint512(), 1)
+	foreign := &stake.Share{BlockNumber: 1, InitNum: 2, Value: big.NewInt(1), Income: new(big.Int), Profit: new(big.Int)}
+	foreignPk := keys.Uint512{1}
+	foreign.VotePKr = prepare.CreatePkr(&foreignPk, 1)
+	shares := []*stake.Share{local, foreign}
+
+	db := serodb.NewMemDatabase()
+	parent := (&core.Genesis{Config: params.TestChainConfig}).MustCommit(db)
+	chain, err := core.NewBlockChain(db, nil, params.TestChainConfig, engine, vm.Config{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer chain.Stop()
+	chain.SetProcessor(&shareProcessor{core.NewStateProcessor(params.TestChainConfig, chain, engine), shares})
+
+	// Find a block selecting the local shares
+	for number := 1; number < 64; number++ {
+		block := makeBlock(t, chain, engine, parent, shares)
+		statedb, err := chain.StateAt(parent.Header())
+		if err != nil {
+			t.Fatal(err)
+		}
+		stakeState := stake.NewStakeState(statedb)
+		if err := stakeState.ProcessBeforeApply(chain, block.Header()); err != nil {
+			t.Fatal(err)
+		}
+		if number >= 2 {
+			idxs, selected, err := stakeState.SeleteShare(block.HashPos())
+			if err != nil {
+				t.Fatal(err)
+			}
+			votes := engine.Votes(stakeState, block, parent, idxs, selected)
+			locals := 0
+			for _, share := range selected {
+				if share.VotePKr == local.VotePKr {
+					locals++
+				}
+			}
+			if len(votes) != locals {
+				t.Fatalf("block %d: %d votes for %d local shares selected", number, len(votes), locals)
+			}
+			if locals > 0 {
+				header := block.Header()
+				seen := make(map[keys.Uint512]bool)
+				for _, vote := range votes {
+					if vote.ShareId != common.BytesToHash(local.Id()) || vote.IsPool {
+						t.Fatalf("vote of share %x, want %x", vote.ShareId, local.Id())
+					}
+					if !seen[vote.Sign] {
+						seen[vote.Sign] = true
+						header.CurrentVotes = append(header.CurrentVotes, types.HeaderVote{Id: vote.ShareId, IsPool: vote.IsPool, Sign: vote.Sign})
+					}
+				}
+				if err := stakeState.CheckVotes(types.NewBlockWithHeader(header), chain); err != nil {
+					t.Fatalf("votes of the dev engine rejected: %v", err)
+				}
+				header.CurrentVotes[0].Sign[0] ^= 1
+				if err := stakeState.CheckVotes(types.NewBlockWithHeader(header), chain); err == nil {
+					t.Fatalf("forged vote accepted")
+				}
+				return
+			}
+		}
+		if _, err := chain.InsertChain(types.Blocks{block}); err != nil {
+			t.Fatal(err)
+		}
+		parent = block
+	}
+	t.Fatalf("local shares never selected")
+}
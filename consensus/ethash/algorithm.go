@@ -173,7 +173,9 @@ func generateCache(dest []uint32, epoch uint64, seed []byte) {
 			case <-done:
 				return
 			case <-time.After(3 * time.Second):
-				logger.Info("Generating ethash verification cache", "percentage", atomic.LoadUint32(&progress)*100/uint32(rows)/4, "elapsed", common.PrettyDuration(time.Since(start)))
+				percentage := atomic.LoadUint32(&progress) * 100 / uint32(rows) / 4
+				dags.update("cache", epoch, uint64(percentage))
+				logger.Info("Generating ethash verification cache", "percentage", percentage, "elapsed", common.PrettyDuration(time.Since(start)))
 			}
 		}
 	}()
@@ -322,7 +324,9 @@ func generateDataset(dest []uint32, epoch uint64, cache []uint32) {
 				copy(dataset[index*hashBytes:], item)
 
 				if status := atomic.AddUint32(&progress, 1); status%percent == 0 {
-					logger.Info("Generating DAG in progress", "percentage", uint64(status*100)/(size/hashBytes), "elapsed", common.PrettyDuration(time.Since(start)))
+					percentage := uint64(status) * 100 / (size / hashBytes)
+					dags.update("dataset", epoch, percentage)
+					logger.Info("Generating DAG in progress", "percentage", percentage, "elapsed", common.PrettyDuration(time.Since(start)))
 				}
 			}
 		}(i)
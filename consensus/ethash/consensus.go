@@ -342,6 +342,46 @@ func (ethash *Ethash) VerifySeal(chain consensus.ChainReader, header *types.Head
 		return errInvalidDifficulty
 	}
 	// Recompute the digest and PoW value and verify against the header
+	digest, result := ethash.powHash(header)
+
+	if !bytes.Equal(header.MixDigest[:], digest) {
+		return errInvalidMixDigest
+	}
+	target := new(big.Int).Div(maxUint256, header.ActualDifficulty())
+	if new(big.Int).SetBytes(result).Cmp(target) > 0 {
+		return errInvalidPoW
+	}
+	return nil
+}
+
+// VerifyShare checks the proof-of-work of a header against a share difficulty
+// lower than the one of the block, as pools do for the shares of their miners.
+// It returns the mix digest of the nonce and whether the header seals the block.
+func (ethash *Ethash) VerifyShare(header *types.Header, difficulty *big.Int) (digest common.Hash, block bool, err error) {
+	// If we're running a fake PoW, every share seals the block
+	if ethash.config.PowMode == ModeFake || ethash.config.PowMode == ModeFullFake {
+		return common.Hash{}, true, nil
+	}
+	if ethash.shared != nil {
+		return ethash.shared.VerifyShare(header, difficulty)
+	}
+	if difficulty.Sign() <= 0 || header.Difficulty.Sign() <= 0 {
+		return common.Hash{}, false, errInvalidDifficulty
+	}
+	mix, result := ethash.powHash(header)
+	digest = common.BytesToHash(mix)
+
+	value := new(big.Int).SetBytes(result)
+	if value.Cmp(new(big.Int).Div(maxUint256, difficulty)) > 0 {
+		return digest, false, errInvalidPoW
+	}
+	block = value.Cmp(new(big.Int).Div(maxUint256, header.ActualDifficulty())) <= 0
+	return digest, block, nil
+}
+
+// powHash computes the mix digest and the PoW value of a header with the
+// verification cache.
+func (ethash *Ethash) powHash(header *types.Header) (digest []byte, result []byte) {
 	number := header.Number.Uint64()
 
 	cache := ethash.cache(number)
@@ -350,8 +390,6 @@ func (ethash *Ethash) VerifySeal(chain consensus.ChainReader, header *types.Head
 		size = 32 * 1024
 	}
 
-	var digest []byte
-	var result []byte
 	if number >= seroparam.SIP3() {
 		//dataset := ethash.dataset_async(number)
 		//if dataset.generated() {
@@ -365,15 +403,7 @@ func (ethash *Ethash) VerifySeal(chain consensus.ChainReader, header *types.Head
 	// Caches are unmapped in a finalizer. Ensure that the cache stays live
 	// until after the call to hashimotoLight so it's not unmapped while being used.
 	runtime.KeepAlive(cache)
-
-	if !bytes.Equal(header.MixDigest[:], digest) {
-		return errInvalidMixDigest
-	}
-	target := new(big.Int).Div(maxUint256, header.ActualDifficulty())
-	if new(big.Int).SetBytes(result).Cmp(target) > 0 {
-		return errInvalidPoW
-	}
-	return nil
+	return
 }
 
 // Prepare implements consensus.Engine, initializing the difficulty field of a
@@ -473,6 +503,27 @@ func accumulateRewards(config *params.ChainConfig, statedb *state.StateDB, heade
 	statedb.NextZState().AddTxOut(header.Coinbase, asset, common.BytesToHash([]byte{1}))
 }
 
+// BlockReward returns the proof of work reward of the coinbase of a block
+// mined on statedb, the fees excluded. The rewards are computed on a copy of
+// statedb.
+func BlockReward(config *params.ChainConfig, statedb *state.StateDB, header *types.Header) (reward *big.Int) {
+	statedb = statedb.Copy()
+	if header.Number.Uint64() >= seroparam.SIP4() {
+		reward = accumulateRewardsV4(statedb, header)
+	} else if header.Number.Uint64() >= seroparam.SIP3() {
+		reward = accumulateRewardsV3(statedb, header)
+	} else if header.Number.Uint64() >= seroparam.SIP1() {
+		reward = accumulateRewardsV2(statedb, header)
+	} else {
+		reward = accumulateRewardsV1(config, statedb, header)
+	}
+
+	if seroparam.Is_Dev() {
+		reward = new(big.Int).Set(oneSero)
+	}
+	return
+}
+
 func accumulateRewardsV1(config *params.ChainConfig, statedb *state.StateDB, header *types.Header) *big.Int {
 	poolBalance := statedb.GetBalance(state.EmptyAddress, "SERO")
 	if poolBalance.Sign() <= 0 {
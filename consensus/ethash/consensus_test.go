@@ -25,8 +25,10 @@ import (
 	"testing"
 
 	"github.com/sero-cash/go-sero/common/math"
+	"github.com/sero-cash/go-sero/core/state"
 	"github.com/sero-cash/go-sero/core/types"
 	"github.com/sero-cash/go-sero/params"
+	"github.com/sero-cash/go-sero/serodb"
 )
 
 type diffTest struct {
@@ -244,7 +246,8 @@ func print(number int64, difficulty int64) {
 		Number:     big.NewInt(number),
 		Difficulty: big.NewInt(difficulty),
 	}
-	v2 := accumulateRewardsV4(nil, header)
+	statedb, _ := state.New(state.NewDatabase(serodb.NewMemDatabase()), nil)
+	v2 := BlockReward(params.TestChainConfig, statedb, header)
 	fmt.Println(number, difficulty)
 	fmt.Println(v2)
 	fmt.Println(new(big.Float).Quo(new(big.Float).SetInt(v2), big.NewFloat(1e+18)))
@@ -323,4 +326,48 @@ func TestCalcDifficultyV4(t *testing.T) {
 	print(3057601+8294400*2, 140000000000000)
 	print(3057601+8294400*2, 140000000000001)
 	print(3057601+8294400*2, 150000000000001)
-}
\ No newline at end of file
+}
+
+func TestBlockReward(t *testing.T) {
+	sero := func(tenths int64) *big.Int { return new(big.Int).Mul(big.NewInt(tenths), base) }
+	statedb, err := state.New(state.NewDatabase(serodb.NewMemDatabase()), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		number     int64
+		difficulty *big.Int
+		pool       *big.Int
+		licr       uint64
+		reward     *big.Int
+	}{
+		{1, big.NewInt(1717986918), nil, 0, new(big.Int)},        // empty reward pool
+		{1, big.NewInt(1717986918), sero(10000), 0, sero(280)},   // 350 sero, 4/5 with the gas unused
+		{130000, big.NewInt(0), nil, 0, sero(10)},                // below the first difficulty level
+		{940000, big.NewInt(0), nil, 0, sero(176)},               // lowest reward
+		{940000, big.NewInt(0), nil, 1, new(big.Int)},            // licensed miner
+		{1300000, big.NewInt(0), nil, 0, sero(176)},              // lowest reward
+		{1300000, new(big.Int).Lsh(big1, 80), nil, 0, sero(356)}, // highest reward
+		{3057600, new(big.Int).Lsh(big1, 80), nil, 0, sero(178)}, // first halving
+	}
+	for i, tt := range tests {
+		if pool := statedb.GetBalance(state.EmptyAddress, "SERO"); pool.Sign() > 0 {
+			statedb.SubBalance(state.EmptyAddress, "SERO", pool)
+		}
+		if tt.pool != nil {
+			statedb.AddBalance(state.EmptyAddress, "SERO", tt.pool)
+		}
+		header := &types.Header{Number: big.NewInt(tt.number), Difficulty: tt.difficulty, GasLimit: 1}
+		header.Licr.C = tt.licr
+		if reward := BlockReward(params.TestChainConfig, statedb, header); reward.Cmp(tt.reward) != 0 {
+			t.Errorf("test %d: reward mismatch: have %v, want %v", i, reward, tt.reward)
+		}
+		// The reward pools are left unchanged
+		if tt.pool != nil && statedb.GetBalance(state.EmptyAddress, "SERO").Cmp(tt.pool) != 0 {
+			t.Errorf("test %d: reward pool changed", i)
+		}
+		if balance := statedb.GetBalance(teamRewardPool, "SERO"); balance.Sign() != 0 {
+			t.Errorf("test %d: team reward pool credited %v", i, balance)
+		}
+	}
+}
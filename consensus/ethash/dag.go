@@ -0,0 +1,276 @@
+package ethash
+
+import (
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sero-cash/go-sero/log"
+	"github.com/sero-cash/go-sero/metrics"
+)
+
+var ErrInvalidDumpChecksum = errors.New("invalid dump checksum")
+
+// checksumPath returns the path of the checksum of a dump.
+func checksumPath(path string) string {
+	return path + ".sum"
+}
+
+// writeChecksum stores the checksum of the content of a dump, magic included.
+// It is computed once when the dump is generated, the loads map the dumps
+// without reading them and the checksums are only verified on demand.
+func writeChecksum(path string, mem []byte) error {
+	return ioutil.WriteFile(checksumPath(path), []byte(fmt.Sprintf("%08x", crc32.ChecksumIEEE(mem))), 0644)
+}
+
+// verifyChecksum checks the content of a dump against its checksum.
+func verifyChecksum(path string) error {
+	data, err := ioutil.ReadFile(checksumPath(path))
+	if err != nil {
+		return err
+	}
+	want, err := strconv.ParseUint(strings.TrimSpace(string(data)), 16, 32)
+	if err != nil {
+		return ErrInvalidDumpChecksum
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	hash := crc32.NewIEEE()
+	if _, err := io.Copy(hash, file); err != nil {
+		return err
+	}
+	if hash.Sum32() != uint32(want) {
+		return ErrInvalidDumpChecksum
+	}
+	return nil
+}
+
+// DumpCheck is the verification of a dump against its checksum.
+type DumpCheck struct {
+	Path  string `json:"path"`
+	Valid bool   `json:"valid"`
+	Error string `json:"error,omitempty"`
+}
+
+// verifyDumps checks the dumps of dir having a checksum. The corrupted ones
+// are removed, they are generated again when next used.
+func verifyDumps(dir string) (checks []DumpCheck) {
+	if dir == "" {
+		return nil
+	}
+	sums, _ := filepath.Glob(filepath.Join(dir, "*.sum"))
+	for _, sum := range sums {
+		path := strings.TrimSuffix(sum, ".sum")
+		check := DumpCheck{Path: path, Valid: true}
+		if err := verifyChecksum(path); err != nil {
+			check.Valid, check.Error = false, err.Error()
+			if err == ErrInvalidDumpChecksum || os.IsNotExist(err) {
+				log.Warn("Removing corrupted ethash dump", "path", path, "err", err)
+				removeDump(path)
+			}
+		}
+		checks = append(checks, check)
+	}
+	return
+}
+
+// removeDump deletes a dump and its checksum.
+func removeDump(path string) {
+	os.Remove(path)
+	os.Remove(checksumPath(path))
+}
+
+// DAGStatus is the state of the generation of a verification cache, a progpow
+// cdag or a mining dataset.
+type DAGStatus struct {
+	Kind       string  `json:"kind"` // cache, cdag or dataset
+	Epoch      uint64  `json:"epoch"`
+	Percentage uint64  `json:"percentage"`
+	Loaded     bool    `json:"loaded"` // Read from disk instead of generated
+	Done       bool    `json:"done"`
+	Elapsed    float64 `json:"elapsed"` // Seconds
+
+	start, end time.Time
+}
+
+// dagGauges are the metrics of the latest generation of a kind.
+type dagGauges struct {
+	epoch    metrics.Gauge
+	progress metrics.Gauge
+}
+
+// dagTracker follows the generations of the process, whatever ethash instance
+// requested them.
+type dagTracker struct {
+	mu     sync.Mutex
+	items  map[string]*DAGStatus
+	gauges map[string]dagGauges
+}
+
+// maxTrackedEpochs is the number of epochs the tracker remembers.
+const maxTrackedEpochs = 4
+
+var dags = &dagTracker{
+	items:  make(map[string]*DAGStatus),
+	gauges: make(map[string]dagGauges),
+}
+
+func dagKey(kind string, epoch uint64) string {
+	return kind + "-" + strconv.FormatUint(epoch, 10)
+}
+
+func (self *dagTracker) gauge(kind string) dagGauges {
+	g, ok := self.gauges[kind]
+	if !ok {
+		g = dagGauges{
+			epoch:    metrics.NewRegisteredGauge("ethash/"+kind+"/epoch", nil),
+			progress: metrics.NewRegisteredGauge("ethash/"+kind+"/progress", nil),
+		}
+		self.gauges[kind] = g
+	}
+	return g
+}
+
+// start records the beginning of a generation and forgets the oldest epochs.
+func (self *dagTracker) start(kind string, epoch uint64) {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+
+	self.items[dagKey(kind, epoch)] = &DAGStatus{Kind: kind, Epoch: epoch, start: time.Now()}
+	for key, item := range self.items {
+		if item.Epoch+maxTrackedEpochs <= epoch {
+			delete(self.items, key)
+		}
+	}
+	g := self.gauge(kind)
+	g.epoch.Update(int64(epoch))
+	g.progress.Update(0)
+}
+
+// update sets the percentage of a generation.
+func (self *dagTracker) update(kind string, epoch uint64, percentage uint64) {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+
+	if item, ok := self.items[dagKey(kind, epoch)]; ok && !item.Done {
+		item.Percentage = percentage
+		self.gauge(kind).progress.Update(int64(percentage))
+	}
+}
+
+// finish records the end of a generation, or the load of a dump if loaded.
+func (self *dagTracker) finish(kind string, epoch uint64, loaded bool) {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+
+	item, ok := self.items[dagKey(kind, epoch)]
+	if !ok {
+		item = &DAGStatus{Kind: kind, Epoch: epoch, start: time.Now()}
+		self.items[dagKey(kind, epoch)] = item
+	}
+	item.Percentage, item.Loaded, item.Done = 100, loaded, true
+	item.end = time.Now()
+
+	g := self.gauge(kind)
+	g.epoch.Update(int64(epoch))
+	g.progress.Update(100)
+}
+
+// list returns the tracked generations by epoch.
+func (self *dagTracker) list() []DAGStatus {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+
+	list := make([]DAGStatus, 0, len(self.items))
+	for _, item := range self.items {
+		status := *item
+		if status.Done {
+			status.Elapsed = status.end.Sub(status.start).Seconds()
+		} else {
+			status.Elapsed = time.Since(status.start).Seconds()
+		}
+		list = append(list, status)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Epoch != list[j].Epoch {
+			return list[i].Epoch < list[j].Epoch
+		}
+		return list[i].Kind < list[j].Kind
+	})
+	return list
+}
+
+// Pregenerate prepares in the background the verification cache of the epoch
+// of block and of the next one, and their datasets when mining, so that the
+// blocks crossing an epoch boundary don't wait for them. It returns at once and
+// does nothing while a previous pregeneration runs.
+func (ethash *Ethash) Pregenerate(block uint64, mining bool) {
+	if ethash.shared != nil {
+		ethash.shared.Pregenerate(block, mining)
+		return
+	}
+	if ethash.config.PowMode != ModeNormal && ethash.config.PowMode != ModeTest {
+		return
+	}
+	if !atomic.CompareAndSwapUint32(&ethash.pregenerating, 0, 1) {
+		return
+	}
+	go func() {
+		defer atomic.StoreUint32(&ethash.pregenerating, 0)
+
+		test := ethash.config.PowMode == ModeTest
+		epoch := block / epochLength
+
+		ethash.cache(block)
+		if next, ok := ethash.caches.peek(epoch + 1).(*cache); ok {
+			next.generate(ethash.config.CacheDir, ethash.config.CachesOnDisk, test)
+		}
+		if !mining {
+			return
+		}
+		ethash.dataset(block)
+		if next, ok := ethash.datasets.peek(epoch + 1).(*dataset); ok {
+			next.generate(ethash.config.DatasetDir, ethash.config.DatasetsOnDisk, test)
+		}
+	}()
+}
+
+// API exposes the state of the ethash caches and datasets.
+type API struct {
+	ethash *Ethash
+}
+
+// DagStatus returns the generations of the verification caches, progpow cdags
+// and mining datasets of the latest epochs.
+func (api *API) DagStatus() []DAGStatus {
+	return dags.list()
+}
+
+// VerifyDags checks the dumps on disk against their checksums, reading them
+// whole. The corrupted dumps are removed, those in use stay mapped until their
+// epoch is evicted.
+func (api *API) VerifyDags() []DumpCheck {
+	ethash := api.ethash
+	if ethash.shared != nil {
+		ethash = ethash.shared
+	}
+	checks := verifyDumps(ethash.config.CacheDir)
+	if ethash.config.DatasetDir != ethash.config.CacheDir {
+		checks = append(checks, verifyDumps(ethash.config.DatasetDir)...)
+	}
+	return checks
+}
@@ -0,0 +1,83 @@
+package ethash
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDumpChecksum(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ethash-dag")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "cache-R23-0000000000000000")
+	generate := func(buffer []uint32) {
+		for i := range buffer {
+			buffer[i] = uint32(i)
+		}
+	}
+	dump, mem, buffer, err := memoryMapAndGenerate(path, 1024, generate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(buffer) != 256 || buffer[255] != 255 {
+		t.Fatalf("generated dump mismatch: %d words", len(buffer))
+	}
+	mem.Unmap()
+	dump.Close()
+
+	// The dumps are loaded without their checksum
+	if err := os.Remove(checksumPath(path)); err != nil {
+		t.Fatalf("checksum not written: %v", err)
+	}
+	dump, mem, _, err = memoryMap(path)
+	if err != nil {
+		t.Fatalf("dump without checksum not loaded: %v", err)
+	}
+	mem.Unmap()
+	dump.Close()
+	if checks := verifyDumps(dir); len(checks) != 0 {
+		t.Fatalf("dump without checksum checked: %v", checks)
+	}
+
+	// And checked on demand, the corrupted ones are removed
+	os.Remove(path)
+	if dump, mem, _, err = memoryMapAndGenerate(path, 1024, generate); err != nil {
+		t.Fatal(err)
+	}
+	mem.Unmap()
+	dump.Close()
+	if checks := verifyDumps(dir); len(checks) != 1 || !checks[0].Valid || checks[0].Path != path {
+		t.Fatalf("valid dump check mismatch: %v", checks)
+	}
+	file, err := os.OpenFile(path, os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.WriteAt([]byte{0xff}, 100)
+	file.Close()
+	if checks := verifyDumps(dir); len(checks) != 1 || checks[0].Valid {
+		t.Fatalf("corrupted dump check mismatch: %v", checks)
+	}
+	for _, p := range []string{path, checksumPath(path)} {
+		if _, err := os.Stat(p); !os.IsNotExist(err) {
+			t.Fatalf("%s of the corrupted dump kept", p)
+		}
+	}
+
+	// A dump whose checksum can't be written is dropped
+	path = filepath.Join(dir, "full-R23-0000000000000000")
+	if err := os.Mkdir(checksumPath(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, _, err := memoryMapAndGenerate(path, 1024, generate); err == nil {
+		t.Fatalf("dump generated without checksum")
+	}
+	if temps, _ := filepath.Glob(path + ".[0-9]*"); len(temps) != 0 {
+		t.Fatalf("temporary dumps left: %v", temps)
+	}
+}
@@ -133,6 +133,12 @@ func memoryMapAndGenerate(path string, size uint64, generator func(buffer []uint
 	data := buffer[len(dumpMagic):]
 	generator(data)
 
+	if err := writeChecksum(path, mem); err != nil {
+		mem.Unmap()
+		dump.Close()
+		os.Remove(temp)
+		return nil, nil, nil, err
+	}
 	if err := mem.Unmap(); err != nil {
 		return nil, nil, nil, err
 	}
@@ -197,19 +203,63 @@ func (lru *lru) get(epoch uint64) (item, future interface{}) {
 	return item, future
 }
 
+// peek returns the item of an epoch if it is in memory, the future item
+// included, without creating it nor updating its use time.
+func (lru *lru) peek(epoch uint64) interface{} {
+	lru.mu.Lock()
+	defer lru.mu.Unlock()
+
+	if item, ok := lru.cache.Peek(epoch); ok {
+		return item
+	}
+	if lru.future > 0 && lru.future == epoch {
+		return lru.futureItem
+	}
+	return nil
+}
+
 // cache wraps an ethash cache with some metadata to allow easier concurrent use.
 type cache struct {
 	epoch uint64    // Epoch for which this cache is relevant
 	dump  *os.File  // File descriptor of the memory mapped cache
 	mmap  mmap.MMap // Memory map itself to unmap before releasing
 	cache []uint32  // The actual cache data content (may be memory mapped)
-	cdag  []uint32
 	once  sync.Once // Ensures the cache is generated only once
+
+	cdagDump *os.File  // File descriptor of the memory mapped progpow cdag
+	cdagMmap mmap.MMap // Memory map of the cdag to unmap before releasing
+	cdag     []uint32  // The progpow cdag derived from the cache (may be memory mapped)
 }
 
-func (self *cache) generateCDag() {
-	self.cdag = make([]uint32, progpowCacheWords)
-	generateCDag(self.cdag, self.cache, self.epoch)
+// generateCDag loads the progpow cdag of the cache from path, generating and
+// storing it if missing. Without path it is generated in memory.
+func (self *cache) generateCDag(path string) {
+	dags.start("cdag", self.epoch)
+	if path == "" {
+		self.cdag = make([]uint32, progpowCacheWords)
+		generateCDag(self.cdag, self.cache, self.epoch)
+		dags.finish("cdag", self.epoch, false)
+		return
+	}
+	logger := log.New("epoch", self.epoch)
+
+	var err error
+	self.cdagDump, self.cdagMmap, self.cdag, err = memoryMap(path)
+	if err == nil {
+		logger.Debug("Loaded old progpow cdag from disk")
+		dags.finish("cdag", self.epoch, true)
+		return
+	}
+	logger.Debug("Failed to load old progpow cdag", "err", err)
+
+	self.cdagDump, self.cdagMmap, self.cdag, err = memoryMapAndGenerate(path, progpowCacheWords*4, func(buffer []uint32) { generateCDag(buffer, self.cache, self.epoch) })
+	if err != nil {
+		logger.Error("Failed to generate mapped progpow cdag", "err", err)
+
+		self.cdag = make([]uint32, progpowCacheWords)
+		generateCDag(self.cdag, self.cache, self.epoch)
+	}
+	dags.finish("cdag", self.epoch, false)
 }
 
 // newCache creates a new ethash verification cache and returns it as a plain Go
@@ -228,9 +278,11 @@ func (c *cache) generate(dir string, limit int, test bool) {
 		}
 		// If we don't store anything on disk, generate and return.
 		if dir == "" {
+			dags.start("cache", c.epoch)
 			c.cache = make([]uint32, size/4)
 			generateCache(c.cache, c.epoch, seed)
-			c.generateCDag()
+			dags.finish("cache", c.epoch, false)
+			c.generateCDag("")
 			return
 		}
 		// Disk storage is needed, this will get fancy
@@ -239,6 +291,7 @@ func (c *cache) generate(dir string, limit int, test bool) {
 			endian = ".be"
 		}
 		path := filepath.Join(dir, fmt.Sprintf("cache-R%d-%x%s", algorithmRevision, seed[:8], endian))
+		cdagPath := filepath.Join(dir, fmt.Sprintf("cdag-R%d-%x%s", algorithmRevision, seed[:8], endian))
 		logger := log.New("epoch", c.epoch)
 
 		// We're about to mmap the file, ensure that the mapping is cleaned up when the
@@ -249,13 +302,14 @@ func (c *cache) generate(dir string, limit int, test bool) {
 		var err error
 		c.dump, c.mmap, c.cache, err = memoryMap(path)
 		if err == nil {
-			c.generateCDag()
 			logger.Debug("Loaded old ethash cache from disk")
+			dags.finish("cache", c.epoch, true)
+			c.generateCDag(cdagPath)
 			return
 		}
 		logger.Debug("Failed to load old ethash cache", "err", err)
-
 		// No previous cache available, create a new cache file to fill
+		dags.start("cache", c.epoch)
 		c.dump, c.mmap, c.cache, err = memoryMapAndGenerate(path, size, func(buffer []uint32) { generateCache(buffer, c.epoch, seed) })
 		if err != nil {
 			logger.Error("Failed to generate mapped ethash cache", "err", err)
@@ -263,13 +317,15 @@ func (c *cache) generate(dir string, limit int, test bool) {
 			c.cache = make([]uint32, size/4)
 			generateCache(c.cache, c.epoch, seed)
 		}
+		dags.finish("cache", c.epoch, false)
+
 		// Iterate over all previous instances and delete old ones
 		for ep := int(c.epoch) - limit; ep >= 0; ep-- {
 			seed := seedHash(uint64(ep)*epochLength + 1)
-			path := filepath.Join(dir, fmt.Sprintf("cache-R%d-%x%s", algorithmRevision, seed[:8], endian))
-			os.Remove(path)
+			removeDump(filepath.Join(dir, fmt.Sprintf("cache-R%d-%x%s", algorithmRevision, seed[:8], endian)))
+			removeDump(filepath.Join(dir, fmt.Sprintf("cdag-R%d-%x%s", algorithmRevision, seed[:8], endian)))
 		}
-		c.generateCDag()
+		c.generateCDag(cdagPath)
 	})
 }
 
@@ -280,6 +336,11 @@ func (c *cache) finalizer() {
 		c.dump.Close()
 		c.mmap, c.dump = nil, nil
 	}
+	if c.cdagMmap != nil {
+		c.cdagMmap.Unmap()
+		c.cdagDump.Close()
+		c.cdagMmap, c.cdagDump = nil, nil
+	}
 }
 
 // dataset wraps an ethash dataset with some metadata to allow easier concurrent use.
@@ -316,11 +377,14 @@ func (d *dataset) generate(dir string, limit int, test bool) {
 		}
 		// If we don't store anything on disk, generate and return
 		if dir == "" {
+			dags.start("dataset", d.epoch)
 			cache := make([]uint32, csize/4)
 			generateCache(cache, d.epoch, seed)
 
 			d.dataset = make([]uint32, dsize/4)
 			generateDataset(d.dataset, d.epoch, cache)
+			dags.finish("dataset", d.epoch, false)
+			return
 		}
 		// Disk storage is needed, this will get fancy
 		var endian string
@@ -339,11 +403,12 @@ func (d *dataset) generate(dir string, limit int, test bool) {
 		d.dump, d.mmap, d.dataset, err = memoryMap(path)
 		if err == nil {
 			logger.Debug("Loaded old ethash dataset from disk")
+			dags.finish("dataset", d.epoch, true)
 			return
 		}
 		logger.Debug("Failed to load old ethash dataset", "err", err)
-
 		// No previous dataset available, create a new dataset file to fill
+		dags.start("dataset", d.epoch)
 		cache := make([]uint32, csize/4)
 		generateCache(cache, d.epoch, seed)
 
@@ -354,11 +419,12 @@ func (d *dataset) generate(dir string, limit int, test bool) {
 			d.dataset = make([]uint32, dsize/2)
 			generateDataset(d.dataset, d.epoch, cache)
 		}
+		dags.finish("dataset", d.epoch, false)
+
 		// Iterate over all previous instances and delete old ones
 		for ep := int(d.epoch) - limit; ep >= 0; ep-- {
 			seed := seedHash(uint64(ep)*epochLength + 1)
-			path := filepath.Join(dir, fmt.Sprintf("full-R%d-%x%s", algorithmRevision, seed[:8], endian))
-			os.Remove(path)
+			removeDump(filepath.Join(dir, fmt.Sprintf("full-R%d-%x%s", algorithmRevision, seed[:8], endian)))
 		}
 	})
 }
@@ -425,6 +491,8 @@ type Ethash struct {
 	fakeFail  uint64        // Block number which fails PoW check even in fake mode
 	fakeDelay time.Duration // Time delay to sleep for before returning from verify
 
+	pregenerating uint32 // Whether a pregeneration runs, see Pregenerate
+
 	lock sync.Mutex // Ensures thread safety for the in-memory caches and mining fields
 }
 
@@ -613,10 +681,16 @@ func (ethash *Ethash) StopHashrate() {
 
 }
 
-// APIs implements consensus.Engine, returning the user facing RPC APIs. Currently
-// that is empty.
+// APIs implements consensus.Engine, returning the user facing RPC APIs.
 func (ethash *Ethash) APIs(chain consensus.ChainReader) []rpc.API {
-	return nil
+	return []rpc.API{
+		{
+			Namespace: "ethash",
+			Version:   "1.0",
+			Service:   &API{ethash},
+			Public:    false,
+		},
+	}
 }
 
 // SeedHash is the seed to use for generating a verification cache and the mining
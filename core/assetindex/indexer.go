@@ -0,0 +1,312 @@
+// copyright 2018 The sero.cash Authors
+// This file is part of the go-sero library.
+//
+// The go-sero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-sero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-sero library. If not, see <http://www.gnu.org/licenses/>.
+
+package assetindex
+
+import (
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/sero-cash/go-sero/common"
+	"github.com/sero-cash/go-sero/core"
+	"github.com/sero-cash/go-sero/core/rawdb"
+	"github.com/sero-cash/go-sero/core/types"
+	"github.com/sero-cash/go-sero/log"
+	"github.com/sero-cash/go-sero/rlp"
+	"github.com/sero-cash/go-sero/serodb"
+)
+
+const (
+	// SectionBlocks is the number of blocks processed in one registry section.
+	SectionBlocks = 64
+
+	// confirms is the number of confirmation blocks before a section is
+	// considered final and indexed.
+	confirms = 12
+
+	// throttling is the time to wait between processing two consecutive
+	// sections.
+	throttling = 100 * time.Millisecond
+)
+
+// sectionRecord is an asset record together with the block it was made in, as
+// kept in the journal used to roll a section back.
+type sectionRecord struct {
+	Number uint64
+	Record *types.AssetRecord
+}
+
+// MissingRecordsError is returned for a section holding a block this node
+// didn't execute, whose asset records are therefore unknown: blocks inserted by
+// a fast sync or a snapshot import, or imported before the records were kept.
+// Such a section isn't indexed, rather than leaving a partial registry.
+type MissingRecordsError struct {
+	Section uint64
+	Number  uint64
+	Hash    common.Hash
+}
+
+func (e *MissingRecordsError) Error() string {
+	return fmt.Sprintf("asset records of block #%d [%x…] missing, registry section %d not indexed", e.Number, e.Hash[:4], e.Section)
+}
+
+// NewIndexer returns a chain indexer maintaining the token and ticket registry
+// of the canonical chain, along with the registry itself.
+func NewIndexer(db serodb.Database) (*core.ChainIndexer, *Registry) {
+	registry := &Registry{
+		chainDb: db,
+		db:      serodb.NewTable(db, string(rawdb.AssetIndexPrefix)),
+	}
+	return core.NewChainIndexer(db, registry.db, registry, SectionBlocks, confirms, throttling, "assets"), registry
+}
+
+// Reset implements core.ChainIndexerBackend, starting a new registry section.
+// Sections committed after the requested one have been dropped by a reorg and
+// are rolled back first.
+func (r *Registry) Reset(section uint64, prevHead common.Hash) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.section, r.records, r.missing = section, nil, nil
+
+	next := readUint64(r.db, nextSectionKey)
+	if next <= section {
+		return nil
+	}
+	u := newUpdate(r.db)
+	batch := r.db.NewBatch()
+	for s := next; s > section; s-- {
+		journal := readJournal(r.db, s-1)
+		for i := len(journal) - 1; i >= 0; i-- {
+			u.revert(batch, journal[i])
+		}
+		batch.Delete(journalKey(s - 1))
+	}
+	if err := u.flush(batch); err != nil {
+		return err
+	}
+	batch.Put(nextSectionKey, encodeUint64(section))
+	log.Info("Rolled back asset registry", "from", next, "to", section)
+	return batch.Write()
+}
+
+// Process implements core.ChainIndexerBackend, collecting the asset records of
+// a new header. The genesis block makes no records.
+func (r *Registry) Process(header *types.Header) {
+	number := header.Number.Uint64()
+	if number > 0 && !rawdb.HasAssetRecords(r.chainDb, header.Hash(), number) {
+		if r.missing == nil {
+			r.missing = &MissingRecordsError{r.section, number, header.Hash()}
+		}
+		return
+	}
+	for _, record := range rawdb.ReadAssetRecords(r.chainDb, header.Hash(), number) {
+		r.records = append(r.records, sectionRecord{number, record})
+	}
+}
+
+// Commit implements core.ChainIndexerBackend, applying the collected records
+// to the registry. A section missing the records of a block is refused, and the
+// registry reports it until the section is indexed.
+func (r *Registry) Commit() error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.missing != nil {
+		r.refused = r.missing
+		log.Error("Asset registry incomplete, blocks have to be executed by a full sync", "section", r.missing.Section, "number", r.missing.Number)
+		return r.missing
+	}
+	u := newUpdate(r.db)
+	batch := r.db.NewBatch()
+
+	journal := []sectionRecord{}
+	for _, record := range r.records {
+		journal = append(journal, u.apply(batch, record)...)
+	}
+	if err := u.flush(batch); err != nil {
+		return err
+	}
+	if len(journal) > 0 {
+		data, err := rlp.EncodeToBytes(journal)
+		if err != nil {
+			return err
+		}
+		batch.Put(journalKey(r.section), data)
+	}
+	batch.Put(nextSectionKey, encodeUint64(r.section+1))
+	if err := batch.Write(); err != nil {
+		return err
+	}
+	r.refused = nil
+	return nil
+}
+
+func readJournal(db serodb.Getter, section uint64) []sectionRecord {
+	data, _ := db.Get(journalKey(section))
+	if len(data) == 0 {
+		return nil
+	}
+	journal := []sectionRecord{}
+	if err := rlp.DecodeBytes(data, &journal); err != nil {
+		log.Error("Invalid asset registry journal RLP", "section", section, "err", err)
+		return nil
+	}
+	return journal
+}
+
+// update accumulates the registry changes of a section, so that entries touched
+// several times are read once and written in a single batch.
+type update struct {
+	db        serodb.Getter
+	infos     map[string]*Info
+	counts    map[string]uint64
+	contracts map[common.Address][]Ref
+}
+
+func newUpdate(db serodb.Getter) *update {
+	return &update{
+		db:        db,
+		infos:     make(map[string]*Info),
+		counts:    make(map[string]uint64),
+		contracts: make(map[common.Address][]Ref),
+	}
+}
+
+func (u *update) info(kind string, name string) *Info {
+	key := string(infoKey(kind, name))
+	if info, ok := u.infos[key]; ok {
+		return info
+	}
+	info := readInfo(u.db, kind, name)
+	u.infos[key] = info
+	return info
+}
+
+func (u *update) count(kind string) uint64 {
+	if count, ok := u.counts[kind]; ok {
+		return count
+	}
+	return readCount(u.db, kind)
+}
+
+func (u *update) refs(contract common.Address) []Ref {
+	if refs, ok := u.contracts[contract]; ok {
+		return refs
+	}
+	return readContractRefs(u.db, contract)
+}
+
+// apply adds a record to the registry, returning the records to journal for a
+// later rollback. Issuance of an asset whose registration was never indexed
+// registers it on the fly.
+func (u *update) apply(batch serodb.Batch, record sectionRecord) (journal []sectionRecord) {
+	rec := record.Record
+	info := u.info(rec.Kind, rec.Name)
+
+	if info == nil {
+		register := record
+		if rec.Op != types.AssetOpRegister {
+			register.Record = &types.AssetRecord{Kind: rec.Kind, Op: types.AssetOpRegister, Name: rec.Name, Contract: rec.Contract, TxHash: rec.TxHash}
+		}
+		info = &Info{
+			Kind:          rec.Kind,
+			Name:          rec.Name,
+			Contract:      rec.Contract,
+			RegisterBlock: record.Number,
+			RegisterTx:    rec.TxHash,
+			Supply:        new(big.Int),
+		}
+		u.infos[string(infoKey(rec.Kind, rec.Name))] = info
+
+		count := u.count(rec.Kind)
+		batch.Put(seqKey(rec.Kind, count), []byte(rec.Name))
+		u.counts[rec.Kind] = count + 1
+		u.contracts[rec.Contract] = append(u.refs(rec.Contract), Ref{rec.Kind, rec.Name})
+
+		journal = append(journal, register)
+	}
+	if rec.Op == types.AssetOpIssue {
+		info.Supply = new(big.Int).Add(info.Supply, issued(rec))
+		journal = append(journal, record)
+	}
+	return journal
+}
+
+// revert undoes a journaled record.
+func (u *update) revert(batch serodb.Batch, record sectionRecord) {
+	rec := record.Record
+	info := u.info(rec.Kind, rec.Name)
+	if info == nil {
+		return
+	}
+	switch rec.Op {
+	case types.AssetOpIssue:
+		info.Supply = new(big.Int).Sub(info.Supply, issued(rec))
+	case types.AssetOpRegister:
+		u.infos[string(infoKey(rec.Kind, rec.Name))] = nil
+
+		if count := u.count(rec.Kind); count > 0 {
+			batch.Delete(seqKey(rec.Kind, count-1))
+			u.counts[rec.Kind] = count - 1
+		}
+		refs := []Ref{}
+		for _, ref := range u.refs(rec.Contract) {
+			if ref.Kind != rec.Kind || ref.Name != rec.Name {
+				refs = append(refs, ref)
+			}
+		}
+		u.contracts[rec.Contract] = refs
+	}
+}
+
+func (u *update) flush(batch serodb.Batch) error {
+	for key, info := range u.infos {
+		if info == nil {
+			batch.Delete([]byte(key))
+		} else if err := writeInfo(batch, info); err != nil {
+			return err
+		}
+	}
+	for kind, count := range u.counts {
+		batch.Put(countKey(kind), encodeUint64(count))
+	}
+	for contract, refs := range u.contracts {
+		if len(refs) == 0 {
+			batch.Delete(contractKey(contract))
+			continue
+		}
+		data, err := rlp.EncodeToBytes(refs)
+		if err != nil {
+			return err
+		}
+		batch.Put(contractKey(contract), data)
+	}
+	return nil
+}
+
+// issued returns the supply added by an issue record: the token amount, or one
+// ticket.
+func issued(rec *types.AssetRecord) *big.Int {
+	if rec.Kind == types.AssetTicket {
+		return big.NewInt(1)
+	}
+	if rec.Amount == nil {
+		return new(big.Int)
+	}
+	return rec.Amount
+}
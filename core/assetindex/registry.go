@@ -0,0 +1,324 @@
+// copyright 2018 The sero.cash Authors
+// This file is part of the go-sero library.
+//
+// The go-sero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-sero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-sero library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package assetindex maintains a registry of the tokens and ticket categories
+// issued on chain, built by a chain indexer from the asset records stored with
+// every block.
+//
+// Asset records are only written for blocks executed by a full node running
+// this code. A section holding blocks inserted by a fast sync, a snapshot import
+// or an older version isn't indexed, and the registry reports it through a
+// MissingRecordsError: those blocks have to be reprocessed by a full sync for
+// the registry to be built.
+package assetindex
+
+import (
+	"encoding/binary"
+	"errors"
+	"math/big"
+	"strings"
+	"sync"
+
+	"github.com/sero-cash/go-sero/common"
+	"github.com/sero-cash/go-sero/core/types"
+	"github.com/sero-cash/go-sero/log"
+	"github.com/sero-cash/go-sero/rlp"
+	"github.com/sero-cash/go-sero/serodb"
+)
+
+// SeroDecimals is the number of decimals of the native SERO currency.
+const SeroDecimals = 18
+
+var (
+	ErrAssetNotFound = errors.New("asset not found in registry")
+	ErrInvalidKind   = errors.New("asset kind must be token or ticket")
+)
+
+// Info describes a registered token or ticket category.
+type Info struct {
+	Kind          string
+	Name          string
+	Contract      common.Address
+	RegisterBlock uint64
+	RegisterTx    common.Hash
+	Supply        *big.Int // Total tokens issued, or number of tickets of a category
+	HasDecimals   bool
+	Decimals      uint8
+}
+
+// Ref references a registry entry.
+type Ref struct {
+	Kind string
+	Name string
+}
+
+// Registry is the token and ticket registry. It implements core.ChainIndexerBackend
+// so that it is kept up to date by a core.ChainIndexer.
+type Registry struct {
+	chainDb serodb.Database // Database holding the block asset records
+	db      serodb.Database // Table storing the registry
+
+	lock sync.RWMutex
+
+	section uint64
+	records []sectionRecord
+	missing *MissingRecordsError // First block of the section missing its records
+	refused *MissingRecordsError // Last section refused for missing records
+
+	contractDecimals map[string]contractDecimals // Decimals asked to the issuing contracts, misses included
+}
+
+// contractDecimals is the answer of the issuing contract of a token to the
+// decimals calls.
+type contractDecimals struct {
+	contract  common.Address
+	decimals  uint8
+	supported bool
+}
+
+// Status returns the MissingRecordsError of the section the registry was
+// refused at, if any: the registry is then incomplete.
+func (r *Registry) Status() error {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	if r.refused != nil {
+		return r.refused
+	}
+	return nil
+}
+
+// Get returns the registry entry of a token or ticket category.
+func (r *Registry) Get(kind string, name string) (*Info, error) {
+	if err := checkKind(kind); err != nil {
+		return nil, err
+	}
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	if r.refused != nil {
+		return nil, r.refused
+	}
+	info := readInfo(r.db, kind, strings.ToUpper(name))
+	if info == nil {
+		return nil, ErrAssetNotFound
+	}
+	return info, nil
+}
+
+// Count returns the number of registered assets of the given kind.
+func (r *Registry) Count(kind string) uint64 {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	return readCount(r.db, kind)
+}
+
+// List returns at most count assets of the given kind in registration order,
+// starting from the start-th registered one.
+func (r *Registry) List(kind string, start uint64, count uint64) ([]*Info, error) {
+	if err := checkKind(kind); err != nil {
+		return nil, err
+	}
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	if r.refused != nil {
+		return nil, r.refused
+	}
+	infos := []*Info{}
+	total := readCount(r.db, kind)
+	for i := start; i < total && uint64(len(infos)) < count; i++ {
+		name, _ := r.db.Get(seqKey(kind, i))
+		if info := readInfo(r.db, kind, string(name)); info != nil {
+			infos = append(infos, info)
+		}
+	}
+	return infos, nil
+}
+
+// ByContract returns the assets registered by a contract.
+func (r *Registry) ByContract(contract common.Address) []*Info {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	infos := []*Info{}
+	for _, ref := range readContractRefs(r.db, contract) {
+		if info := readInfo(r.db, ref.Kind, ref.Name); info != nil {
+			infos = append(infos, info)
+		}
+	}
+	return infos
+}
+
+// Decimals returns the cached decimals of a token currency.
+func (r *Registry) Decimals(currency string) (uint8, bool) {
+	currency = strings.ToUpper(currency)
+	if currency == "SERO" {
+		return SeroDecimals, true
+	}
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	if info := readInfo(r.db, types.AssetToken, currency); info != nil && info.HasDecimals {
+		return info.Decimals, true
+	}
+	return 0, false
+}
+
+// SetDecimals caches the decimals of a token currency, as reported by its
+// issuing contract.
+func (r *Registry) SetDecimals(currency string, decimals uint8) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	info := readInfo(r.db, types.AssetToken, strings.ToUpper(currency))
+	if info == nil {
+		return ErrAssetNotFound
+	}
+	info.HasDecimals, info.Decimals = true, decimals
+	return writeInfo(r.db, info)
+}
+
+// ContractDecimals returns the decimals of a token currency reported by its
+// issuing contract, and whether the contract supports the decimals calls. ok is
+// false if the contract hasn't been asked yet.
+func (r *Registry) ContractDecimals(currency string, contract common.Address) (decimals uint8, supported bool, ok bool) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	entry, ok := r.contractDecimals[strings.ToUpper(currency)]
+	if !ok || entry.contract != contract {
+		return 0, false, false
+	}
+	return entry.decimals, entry.supported, true
+}
+
+// SetContractDecimals caches the answer of the issuing contract of a token
+// currency to the decimals calls, so the contract is asked only once. The
+// decimals are also recorded in the registry entry of the token if it's
+// indexed already.
+func (r *Registry) SetContractDecimals(currency string, contract common.Address, decimals uint8, supported bool) error {
+	currency = strings.ToUpper(currency)
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.contractDecimals == nil {
+		r.contractDecimals = make(map[string]contractDecimals)
+	}
+	r.contractDecimals[currency] = contractDecimals{contract, decimals, supported}
+	if !supported {
+		return nil
+	}
+	info := readInfo(r.db, types.AssetToken, currency)
+	if info == nil {
+		return ErrAssetNotFound
+	}
+	info.HasDecimals, info.Decimals = true, decimals
+	return writeInfo(r.db, info)
+}
+
+func checkKind(kind string) error {
+	if kind != types.AssetToken && kind != types.AssetTicket {
+		return ErrInvalidKind
+	}
+	return nil
+}
+
+var (
+	infoPrefix     = []byte("asset-")         // infoPrefix + kind + "-" + name -> Info
+	seqPrefix      = []byte("asset-seq-")     // seqPrefix + kind + "-" + index (uint64 big endian) -> name
+	countPrefix    = []byte("asset-count-")   // countPrefix + kind -> number of registered assets
+	contractPrefix = []byte("asset-contract") // contractPrefix + contract -> []Ref
+	journalPrefix  = []byte("asset-journal-") // journalPrefix + section (uint64 big endian) -> []sectionRecord
+	nextSectionKey = []byte("asset-next")     // next section to be committed
+)
+
+func infoKey(kind string, name string) []byte {
+	return append(append(append(infoPrefix, kind...), '-'), name...)
+}
+
+func seqKey(kind string, index uint64) []byte {
+	key := append(append(append(seqPrefix, kind...), '-'), make([]byte, 8)...)
+	binary.BigEndian.PutUint64(key[len(key)-8:], index)
+	return key
+}
+
+func countKey(kind string) []byte {
+	return append(countPrefix, kind...)
+}
+
+func contractKey(contract common.Address) []byte {
+	return append(contractPrefix, contract[:]...)
+}
+
+func journalKey(section uint64) []byte {
+	key := append(journalPrefix, make([]byte, 8)...)
+	binary.BigEndian.PutUint64(key[len(journalPrefix):], section)
+	return key
+}
+
+func readInfo(db serodb.Getter, kind string, name string) *Info {
+	data, _ := db.Get(infoKey(kind, name))
+	if len(data) == 0 {
+		return nil
+	}
+	info := new(Info)
+	if err := rlp.DecodeBytes(data, info); err != nil {
+		log.Error("Invalid asset registry entry RLP", "kind", kind, "name", name, "err", err)
+		return nil
+	}
+	return info
+}
+
+func writeInfo(db serodb.Putter, info *Info) error {
+	data, err := rlp.EncodeToBytes(info)
+	if err != nil {
+		return err
+	}
+	return db.Put(infoKey(info.Kind, info.Name), data)
+}
+
+func readUint64(db serodb.Getter, key []byte) uint64 {
+	data, _ := db.Get(key)
+	if len(data) != 8 {
+		return 0
+	}
+	return binary.BigEndian.Uint64(data)
+}
+
+func encodeUint64(n uint64) []byte {
+	enc := make([]byte, 8)
+	binary.BigEndian.PutUint64(enc, n)
+	return enc
+}
+
+func readCount(db serodb.Getter, kind string) uint64 {
+	return readUint64(db, countKey(kind))
+}
+
+func readContractRefs(db serodb.Getter, contract common.Address) []Ref {
+	data, _ := db.Get(contractKey(contract))
+	if len(data) == 0 {
+		return nil
+	}
+	refs := []Ref{}
+	if err := rlp.DecodeBytes(data, &refs); err != nil {
+		log.Error("Invalid asset registry contract RLP", "contract", contract, "err", err)
+		return nil
+	}
+	return refs
+}
@@ -0,0 +1,185 @@
+// copyright 2018 The sero.cash Authors
+// This file is part of the go-sero library.
+//
+// The go-sero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-sero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-sero library. If not, see <http://www.gnu.org/licenses/>.
+
+package assetindex
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/sero-cash/go-sero/common"
+	"github.com/sero-cash/go-sero/core/rawdb"
+	"github.com/sero-cash/go-sero/core/types"
+	"github.com/sero-cash/go-sero/serodb"
+)
+
+func newTestRegistry() (*Registry, serodb.Database) {
+	db := serodb.NewMemDatabase()
+	return &Registry{chainDb: db, db: serodb.NewTable(db, string(rawdb.AssetIndexPrefix))}, db
+}
+
+func processBlock(r *Registry, db serodb.Database, number uint64, records ...*types.AssetRecord) {
+	header := &types.Header{Number: new(big.Int).SetUint64(number)}
+	rawdb.WriteAssetRecords(db, header.Hash(), number, records)
+	r.Process(header)
+}
+
+func TestRegistryCommitAndRollback(t *testing.T) {
+	r, db := newTestRegistry()
+	contract := common.BytesToAddress([]byte{1})
+
+	if err := r.Reset(0, common.Hash{}); err != nil {
+		t.Fatalf("reset failed: %v", err)
+	}
+	processBlock(r, db, 1,
+		&types.AssetRecord{Kind: types.AssetToken, Op: types.AssetOpRegister, Name: "ABC", Contract: contract, Amount: new(big.Int)},
+		&types.AssetRecord{Kind: types.AssetToken, Op: types.AssetOpIssue, Name: "ABC", Contract: contract, Amount: big.NewInt(100)},
+	)
+	processBlock(r, db, 2,
+		&types.AssetRecord{Kind: types.AssetToken, Op: types.AssetOpIssue, Name: "ABC", Contract: contract, Amount: big.NewInt(50)},
+		&types.AssetRecord{Kind: types.AssetTicket, Op: types.AssetOpIssue, Name: "VIP", Contract: contract, Amount: new(big.Int)},
+	)
+	if err := r.Commit(); err != nil {
+		t.Fatalf("commit failed: %v", err)
+	}
+
+	info, err := r.Get(types.AssetToken, "abc")
+	if err != nil {
+		t.Fatalf("token not indexed: %v", err)
+	}
+	if info.Supply.Cmp(big.NewInt(150)) != 0 || info.RegisterBlock != 1 || info.Contract != contract {
+		t.Errorf("token entry mismatch: supply %v, block %d", info.Supply, info.RegisterBlock)
+	}
+	ticket, err := r.Get(types.AssetTicket, "VIP")
+	if err != nil {
+		t.Fatalf("ticket not indexed: %v", err)
+	}
+	if ticket.Supply.Cmp(big.NewInt(1)) != 0 || ticket.RegisterBlock != 2 {
+		t.Errorf("ticket entry mismatch: supply %v, block %d", ticket.Supply, ticket.RegisterBlock)
+	}
+	if infos := r.ByContract(contract); len(infos) != 2 {
+		t.Errorf("contract lookup mismatch: have %d entries, want 2", len(infos))
+	}
+	if infos, _ := r.List(types.AssetToken, 0, 10); len(infos) != 1 || infos[0].Name != "ABC" {
+		t.Errorf("token listing mismatch: %v", infos)
+	}
+
+	if err := r.SetDecimals("ABC", 9); err != nil {
+		t.Fatalf("set decimals failed: %v", err)
+	}
+	if decimals, ok := r.Decimals("abc"); !ok || decimals != 9 {
+		t.Errorf("decimals mismatch: have %d (%v), want 9", decimals, ok)
+	}
+	if decimals, ok := r.Decimals("SERO"); !ok || decimals != SeroDecimals {
+		t.Errorf("sero decimals mismatch: have %d (%v)", decimals, ok)
+	}
+
+	// Reprocessing the section after a reorg rolls the registry back
+	if err := r.Reset(0, common.Hash{}); err != nil {
+		t.Fatalf("rollback failed: %v", err)
+	}
+	if _, err := r.Get(types.AssetToken, "ABC"); err != ErrAssetNotFound {
+		t.Errorf("token still registered after rollback: %v", err)
+	}
+	if count := r.Count(types.AssetTicket); count != 0 {
+		t.Errorf("ticket count mismatch after rollback: have %d, want 0", count)
+	}
+	if infos := r.ByContract(contract); len(infos) != 0 {
+		t.Errorf("contract lookup mismatch after rollback: have %d entries", len(infos))
+	}
+}
+
+func TestRegistryContractDecimals(t *testing.T) {
+	r, db := newTestRegistry()
+	contract, other := common.BytesToAddress([]byte{1}), common.BytesToAddress([]byte{2})
+
+	if _, _, ok := r.ContractDecimals("ABC", contract); ok {
+		t.Fatalf("contract decimals known before asking the contract")
+	}
+	// A contract not supporting the decimals is asked once
+	if err := r.SetContractDecimals("abc", contract, 0, false); err != nil {
+		t.Fatalf("caching a miss failed: %v", err)
+	}
+	if _, supported, ok := r.ContractDecimals("ABC", contract); !ok || supported {
+		t.Errorf("miss not cached: supported %v, known %v", supported, ok)
+	}
+	// Another contract issuing the currency is asked again
+	if _, _, ok := r.ContractDecimals("ABC", other); ok {
+		t.Errorf("decimals of another contract reused")
+	}
+
+	// Decimals of a token not indexed yet are cached in memory only
+	if err := r.SetContractDecimals("ABC", contract, 6, true); err != ErrAssetNotFound {
+		t.Errorf("decimals of an unindexed token recorded: %v", err)
+	}
+	if decimals, supported, ok := r.ContractDecimals("abc", contract); !ok || !supported || decimals != 6 {
+		t.Errorf("decimals mismatch: have %d (%v, %v), want 6", decimals, supported, ok)
+	}
+
+	r.Reset(0, common.Hash{})
+	processBlock(r, db, 1,
+		&types.AssetRecord{Kind: types.AssetToken, Op: types.AssetOpRegister, Name: "ABC", Contract: contract, Amount: new(big.Int)},
+	)
+	r.Commit()
+	if err := r.SetContractDecimals("ABC", contract, 6, true); err != nil {
+		t.Fatalf("set contract decimals failed: %v", err)
+	}
+	if decimals, ok := r.Decimals("ABC"); !ok || decimals != 6 {
+		t.Errorf("decimals not recorded in the registry: have %d (%v)", decimals, ok)
+	}
+}
+
+// TestRegistryMissingRecords checks that a section holding a block without
+// records, as inserted by a fast sync, is refused rather than partially indexed.
+func TestRegistryMissingRecords(t *testing.T) {
+	r, db := newTestRegistry()
+	contract := common.BytesToAddress([]byte{1})
+	register := &types.AssetRecord{Kind: types.AssetToken, Op: types.AssetOpRegister, Name: "ABC", Contract: contract, Amount: new(big.Int)}
+
+	r.Reset(0, common.Hash{})
+	processBlock(r, db, 1, register)
+	r.Process(&types.Header{Number: big.NewInt(2)})
+	err := r.Commit()
+	if missing, ok := err.(*MissingRecordsError); !ok || missing.Number != 2 || missing.Section != 0 {
+		t.Fatalf("section missing the records of block 2 committed: %v", err)
+	}
+	if r.Status() != err {
+		t.Errorf("refused section not reported: %v", r.Status())
+	}
+	if _, err := r.Get(types.AssetToken, "ABC"); err == nil || err == ErrAssetNotFound {
+		t.Errorf("incomplete registry queried: %v", err)
+	}
+	if infos, err := r.List(types.AssetToken, 0, 10); err == nil {
+		t.Errorf("incomplete registry listed: %v", infos)
+	}
+	if count := r.Count(types.AssetToken); count != 0 {
+		t.Errorf("refused section indexed: %d tokens", count)
+	}
+
+	// Once the block is executed, the section is indexed
+	r.Reset(0, common.Hash{})
+	processBlock(r, db, 1, register)
+	processBlock(r, db, 2)
+	if err := r.Commit(); err != nil {
+		t.Fatalf("commit failed: %v", err)
+	}
+	if err := r.Status(); err != nil {
+		t.Errorf("indexed section still reported: %v", err)
+	}
+	if _, err := r.Get(types.AssetToken, "ABC"); err != nil {
+		t.Errorf("token not indexed: %v", err)
+	}
+}
@@ -32,7 +32,7 @@ import (
 // Tests that simple header verification works, for both good and bad blocks.
 func TestHeaderVerification(t *testing.T) {
 	// Create a simple chain to verify
-	cpt.ZeroInit(cpt.NET_Alpha)
+	cpt.ZeroInit("", cpt.NET_Alpha)
 	var (
 		testdb    = serodb.NewMemDatabase()
 		gspec     = &Genesis{Config: params.TestChainConfig}
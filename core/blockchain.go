@@ -28,6 +28,8 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/sero-cash/go-sero/zero/checkpoint"
+	"github.com/sero-cash/go-sero/zero/localdb"
 	"github.com/sero-cash/go-sero/zero/zconfig"
 
 	"github.com/sero-cash/go-sero/zero/txtool/verify"
@@ -78,6 +80,7 @@ const (
 	maxTimeFutureBlocks = 30
 	badBlockLimit       = 10
 	triesInMemory       = 128
+	zeroPruneBatch      = 1024
 
 	// BlockChainVersion ensures that an incompatible database forces a resync from scratch.
 	BlockChainVersion = 3
@@ -89,6 +92,7 @@ type CacheConfig struct {
 	Disabled      bool          // Whether to disable trie write caching (archive node)
 	TrieNodeLimit int           // Memory limit (MB) at which to flush the current in-memory trie to disk
 	TrieTimeLimit time.Duration // Time limit after which to flush the current in-memory trie to disk
+	ZeroKeep      uint64        // Number of recent blocks whose zero state shortcuts are kept, 0 keeps all
 }
 
 type Downloader interface {
@@ -134,6 +138,7 @@ type BlockChain struct {
 	checkpoint       int          // checkpoint counts towards the new checkpoint
 	currentBlock     atomic.Value // Current head of the block chain
 	currentFastBlock atomic.Value // Current head of the fast-sync chain (may be above the block chain!)
+	trusted          atomic.Value // Downloaded chain anchored to a checkpoint (*trustedChain)
 
 	stateCache   state.Database // State database to reuse between imports (contains state cache)
 	bodyCache    *lru.Cache     // Cache for the most recent block bodies
@@ -469,6 +474,77 @@ func (bc *BlockChain) CurrentFastBlock() *types.Block {
 	return bc.currentFastBlock.Load().(*types.Block)
 }
 
+// trustedChain is a chain of blocks leading to a checkpoint, hashes are the
+// hashes of the blocks from the number from up to the checkpoint excluded.
+type trustedChain struct {
+	cp     *checkpoint.Checkpoint
+	from   uint64
+	hashes []common.Hash
+}
+
+// TrustCheckpoint sets the chain the downloader linked up to a checkpoint,
+// hashes are the hashes of its blocks from the number from, the last one is
+// the checkpoint. The zero proofs of the blocks of this chain below the
+// checkpoint aren't verified again, the blocks of any other chain are.
+func (bc *BlockChain) TrustCheckpoint(cp *checkpoint.Checkpoint, from uint64, hashes []common.Hash) {
+	if len(hashes) == 0 || hashes[len(hashes)-1] != cp.Hash || from+uint64(len(hashes))-1 != cp.Number {
+		log.Warn("Chain doesn't lead to the checkpoint", "number", cp.Number, "hash", cp.Hash, "from", from, "count", len(hashes))
+		return
+	}
+	if old, _ := bc.trusted.Load().(*trustedChain); old == nil || old.cp.Number < cp.Number {
+		log.Info("Anchored chain to checkpoint", "number", cp.Number, "hash", cp.Hash, "from", from)
+		bc.trusted.Store(&trustedChain{cp, from, append([]common.Hash{}, hashes[:len(hashes)-1]...)})
+	}
+}
+
+// trustedBlock returns whether a block is on the chain leading to the trusted
+// checkpoint.
+func (bc *BlockChain) trustedBlock(block *types.Block) bool {
+	tc, _ := bc.trusted.Load().(*trustedChain)
+	if tc == nil {
+		return false
+	}
+	num := block.NumberU64()
+	return num >= tc.from && num < tc.cp.Number && tc.hashes[num-tc.from] == block.Hash()
+}
+
+// passCheckpoint drops the trusted chain once the chain got to its checkpoint.
+func (bc *BlockChain) passCheckpoint(block *types.Block) {
+	if tc, _ := bc.trusted.Load().(*trustedChain); tc != nil && block.NumberU64() >= tc.cp.Number {
+		bc.trusted.Store((*trustedChain)(nil))
+	}
+}
+
+// pruneZero drops the zero state shortcuts of the canonical blocks older than
+// the ZeroKeep latest ones. The merkle leaves, the roots and the nils needed to
+// validate the next blocks are kept, so are the stake records of the blocks,
+// the payments and the expiries of the shares read them back for months. At most
+// zeroPruneBatch blocks are pruned at once, so that a node turning the pruning
+// on catches up over the next blocks.
+func (bc *BlockChain) pruneZero(batch serodb.Batch, number uint64) {
+	keep := bc.cacheConfig.ZeroKeep
+	if keep == 0 || number <= keep {
+		return
+	}
+	from := localdb.GetPrunedNum(bc.db)
+	to := number - keep
+	if to > from+zeroPruneBatch {
+		to = from + zeroPruneBatch
+	}
+	if to <= from {
+		return
+	}
+	for num := from; num < to; num++ {
+		hash := rawdb.ReadCanonicalHash(bc.db, num)
+		if hash == (common.Hash{}) {
+			continue
+		}
+		localdb.DeleteBlock(batch, num, hash.HashToUint256())
+	}
+	localdb.PutPrunedNum(batch, to)
+	log.Debug("Pruned zero state", "from", from, "to", to)
+}
+
 // SetProcessor sets the processor required for making state modifications.
 func (bc *BlockChain) SetProcessor(processor Processor) {
 	bc.procmu.Lock()
@@ -1087,6 +1163,7 @@ func (bc *BlockChain) WriteBlockWithState(block *types.Block, receipts []*types.
 	}
 
 	rawdb.WriteReceipts(batch, block.Hash(), block.NumberU64(), receipts)
+	rawdb.WriteAssetRecords(batch, block.Hash(), block.NumberU64(), state.AssetRecords())
 
 	// If the total difficulty is higher than our known, add it to the canonical chain
 	// Second clause in the if statement reduces the vulnerability to selfish mining.
@@ -1132,6 +1209,9 @@ func (bc *BlockChain) WriteBlockWithState(block *types.Block, receipts []*types.
 		status = SideStatTy
 	}
 
+	if status == CanonStatTy {
+		bc.pruneZero(batch, block.NumberU64())
+	}
 	if err := batch.Write(); err != nil {
 		return NonStatTy, err
 	}
@@ -1334,6 +1414,9 @@ func (bc *BlockChain) insertChain(chain types.Blocks, local bool) (int, []interf
 		}
 		// Validate the state using the default validator
 		err = bc.Validator().ValidateState(block, parent, state, receipts, usedGas)
+		if err == nil {
+			err = checkpoint.Check(block.Header(), state)
+		}
 		if err != nil {
 			bc.reportBlock(block, receipts, err)
 			return i, events, coalescedLogs, err
@@ -1345,6 +1428,7 @@ func (bc *BlockChain) insertChain(chain types.Blocks, local bool) (int, []interf
 		if err != nil {
 			return i, events, coalescedLogs, err
 		}
+		bc.passCheckpoint(block)
 		switch status {
 		case CanonStatTy:
 			log.Debug("Inserted new block", "number", block.Number(), "hash", block.Hash(),
@@ -86,11 +86,18 @@ func (b *BlockGen) AddTx(tx *types.Transaction) {
 // added. If contract code relies on the BLOCKHASH instruction,
 // the block in chain will be returned.
 func (b *BlockGen) AddTxWithChain(bc *BlockChain, tx *types.Transaction) {
+	b.AddTxWithConfig(bc, tx, vm.Config{})
+}
+
+// AddTxWithConfig adds a transaction to the generated block, executing it with
+// the given EVM configuration. It allows callers to attach a tracer to the
+// execution, otherwise it behaves like AddTxWithChain.
+func (b *BlockGen) AddTxWithConfig(bc *BlockChain, tx *types.Transaction, cfg vm.Config) {
 	if b.gasPool == nil {
 		b.SetCoinbase(common.Address{})
 	}
 	b.statedb.Prepare(tx.Hash(), common.Hash{}, len(b.txs))
-	receipt, _, err := ApplyTransaction(b.config, bc, &b.header.Coinbase, b.gasPool, b.statedb, b.header, tx, &b.header.GasUsed, vm.Config{})
+	receipt, _, err := ApplyTransaction(b.config, bc, &b.header.Coinbase, b.gasPool, b.statedb, b.header, tx, &b.header.GasUsed, cfg)
 	if err != nil {
 		panic(err)
 	}
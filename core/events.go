@@ -57,3 +57,10 @@ type NewLotteryEvent struct {
 type NewVoteEvent struct {
 	Vote *types.Vote
 }
+
+// TxReplacedEvent is posted when a pooled transaction is replaced by a better
+// priced one spending the same nil.
+type TxReplacedEvent struct {
+	Old *types.Transaction
+	New *types.Transaction
+}
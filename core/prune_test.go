@@ -0,0 +1,113 @@
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/sero-cash/go-czero-import/cpt"
+	"github.com/sero-cash/go-czero-import/seroparam"
+	"github.com/sero-cash/go-sero/common"
+	"github.com/sero-cash/go-sero/consensus/ethash"
+	"github.com/sero-cash/go-sero/core/rawdb"
+	"github.com/sero-cash/go-sero/core/state"
+	"github.com/sero-cash/go-sero/core/types"
+	"github.com/sero-cash/go-sero/core/vm"
+	"github.com/sero-cash/go-sero/params"
+	"github.com/sero-cash/go-sero/serodb"
+	"github.com/sero-cash/go-sero/zero/localdb"
+	"github.com/sero-cash/go-sero/zero/stake"
+)
+
+// shareProcessor adds a share bought in the block 1 as the processing of a
+// transaction buying it would.
+type shareProcessor struct {
+	Processor
+	share *stake.Share
+}
+
+func (p *shareProcessor) Process(block *types.Block, statedb *state.StateDB, cfg vm.Config) (types.Receipts, []*types.Log, uint64, error) {
+	if block.NumberU64() == 1 {
+		stake.NewStakeState(statedb).AddPendingShare(p.share.CopyTo().(*stake.Share))
+	}
+	return p.Processor.Process(block, statedb, cfg)
+}
+
+func newShareChain(t *testing.T, db serodb.Database, cacheConfig *CacheConfig, share *stake.Share) *BlockChain {
+	chain, err := NewBlockChain(db, cacheConfig, params.TestChainConfig, ethash.NewFaker(), vm.Config{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	chain.SetProcessor(&shareProcessor{NewStateProcessor(params.TestChainConfig, chain, ethash.NewFaker()), share})
+	return chain
+}
+
+// TestPruneZeroKeepsStakeRecords checks that a node pruning the zero state
+// pays the income of a share from the records of the block it was bought in,
+// the dev pay window is 5 blocks.
+func TestPruneZeroKeepsStakeRecords(t *testing.T) {
+	seroparam.Init_Dev(true)
+	cpt.ZeroInit("", cpt.NET_Dev)
+	gspec := &Genesis{Config: params.TestChainConfig}
+
+	src, closeSrc := newSnapshotDB(t)
+	defer closeSrc()
+	parent := gspec.MustCommit(src)
+	share := &stake.Share{BlockNumber: 1, InitNum: 1, Value: big.NewInt(1), Income: big.NewInt(1000), Profit: new(big.Int)}
+	share.PKr[0] = 1
+	chain := newShareChain(t, src, &CacheConfig{Disabled: true}, share)
+
+	var blocks []*types.Block
+	for i := 0; i < 8; i++ {
+		generated, _ := GenerateChain(params.TestChainConfig, parent, ethash.NewFaker(), src, 1, func(i int, b *BlockGen) {
+			stakeState := stake.NewStakeState(b.statedb)
+			if err := stakeState.ProcessBeforeApply(chain, b.header); err != nil {
+				t.Fatal(err)
+			}
+			if b.header.Number.Uint64() == 1 {
+				stakeState.AddPendingShare(share.CopyTo().(*stake.Share))
+			}
+		})
+		if _, err := chain.InsertChain(generated); err != nil {
+			t.Fatal(err)
+		}
+		parent = generated[0]
+		blocks = append(blocks, parent)
+	}
+	chain.Stop()
+
+	// The records of the block 1 are read back by the block 6, long after
+	// they were pruned
+	db, closeDB := newSnapshotDB(t)
+	defer closeDB()
+	gspec.MustCommit(db)
+	pruned := newShareChain(t, db, &CacheConfig{Disabled: true, ZeroKeep: 2}, share)
+	defer pruned.Stop()
+	if n, err := pruned.InsertChain(blocks); err != nil {
+		t.Fatalf("block %d of the pruned chain: %v", blocks[n].NumberU64(), err)
+	}
+	head := pruned.CurrentBlock()
+	if head.Hash() != blocks[7].Hash() || head.Root() != blocks[7].Root() {
+		t.Fatalf("pruned head mismatch: have %d %x, want %d %x", head.NumberU64(), head.Root(), blocks[7].NumberU64(), blocks[7].Root())
+	}
+	if num := localdb.GetPrunedNum(db); num != 6 {
+		t.Fatalf("pruned number mismatch: have %d, want 6", num)
+	}
+	hash := blocks[0].Hash()
+	if localdb.GetBlock(db, 1, hash.HashToUint256()) != nil {
+		t.Fatalf("shortcut of block 1 not pruned")
+	}
+	if records := state.StakeDB.GetBlockRecords(db, 1, &hash); len(records) == 0 {
+		t.Fatalf("stake records of block 1 pruned")
+	}
+
+	// The income was paid
+	header := rawdb.ReadHeader(db, head.Hash(), head.NumberU64())
+	statedb, err := state.New(state.NewDatabase(db), header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	paid := stake.NewStakeState(statedb).GetShare(common.BytesToHash(share.Id()))
+	if paid == nil || paid.Income.Sign() != 0 || paid.LastPayTime != 6 {
+		t.Fatalf("share income not paid: %+v", paid)
+	}
+}
@@ -324,6 +324,49 @@ func DeleteReceipts(db DatabaseDeleter, hash common.Hash, number uint64) {
 	}
 }
 
+// ReadAssetRecords retrieves the token and ticket records made while processing
+// a block.
+func ReadAssetRecords(db DatabaseReader, hash common.Hash, number uint64) []*types.AssetRecord {
+	data, _ := db.Get(blockAssetsKey(number, hash))
+	if len(data) == 0 {
+		return nil
+	}
+	records := []*types.AssetRecord{}
+	if err := rlp.DecodeBytes(data, &records); err != nil {
+		log.Error("Invalid asset record array RLP", "hash", hash, "err", err)
+		return nil
+	}
+	return records
+}
+
+// HasAssetRecords verifies the existence of the asset records of a block, that
+// is whether the block was executed by this node.
+func HasAssetRecords(db DatabaseReader, hash common.Hash, number uint64) bool {
+	if has, err := db.Has(blockAssetsKey(number, hash)); !has || err != nil {
+		return false
+	}
+	return true
+}
+
+// WriteAssetRecords stores the token and ticket records made while processing a
+// block. The records of a block making none are stored too, as an empty list.
+func WriteAssetRecords(db DatabaseWriter, hash common.Hash, number uint64, records []*types.AssetRecord) {
+	bytes, err := rlp.EncodeToBytes(records)
+	if err != nil {
+		log.Crit("Failed to encode block asset records", "err", err)
+	}
+	if err := db.Put(blockAssetsKey(number, hash), bytes); err != nil {
+		log.Crit("Failed to store block asset records", "err", err)
+	}
+}
+
+// DeleteAssetRecords removes all token and ticket records of a block.
+func DeleteAssetRecords(db DatabaseDeleter, hash common.Hash, number uint64) {
+	if err := db.Delete(blockAssetsKey(number, hash)); err != nil {
+		log.Crit("Failed to delete block asset records", "err", err)
+	}
+}
+
 // ReadBlock retrieves an entire block corresponding to the hash, assembling it
 // back from the stored header and body. If either the header or body could not
 // be retrieved nil is returned.
@@ -352,6 +395,7 @@ func WriteBlock(db DatabaseWriter, block *types.Block) {
 // DeleteBlock removes all block data associated with a hash.
 func DeleteBlock(db DatabaseDeleter, hash common.Hash, number uint64) {
 	DeleteReceipts(db, hash, number)
+	DeleteAssetRecords(db, hash, number)
 	DeleteHeader(db, hash, number)
 	DeleteBody(db, hash, number)
 	DeleteTd(db, hash, number)
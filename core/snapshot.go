@@ -0,0 +1,597 @@
+package core
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"math/big"
+
+	"github.com/sero-cash/go-czero-import/keys"
+	"github.com/sero-cash/go-sero/common"
+	"github.com/sero-cash/go-sero/core/rawdb"
+	"github.com/sero-cash/go-sero/core/state"
+	"github.com/sero-cash/go-sero/core/types"
+	"github.com/sero-cash/go-sero/crypto"
+	"github.com/sero-cash/go-sero/crypto/sha3"
+	"github.com/sero-cash/go-sero/log"
+	"github.com/sero-cash/go-sero/rlp"
+	"github.com/sero-cash/go-sero/serodb"
+	"github.com/sero-cash/go-sero/trie"
+	"github.com/sero-cash/go-sero/zero/localdb"
+	"github.com/sero-cash/go-sero/zero/stake"
+	"github.com/sero-cash/go-sero/zero/txs/zstate/txstate"
+	"github.com/sero-cash/go-sero/zero/utils"
+)
+
+const (
+	snapshotMagic   = "SERO-SNAPSHOT"
+	snapshotVersion = 1
+)
+
+// Kinds of the entries of a snapshot archive.
+const (
+	snapshotHeader uint8 = iota + 1 // Canonical header, from the genesis up to the snapshot block
+	snapshotBlock                   // Snapshot block, the key is its total difficulty
+	snapshotNode                    // Node of the state trie or contract code, the key is its hash
+	snapshotGlobal                  // Record of the zero state kept out of the state trie
+	snapshotEnd                     // Checksum of the archive
+)
+
+var (
+	ErrSnapshotFormat   = errors.New("invalid snapshot archive")
+	ErrSnapshotChecksum = errors.New("snapshot checksum mismatch")
+	ErrSnapshotNotEmpty = errors.New("chain database already holds blocks")
+	ErrSnapshotGlobal   = errors.New("snapshot record doesn't match the chain")
+)
+
+// Prefixes of the zero state records of the chain database.
+var (
+	snapshotOutPrefix      = []byte("$SERO_LOCALDB_OUTSTATE$")
+	snapshotRootPrefix     = []byte("$SERO_LOCALDB_ROOTSTATE$")
+	snapshotRootCMPrefix   = []byte("$SERO_LOCALDB_ROOTCM2ROOT$")
+	snapshotPkgPrefix      = []byte("$SERO_LOCALDB_PKG_HASH$")
+	snapshotOutStatPrefix  = []byte("$ZSTATE_OUT_STAT$")
+	snapshotPrunedPrefix   = []byte("$SERO_ZSTATE_PRUNED$") // Lowest block whose records are kept
+	snapshotSharePrefix    = []byte(stake.ShareDB.Pre)
+	snapshotPoolPrefix     = []byte(stake.StakePoolDB.Pre)
+	snapshotShortcutPrefix = []byte("$SERO_ZSTATE_BLOCK_SHOOTCUT$")
+	snapshotRecordsPrefix  = []byte(state.StakeDB.Pre)
+	snapshotVotesPrefix    = []byte("STAKE$BLOCKVOTES$")
+)
+
+// snapshotPrefixes are the prefixes of the zero state records which are keyed
+// by content, they are exported whole. The records keyed by block are exported
+// for the canonical blocks only.
+var snapshotPrefixes = [][]byte{
+	snapshotOutPrefix,
+	snapshotRootPrefix,
+	snapshotRootCMPrefix,
+	snapshotPkgPrefix,
+	snapshotOutStatPrefix,
+	snapshotPrunedPrefix,
+	snapshotSharePrefix,
+	snapshotPoolPrefix,
+}
+
+type snapshotMeta struct {
+	Magic   string
+	Version uint64
+	Number  uint64
+	Hash    common.Hash
+	Root    common.Hash
+}
+
+type snapshotEntry struct {
+	Kind  uint8
+	Key   []byte
+	Value []byte
+}
+
+// snapshotStream reads or writes the entries of an archive and hashes them.
+type snapshotStream struct {
+	w      io.Writer
+	s      *rlp.Stream
+	hasher hash.Hash
+}
+
+func (self *snapshotStream) write(val interface{}) error {
+	b, err := rlp.EncodeToBytes(val)
+	if err != nil {
+		return err
+	}
+	self.hasher.Write(b)
+	_, err = self.w.Write(b)
+	return err
+}
+
+func (self *snapshotStream) read(val interface{}) error {
+	b, err := self.s.Raw()
+	if err != nil {
+		if err == io.EOF {
+			return io.ErrUnexpectedEOF
+		}
+		return err
+	}
+	if err := rlp.DecodeBytes(b, val); err != nil {
+		return err
+	}
+	self.hasher.Write(b)
+	return nil
+}
+
+func (self *snapshotStream) sum() []byte {
+	return self.hasher.Sum(nil)
+}
+
+// ExportSnapshot writes the state of the canonical block number into an
+// archive: the headers up to it, the block, the nodes of the state trie, which
+// holds the zero state trees, and the zero state records of the database. The
+// state must have been committed to disk, see the --snapshot flag.
+func ExportSnapshot(db *serodb.LDBDatabase, number uint64, w io.Writer) error {
+	hash := rawdb.ReadCanonicalHash(db, number)
+	if hash == (common.Hash{}) {
+		return fmt.Errorf("block %d not found", number)
+	}
+	block := rawdb.ReadBlock(db, hash, number)
+	td := rawdb.ReadTd(db, hash, number)
+	if block == nil || td == nil {
+		return fmt.Errorf("block %d not found", number)
+	}
+	statedb, err := state.New(state.NewDatabase(db), block.Header())
+	if err != nil {
+		return fmt.Errorf("state of block %d not found, it is only kept with --snapshot %d: %v", number, number, err)
+	}
+	stream := &snapshotStream{w: w, hasher: sha3.NewKeccak256()}
+
+	meta := snapshotMeta{snapshotMagic, snapshotVersion, number, hash, block.Root()}
+	if err := stream.write(&meta); err != nil {
+		return err
+	}
+
+	for i := uint64(0); i <= number; i++ {
+		header := rawdb.ReadHeaderRLP(db, rawdb.ReadCanonicalHash(db, i), i)
+		if len(header) == 0 {
+			return fmt.Errorf("header %d not found", i)
+		}
+		if err := stream.write(&snapshotEntry{Kind: snapshotHeader, Value: header}); err != nil {
+			return err
+		}
+	}
+	blob, err := rlp.EncodeToBytes(block)
+	if err != nil {
+		return err
+	}
+	if err := stream.write(&snapshotEntry{Kind: snapshotBlock, Key: td.Bytes(), Value: blob}); err != nil {
+		return err
+	}
+
+	nodes := 0
+	if err := walkSnapshotState(statedb.Database(), block.Root(), func(hash common.Hash, blob []byte) error {
+		if err := stream.write(&snapshotEntry{Kind: snapshotNode, Key: hash[:], Value: blob}); err != nil {
+			return err
+		}
+		if nodes++; nodes%100000 == 0 {
+			log.Info("Exporting snapshot state", "nodes", nodes)
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	// The records are checked as the importer does, a snapshot of a database
+	// whose records don't match its chain is refused here already.
+	globals := 0
+	checker := newSnapshotChecker(db, statedb, number)
+	writeGlobal := func(key, value []byte) error {
+		if err := checker.check(key, value); err != nil {
+			return err
+		}
+		globals++
+		return stream.write(&snapshotEntry{Kind: snapshotGlobal, Key: key, Value: value})
+	}
+	for i := uint64(0); i <= number; i++ {
+		hash := rawdb.ReadCanonicalHash(db, i)
+		for _, key := range [][]byte{
+			localdb.BlockKey(i, hash.HashToUint256()),
+			state.StakeDB.BlockRecordsKey(i, &hash),
+			stake.BlockVotesKey(hash),
+		} {
+			if value, err := db.Get(key); err == nil {
+				if err := writeGlobal(key, value); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	for _, prefix := range snapshotPrefixes {
+		it := db.NewIteratorWithPrefix(prefix)
+		for it.Next() {
+			if err := writeGlobal(common.CopyBytes(it.Key()), common.CopyBytes(it.Value())); err != nil {
+				it.Release()
+				return err
+			}
+		}
+		it.Release()
+		if err := it.Error(); err != nil {
+			return err
+		}
+	}
+
+	if err := stream.write(&snapshotEntry{Kind: snapshotEnd, Value: stream.sum()}); err != nil {
+		return err
+	}
+	log.Info("Exported snapshot", "number", number, "hash", hash, "root", block.Root(), "nodes", nodes, "globals", globals)
+	return nil
+}
+
+// walkSnapshotState calls fn with every node of the state trie, of the storage
+// tries and with the contract codes reachable from root. Besides
+// the accounts, the state trie holds the leaves of the zero state, which are
+// no accounts and have nothing below them. Unlike state.NodeIterator, a leaf
+// that doesn't decode as an account is taken as one of them.
+func walkSnapshotState(sdb state.Database, root common.Hash, fn func(hash common.Hash, blob []byte) error) error {
+	triedb := sdb.TrieDB()
+	walk := func(it trie.NodeIterator, leaf func(it trie.NodeIterator) error) error {
+		for it.Next(true) {
+			if hash := it.Hash(); hash != (common.Hash{}) {
+				blob, err := triedb.Node(hash)
+				if err != nil {
+					return err
+				}
+				if err := fn(hash, blob); err != nil {
+					return err
+				}
+			}
+			if it.Leaf() && leaf != nil {
+				if err := leaf(it); err != nil {
+					return err
+				}
+			}
+		}
+		return it.Error()
+	}
+	tr, err := sdb.OpenTrie(root)
+	if err != nil {
+		return err
+	}
+	return walk(tr.NodeIterator(nil), func(it trie.NodeIterator) error {
+		var account state.Account
+		if err := rlp.DecodeBytes(it.LeafBlob(), &account); err != nil {
+			return nil
+		}
+		addrHash := common.BytesToHash(it.LeafKey())
+		storage, err := sdb.OpenStorageTrie(addrHash, account.Root)
+		if err != nil {
+			return err
+		}
+		if err := walk(storage.NodeIterator(nil), nil); err != nil {
+			return err
+		}
+		if codeHash := common.BytesToHash(account.CodeHash); len(account.CodeHash) > 0 && codeHash != emptySnapshotCode {
+			code, err := sdb.ContractCode(addrHash, codeHash)
+			if err != nil {
+				return fmt.Errorf("code %x: %v", account.CodeHash, err)
+			}
+			return fn(codeHash, code)
+		}
+		return nil
+	})
+}
+
+var emptySnapshotCode = crypto.Keccak256Hash(nil)
+
+// snapshotChecker checks the zero state records against the chain of the
+// snapshot: the records of a block must be keyed by a canonical block up to
+// the snapshot, the outs must be leaves of the zero state tree of the snapshot
+// state under their roots and the objects keyed by content must hash to their
+// keys. The records of the blocks come in the order of the blocks.
+type snapshotChecker struct {
+	db     serodb.Getter
+	number uint64
+	tree   *txstate.MerkleTree
+	cursor uint64
+}
+
+func newSnapshotChecker(db serodb.Getter, statedb *state.StateDB, number uint64) *snapshotChecker {
+	return &snapshotChecker{
+		db:     db,
+		number: number,
+		tree:   &statedb.CurrentZState().State.MTree,
+	}
+}
+
+func (self *snapshotChecker) check(key, value []byte) (e error) {
+	defer func() {
+		if r := recover(); r != nil {
+			e = self.fail(key, fmt.Sprint(r))
+		}
+	}()
+	switch {
+	case bytes.HasPrefix(key, snapshotShortcutPrefix):
+		rest := key[len(snapshotShortcutPrefix):]
+		if len(rest) < common.HashLength+1 || rest[len(rest)-common.HashLength-1] != '$' {
+			return self.fail(key, "malformed key")
+		}
+		num := new(big.Int).SetBytes(rest[:len(rest)-common.HashLength-1]).Uint64()
+		return self.block(key, num, common.BytesToHash(rest[len(rest)-common.HashLength:]))
+
+	case bytes.HasPrefix(key, snapshotRecordsPrefix):
+		rest := key[len(snapshotRecordsPrefix):]
+		if len(rest) < common.HashLength {
+			return self.fail(key, "malformed key")
+		}
+		num := new(big.Int).SetBytes(rest[:len(rest)-common.HashLength]).Uint64()
+		return self.block(key, num, common.BytesToHash(rest[len(rest)-common.HashLength:]))
+
+	case bytes.HasPrefix(key, snapshotVotesPrefix):
+		rest := key[len(snapshotVotesPrefix):]
+		if len(rest) != common.HashLength {
+			return self.fail(key, "malformed key")
+		}
+		hash := common.BytesToHash(rest)
+		for num := self.cursor; num <= self.number; num++ {
+			if rawdb.ReadCanonicalHash(self.db, num) == hash {
+				self.cursor = num
+				return nil
+			}
+		}
+		return self.fail(key, "block not on the chain")
+
+	case bytes.HasPrefix(key, snapshotRootPrefix):
+		rs := localdb.RootState{}
+		if err := rlp.DecodeBytes(value, &rs); err != nil {
+			return self.fail(key, err.Error())
+		}
+		if rs.Num > self.number {
+			return self.fail(key, "out of a later block")
+		}
+		return self.out(key, key[len(snapshotRootPrefix):], &rs.OS)
+
+	case bytes.HasPrefix(key, snapshotOutPrefix):
+		os := localdb.OutState{}
+		if err := rlp.DecodeBytes(value, &os); err != nil {
+			return self.fail(key, err.Error())
+		}
+		return self.out(key, key[len(snapshotOutPrefix):], &os)
+
+	case bytes.HasPrefix(key, snapshotRootCMPrefix):
+		var root keys.Uint256
+		copy(root[:], value)
+		if got, ok := self.tree.LeafRoot(keys.Uint256(common.BytesToHash(key[len(snapshotRootCMPrefix):]))); len(value) != 32 || !ok || got != root {
+			return self.fail(key, "root commitment not in the zero state")
+		}
+
+	case bytes.HasPrefix(key, snapshotPkgPrefix):
+		pkg := localdb.ZPkg{}
+		if err := rlp.DecodeBytes(value, &pkg); err != nil {
+			return self.fail(key, err.Error())
+		}
+		if hash := pkg.ToHash(); !bytes.Equal(hash[:], key[len(snapshotPkgPrefix):]) || pkg.High > self.number {
+			return self.fail(key, "pkg doesn't match its hash")
+		}
+
+	case bytes.HasPrefix(key, snapshotOutStatPrefix):
+		if err := rlp.DecodeBytes(value, &localdb.OutStat{}); err != nil {
+			return self.fail(key, err.Error())
+		}
+
+	case bytes.HasPrefix(key, snapshotPrunedPrefix):
+		if utils.DecodeNumber(value) > self.number {
+			return self.fail(key, "pruned above the snapshot block")
+		}
+
+	case bytes.HasPrefix(key, snapshotSharePrefix):
+		share := stake.Share{}
+		if err := rlp.DecodeBytes(value, &share); err != nil {
+			return self.fail(key, err.Error())
+		}
+		if !bytes.Equal(share.State(), key[len(snapshotSharePrefix):]) {
+			return self.fail(key, "share doesn't match its hash")
+		}
+
+	case bytes.HasPrefix(key, snapshotPoolPrefix):
+		pool := stake.StakePool{}
+		if err := rlp.DecodeBytes(value, &pool); err != nil {
+			return self.fail(key, err.Error())
+		}
+		if !bytes.Equal(pool.State(), key[len(snapshotPoolPrefix):]) {
+			return self.fail(key, "stake pool doesn't match its hash")
+		}
+
+	default:
+		return self.fail(key, "unexpected record")
+	}
+	return nil
+}
+
+func (self *snapshotChecker) fail(key []byte, reason string) error {
+	return fmt.Errorf("%v: record %q: %v", ErrSnapshotGlobal, key, reason)
+}
+
+// block checks a record of the block num, hash.
+func (self *snapshotChecker) block(key []byte, num uint64, hash common.Hash) error {
+	if num > self.number || num < self.cursor || rawdb.ReadCanonicalHash(self.db, num) != hash {
+		return self.fail(key, "block not on the chain")
+	}
+	self.cursor = num
+	return nil
+}
+
+// out checks that an out is the leaf appended to the zero state tree with
+// the root, its commitment is recomputed rather than taken from the record.
+func (self *snapshotChecker) out(key []byte, root []byte, os *localdb.OutState) error {
+	if len(root) != 32 || (os.Out_O == nil) == (os.Out_Z == nil) {
+		return self.fail(key, "malformed out")
+	}
+	out := *os
+	out.OutCM, out.RootCM = nil, nil
+	if got, ok := self.tree.LeafRoot(*out.ToRootCM()); !ok || !bytes.Equal(got[:], root) {
+		return self.fail(key, "out not in the zero state")
+	}
+	return nil
+}
+
+// snapshotOverlay holds the entries of an archive being imported over the
+// chain database, so that the state is read from them before it's written.
+type snapshotOverlay struct {
+	*serodb.MemDatabase
+	db serodb.Getter
+}
+
+func (self *snapshotOverlay) Has(key []byte) (bool, error) {
+	if has, _ := self.MemDatabase.Has(key); has {
+		return true, nil
+	}
+	return self.db.Has(key)
+}
+
+func (self *snapshotOverlay) Get(key []byte) ([]byte, error) {
+	if value, err := self.MemDatabase.Get(key); err == nil {
+		return value, nil
+	}
+	return self.db.Get(key)
+}
+
+// ImportSnapshot loads an archive written by ExportSnapshot into a chain
+// database holding nothing but the same genesis and makes its block the head
+// of the chain. The headers have to link up to the block, its total difficulty
+// has to be the one of the headers, every node has to match its hash and the
+// whole state has to be reachable from the state root of the block. Nothing is
+// written until all of it is checked, then the archive is written in one batch.
+func ImportSnapshot(db *serodb.LDBDatabase, r io.Reader) (*types.Block, error) {
+	genesis := rawdb.ReadCanonicalHash(db, 0)
+	if genesis == (common.Hash{}) || rawdb.ReadTd(db, genesis, 0) == nil {
+		return nil, ErrNoGenesis
+	}
+	if head := rawdb.ReadHeadBlockHash(db); head != (common.Hash{}) {
+		if number := rawdb.ReadHeaderNumber(db, head); number != nil && *number > 0 {
+			return nil, ErrSnapshotNotEmpty
+		}
+	}
+	stream := &snapshotStream{s: rlp.NewStream(r, 0), hasher: sha3.NewKeccak256()}
+
+	meta := snapshotMeta{}
+	if err := stream.read(&meta); err != nil {
+		return nil, err
+	}
+	if meta.Magic != snapshotMagic || meta.Version != snapshotVersion {
+		return nil, ErrSnapshotFormat
+	}
+
+	var (
+		overlay = &snapshotOverlay{serodb.NewMemDatabase(), db}
+		parent  common.Hash
+		headers uint64
+		block   *types.Block
+		td      = rawdb.ReadTd(db, genesis, 0)
+		nodes   int
+		globals int
+		checker *snapshotChecker
+	)
+	for {
+		checksum := stream.sum()
+		entry := snapshotEntry{}
+		if err := stream.read(&entry); err != nil {
+			return nil, err
+		}
+		if entry.Kind == snapshotEnd {
+			if !bytes.Equal(entry.Value, checksum) {
+				return nil, ErrSnapshotChecksum
+			}
+			break
+		}
+		switch entry.Kind {
+		case snapshotHeader:
+			header := new(types.Header)
+			if err := rlp.DecodeBytes(entry.Value, header); err != nil {
+				return nil, err
+			}
+			if header.Number.Uint64() != headers || header.ParentHash != parent {
+				return nil, fmt.Errorf("%v: header %d doesn't link to its parent", ErrSnapshotFormat, headers)
+			}
+			if headers == 0 && header.Hash() != genesis {
+				return nil, fmt.Errorf("genesis mismatch: have %x, snapshot %x", genesis, header.Hash())
+			}
+			if headers > 0 {
+				td.Add(td, header.Difficulty)
+			}
+			rawdb.WriteHeader(overlay, header)
+			rawdb.WriteCanonicalHash(overlay, header.Hash(), headers)
+			parent = header.Hash()
+			headers++
+
+		case snapshotBlock:
+			block = new(types.Block)
+			if err := rlp.DecodeBytes(entry.Value, block); err != nil {
+				return nil, err
+			}
+			if block.Hash() != meta.Hash || block.Hash() != parent || block.Root() != meta.Root {
+				return nil, fmt.Errorf("%v: block %d doesn't match the headers", ErrSnapshotFormat, meta.Number)
+			}
+			if new(big.Int).SetBytes(entry.Key).Cmp(td) != 0 {
+				return nil, fmt.Errorf("%v: total difficulty %x doesn't match the headers", ErrSnapshotFormat, entry.Key)
+			}
+
+		case snapshotNode:
+			if !bytes.Equal(crypto.Keccak256(entry.Value), entry.Key) {
+				return nil, fmt.Errorf("%v: node %x doesn't match its hash", ErrSnapshotFormat, entry.Key)
+			}
+			if err := overlay.Put(entry.Key, entry.Value); err != nil {
+				return nil, err
+			}
+			nodes++
+
+		case snapshotGlobal:
+			if checker == nil {
+				// The records follow the state, they are checked against it.
+				if block == nil {
+					return nil, fmt.Errorf("%v: snapshot block missing", ErrSnapshotFormat)
+				}
+				statedb, err := state.New(state.NewDatabase(overlay), block.Header())
+				if err != nil {
+					return nil, err
+				}
+				checker = newSnapshotChecker(overlay, statedb, meta.Number)
+			}
+			if err := checker.check(entry.Key, entry.Value); err != nil {
+				return nil, err
+			}
+			if err := overlay.Put(entry.Key, entry.Value); err != nil {
+				return nil, err
+			}
+			globals++
+
+		default:
+			return nil, fmt.Errorf("%v: unknown entry kind %d", ErrSnapshotFormat, entry.Kind)
+		}
+	}
+	if block == nil || headers != meta.Number+1 {
+		return nil, fmt.Errorf("%v: snapshot block missing", ErrSnapshotFormat)
+	}
+
+	// Walk the whole state from the root of the block, a node missing from the
+	// archive would only show up on the first block processed otherwise.
+	if err := walkSnapshotState(state.NewDatabase(overlay), block.Root(), func(common.Hash, []byte) error { return nil }); err != nil {
+		return nil, fmt.Errorf("snapshot state incomplete: %v", err)
+	}
+
+	batch := db.NewBatch()
+	for _, key := range overlay.Keys() {
+		value, _ := overlay.MemDatabase.Get(key)
+		if err := batch.Put(key, value); err != nil {
+			return nil, err
+		}
+	}
+	rawdb.WriteBlock(batch, block)
+	rawdb.WriteTd(batch, block.Hash(), block.NumberU64(), td)
+	rawdb.WriteHeadBlockHash(batch, block.Hash())
+	rawdb.WriteHeadHeaderHash(batch, block.Hash())
+	rawdb.WriteHeadFastBlockHash(batch, block.Hash())
+	if err := batch.Write(); err != nil {
+		return nil, err
+	}
+	log.Info("Imported snapshot", "number", block.NumberU64(), "hash", block.Hash(), "root", block.Root(), "nodes", nodes, "globals", globals)
+	return block, nil
+}
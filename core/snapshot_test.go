@@ -0,0 +1,239 @@
+package core
+
+import (
+	"bytes"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"testing"
+
+	"github.com/sero-cash/go-czero-import/cpt"
+	"github.com/sero-cash/go-czero-import/seroparam"
+	"github.com/sero-cash/go-sero/common"
+	"github.com/sero-cash/go-sero/consensus/ethash"
+	"github.com/sero-cash/go-sero/core/rawdb"
+	"github.com/sero-cash/go-sero/core/state"
+	"github.com/sero-cash/go-sero/core/vm"
+	"github.com/sero-cash/go-sero/crypto/sha3"
+	"github.com/sero-cash/go-sero/params"
+	"github.com/sero-cash/go-sero/rlp"
+	"github.com/sero-cash/go-sero/serodb"
+	"github.com/sero-cash/go-sero/zero/stake"
+)
+
+func newSnapshotDB(t *testing.T) (*serodb.LDBDatabase, func()) {
+	dir, err := ioutil.TempDir("", "snapshot")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := serodb.NewLDBDatabase(dir, 16, 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db, func() {
+		db.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+// newSnapshotChain returns an archive of the block 8 of a generated chain.
+func newSnapshotChain(t *testing.T) (*Genesis, *serodb.LDBDatabase, []byte, func()) {
+	// The outs are only recorded from the SIP2, which is the genesis on dev
+	seroparam.Init_Dev(true)
+	cpt.ZeroInit("", cpt.NET_Dev)
+	db, closeDB := newSnapshotDB(t)
+	gspec := &Genesis{Config: params.TestChainConfig}
+	parent := gspec.MustCommit(db)
+
+	chain, err := NewBlockChain(db, &CacheConfig{Disabled: true}, params.TestChainConfig, ethash.NewFaker(), vm.Config{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// One by one, the rewards of a block are read from the chain before it
+	for i := 0; i < 8; i++ {
+		blocks, _ := GenerateChain(params.TestChainConfig, parent, ethash.NewFaker(), db, 1, func(i int, b *BlockGen) {
+			if err := stake.NewStakeState(b.statedb).ProcessBeforeApply(chain, b.header); err != nil {
+				t.Fatal(err)
+			}
+		})
+		if _, err := chain.InsertChain(blocks); err != nil {
+			t.Fatal(err)
+		}
+		parent = blocks[0]
+	}
+	chain.Stop()
+
+	var archive bytes.Buffer
+	if err := ExportSnapshot(db, 8, &archive); err != nil {
+		t.Fatal(err)
+	}
+	return gspec, db, archive.Bytes(), closeDB
+}
+
+// rewriteSnapshot changes the entries of an archive with fn, drops those fn
+// returns false for and signs the archive again.
+func rewriteSnapshot(t *testing.T, archive []byte, fn func(entry *snapshotEntry) bool) []byte {
+	in := &snapshotStream{s: rlp.NewStream(bytes.NewReader(archive), 0), hasher: sha3.NewKeccak256()}
+	var out bytes.Buffer
+	w := &snapshotStream{w: &out, hasher: sha3.NewKeccak256()}
+
+	meta := snapshotMeta{}
+	if err := in.read(&meta); err != nil {
+		t.Fatal(err)
+	}
+	w.write(&meta)
+	for {
+		entry := snapshotEntry{}
+		if err := in.read(&entry); err != nil {
+			t.Fatal(err)
+		}
+		if entry.Kind == snapshotEnd {
+			w.write(&snapshotEntry{Kind: snapshotEnd, Value: w.sum()})
+			return out.Bytes()
+		}
+		if fn(&entry) {
+			w.write(&entry)
+		}
+	}
+}
+
+func importSnapshot(t *testing.T, gspec *Genesis, archive []byte) (*serodb.LDBDatabase, error, func()) {
+	db, closeDB := newSnapshotDB(t)
+	gspec.MustCommit(db)
+	_, err := ImportSnapshot(db, bytes.NewReader(archive))
+	return db, err, closeDB
+}
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	gspec, src, archive, closeSrc := newSnapshotChain(t)
+	defer closeSrc()
+
+	dst, err, closeDst := importSnapshot(t, gspec, archive)
+	defer closeDst()
+	if err != nil {
+		t.Fatalf("import failed: %v", err)
+	}
+	head := rawdb.ReadHeadBlockHash(dst)
+	if want := rawdb.ReadCanonicalHash(src, 8); head != want {
+		t.Fatalf("head mismatch: have %x, want %x", head, want)
+	}
+	header := rawdb.ReadHeader(dst, head, 8)
+	statedb, err := state.New(state.NewDatabase(dst), header)
+	if err != nil {
+		t.Fatalf("state of the snapshot missing: %v", err)
+	}
+	if root := statedb.IntermediateRoot(false); root != header.Root {
+		t.Fatalf("state root mismatch: have %x, want %x", root, header.Root)
+	}
+
+	records := 0
+	for _, prefix := range append(snapshotPrefixes, snapshotShortcutPrefix, snapshotRecordsPrefix, snapshotVotesPrefix) {
+		it := src.NewIteratorWithPrefix(prefix)
+		for it.Next() {
+			value, err := dst.Get(it.Key())
+			if err != nil || !bytes.Equal(value, it.Value()) {
+				t.Fatalf("record %q not imported", it.Key())
+			}
+			records++
+		}
+		it.Release()
+	}
+	if records == 0 {
+		t.Fatalf("no zero state records exported")
+	}
+	// Again on top of the snapshot is refused
+	if _, err := ImportSnapshot(dst, bytes.NewReader(archive)); err != ErrSnapshotNotEmpty {
+		t.Fatalf("import on a chain: have %v, want %v", err, ErrSnapshotNotEmpty)
+	}
+}
+
+func TestSnapshotTampered(t *testing.T) {
+	gspec, _, archive, closeSrc := newSnapshotChain(t)
+	defer closeSrc()
+
+	nodes := 0
+	tests := []struct {
+		name   string
+		keep   bool
+		tamper func(entry *snapshotEntry) bool
+	}{
+		{"total difficulty", true, func(entry *snapshotEntry) bool {
+			if entry.Kind == snapshotBlock {
+				entry.Key = new(big.Int).Add(new(big.Int).SetBytes(entry.Key), common.Big1).Bytes()
+				return true
+			}
+			return false
+		}},
+		{"node", true, func(entry *snapshotEntry) bool {
+			if entry.Kind == snapshotNode {
+				entry.Value = append(common.CopyBytes(entry.Value), 0)
+				return true
+			}
+			return false
+		}},
+		{"dropped node", false, func(entry *snapshotEntry) bool {
+			if entry.Kind == snapshotNode {
+				nodes++
+				return nodes == 2
+			}
+			return false
+		}},
+		{"root record", true, func(entry *snapshotEntry) bool {
+			if entry.Kind == snapshotGlobal && bytes.HasPrefix(entry.Key, snapshotRootPrefix) {
+				entry.Key = append(common.CopyBytes(entry.Key[:len(entry.Key)-1]), entry.Key[len(entry.Key)-1]^1)
+				return true
+			}
+			return false
+		}},
+		{"block record", true, func(entry *snapshotEntry) bool {
+			if entry.Kind == snapshotGlobal && bytes.HasPrefix(entry.Key, snapshotShortcutPrefix) {
+				entry.Key = append(common.CopyBytes(entry.Key[:len(entry.Key)-1]), entry.Key[len(entry.Key)-1]^1)
+				return true
+			}
+			return false
+		}},
+		{"foreign record", true, func(entry *snapshotEntry) bool {
+			if entry.Kind == snapshotGlobal {
+				entry.Key = []byte("LastBlock")
+				return true
+			}
+			return false
+		}},
+	}
+	for _, test := range tests {
+		tampered := false
+		bad := rewriteSnapshot(t, archive, func(entry *snapshotEntry) bool {
+			if !tampered && test.tamper(entry) {
+				tampered = true
+				return test.keep
+			}
+			return true
+		})
+		if !tampered {
+			t.Fatalf("%s: nothing to tamper with", test.name)
+		}
+		db, err, closeDB := importSnapshot(t, gspec, bad)
+		if err == nil {
+			t.Errorf("%s: tampered snapshot imported", test.name)
+		}
+		if head := rawdb.ReadHeadBlockHash(db); head != gspec.ToBlock(nil).Hash() {
+			t.Errorf("%s: head moved to %x", test.name, head)
+		}
+		if hash := rawdb.ReadCanonicalHash(db, 1); hash != (common.Hash{}) {
+			t.Errorf("%s: headers written before the archive is checked", test.name)
+		}
+		closeDB()
+	}
+
+	// A changed archive which isn't signed again fails on its checksum
+	bad := common.CopyBytes(archive)
+	bad[len(bad)-1] ^= 1
+	db, err, closeDB := importSnapshot(t, gspec, bad)
+	defer closeDB()
+	if err != ErrSnapshotChecksum {
+		t.Errorf("checksum: have %v, want %v", err, ErrSnapshotChecksum)
+	}
+	if head := rawdb.ReadHeadBlockHash(db); head != gspec.ToBlock(nil).Hash() {
+		t.Errorf("checksum: head moved to %x", head)
+	}
+}
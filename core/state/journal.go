@@ -126,6 +126,7 @@ type (
 	addLogChange struct {
 		txhash common.Hash
 	}
+	addAssetRecordChange struct{}
 	addPreimageChange struct {
 		hash common.Hash
 	}
@@ -230,6 +231,14 @@ func (ch addLogChange) dirtied() *common.Address {
 	return nil
 }
 
+func (ch addAssetRecordChange) revert(s *StateDB) {
+	s.assetRecords = s.assetRecords[:len(s.assetRecords)-1]
+}
+
+func (ch addAssetRecordChange) dirtied() *common.Address {
+	return nil
+}
+
 func (ch addPreimageChange) revert(s *StateDB) {
 	delete(s.preimages, ch.hash)
 }
@@ -94,6 +94,8 @@ type StateDB struct {
 	logs         map[common.Hash][]*types.Log
 	logSize      uint
 
+	assetRecords []*types.AssetRecord
+
 	preimages map[common.Hash][]byte
 
 	// Journal of state modifications. This is the backbone of
@@ -369,6 +371,7 @@ func (self *StateDB) Reset(root common.Hash) error {
 	self.txIndex = 0
 	self.logs = make(map[common.Hash][]*types.Log)
 	self.logSize = 0
+	self.assetRecords = nil
 	self.preimages = make(map[common.Hash][]byte)
 	self.clearJournalAndRefund()
 	return nil
@@ -397,6 +400,20 @@ func (self *StateDB) Logs() []*types.Log {
 	return logs
 }
 
+// AddAssetRecord records a token or ticket registration or issuance made by the
+// current transaction.
+func (self *StateDB) AddAssetRecord(record *types.AssetRecord) {
+	self.journal.append(addAssetRecordChange{})
+
+	record.TxHash = self.thash
+	self.assetRecords = append(self.assetRecords, record)
+}
+
+// AssetRecords returns the token and ticket records collected so far.
+func (self *StateDB) AssetRecords() []*types.AssetRecord {
+	return self.assetRecords
+}
+
 // AddPreimage records a SHA3 preimage seen by the VM.
 func (self *StateDB) AddPreimage(hash common.Hash, preimage []byte) {
 	if _, ok := self.preimages[hash]; !ok {
@@ -792,6 +809,10 @@ func (self *StateDB) Copy() *StateDB {
 		state.logs[hash] = make([]*types.Log, len(logs))
 		copy(state.logs[hash], logs)
 	}
+	if len(self.assetRecords) > 0 {
+		state.assetRecords = make([]*types.AssetRecord, len(self.assetRecords))
+		copy(state.assetRecords, self.assetRecords)
+	}
 	for hash, preimage := range self.preimages {
 		state.preimages[hash] = preimage
 	}
@@ -218,6 +218,7 @@ func (st *StateTransition) TransitionDb() (ret []byte, usedGas uint64, failed bo
 		}
 		if msg.Asset() != nil {
 			st.state.NextZState().AddTxOut(msg.From(), *msg.Asset(), msg.TxHash())
+			evm.TraceTxOut(msg.From(), msg.Asset())
 		}
 	}
 
@@ -256,6 +257,7 @@ func (st *StateTransition) refundGas() {
 				},
 				}
 				st.state.NextZState().AddTxOut(st.msg.From(), asset, st.msg.TxHash())
+				st.evm.TraceTxOut(st.msg.From(), &asset)
 				st.state.SubBalance(*st.msg.To(), curency, remainToken)
 			}
 		} else {
@@ -265,6 +267,7 @@ func (st *StateTransition) refundGas() {
 			},
 			}
 			st.state.NextZState().AddTxOut(st.msg.From(), asset, st.msg.TxHash())
+			st.evm.TraceTxOut(st.msg.From(), &asset)
 		}
 	}
 
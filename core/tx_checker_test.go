@@ -0,0 +1,131 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/sero-cash/go-czero-import/cpt"
+	"github.com/sero-cash/go-czero-import/seroparam"
+	"github.com/sero-cash/go-sero/common"
+	"github.com/sero-cash/go-sero/consensus/ethash"
+	"github.com/sero-cash/go-sero/core/types"
+	"github.com/sero-cash/go-sero/core/vm"
+	"github.com/sero-cash/go-sero/params"
+	"github.com/sero-cash/go-sero/zero/checkpoint"
+	"github.com/sero-cash/go-sero/zero/stake"
+	"github.com/sero-cash/go-sero/zero/txtool/verify"
+)
+
+// newCheckpointChain returns a chain holding the first of three generated
+// blocks, and the blocks.
+func newCheckpointChain(t *testing.T) (*BlockChain, []*types.Block, func()) {
+	seroparam.Init_Dev(true)
+	cpt.ZeroInit("", cpt.NET_Dev)
+	db, closeDB := newSnapshotDB(t)
+	gspec := &Genesis{Config: params.TestChainConfig}
+	parent := gspec.MustCommit(db)
+
+	chain, err := NewBlockChain(db, &CacheConfig{Disabled: true}, params.TestChainConfig, ethash.NewFaker(), vm.Config{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var blocks []*types.Block
+	for i := 0; i < 3; i++ {
+		generated, _ := GenerateChain(params.TestChainConfig, parent, ethash.NewFaker(), db, 1, func(i int, b *BlockGen) {
+			if err := stake.NewStakeState(b.statedb).ProcessBeforeApply(chain, b.header); err != nil {
+				t.Fatal(err)
+			}
+		})
+		if _, err := chain.InsertChain(generated); err != nil {
+			t.Fatal(err)
+		}
+		parent = generated[0]
+		blocks = append(blocks, parent)
+	}
+	chain.Stop()
+	closeDB()
+
+	// Again on a chain only holding the first one
+	db, closeDB = newSnapshotDB(t)
+	gspec.MustCommit(db)
+	chain, err = NewBlockChain(db, &CacheConfig{Disabled: true}, params.TestChainConfig, ethash.NewFaker(), vm.Config{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := chain.InsertChain(blocks[:1]); err != nil {
+		t.Fatal(err)
+	}
+	return chain, blocks, func() {
+		chain.Stop()
+		closeDB()
+	}
+}
+
+// forkBlock returns a block at the number of block with a transaction spending
+// unknown nils without signing them.
+func forkBlock(block *types.Block) *types.Block {
+	header := types.CopyHeader(block.Header())
+	header.Extra = []byte("fork")
+	return types.NewBlock(header, []*types.Transaction{nilTx(1, 1, 1)}, nil)
+}
+
+func TestCheckpointTrustsLinkedChain(t *testing.T) {
+	chain, blocks, closeChain := newCheckpointChain(t)
+	defer closeChain()
+
+	cp := &checkpoint.Checkpoint{Number: 3, Hash: blocks[2].Hash()}
+	chain.TrustCheckpoint(cp, 1, []common.Hash{blocks[0].Hash(), blocks[1].Hash(), blocks[2].Hash()})
+	if !chain.trustedBlock(blocks[1]) {
+		t.Fatalf("block on the checkpoint chain not trusted")
+	}
+
+	// A fork below the checkpoint gets its proofs verified
+	fork := forkBlock(blocks[1])
+	if chain.trustedBlock(fork) {
+		t.Fatalf("fork below the checkpoint trusted")
+	}
+	_, err := chain.InsertChain(types.Blocks{fork})
+	if code := verify.ErrorCodeOf(err); code == 0 || code == verify.ErrCodeRootNotFound {
+		t.Fatalf("fork with a bad proof: have %v, want a proof check error", err)
+	}
+	if head := chain.CurrentBlock().Hash(); head != blocks[0].Hash() {
+		t.Fatalf("head moved to %x", head)
+	}
+
+	// Had the fork been on the chain leading to the checkpoint, only the
+	// state checks would have run
+	forked := &checkpoint.Checkpoint{Number: 3, Hash: common.Hash{3}}
+	chain.trusted.Store((*trustedChain)(nil))
+	chain.TrustCheckpoint(forked, 1, []common.Hash{blocks[0].Hash(), fork.Hash(), forked.Hash})
+	_, err = chain.InsertChain(types.Blocks{fork})
+	if code := verify.ErrorCodeOf(err); code != verify.ErrCodeRootNotFound {
+		t.Fatalf("trusted fork: have %v, want %v", err, verify.ErrCodeRootNotFound)
+	}
+
+	// Past the checkpoint nothing is trusted anymore
+	chain.trusted.Store((*trustedChain)(nil))
+	chain.TrustCheckpoint(cp, 1, []common.Hash{blocks[0].Hash(), blocks[1].Hash(), blocks[2].Hash()})
+	if _, err := chain.InsertChain(types.Blocks{blocks[1], blocks[2]}); err != nil {
+		t.Fatal(err)
+	}
+	if chain.trustedBlock(blocks[1]) {
+		t.Fatalf("trust kept past the checkpoint")
+	}
+}
+
+func TestCheckpointRejectsUnlinkedChain(t *testing.T) {
+	chain, blocks, closeChain := newCheckpointChain(t)
+	defer closeChain()
+
+	cp := &checkpoint.Checkpoint{Number: 3, Hash: blocks[2].Hash()}
+	for _, hashes := range [][]common.Hash{
+		nil,
+		{blocks[0].Hash(), blocks[1].Hash()}, // not leading to the checkpoint
+		{blocks[1].Hash(), blocks[2].Hash()}, // numbers not matching
+		{blocks[0].Hash(), blocks[1].Hash(), common.Hash{3}}, // other checkpoint hash
+	} {
+		chain.TrustCheckpoint(cp, 1, hashes)
+		if chain.trustedBlock(blocks[0]) || chain.trustedBlock(blocks[1]) {
+			t.Fatalf("chain %x trusted", hashes)
+		}
+	}
+}
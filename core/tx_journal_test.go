@@ -0,0 +1,281 @@
+// copyright 2018 The sero.cash Authors
+// This file is part of the go-sero library.
+//
+// The go-sero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-sero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-sero library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sero-cash/go-czero-import/cpt"
+	"github.com/sero-cash/go-czero-import/keys"
+	"github.com/sero-cash/go-czero-import/seroparam"
+	"github.com/sero-cash/go-sero/common"
+	"github.com/sero-cash/go-sero/core/state"
+	"github.com/sero-cash/go-sero/core/types"
+	"github.com/sero-cash/go-sero/event"
+	"github.com/sero-cash/go-sero/params"
+	"github.com/sero-cash/go-sero/serodb"
+	"github.com/sero-cash/go-sero/zero/localdb"
+	"github.com/sero-cash/go-sero/zero/txs/assets"
+	"github.com/sero-cash/go-sero/zero/txs/stx"
+	"github.com/sero-cash/go-sero/zero/txtool"
+	"github.com/sero-cash/go-sero/zero/txtool/generate"
+	"github.com/sero-cash/go-sero/zero/utils"
+)
+
+// testBlockChain is a chain of a single head block, whose state the tests
+// change to fund the transactions or spend their nils.
+type testBlockChain struct {
+	db     state.Database
+	header *types.Header
+	feed   event.Feed
+}
+
+func newTestBlockChain(t *testing.T) *testBlockChain {
+	seroparam.Init_Dev(true)
+	cpt.ZeroInit("", cpt.NET_Dev)
+	bc := &testBlockChain{
+		db:     state.NewDatabase(serodb.NewMemDatabase()),
+		header: &types.Header{Number: big.NewInt(0), GasLimit: params.GenesisGasLimit},
+	}
+	bc.update(t, func(*state.StateDB) {})
+	return bc
+}
+
+func (bc *testBlockChain) CurrentBlock() *types.Block {
+	return types.NewBlockWithHeader(bc.header)
+}
+
+func (bc *testBlockChain) GetBlock(hash common.Hash, number uint64) *types.Block {
+	return bc.CurrentBlock()
+}
+
+func (bc *testBlockChain) StateAt(header *types.Header) (*state.StateDB, error) {
+	return state.New(bc.db, header)
+}
+
+func (bc *testBlockChain) SubscribeChainHeadEvent(ch chan<- ChainHeadEvent) event.Subscription {
+	return bc.feed.Subscribe(ch)
+}
+
+// update changes the zero state of the head block with fn.
+func (bc *testBlockChain) update(t *testing.T, fn func(statedb *state.StateDB)) {
+	statedb, err := state.New(bc.db, bc.header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fn(statedb)
+	statedb.IntermediateRoot(true)
+	statedb.NextZState().RecordBlock(bc.db.TrieDB().WDiskDB(), bc.header.Hash().HashToUint256())
+	root, err := statedb.Commit(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	header := types.CopyHeader(bc.header)
+	header.Root = root
+	bc.header = header
+}
+
+// fundedTx returns a local zero transaction of the account i, spending an out
+// of the chain state.
+func (bc *testBlockChain) fundedTx(t *testing.T, i byte) *types.Transaction {
+	seed := keys.Uint256{i}
+	sk := keys.Seed2Sk(&seed)
+	pk := keys.Sk2PK(&sk)
+	pkr := keys.Addr2PKr(&pk, &keys.Uint256{i})
+
+	fee := assets.Token{Currency: utils.CurrencyToUint256("SERO"), Value: utils.NewU256(25000)}
+	var in txtool.GIn
+	bc.update(t, func(statedb *state.StateDB) {
+		st := statedb.NextZState()
+		out := stx.Out_O{Addr: pkr, Asset: assets.Asset{Tkn: &fee}}
+		root := st.State.AddOut(&out, nil, &keys.Uint256{})
+		in = txtool.GIn{Out: txtool.Out{Root: root, State: localdb.RootState{OS: *st.State.GetOut(&root)}}}
+	})
+	param := txtool.GTxParam{
+		Gas:      25000,
+		GasPrice: big.NewInt(1),
+		Fee:      fee,
+		From:     txtool.Kr{PKr: pkr},
+		Ins:      []txtool.GIn{in},
+	}
+	copy(param.From.SKr[:], sk[:])
+	copy(param.Ins[0].SKr[:], sk[:])
+	tx, _, _, err := generate.GenTx(&param)
+	if err != nil {
+		t.Fatalf("failed to generate tx: %v", err)
+	}
+	return types.NewTxWithGTx(25000, big.NewInt(1), &tx)
+}
+
+// spend spends the nils of a transaction in the chain state, as its inclusion
+// in a block would.
+func (bc *testBlockChain) spend(t *testing.T, tx *types.Transaction) {
+	bc.update(t, func(statedb *state.StateDB) {
+		st := statedb.NextZState()
+		if err := st.AddStx(tx.GetZZSTX()); err != nil {
+			t.Fatalf("failed to apply tx: %v", err)
+		}
+		st.Update()
+	})
+}
+
+// newJournalPool creates a pool on the chain journaling its local transactions
+// into the journal file.
+func newJournalPool(bc *testBlockChain, journal string) *TxPool {
+	config := DefaultTxPoolConfig
+	config.PriceLimit = 1
+	config.Journal = journal
+	return NewTxPool(config, params.TestChainConfig, bc)
+}
+
+func newJournalFile(t *testing.T) (string, func()) {
+	dir, err := ioutil.TempDir("", "txjournal")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return filepath.Join(dir, "transactions.rlp"), func() { os.RemoveAll(dir) }
+}
+
+// journaled returns the hashes of the transactions of a journal file.
+func journaled(t *testing.T, path string) map[common.Hash]bool {
+	journal := newTxJournal(path)
+	defer journal.close()
+
+	hashes := make(map[common.Hash]bool)
+	err := journal.load(func(txs []*types.Transaction) []error {
+		for _, tx := range txs {
+			hashes[tx.Hash()] = true
+		}
+		return make([]error, len(txs))
+	})
+	if err != nil {
+		t.Fatalf("failed to load journal: %v", err)
+	}
+	return hashes
+}
+
+// TestTxPoolJournal checks that the local zero transactions survive a restart
+// through the journal, unlike the remote ones.
+func TestTxPoolJournal(t *testing.T) {
+	bc := newTestBlockChain(t)
+	path, remove := newJournalFile(t)
+	defer remove()
+
+	local, remote := bc.fundedTx(t, 1), bc.fundedTx(t, 2)
+	pool := newJournalPool(bc, path)
+	if err := pool.AddLocal(local); err != nil {
+		t.Fatalf("failed to add local tx: %v", err)
+	}
+	if err := pool.AddRemote(remote); err != nil {
+		t.Fatalf("failed to add remote tx: %v", err)
+	}
+	pool.Stop()
+
+	pool = newJournalPool(bc, path)
+	defer pool.Stop()
+	if pool.Get(local.Hash()) == nil {
+		t.Errorf("local tx lost on restart")
+	}
+	if pool.Get(remote.Hash()) != nil {
+		t.Errorf("remote tx journaled")
+	}
+}
+
+// TestTxPoolJournalSpent checks that the journaled transactions whose nils have
+// been spent in the mean time are dropped on load, and from the journal.
+func TestTxPoolJournalSpent(t *testing.T) {
+	bc := newTestBlockChain(t)
+	path, remove := newJournalFile(t)
+	defer remove()
+
+	spent, kept := bc.fundedTx(t, 1), bc.fundedTx(t, 2)
+	pool := newJournalPool(bc, path)
+	if errs := pool.AddLocals([]*types.Transaction{spent, kept}); errs[0] != nil || errs[1] != nil {
+		t.Fatalf("failed to add local txs: %v", errs)
+	}
+	pool.Stop()
+
+	bc.spend(t, spent)
+	pool = newJournalPool(bc, path)
+	if pool.Get(spent.Hash()) != nil {
+		t.Errorf("tx of a spent nil loaded")
+	}
+	if pool.Get(kept.Hash()) == nil {
+		t.Errorf("tx of an unspent nil lost")
+	}
+	pool.Stop()
+	if hashes := journaled(t, path); hashes[spent.Hash()] || !hashes[kept.Hash()] {
+		t.Errorf("journal mismatch after load: %v", hashes)
+	}
+}
+
+// TestTxPoolJournalRotate checks that rotating the journal keeps only the local
+// transactions still pooled.
+func TestTxPoolJournalRotate(t *testing.T) {
+	bc := newTestBlockChain(t)
+	path, remove := newJournalFile(t)
+	defer remove()
+
+	mined, pending, remote := bc.fundedTx(t, 1), bc.fundedTx(t, 2), bc.fundedTx(t, 3)
+	pool := newJournalPool(bc, path)
+	defer pool.Stop()
+	if errs := pool.AddLocals([]*types.Transaction{mined, pending}); errs[0] != nil || errs[1] != nil {
+		t.Fatalf("failed to add local txs: %v", errs)
+	}
+	if err := pool.AddRemote(remote); err != nil {
+		t.Fatalf("failed to add remote tx: %v", err)
+	}
+	pool.mu.Lock()
+	pool.removeTx(mined.Hash())
+	err := pool.journal.rotate(pool.local())
+	pool.mu.Unlock()
+	if err != nil {
+		t.Fatalf("failed to rotate journal: %v", err)
+	}
+	hashes := journaled(t, path)
+	if len(hashes) != 1 || !hashes[pending.Hash()] {
+		t.Errorf("journal mismatch after rotation: have %v, want %x", hashes, pending.Hash())
+	}
+}
+
+// TestTxPoolAddErrors checks that adding a batch reports the error of each
+// transaction at its index.
+func TestTxPoolAddErrors(t *testing.T) {
+	bc := newTestBlockChain(t)
+	valid := bc.fundedTx(t, 1)
+	tampered := *bc.fundedTx(t, 2).GetZZSTX()
+	tampered.Fee.Value = utils.NewU256(24999)
+	invalid := types.NewTxWithGTx(25000, big.NewInt(1), &tampered)
+
+	pool := newJournalPool(bc, "")
+	defer pool.Stop()
+
+	errs := pool.AddRemotes([]*types.Transaction{invalid, valid, valid})
+	if errs[0] == nil {
+		t.Errorf("invalid tx accepted")
+	}
+	if errs[1] != nil {
+		t.Errorf("valid tx rejected: %v", errs[1])
+	}
+	if errs[2] == nil {
+		t.Errorf("known tx accepted again")
+	}
+}
@@ -171,7 +171,6 @@ func (l *txPricedList) Discard(threshold *big.Int, count int) types.Transactions
 	drop := make(types.Transactions, 0, count) // Remote underpriced transactions to drop
 	save := make(types.Transactions, 0, 64)    // Local underpriced transactions to keep
 
-	save_count := len(*l.items) - count
 	for len(*l.items) > 0 {
 		// Discard stale transactions if found during cleanup
 		tx := heap.Pop(l.items).(*types.Transaction)
@@ -180,12 +179,11 @@ func (l *txPricedList) Discard(threshold *big.Int, count int) types.Transactions
 			continue
 		}
 		l.all.Remove(tx.Hash())
-		// Non stale transaction found, discard unless local
-		if save_count > 0 && tx.GasPrice().Cmp(threshold) >= 0 {
-			save = append(save, tx)
-			save_count--
-		} else {
+		// Non stale transaction found, the cheapest ones are discarded first
+		if len(drop) < count || tx.GasPrice().Cmp(threshold) < 0 {
 			drop = append(drop, tx)
+		} else {
+			save = append(save, tx)
 		}
 	}
 	for _, tx := range save {
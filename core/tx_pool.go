@@ -69,6 +69,10 @@ var (
 	ErrOversizedData = errors.New("oversized data")
 
 	ErrCurrencyError = errors.New("currency error")
+
+	// ErrReplaceUnderpriced is returned if a transaction spends a nil already
+	// spent by a pooled transaction without paying enough to replace it.
+	ErrReplaceUnderpriced = errors.New("replacement transaction underpriced")
 )
 
 var (
@@ -80,6 +84,7 @@ var (
 	// General tx metrics
 	invalidTxCounter     = metrics.NewRegisteredCounter("txpool/invalid", nil)
 	underpricedTxCounter = metrics.NewRegisteredCounter("txpool/underpriced", nil)
+	replacedTxCounter    = metrics.NewRegisteredCounter("txpool/replaced", nil)
 )
 
 // TxStatus is the current status of a transaction as seen by the pool.
@@ -106,12 +111,16 @@ type TxPoolConfig struct {
 	NoLocals bool // Whether local transaction handling should be disabled
 
 	PriceLimit uint64 // Minimum gas priced to enforce for acceptance into the pool
+	PriceBump  uint64 // Minimum price bump percentage to replace a transaction spending the same nil
 
 	AccountSlots uint64 // Number of executable transaction slots guaranteed per account
 	GlobalSlots  uint64 // Maximum number of executable transaction slots for all accounts
 	AccountQueue uint64 // Maximum number of non-executable transaction slots permitted per account
 	GlobalQueue  uint64 // Maximum number of non-executable transaction slots for all accounts
 
+	Journal   string        // Journal of local transactions to survive node restarts
+	Rejournal time.Duration // Time interval to regenerate the local transaction journal
+
 	Lifetime time.Duration // Maximum amount of time non-executable transaction are queued
 }
 
@@ -120,11 +129,15 @@ type TxPoolConfig struct {
 var DefaultTxPoolConfig = TxPoolConfig{
 
 	PriceLimit:   params.Gta,
+	PriceBump:    10,
 	AccountSlots: 16,
 	GlobalSlots:  4096,
 	AccountQueue: 64,
 	GlobalQueue:  1024,
 
+	Journal:   "transactions.rlp",
+	Rejournal: time.Hour,
+
 	Lifetime: 3 * time.Hour,
 }
 
@@ -137,6 +150,14 @@ func (config *TxPoolConfig) sanitize() TxPoolConfig {
 		log.Warn("Sanitizing invalid txpool priced limit", "provided", conf.PriceLimit, "updated", DefaultTxPoolConfig.PriceLimit)
 		conf.PriceLimit = DefaultTxPoolConfig.PriceLimit
 	}
+	if conf.PriceBump < 1 {
+		log.Warn("Sanitizing invalid txpool price bump", "provided", conf.PriceBump, "updated", DefaultTxPoolConfig.PriceBump)
+		conf.PriceBump = DefaultTxPoolConfig.PriceBump
+	}
+	if conf.Rejournal < time.Second {
+		log.Warn("Sanitizing invalid txpool journal time", "provided", conf.Rejournal, "updated", time.Second)
+		conf.Rejournal = time.Second
+	}
 	return conf
 }
 
@@ -153,6 +174,7 @@ type TxPool struct {
 	chain        blockChain
 	gasPrice     *big.Int
 	txFeed       event.Feed
+	replaceFeed  event.Feed
 	scope        event.SubscriptionScope
 	chainHeadCh  chan ChainHeadEvent
 	chainHeadSub event.Subscription
@@ -163,8 +185,9 @@ type TxPool struct {
 	pendingState  *state.ManagedState // Pending state tracking virtual nonces
 	currentMaxGas uint64              // Current gas limit for transaction caps
 
-	locals *accountSet // Set of local transaction to exempt from eviction rules
-	//journal *txJournal  // Journal of local transaction to back up to disk
+	locals   *accountSet              // Set of local transaction to exempt from eviction rules
+	localTxs map[common.Hash]struct{} // Locally submitted transactions kept in the journal
+	journal  *txJournal               // Journal of local transaction to back up to disk
 
 	all        *txLookup     // All transactions to allow lookups
 	priced     *txPricedList // All transactions sorted by priced
@@ -172,6 +195,7 @@ type TxPool struct {
 	newPending *txPricedList
 	beats      map[common.Hash]time.Time
 	faileds    map[common.Hash]time.Time
+	nils       map[keys.Uint256]common.Hash // Pooled transaction spending each nil or root
 
 	wg sync.WaitGroup // for shutdown sync
 
@@ -191,6 +215,8 @@ func NewTxPool(config TxPoolConfig, chainconfig *params.ChainConfig, chain block
 		chain:       chain,
 		beats:       make(map[common.Hash]time.Time),
 		faileds:     make(map[common.Hash]time.Time),
+		nils:        make(map[keys.Uint256]common.Hash),
+		localTxs:    make(map[common.Hash]struct{}),
 		all:         newTxLookup(),
 		chainHeadCh: make(chan ChainHeadEvent, chainHeadChanSize),
 		gasPrice:    new(big.Int).SetUint64(config.PriceLimit),
@@ -201,6 +227,17 @@ func NewTxPool(config TxPoolConfig, chainconfig *params.ChainConfig, chain block
 	pool.newPending = newTxPricedList(newTxLookup())
 	pool.reset(nil, chain.CurrentBlock().Header())
 
+	// If local transactions and journaling is enabled, load from disk
+	if !config.NoLocals && config.Journal != "" {
+		pool.journal = newTxJournal(config.Journal)
+
+		if err := pool.journal.load(pool.addJournaled); err != nil {
+			log.Warn("Failed to load transaction journal", "err", err)
+		}
+		if err := pool.journal.rotate(pool.local()); err != nil {
+			log.Warn("Failed to rotate transaction journal", "err", err)
+		}
+	}
 	// Subscribe events from blockchain
 	pool.chainHeadSub = pool.chain.SubscribeChainHeadEvent(pool.chainHeadCh)
 
@@ -226,6 +263,9 @@ func (pool *TxPool) loop() {
 	evict := time.NewTicker(evictionInterval)
 	defer evict.Stop()
 
+	journal := time.NewTicker(pool.config.Rejournal)
+	defer journal.Stop()
+
 	// Track the previous head headers for transaction reorgs
 	head := pool.chain.CurrentBlock()
 
@@ -271,6 +311,16 @@ func (pool *TxPool) loop() {
 				delete(pool.faileds, h)
 			}
 			pool.mu.Unlock()
+
+			// Handle local transaction journal rotation
+		case <-journal.C:
+			if pool.journal != nil {
+				pool.mu.Lock()
+				if err := pool.journal.rotate(pool.local()); err != nil {
+					log.Warn("Failed to rotate local tx journal", "err", err)
+				}
+				pool.mu.Unlock()
+			}
 		}
 	}
 }
@@ -366,6 +416,13 @@ func (pool *TxPool) reset(oldHead, newHead *types.Header) {
 		pool.removeTx(tx.Hash())
 		log.Debug("confirm removeTx tx", "hash", tx.Hash())
 	}
+	// Drop the pooled transactions spending a nil spent by the new blocks
+	for _, tx := range included {
+		for _, conflict := range pool.conflicts(tx) {
+			pool.removeTx(conflict.Hash())
+			log.Debug("Dropped transaction spending an included nil", "hash", conflict.Hash(), "included", tx.Hash())
+		}
+	}
 	// Inject any transactions discarded due to reorgs
 	log.Debug("Reinjecting stale transactions", "count", len(reinject))
 	if len(reinject) > 0 {
@@ -387,6 +444,9 @@ func (pool *TxPool) Stop() {
 	pool.chainHeadSub.Unsubscribe()
 	pool.wg.Wait()
 
+	if pool.journal != nil {
+		pool.journal.close()
+	}
 	log.Info("Transaction pool stopped")
 }
 
@@ -396,6 +456,12 @@ func (pool *TxPool) SubscribeNewTxsEvent(ch chan<- NewTxsEvent) event.Subscripti
 	return pool.scope.Track(pool.txFeed.Subscribe(ch))
 }
 
+// SubscribeTxReplacedEvent registers a subscription of TxReplacedEvent and
+// starts sending event to the given channel.
+func (pool *TxPool) SubscribeTxReplacedEvent(ch chan<- TxReplacedEvent) event.Subscription {
+	return pool.scope.Track(pool.replaceFeed.Subscribe(ch))
+}
+
 // SetGasPrice updates the minimum priced required by the transaction pool for a
 // new transaction, and drops all transactions below this threshold.
 func (pool *TxPool) SetGasPrice(price *big.Int) {
@@ -481,15 +547,15 @@ func (pool *TxPool) validateTx(tx *types.Transaction, local bool) (e error) {
 		return ErrGasLimit
 	}
 
-	if err := verify.VerifyWithoutState(tx.Ehash().NewRef(), tx.GetZZSTX(), pool.chain.CurrentBlock().NumberU64()); err != nil {
+	if err := verify.VerifyWithoutStateCached(tx.Ehash().NewRef(), tx.GetZZSTX(), pool.chain.CurrentBlock().NumberU64()); err != nil {
 		log.Error("validateTx verify without state error", "hash", tx.Hash().Hex(), "verify stx err", err)
 		pool.faileds[tx.Hash()] = time.Now()
-		return ErrVerifyError
+		return verifyError(err)
 	}
 
 	copyState := pool.currentState.CopyWithNoZState()
 	if err := pool.checkDescCmd(tx.GetZZSTX(), copyState); err != nil {
-		return err
+		return verify.ReportError(verify.ErrCodeCmd, err.Error(), tx.GetZZSTX())
 	}
 
 	state := copyState.NextZState()
@@ -498,7 +564,7 @@ func (pool *TxPool) validateTx(tx *types.Transaction, local bool) (e error) {
 	if err != nil {
 		log.Error("validateTx error", "hash", tx.Hash().Hex(), "verify stx err", err)
 		pool.faileds[tx.Hash()] = time.Now()
-		return ErrVerifyError
+		return verifyError(err)
 	}
 
 	// Drop non-local transactions under our own minimal accepted gas priced
@@ -522,6 +588,15 @@ func (pool *TxPool) validateTx(tx *types.Transaction, local bool) (e error) {
 	return nil
 }
 
+// verifyError keeps the code of a verification error for the caller, other
+// errors are reported as ErrVerifyError.
+func verifyError(err error) error {
+	if _, ok := err.(*verify.VerifyError); ok {
+		return err
+	}
+	return ErrVerifyError
+}
+
 func (pool *TxPool) checkDescCmd(tx *stx.T, state *state.StateDB) (err error) {
 	cmd := tx.Desc_Cmd
 	stakeState := stake.NewStakeState(state)
@@ -603,7 +678,7 @@ func (pool *TxPool) add(tx *types.Transaction, local bool) (bool, error) {
 
 	if false {
 		if (seroparam.VP0()-50) < currentBlockNum && currentBlockNum < (seroparam.VP0()+50) {
-			return false, fmt.Errorf("protect vp0:%v", seroparam.VP0)
+			return false, fmt.Errorf("protect vp0:%v", seroparam.VP0())
 		}
 	}
 
@@ -613,28 +688,66 @@ func (pool *TxPool) add(tx *types.Transaction, local bool) (bool, error) {
 		invalidTxCounter.Inc(1)
 		return false, err
 	}
-	// If the transaction pool is full, discard underpriced transactions
-	if uint64(pool.all.Count()) >= pool.config.GlobalSlots+pool.config.GlobalQueue {
+	flag, err := pool.admit(hash, tx, local)
+	if err != nil {
+		return false, err
+	}
+	// Mark local transactions and persist them to the journal
+	if local {
+		pool.journalTx(hash, tx)
+	}
+	log.Trace("Pooled new future transaction", "hash", hash, "from", tx.From(), "to", tx.To())
+	return flag, nil
+}
+
+// admit inserts a validated transaction into the queue. A transaction spending
+// the nils of pooled transactions replaces them only if it pays enough more than
+// all of them, and a full pool only makes room for a better priced transaction.
+// Nothing is removed from the pool unless the transaction is accepted.
+//
+// Note, this method assumes the pool lock is held!
+func (pool *TxPool) admit(hash common.Hash, tx *types.Transaction, local bool) (bool, error) {
+	// If the transaction spends nils of pooled transactions, it has to pay
+	// enough more than all of them to replace them
+	conflicts := pool.conflicts(tx)
+	for _, old := range conflicts {
+		if !pool.replaces(tx, old) {
+			log.Trace("Discarding underpriced replacement transaction", "hash", hash, "old", old.Hash(), "priced", tx.GasPrice())
+			underpricedTxCounter.Inc(1)
+			return false, ErrReplaceUnderpriced
+		}
+	}
+	// The queue never takes transactions under the minimal gas price, even local
+	if tx.GasPrice().Cmp(pool.gasPrice) < 0 {
+		return false, ErrUnderpriced
+	}
+	// If the transaction pool is full, discard underpriced transactions. The
+	// replaced transactions already make room for the new one.
+	count := pool.all.Count() - len(conflicts)
+	if uint64(count) >= pool.config.GlobalSlots+pool.config.GlobalQueue {
 		// If the new transaction is underpriced, don't accept it
 		if !local && pool.newQueue.Underpriced(tx, pool.locals) {
 			log.Trace("Discarding underpriced transaction", "hash", hash, "priced", tx.GasPrice())
 			underpricedTxCounter.Inc(1)
 			return false, ErrUnderpriced
 		}
+	}
+	// The transaction is accepted, remove the transactions it replaces
+	for _, old := range conflicts {
+		pool.removeTx(old.Hash())
+		replacedTxCounter.Inc(1)
+		log.Debug("Replaced pooled transaction", "old", old.Hash(), "new", hash)
+		go pool.replaceFeed.Send(TxReplacedEvent{Old: old, New: tx})
+	}
+	if uint64(count) >= pool.config.GlobalSlots+pool.config.GlobalQueue {
 		// New transaction is better than our worse ones, make room for it
 		drop := pool.priced.Discard(pool.gasPrice, pool.all.Count()-int(pool.config.GlobalSlots+pool.config.GlobalQueue-1))
 		for _, tx := range drop {
-			pool.removeTx(tx.Hash())
+			pool.discardTx(tx)
 			log.Trace("Discarding freshly underpriced transaction", "hash", tx.Hash(), "priced", tx.GasPrice())
 		}
 	}
-
-	flag, err := pool.enqueueTx(hash, tx)
-	if err != nil {
-		return false, err
-	}
-	log.Trace("Pooled new future transaction", "hash", hash, "from", tx.From(), "to", tx.To())
-	return flag, nil
+	return pool.enqueueTx(hash, tx)
 }
 
 // Note, this method assumes the pool lock is held!
@@ -645,11 +758,74 @@ func (pool *TxPool) enqueueTx(hash common.Hash, tx *types.Transaction) (bool, er
 		if pool.all.Get(hash) == nil {
 			pool.priced.Add(tx, pool.gasPrice)
 		}
+		for _, n := range txNils(tx) {
+			pool.nils[n] = hash
+		}
 	}
 
 	return true, nil
 }
 
+// journalTx adds the specified transaction to the local disk journal if it is
+// deemed to have been sent from a local account.
+func (pool *TxPool) journalTx(hash common.Hash, tx *types.Transaction) {
+	if _, ok := pool.localTxs[hash]; ok {
+		return
+	}
+	pool.localTxs[hash] = struct{}{}
+	if pool.journal == nil {
+		return
+	}
+	if err := pool.journal.insert(tx); err != nil {
+		log.Warn("Failed to journal local transaction", "err", err)
+	}
+}
+
+// local retrieves all currently known local transactions. The returned
+// transaction set is a copy and can be freely modified by calling code.
+func (pool *TxPool) local() types.Transactions {
+	txs := types.Transactions{}
+	for hash := range pool.localTxs {
+		if tx := pool.all.Get(hash); tx != nil {
+			txs = append(txs, tx)
+		}
+	}
+	return txs
+}
+
+// addJournaled re-injects the journaled local transactions, dropping the ones
+// spending a nil already spent in the current zero state. The others are fully
+// validated against the current state like any new local transaction.
+func (pool *TxPool) addJournaled(txs []*types.Transaction) []error {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	errs := make([]error, len(txs))
+	valid := make([]*types.Transaction, 0, len(txs))
+	indexes := make([]int, 0, len(txs))
+
+	state := pool.currentState.CopyWithNoZState().NextZState()
+	for i, tx := range txs {
+		spent := false
+		for _, n := range txNils(tx) {
+			if state.State.HasIn(&n) {
+				spent = true
+				break
+			}
+		}
+		if spent {
+			errs[i] = fmt.Errorf("nil already spent: %x", tx.Hash())
+			continue
+		}
+		valid = append(valid, tx)
+		indexes = append(indexes, i)
+	}
+	for i, err := range pool.addTxsLocked(valid, !pool.config.NoLocals) {
+		errs[indexes[i]] = err
+	}
+	return errs
+}
+
 // AddLocal enqueues a single transaction into the pool if it is valid, marking
 // the sender as a local one in the mean time, ensuring it goes around the local
 // pricing constraints.
@@ -709,9 +885,9 @@ func (pool *TxPool) addTxsLocked(txs []*types.Transaction, local bool) []error {
 	// Add the batch of transaction, tracking the accepted ones
 	errs := make([]error, len(txs))
 
-	for _, tx := range txs {
+	for i, tx := range txs {
 		_, err := pool.add(tx, local)
-		errs = append(errs, err)
+		errs[i] = err
 	}
 	pool.promoteExecutables()
 	return errs
@@ -754,7 +930,20 @@ func (pool *TxPool) removeTx(hash common.Hash) {
 	}
 
 	pool.priced.Remove(tx)
+	pool.discardTx(tx)
+}
+
+// discardTx removes a transaction already taken off the priced list from the
+// rest of the pool.
+func (pool *TxPool) discardTx(tx *types.Transaction) {
+	hash := tx.Hash()
 	delete(pool.beats, hash)
+	delete(pool.localTxs, hash)
+	for _, n := range txNils(tx) {
+		if pool.nils[n] == hash {
+			delete(pool.nils, n)
+		}
+	}
 	//Remove it from the list of known transactions
 	if pool.newQueue.Remove(tx) {
 		return
@@ -765,6 +954,54 @@ func (pool *TxPool) removeTx(hash common.Hash) {
 
 }
 
+// conflicts returns the pooled transactions spending a nil or a root spent by
+// the given transaction.
+func (pool *TxPool) conflicts(tx *types.Transaction) (txs types.Transactions) {
+	seen := make(map[common.Hash]bool)
+	for _, n := range txNils(tx) {
+		hash, ok := pool.nils[n]
+		if !ok || hash == tx.Hash() || seen[hash] {
+			continue
+		}
+		seen[hash] = true
+		old := pool.all.Get(hash)
+		if old == nil {
+			// The transaction was discarded without going through removeTx
+			delete(pool.nils, n)
+			continue
+		}
+		txs = append(txs, old)
+	}
+	return
+}
+
+// replaces returns whether tx pays enough to replace the pooled transaction old,
+// the gas price has to be higher by at least the configured price bump.
+func (pool *TxPool) replaces(tx *types.Transaction, old *types.Transaction) bool {
+	price, oldPrice := tx.GasPrice(), old.GasPrice()
+	if price.Cmp(oldPrice) <= 0 {
+		return false
+	}
+	threshold := new(big.Int).Mul(oldPrice, new(big.Int).SetUint64(100+pool.config.PriceBump))
+	return new(big.Int).Mul(price, big.NewInt(100)).Cmp(threshold) >= 0
+}
+
+// txNils returns the nils and roots spent by a transaction, two transactions
+// spending one of them cannot both be included in the chain.
+func txNils(tx *types.Transaction) (nils []keys.Uint256) {
+	stxt := tx.GetZZSTX()
+	if stxt == nil {
+		return
+	}
+	for _, in := range stxt.Desc_O.Ins {
+		nils = append(nils, in.Root, in.Nil)
+	}
+	for _, in := range stxt.Desc_Z.Ins {
+		nils = append(nils, in.Nil)
+	}
+	return
+}
+
 func (pool *TxPool) promoteTx(hash common.Hash, tx *types.Transaction) bool {
 	// Try to insert the transaction into the pending queue
 	if pool.newPending.Add(tx, new(big.Int).Set(pool.gasPrice)) {
@@ -0,0 +1,186 @@
+// Copyright 2015 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/sero-cash/go-czero-import/keys"
+	"github.com/sero-cash/go-sero/common"
+	"github.com/sero-cash/go-sero/core/types"
+	"github.com/sero-cash/go-sero/zero/txs/stx"
+)
+
+// newTestTxPool creates a pool without a chain, only fit to admit transactions
+// already validated.
+func newTestTxPool(slots uint64) *TxPool {
+	config := DefaultTxPoolConfig
+	config.PriceLimit = 1
+	config.GlobalSlots = slots
+	config.GlobalQueue = 0
+
+	pool := &TxPool{
+		config:   config.sanitize(),
+		beats:    make(map[common.Hash]time.Time),
+		faileds:  make(map[common.Hash]time.Time),
+		nils:     make(map[keys.Uint256]common.Hash),
+		localTxs: make(map[common.Hash]struct{}),
+		all:      newTxLookup(),
+		gasPrice: big.NewInt(1),
+	}
+	pool.locals = newAccountSet()
+	pool.priced = newTxPricedList(pool.all)
+	pool.newQueue = newTxPricedList(newTxLookup())
+	pool.newPending = newTxPricedList(newTxLookup())
+	return pool
+}
+
+// nilTx creates a transaction spending the given nils, the id tells apart the
+// transactions spending the same nils at the same price.
+func nilTx(id byte, price int64, nils ...byte) *types.Transaction {
+	stxt := &stx.T{}
+	stxt.Ehash[0] = id
+	for _, n := range nils {
+		in := stx.In_S{}
+		in.Root[0], in.Root[1] = n, 1
+		in.Nil[0], in.Nil[1] = n, 2
+		stxt.Desc_O.Ins = append(stxt.Desc_O.Ins, in)
+	}
+	return types.NewTxWithGTx(21000, big.NewInt(price), stxt)
+}
+
+func admitTx(t *testing.T, pool *TxPool, tx *types.Transaction) error {
+	t.Helper()
+	_, err := pool.admit(tx.Hash(), tx, false)
+	return err
+}
+
+// checkPooled checks that exactly the given transactions are in the pool and
+// index the nils they spend.
+func checkPooled(t *testing.T, pool *TxPool, txs ...*types.Transaction) {
+	t.Helper()
+	if count := pool.all.Count(); count != len(txs) {
+		t.Errorf("pooled transactions mismatch: have %d, want %d", count, len(txs))
+	}
+	for _, tx := range txs {
+		if pool.Get(tx.Hash()) == nil {
+			t.Errorf("transaction %x not pooled", tx.Hash())
+		}
+		for _, n := range txNils(tx) {
+			if pool.nils[n] != tx.Hash() {
+				t.Errorf("nil %x indexed to %x, want %x", n, pool.nils[n], tx.Hash())
+			}
+		}
+	}
+}
+
+func TestTxPoolReplace(t *testing.T) {
+	pool := newTestTxPool(16)
+	replaced := make(chan TxReplacedEvent, 1)
+	sub := pool.SubscribeTxReplacedEvent(replaced)
+	defer sub.Unsubscribe()
+
+	old, other := nilTx(1, 10, 1, 2), nilTx(2, 10, 3)
+	for _, tx := range []*types.Transaction{old, other} {
+		if err := admitTx(t, pool, tx); err != nil {
+			t.Fatalf("failed to admit transaction: %v", err)
+		}
+	}
+	// The replacement pays the price bump and spends one of the old nils
+	tx := nilTx(3, 11, 2)
+	if err := admitTx(t, pool, tx); err != nil {
+		t.Fatalf("failed to admit replacement: %v", err)
+	}
+	checkPooled(t, pool, other, tx)
+	for _, n := range txNils(old) {
+		if hash, ok := pool.nils[n]; ok && hash == old.Hash() {
+			t.Errorf("nil %x still indexed to the replaced transaction", n)
+		}
+	}
+	select {
+	case ev := <-replaced:
+		if ev.Old.Hash() != old.Hash() || ev.New.Hash() != tx.Hash() {
+			t.Errorf("replace event mismatch: have %x -> %x, want %x -> %x", ev.Old.Hash(), ev.New.Hash(), old.Hash(), tx.Hash())
+		}
+	case <-time.After(time.Second):
+		t.Errorf("no replace event")
+	}
+}
+
+func TestTxPoolReplaceUnderpriced(t *testing.T) {
+	pool := newTestTxPool(16)
+
+	old, other := nilTx(1, 10, 1), nilTx(2, 20, 2)
+	for _, tx := range []*types.Transaction{old, other} {
+		if err := admitTx(t, pool, tx); err != nil {
+			t.Fatalf("failed to admit transaction: %v", err)
+		}
+	}
+	tests := []*types.Transaction{
+		nilTx(3, 10, 1),    // same price
+		nilTx(4, 10, 3, 1), // same price, spending a free nil too
+		nilTx(5, 10, 1, 2), // pays the bump of old only
+		nilTx(6, 21, 1, 2), // pays the bump of old only
+	}
+	for i, tx := range tests {
+		if err := admitTx(t, pool, tx); err != ErrReplaceUnderpriced {
+			t.Errorf("test %d: error mismatch: have %v, want %v", i, err, ErrReplaceUnderpriced)
+		}
+		checkPooled(t, pool, old, other)
+	}
+	// Paying the bump of all the conflicting transactions replaces them all
+	tx := nilTx(7, 22, 1, 2)
+	if err := admitTx(t, pool, tx); err != nil {
+		t.Fatalf("failed to admit replacement: %v", err)
+	}
+	checkPooled(t, pool, tx)
+}
+
+func TestTxPoolFull(t *testing.T) {
+	pool := newTestTxPool(2)
+
+	cheap, dear := nilTx(1, 5, 1), nilTx(2, 8, 2)
+	for _, tx := range []*types.Transaction{cheap, dear} {
+		if err := admitTx(t, pool, tx); err != nil {
+			t.Fatalf("failed to admit transaction: %v", err)
+		}
+	}
+	// An underpriced transaction is rejected without touching the pool
+	if err := admitTx(t, pool, nilTx(3, 5, 3)); err != ErrUnderpriced {
+		t.Errorf("error mismatch: have %v, want %v", err, ErrUnderpriced)
+	}
+	checkPooled(t, pool, cheap, dear)
+
+	// A replacement takes the room of the transaction it replaces
+	replacement := nilTx(4, 10, 2)
+	if err := admitTx(t, pool, replacement); err != nil {
+		t.Fatalf("failed to admit replacement: %v", err)
+	}
+	checkPooled(t, pool, cheap, replacement)
+
+	// A better priced transaction evicts the cheapest one
+	tx := nilTx(5, 6, 3)
+	if err := admitTx(t, pool, tx); err != nil {
+		t.Fatalf("failed to admit transaction: %v", err)
+	}
+	checkPooled(t, pool, replacement, tx)
+	if pool.newQueue.Get(cheap.Hash()) != nil {
+		t.Errorf("evicted transaction still queued")
+	}
+}
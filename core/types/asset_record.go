@@ -0,0 +1,46 @@
+// copyright 2018 The sero.cash Authors
+// This file is part of the go-sero library.
+//
+// The go-sero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-sero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-sero library. If not, see <http://www.gnu.org/licenses/>.
+
+package types
+
+import (
+	"math/big"
+
+	"github.com/sero-cash/go-sero/common"
+)
+
+const (
+	AssetToken  = "token"
+	AssetTicket = "ticket"
+)
+
+const (
+	AssetOpRegister = "register"
+	AssetOpIssue    = "issue"
+)
+
+// AssetRecord is a token or ticket category registration or issuance made by a
+// contract through a system call. Records are not part of consensus, they are
+// collected while processing a block and stored alongside its receipts.
+type AssetRecord struct {
+	Kind     string         // AssetToken or AssetTicket
+	Op       string         // AssetOpRegister or AssetOpIssue
+	Name     string         // Currency or ticket category
+	Contract common.Address // Contract owning the currency or category
+	Amount   *big.Int       // Tokens issued, zero for registrations and tickets
+	Ticket   common.Hash    // Ticket value issued, empty for tokens
+	TxHash   common.Hash    // Transaction during which the record was made
+}
@@ -0,0 +1,132 @@
+// copyright 2018 The sero.cash Authors
+// This file is part of the go-sero library.
+//
+// The go-sero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-sero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-sero library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"github.com/sero-cash/go-sero/common"
+	"github.com/sero-cash/go-sero/zero/txs/assets"
+)
+
+// Kinds of asset transfers reported to an AssetTracer.
+const (
+	TransferCall   = "call"        // Asset attached to a call or a transaction
+	TransferCreate = "create"      // Asset attached to a contract creation
+	TransferSend   = "send"        // Currency or ticket sent by the send system call
+	TransferTicket = "allotTicket" // Ticket handed out by the allotTicket system call
+)
+
+// AssetTracer is an optional extension of Tracer following the currency and
+// ticket movements of a transaction. SERO system calls are triggered through
+// LOG opcodes and move assets without any dedicated opcode, so a Tracer that
+// also implements AssetTracer is notified of them directly.
+//
+// Movements made inside a call frame that is later reverted are followed by a
+// CaptureRevert with the snapshot reported by the CaptureEnter of that frame.
+type AssetTracer interface {
+	// CaptureEnter is called when a call frame starts, snapshot identifies the
+	// frame in a later CaptureRevert.
+	CaptureEnter(env *EVM, snapshot int) error
+	// CaptureRevert is called when a call frame fails and its state changes,
+	// including asset movements, are reverted.
+	CaptureRevert(env *EVM, snapshot int) error
+	// CaptureTransfer is called when an asset moves between two accounts.
+	CaptureTransfer(env *EVM, kind string, from common.Address, to common.Address, asset *assets.Asset) error
+	// CaptureIssue is called when a contract issues tokens or creates a ticket.
+	CaptureIssue(env *EVM, contract common.Address, asset *assets.Asset) error
+	// CaptureCallValues is called when a contract sets the asset attached to
+	// its next call through the setCallValues system call.
+	CaptureCallValues(env *EVM, contract common.Address, asset *assets.Asset) error
+	// CaptureTxOut is called when an asset leaves the account state as a
+	// zero-state output owned by to.
+	CaptureTxOut(env *EVM, to common.Address, asset *assets.Asset) error
+}
+
+// assetTracer returns the configured tracer if it follows asset movements.
+func (evm *EVM) assetTracer() AssetTracer {
+	if !evm.vmConfig.Debug {
+		return nil
+	}
+	tracer, _ := evm.vmConfig.Tracer.(AssetTracer)
+	return tracer
+}
+
+// snapshot takes a state snapshot for a new call frame.
+func (evm *EVM) snapshot() int {
+	snapshot := evm.StateDB.Snapshot()
+	if tracer := evm.assetTracer(); tracer != nil {
+		tracer.CaptureEnter(evm, snapshot)
+	}
+	return snapshot
+}
+
+// revertToSnapshot reverts the state changes of a failed call frame.
+func (evm *EVM) revertToSnapshot(snapshot int) {
+	evm.StateDB.RevertToSnapshot(snapshot)
+	if tracer := evm.assetTracer(); tracer != nil {
+		tracer.CaptureRevert(evm, snapshot)
+	}
+}
+
+// takeTransferKind returns the kind of transfer made by the current call,
+// resetting the kind set by a system call so it only applies to one call.
+func (evm *EVM) takeTransferKind() string {
+	kind := evm.transferKind
+	if kind == "" {
+		return TransferCall
+	}
+	evm.transferKind = ""
+	return kind
+}
+
+// traceTransfer reports an asset moved by a call or a creation.
+func (evm *EVM) traceTransfer(kind string, from common.Address, to common.Address, asset *assets.Asset) {
+	tracer := evm.assetTracer()
+	if tracer == nil || isEmptyAsset(asset) {
+		return
+	}
+	tracer.CaptureTransfer(evm, kind, from, to, asset)
+	if !evm.StateDB.IsContract(to) {
+		tracer.CaptureTxOut(evm, to, asset)
+	}
+}
+
+func (evm *EVM) traceIssue(contract common.Address, asset *assets.Asset) {
+	if tracer := evm.assetTracer(); tracer != nil {
+		tracer.CaptureIssue(evm, contract, asset)
+	}
+}
+
+func (evm *EVM) traceCallValues(contract common.Address, asset *assets.Asset) {
+	if tracer := evm.assetTracer(); tracer != nil {
+		tracer.CaptureCallValues(evm, contract, asset)
+	}
+}
+
+// TraceTxOut reports a zero-state output created outside of the EVM execution
+// itself, such as fee refunds and reverted transaction values.
+func (evm *EVM) TraceTxOut(to common.Address, asset *assets.Asset) {
+	if tracer := evm.assetTracer(); tracer != nil && !isEmptyAsset(asset) {
+		tracer.CaptureTxOut(evm, to, asset)
+	}
+}
+
+func isEmptyAsset(asset *assets.Asset) bool {
+	if asset == nil {
+		return true
+	}
+	return (asset.Tkn == nil || asset.Tkn.Value.ToIntRef().Sign() == 0) && asset.Tkt == nil
+}
@@ -118,6 +118,9 @@ type EVM struct {
 	// available gas is calculated in gasCall* according to the 63/64 rule and later
 	// applied in opCall*.
 	callGasTemp uint64
+	// transferKind holds the kind of the transfer made by the next call, set by
+	// the system calls moving assets and reported to asset tracers.
+	transferKind string
 }
 
 // NewEVM returns a new EVM. The returned EVM is not thread safe and should
@@ -154,6 +157,8 @@ func (evm *EVM) Interpreter() Interpreter {
 // the necessary steps to create accounts and reverses the state in case of an
 // execution error or failed value transfer.
 func (evm *EVM) Call(caller ContractRef, addr common.Address, input []byte, gas uint64, asset *assets.Asset) (ret []byte, leftOverGas uint64, err error, alarm bool) {
+	kind := evm.takeTransferKind()
+
 	if evm.vmConfig.NoRecursion && evm.depth > 0 {
 		return nil, gas, nil, false
 	}
@@ -169,10 +174,11 @@ func (evm *EVM) Call(caller ContractRef, addr common.Address, input []byte, gas
 
 	var (
 		to       = AccountRef(addr)
-		snapshot = evm.StateDB.Snapshot()
+		snapshot = evm.snapshot()
 	)
 
 	alarm = evm.Transfer(evm.StateDB, caller.Address(), to.Address(), asset, evm.TxHash)
+	evm.traceTransfer(kind, caller.Address(), addr, asset)
 
 	// Initialise a new contract and set the code that is to be used by the EVM.
 	// The contract is a scoped environment for this execution context only.
@@ -180,7 +186,7 @@ func (evm *EVM) Call(caller ContractRef, addr common.Address, input []byte, gas
 	contract.SetCallCode(&addr, evm.StateDB.GetCodeHash(addr), evm.StateDB.GetCode(addr))
 	input, err = loadAddress(evm, caller, input, contract, false)
 	if err != nil {
-		evm.StateDB.RevertToSnapshot(snapshot)
+		evm.revertToSnapshot(snapshot)
 		return ret, leftOverGas, err, alarm
 	}
 
@@ -200,7 +206,7 @@ func (evm *EVM) Call(caller ContractRef, addr common.Address, input []byte, gas
 	// above we revert to the snapshot and consume any gas remaining. Additionally
 	// when we're in homestead this also counts for code storage gas errors.
 	if err != nil {
-		evm.StateDB.RevertToSnapshot(snapshot)
+		evm.revertToSnapshot(snapshot)
 		if err != errExecutionReverted {
 			contract.UseGas(contract.Gas)
 		}
@@ -230,7 +236,7 @@ func (evm *EVM) CallCode(caller ContractRef, addr common.Address, input []byte,
 	}
 
 	var (
-		snapshot = evm.StateDB.Snapshot()
+		snapshot = evm.snapshot()
 		to       = AccountRef(caller.Address())
 	)
 	// initialise a new contract and set the code that is to be used by the
@@ -240,13 +246,13 @@ func (evm *EVM) CallCode(caller ContractRef, addr common.Address, input []byte,
 	contract.SetCallCode(&addr, evm.StateDB.GetCodeHash(addr), evm.StateDB.GetCode(addr))
 	input, err = loadAddress(evm, caller, input, contract, false)
 	if err != nil {
-		evm.StateDB.RevertToSnapshot(snapshot)
+		evm.revertToSnapshot(snapshot)
 		return ret, leftOverGas, err
 	}
 
 	ret, err = run(evm, contract, input)
 	if err != nil {
-		evm.StateDB.RevertToSnapshot(snapshot)
+		evm.revertToSnapshot(snapshot)
 		if err != errExecutionReverted {
 			contract.UseGas(contract.Gas)
 		}
@@ -269,7 +275,7 @@ func (evm *EVM) DelegateCall(caller ContractRef, addr common.Address, input []by
 	}
 
 	var (
-		snapshot = evm.StateDB.Snapshot()
+		snapshot = evm.snapshot()
 		to       = AccountRef(caller.Address())
 	)
 
@@ -278,13 +284,13 @@ func (evm *EVM) DelegateCall(caller ContractRef, addr common.Address, input []by
 	contract.SetCallCode(&addr, evm.StateDB.GetCodeHash(addr), evm.StateDB.GetCode(addr))
 	input, err = loadAddress(evm, caller, input, contract, false)
 	if err != nil {
-		evm.StateDB.RevertToSnapshot(snapshot)
+		evm.revertToSnapshot(snapshot)
 		return ret, leftOverGas, err
 	}
 
 	ret, err = run(evm, contract, input)
 	if err != nil {
-		evm.StateDB.RevertToSnapshot(snapshot)
+		evm.revertToSnapshot(snapshot)
 		if err != errExecutionReverted {
 			contract.UseGas(contract.Gas)
 		}
@@ -314,7 +320,7 @@ func (evm *EVM) StaticCall(caller ContractRef, addr common.Address, input []byte
 
 	var (
 		to       = AccountRef(addr)
-		snapshot = evm.StateDB.Snapshot()
+		snapshot = evm.snapshot()
 	)
 	// Initialise a new contract and set the code that is to be used by the
 	// EVM. The contract is a scoped environment for this execution context
@@ -323,7 +329,7 @@ func (evm *EVM) StaticCall(caller ContractRef, addr common.Address, input []byte
 	contract.SetCallCode(&addr, evm.StateDB.GetCodeHash(addr), evm.StateDB.GetCode(addr))
 	input, err = loadAddress(evm, caller, input, contract, false)
 	if err != nil {
-		evm.StateDB.RevertToSnapshot(snapshot)
+		evm.revertToSnapshot(snapshot)
 		return ret, leftOverGas, err
 	}
 
@@ -332,7 +338,7 @@ func (evm *EVM) StaticCall(caller ContractRef, addr common.Address, input []byte
 	// when we're in Homestead this also counts for code storage gas errors.
 	ret, err = run(evm, contract, input)
 	if err != nil {
-		evm.StateDB.RevertToSnapshot(snapshot)
+		evm.revertToSnapshot(snapshot)
 		if err != errExecutionReverted {
 			contract.UseGas(contract.Gas)
 		}
@@ -375,10 +381,11 @@ func (evm *EVM) create(caller ContractRef, code []byte, gas uint64, asset *asset
 		return nil, common.Address{}, 0, ErrContractAddressCollision
 	}
 	// Create a new account on the state
-	snapshot := evm.StateDB.Snapshot()
+	snapshot := evm.snapshot()
 	evm.StateDB.CreateAccount(address)
 
 	evm.Transfer(evm.StateDB, caller.Address(), address, asset, evm.TxHash)
+	evm.traceTransfer(TransferCreate, caller.Address(), address, asset)
 
 	// initialise a new contract and set the code that is to be used by the
 	// EVM. The contract is a scoped environment for this execution context
@@ -386,7 +393,7 @@ func (evm *EVM) create(caller ContractRef, code []byte, gas uint64, asset *asset
 	contract := NewContract(caller, AccountRef(address), asset, gas)
 	code, err := loadAddress(evm, caller, code, contract, true)
 	if err != nil {
-		evm.StateDB.RevertToSnapshot(snapshot)
+		evm.revertToSnapshot(snapshot)
 		return nil, common.Address{}, 0, err
 	}
 	contract.SetCallCode(&address, crypto.Keccak256Hash(code), code)
@@ -421,7 +428,7 @@ func (evm *EVM) create(caller ContractRef, code []byte, gas uint64, asset *asset
 	// above we revert to the snapshot and consume any gas remaining. Additionally
 	// when we're in homestead this also counts for code storage gas errors.
 	if maxCodeSizeExceeded || err != nil {
-		evm.StateDB.RevertToSnapshot(snapshot)
+		evm.revertToSnapshot(snapshot)
 		if err != errExecutionReverted {
 			contract.UseGas(contract.Gas)
 		}
@@ -31,8 +31,6 @@ import (
 
 	"github.com/sero-cash/go-sero/log"
 
-	"regexp"
-
 	"github.com/sero-cash/go-sero/common"
 	"github.com/sero-cash/go-sero/common/math"
 	"github.com/sero-cash/go-sero/core/types"
@@ -880,26 +878,35 @@ func handleAllotTicket(d []byte, evm *EVM, contract *Contract, mem []byte) (comm
 	}
 
 	categoryName := string(mem[offset+32 : offset+32+len])
-	match, err := regexp.Match("^[A-Z][A-Z0-9_]{0,31}$", []byte(categoryName))
-	if err != nil || !match {
+	schedule := evm.chainConfig.SystemCalls(evm.BlockNumber)
+	if !schedule.ValidName(categoryName) {
 		return common.Hash{}, 0, fmt.Errorf("allotTicket error , contract : %s, error : %s", contract.Address(), "illegal categoryName"), false
 	}
 
 	categoryName = strings.ToUpper(categoryName)
-	if strings.Contains(categoryName, "SERO") {
+	if schedule.ReservedNameUsed(categoryName) {
 		return common.Hash{}, 0, fmt.Errorf("allotTicket error , contract : %s, error : %s", contract.Address(), "categoryName can not contains SERO"), false
 	}
 	value := common.BytesToHash(d[0:32])
 	if value == (common.Hash{}) {
+		registered := evm.StateDB.GetContrctAddressByTicket(categoryName) != (common.Address{})
 		if !evm.StateDB.RegisterTicket(contract.Address(), categoryName) {
 			return common.Hash{}, 0, fmt.Errorf("allotTicket error , contract : %s, error : %s", contract.Address(), "categoryName registered by other"), false
 		}
+		if !registered {
+			evm.StateDB.AddAssetRecord(&types.AssetRecord{Kind: types.AssetTicket, Op: types.AssetOpRegister, Name: categoryName, Contract: contract.Address(), Amount: new(big.Int)})
+		}
 
 		nonce := evm.StateDB.GetTicketNonce(contract.Address())
 		evm.StateDB.SetTicketNonce(contract.Address(), nonce+1)
 		bytes, _ := rlp.EncodeToBytes([]interface{}{contract.Address(), categoryName, nonce})
 		value = crypto.Keccak256Hash(bytes)
 		evm.StateDB.AddTicket(contract.Address(), categoryName, value)
+		evm.StateDB.AddAssetRecord(&types.AssetRecord{Kind: types.AssetTicket, Op: types.AssetOpIssue, Name: categoryName, Contract: contract.Address(), Amount: new(big.Int), Ticket: value})
+		evm.traceIssue(contract.Address(), &assets.Asset{Tkt: &assets.Ticket{
+			Category: *common.BytesToHash(common.LeftPadBytes([]byte(categoryName), 32)).HashToUint256(),
+			Value:    *value.HashToUint256(),
+		}})
 	}
 
 	toAddr := evm.StateDB.GetNonceAddress(d[44:64])
@@ -912,7 +919,8 @@ func handleAllotTicket(d []byte, evm *EVM, contract *Contract, mem []byte) (comm
 			},
 		}
 
-		gas := evm.callGasTemp + params.CallStipend
+		gas := evm.callGasTemp + schedule.Cost(params.SysAllotTicket).Stipend
+		evm.transferKind = TransferTicket
 		_, returnGas, err, _alarm := evm.Call(contract, toAddr, nil, gas, &asset)
 		alarm = _alarm
 		//contract.Gas += returnGas
@@ -926,39 +934,6 @@ func handleAllotTicket(d []byte, evm *EVM, contract *Contract, mem []byte) (comm
 	return value, evm.callGasTemp, nil, alarm
 }
 
-var (
-	base   = new(big.Int).SetUint64(1e+17)
-	level1 = new(big.Int).Mul(big.NewInt(10000000), base)
-	level2 = new(big.Int).Mul(big.NewInt(5000000), base)
-	level3 = new(big.Int).Mul(big.NewInt(100000), base)
-	level4 = new(big.Int).Mul(big.NewInt(10000), base)
-	level5 = new(big.Int).Mul(big.NewInt(100), base)
-	level6 = new(big.Int).Mul(big.NewInt(10), base)
-	level7 = new(big.Int).Mul(big.NewInt(1), base)
-)
-
-func tokenFee(coinName string) *big.Int {
-	switch len(coinName) {
-	case 1:
-		return level1
-	case 2:
-		return level2
-	case 3:
-		return level3
-	case 4:
-		return level4
-	case 5:
-		return level5
-	case 6:
-		return level6
-	}
-	return level7
-}
-
-var foundationAccount1 = common.Base58ToAddress("hcZCikh7h3By7FBoDhCj8a6swKukeZRKm5Xg1s9RStPTNV7GzE3rJDzJsDwCWy9p86A8amMdCHgF7jKaMfSt1zfaBEbqkapuveHyFko2V9ZKisMC5qMp4VacYnApXokr4ea")
-
-var foundationAccount2 = common.Base58ToAddress("5niHmAcSoDzaekKTUpLR3qkQf6djC7AGhnJnuPr8w7ArqQzhxyhEf61Rp68WhpoYo57r5q8CVsLopTJ9uc5VS92fRSHsjBqY9rqMJfQ4DBMw5QyXvT4oyeF7P9sb7ruvwZD")
-
 func handleIssueToken(d []byte, evm *EVM, contract *Contract, mem []byte) (bool, error) {
 	offset := new(big.Int).SetBytes(d[0:32]).Uint64()
 	len := new(big.Int).SetBytes(mem[offset:offset+32]).Uint64()
@@ -967,36 +942,31 @@ func handleIssueToken(d []byte, evm *EVM, contract *Contract, mem []byte) (bool,
 	}
 
 	coinName := string(mem[offset+32 : offset+32+len])
-	match, err := regexp.Match("^[A-Z][A-Z0-9_]{0,31}$", []byte(coinName))
-	if err != nil || !match {
+	schedule := evm.chainConfig.SystemCalls(evm.BlockNumber)
+	if !schedule.ValidName(coinName) {
 		return false, fmt.Errorf("issueToken error , contract : %s, error : %s", contract.Address(), "illegal coinName")
 	}
 
 	coinName = strings.ToUpper(coinName)
-	if strings.Contains(coinName, "SERO") {
+	if schedule.ReservedNameUsed(coinName) {
 		return false, fmt.Errorf("issueToken error , contract : %s, error : %s", contract.Address(), "coinName can not contains SERO")
 	}
 	address := evm.StateDB.GetContrctAddressByToken(coinName)
 	if address == (common.Address{}) {
-		fee := new(big.Int).Set(level6)
-		if evm.chainConfig.ChainID.Uint64() == 2019 {
-			fee = tokenFee(coinName)
-		}
+		fee := new(big.Int).Set(schedule.TokenFee(coinName))
 		if evm.StateDB.GetBalance(contract.Address(), "SERO").Cmp(fee) >= 0 {
 			if !evm.StateDB.RegisterToken(contract.Address(), coinName) {
 				return false, fmt.Errorf("issueToken error , contract : %s, error : %s", contract.Address(), "coinName registered by other")
 			} else {
+				evm.StateDB.AddAssetRecord(&types.AssetRecord{Kind: types.AssetToken, Op: types.AssetOpRegister, Name: coinName, Contract: contract.Address(), Amount: new(big.Int)})
 				evm.StateDB.SubBalance(contract.Address(), "SERO", fee)
 				asset := assets.Asset{Tkn: &assets.Token{
 					Currency: *common.BytesToHash(common.LeftPadBytes([]byte("SERO"), 32)).HashToUint256(),
 					Value:    utils.U256(*fee),
 				},
 				}
-				if evm.BlockNumber.Uint64() >= 300000 {
-					evm.StateDB.NextZState().AddTxOut(foundationAccount2, asset, evm.TxHash)
-				} else {
-					evm.StateDB.NextZState().AddTxOut(foundationAccount1, asset, evm.TxHash)
-				}
+				evm.StateDB.NextZState().AddTxOut(schedule.FeeRecipient, asset, evm.TxHash)
+				evm.TraceTxOut(schedule.FeeRecipient, &asset)
 			}
 		} else {
 			return false, fmt.Errorf("issueToken error , contract : %s, error : %s", contract.Address(), "insufficient balance for token fee")
@@ -1007,6 +977,11 @@ func handleIssueToken(d []byte, evm *EVM, contract *Contract, mem []byte) (bool,
 
 	total := new(big.Int).SetBytes(d[32:64])
 	evm.StateDB.AddBalance(contract.Address(), coinName, total)
+	evm.StateDB.AddAssetRecord(&types.AssetRecord{Kind: types.AssetToken, Op: types.AssetOpIssue, Name: coinName, Contract: contract.Address(), Amount: total})
+	evm.traceIssue(contract.Address(), &assets.Asset{Tkn: &assets.Token{
+		Currency: *common.BytesToHash(common.LeftPadBytes([]byte(coinName), 32)).HashToUint256(),
+		Value:    utils.U256(*total),
+	}})
 	return true, nil
 }
 
@@ -1060,7 +1035,8 @@ func handleSend(d []byte, evm *EVM, contract *Contract, mem []byte) ([]byte, uin
 	}
 
 	asset := assets.Asset{Tkn: token, Tkt: ticket}
-	gas := evm.callGasTemp + params.CallStipend
+	gas := evm.callGasTemp + evm.chainConfig.SystemCalls(evm.BlockNumber).Cost(params.SysSend).Stipend
+	evm.transferKind = TransferSend
 	return evm.Call(contract, toAddr, nil, gas, &asset)
 }
 
@@ -1080,6 +1056,22 @@ var (
 	}
 )
 
+// SystemTopics maps the LOG topics that are intercepted as SERO system calls
+// to the name of the call they trigger.
+var SystemTopics = map[common.Hash]string{
+	topic_issueToken:    params.SysIssueToken,
+	topic_send:          params.SysSend,
+	topic_balanceOf:     params.SysBalanceOf,
+	topic_allotTicket:   params.SysAllotTicket,
+	topic_currency:      params.SysCurrency,
+	topic_category:      params.SysCategory,
+	topic_ticket:        params.SysTicket,
+	topic_setCallValues: params.SysSetCallValues,
+	topic_setTokenRate:  params.SysSetTokenRate,
+	topic_closePkg:      params.SysClosePkg,
+	topic_transferPkg:   params.SysTransferPkg,
+}
+
 func makeLog(size int) executionFunc {
 	return func(pc *uint64, interpreter *EVMInterpreter, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
 		topics := make([]common.Hash, size)
@@ -1162,7 +1154,7 @@ func makeLog(size int) executionFunc {
 			}
 			contract.Gas += interpreter.evm.callGasTemp
 		} else if topics[0] == topic_setCallValues {
-			setCallValues(d, data, contract)
+			setCallValues(interpreter.evm, d, data, contract)
 			contract.Gas += interpreter.evm.callGasTemp
 		} else if topics[0] == topic_setTokenRate {
 			offset := new(big.Int).SetBytes(d[0:32]).Uint64()
@@ -1172,8 +1164,7 @@ func makeLog(size int) executionFunc {
 			}
 
 			coinName := string(data[offset+32 : offset+32+len])
-			match, err := regexp.Match("^[A-Z][A-Z0-9_]{0,31}$", []byte(coinName))
-			if err != nil || !match {
+			if !interpreter.evm.chainConfig.SystemCalls(interpreter.evm.BlockNumber).ValidName(coinName) {
 				return nil, fmt.Errorf("issueToken error , contract : %s, error : %s", contract.Address(), "illegal coinName")
 			}
 
@@ -1248,12 +1239,20 @@ func makeLog(size int) executionFunc {
 			contract.Gas += interpreter.evm.callGasTemp
 		}
 
+		// Charge the system call on top of the log pricing
+		if name, ok := SystemTopics[topics[0]]; ok {
+			cost := interpreter.evm.chainConfig.SystemCalls(interpreter.evm.BlockNumber).Cost(name)
+			if !contract.UseGas(cost.Gas) {
+				return nil, ErrOutOfGas
+			}
+		}
+
 		interpreter.intPool.put(mStart, mSize)
 		return nil, nil
 	}
 }
 
-func setCallValues(d []byte, data []byte, contract *Contract) {
+func setCallValues(evm *EVM, d []byte, data []byte, contract *Contract) {
 	currency_offset := new(big.Int).SetBytes(d[0:32]).Uint64()
 	length := new(big.Int).SetBytes(data[currency_offset:currency_offset+32]).Uint64()
 	var currency string
@@ -1285,7 +1284,9 @@ func setCallValues(d []byte, data []byte, contract *Contract) {
 		}
 	}
 	if token != nil || ticket != nil {
-		contract.SetCallMsg(&assets.Asset{Tkn: token, Tkt: ticket})
+		asset := &assets.Asset{Tkn: token, Tkt: ticket}
+		contract.SetCallMsg(asset)
+		evm.traceCallValues(contract.Address(), asset)
 	}
 }
 
@@ -114,14 +114,35 @@ type StructLogger struct {
 	cfg LogConfig
 
 	logs          []StructLog
+	assetLogs     []AssetLog
+	frames        map[int]int // Number of asset logs when a call frame was entered, by snapshot
 	changedValues map[common.Address]Storage
 	output        []byte
 	err           error
 }
 
+// AssetLog is a currency or ticket movement reported to a StructLogger.
+type AssetLog struct {
+	Step     int             // Number of struct logs captured before the movement
+	Kind     string          // Transfer kind, or issue, callValues and txOut
+	From     *common.Address // Source of the movement, if any
+	To       *common.Address // Destination of the movement, if any
+	Asset    assets.Asset
+	Depth    int  // Call depth of the movement
+	Reverted bool // Whether the call frame of the movement was reverted
+}
+
+// Kinds of asset logs besides the transfer kinds.
+const (
+	AssetLogIssue      = "issue"
+	AssetLogCallValues = "callValues"
+	AssetLogTxOut      = "txOut"
+)
+
 // NewStructLogger returns a new logger
 func NewStructLogger(cfg *LogConfig) *StructLogger {
 	logger := &StructLogger{
+		frames:        make(map[int]int),
 		changedValues: make(map[common.Address]Storage),
 	}
 	if cfg != nil {
@@ -204,9 +225,71 @@ func (l *StructLogger) CaptureEnd(output []byte, gasUsed uint64, t time.Duration
 	return nil
 }
 
+// CaptureEnter implements the AssetTracer interface.
+func (l *StructLogger) CaptureEnter(env *EVM, snapshot int) error {
+	l.frames[snapshot] = len(l.assetLogs)
+	return nil
+}
+
+// CaptureRevert implements the AssetTracer interface, flagging the asset logs
+// of the reverted call frame and of the frames it entered.
+func (l *StructLogger) CaptureRevert(env *EVM, snapshot int) error {
+	count, ok := l.frames[snapshot]
+	if !ok {
+		return nil
+	}
+	for i := count; i < len(l.assetLogs); i++ {
+		l.assetLogs[i].Reverted = true
+	}
+	for id := range l.frames {
+		if id >= snapshot {
+			delete(l.frames, id)
+		}
+	}
+	return nil
+}
+
+// CaptureTransfer implements the AssetTracer interface.
+func (l *StructLogger) CaptureTransfer(env *EVM, kind string, from common.Address, to common.Address, asset *assets.Asset) error {
+	l.captureAsset(env, kind, &from, &to, asset)
+	return nil
+}
+
+// CaptureIssue implements the AssetTracer interface.
+func (l *StructLogger) CaptureIssue(env *EVM, contract common.Address, asset *assets.Asset) error {
+	l.captureAsset(env, AssetLogIssue, nil, &contract, asset)
+	return nil
+}
+
+// CaptureCallValues implements the AssetTracer interface.
+func (l *StructLogger) CaptureCallValues(env *EVM, contract common.Address, asset *assets.Asset) error {
+	l.captureAsset(env, AssetLogCallValues, &contract, nil, asset)
+	return nil
+}
+
+// CaptureTxOut implements the AssetTracer interface.
+func (l *StructLogger) CaptureTxOut(env *EVM, to common.Address, asset *assets.Asset) error {
+	l.captureAsset(env, AssetLogTxOut, nil, &to, asset)
+	return nil
+}
+
+func (l *StructLogger) captureAsset(env *EVM, kind string, from *common.Address, to *common.Address, asset *assets.Asset) {
+	l.assetLogs = append(l.assetLogs, AssetLog{
+		Step:  len(l.logs),
+		Kind:  kind,
+		From:  from,
+		To:    to,
+		Asset: asset.Clone(),
+		Depth: env.depth,
+	})
+}
+
 // StructLogs returns the captured log entries.
 func (l *StructLogger) StructLogs() []StructLog { return l.logs }
 
+// AssetLogs returns the captured currency and ticket movements.
+func (l *StructLogger) AssetLogs() []AssetLog { return l.assetLogs }
+
 // Error returns the VM error captured by the trace.
 func (l *StructLogger) Error() error { return l.err }
 
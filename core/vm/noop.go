@@ -69,6 +69,7 @@ func (NoopStateDB) Empty(common.Address) bool
 func (NoopStateDB) RevertToSnapshot(int)                                               {}
 func (NoopStateDB) Snapshot() int                                                      { return 0 }
 func (NoopStateDB) AddLog(*types.Log)                                                  {}
+func (NoopStateDB) AddAssetRecord(*types.AssetRecord)                                  {}
 func (NoopStateDB) AddPreimage(common.Hash, []byte)                                    {}
 func (NoopStateDB) ForEachStorage(common.Address, func(common.Hash, common.Hash) bool) {}
 func (NoopStateDB) IsContract(addr common.Address) bool {
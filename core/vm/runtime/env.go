@@ -24,9 +24,9 @@ import (
 
 func NewEnv(cfg *Config) *vm.EVM {
 	context := vm.Context{
-		//CanTransfer: core.CanTransfer,
-		Transfer: core.Transfer,
-		GetHash:  func(uint64) common.Hash { return common.Hash{} },
+		CanTransfer: core.CanTransfer,
+		Transfer:    core.Transfer,
+		GetHash:     func(uint64) common.Hash { return common.Hash{} },
 
 		Origin:      cfg.Origin,
 		Coinbase:    cfg.Coinbase,
@@ -0,0 +1,495 @@
+// copyright 2018 The sero.cash Authors
+// This file is part of the go-sero library.
+//
+// The go-sero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-sero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-sero library. If not, see <http://www.gnu.org/licenses/>.
+
+package runtime
+
+import (
+	"bytes"
+	"math"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/sero-cash/go-sero/common"
+	"github.com/sero-cash/go-sero/core/state"
+	"github.com/sero-cash/go-sero/core/vm"
+	"github.com/sero-cash/go-sero/params"
+	"github.com/sero-cash/go-sero/serodb"
+	"github.com/sero-cash/go-sero/zero/txs/assets"
+)
+
+var (
+	systemCallContract = common.BytesToAddress([]byte("systemcalls"))
+	oneSero            = new(big.Int).Mul(big.NewInt(10), new(big.Int).SetUint64(1e+17))
+)
+
+// systemTopic returns the log topic triggering a system call.
+func systemTopic(name string) common.Hash {
+	for topic, n := range vm.SystemTopics {
+		if n == name {
+			return topic
+		}
+	}
+	panic("unknown system call " + name)
+}
+
+// sysCode assembles a contract emitting a single system call log.
+type sysCode struct {
+	code []byte
+}
+
+func (c *sysCode) push(value []byte) {
+	c.code = append(c.code, byte(vm.PUSH32))
+	c.code = append(c.code, common.LeftPadBytes(value, 32)...)
+}
+
+// word stores a 32 bytes word at a memory offset.
+func (c *sysCode) word(offset uint64, value []byte) {
+	c.push(value)
+	c.push(new(big.Int).SetUint64(offset).Bytes())
+	c.code = append(c.code, byte(vm.MSTORE))
+}
+
+func (c *sysCode) uint(offset uint64, value *big.Int) {
+	c.word(offset, value.Bytes())
+}
+
+// str stores a length prefixed string at a memory offset, the way solidity
+// lays out dynamic arguments.
+func (c *sysCode) str(offset uint64, s string) {
+	c.uint(offset, big.NewInt(int64(len(s))))
+	data := []byte(s)
+	for i := 0; i < len(data); i += 32 {
+		chunk := make([]byte, 32)
+		copy(chunk, data[i:])
+		c.word(offset+32+uint64(i), chunk)
+	}
+}
+
+// log emits the system call log over memory[start:start+size] and returns
+// memory[start:start+size].
+func (c *sysCode) log(name string, start uint64, size uint64) []byte {
+	topic := systemTopic(name)
+	c.push(topic[:])
+	c.push(new(big.Int).SetUint64(size).Bytes())
+	c.push(new(big.Int).SetUint64(start).Bytes())
+	c.code = append(c.code, byte(vm.LOG1))
+
+	c.push(new(big.Int).SetUint64(size).Bytes())
+	c.push(new(big.Int).SetUint64(start).Bytes())
+	c.code = append(c.code, byte(vm.RETURN))
+	return c.code
+}
+
+// runSystemCall executes code with value SERO attached, returning the output,
+// the gas used and the resulting state.
+func runSystemCall(code []byte, value *big.Int, cfg *Config) ([]byte, uint64, *state.StateDB, error) {
+	if cfg.State == nil {
+		cfg.State, _ = state.New(state.NewDatabase(serodb.NewMemDatabase()), nil)
+	}
+	cfg.Value = value
+	if !cfg.State.Exist(systemCallContract) {
+		cfg.State.CreateAccount(systemCallContract)
+	}
+	cfg.State.SetCode(systemCallContract, code)
+
+	ret, left, err := Call(systemCallContract, nil, cfg)
+	return ret, math.MaxUint64 - left, cfg.State, err
+}
+
+func issueTokenCode(name string, total *big.Int) []byte {
+	c := new(sysCode)
+	c.uint(0, big.NewInt(64))
+	c.uint(32, total)
+	c.str(64, name)
+	return c.log(params.SysIssueToken, 0, 64)
+}
+
+func TestSystemCallIssueToken(t *testing.T) {
+	total := big.NewInt(1000000)
+	tests := []struct {
+		name    string
+		value   *big.Int
+		success bool
+	}{
+		{"ABC", oneSero, true},
+		{"A1_B2", oneSero, true},
+		{strings.Repeat("A", 32), oneSero, true},
+		{"", oneSero, false},                                     // empty name
+		{"abc", oneSero, false},                                  // lowercase name
+		{"1ABC", oneSero, false},                                 // leading digit
+		{"AB-C", oneSero, false},                                 // illegal character
+		{strings.Repeat("A", 33), oneSero, false},                // overlong name
+		{"SERO", oneSero, false},                                 // reserved name
+		{"MYSEROCOIN", oneSero, false},                           // reserved substring
+		{"ABC", new(big.Int).Sub(oneSero, big.NewInt(1)), false}, // fee not covered
+	}
+	for i, tt := range tests {
+		ret, _, statedb, err := runSystemCall(issueTokenCode(tt.name, total), tt.value, new(Config))
+		if err != nil {
+			t.Fatalf("test %d (%q): execution failed: %v", i, tt.name, err)
+		}
+		if success := ret[63] == 1; success != tt.success {
+			t.Errorf("test %d (%q): success mismatch: have %v, want %v", i, tt.name, success, tt.success)
+			continue
+		}
+		if !tt.success {
+			if balance := statedb.GetBalance(systemCallContract, "SERO"); balance.Cmp(tt.value) != 0 {
+				t.Errorf("test %d (%q): fee charged on failure, balance %v", i, tt.name, balance)
+			}
+			continue
+		}
+		if balance := statedb.GetBalance(systemCallContract, tt.name); balance.Cmp(total) != 0 {
+			t.Errorf("test %d (%q): issued balance mismatch: have %v, want %v", i, tt.name, balance, total)
+		}
+		if balance := statedb.GetBalance(systemCallContract, "SERO"); balance.Sign() != 0 {
+			t.Errorf("test %d (%q): fee not charged, balance %v", i, tt.name, balance)
+		}
+		if owner := statedb.GetContrctAddressByToken(tt.name); owner != systemCallContract {
+			t.Errorf("test %d (%q): token not registered to the contract", i, tt.name)
+		}
+	}
+}
+
+func TestSystemCallIssueTokenReissue(t *testing.T) {
+	cfg := new(Config)
+	if _, _, _, err := runSystemCall(issueTokenCode("ABC", big.NewInt(100)), oneSero, cfg); err != nil {
+		t.Fatalf("first issuance failed: %v", err)
+	}
+	// Issuing more of an owned token is free
+	ret, _, statedb, err := runSystemCall(issueTokenCode("ABC", big.NewInt(50)), new(big.Int), cfg)
+	if err != nil {
+		t.Fatalf("second issuance failed: %v", err)
+	}
+	if ret[63] != 1 {
+		t.Fatalf("second issuance rejected")
+	}
+	if balance := statedb.GetBalance(systemCallContract, "ABC"); balance.Cmp(big.NewInt(150)) != 0 {
+		t.Errorf("balance mismatch: have %v, want 150", balance)
+	}
+}
+
+func TestSystemCallTokenFees(t *testing.T) {
+	beta := &params.ChainConfig{ChainID: big.NewInt(2019), AutumnTwilightBlock: new(big.Int)}
+	tests := []struct {
+		name string
+		fee  *big.Int
+	}{
+		{"ABCDEF", oneSero},
+		{"ABCDEFGHIJ", new(big.Int).Div(oneSero, big.NewInt(10))},
+		{"ABCDE", new(big.Int).Mul(oneSero, big.NewInt(10))},
+	}
+	for i, tt := range tests {
+		// One wei short of the fee is rejected
+		short := new(big.Int).Sub(tt.fee, big.NewInt(1))
+		ret, _, _, err := runSystemCall(issueTokenCode(tt.name, big.NewInt(1)), short, &Config{ChainConfig: beta})
+		if err != nil || ret[63] != 0 {
+			t.Errorf("test %d (%q): token registered below the fee (err %v)", i, tt.name, err)
+		}
+		ret, _, statedb, err := runSystemCall(issueTokenCode(tt.name, big.NewInt(1)), tt.fee, &Config{ChainConfig: beta})
+		if err != nil || ret[63] != 1 {
+			t.Errorf("test %d (%q): token not registered with the fee (err %v)", i, tt.name, err)
+			continue
+		}
+		if balance := statedb.GetBalance(systemCallContract, "SERO"); balance.Sign() != 0 {
+			t.Errorf("test %d (%q): fee mismatch, %v SERO left", i, tt.name, balance)
+		}
+	}
+}
+
+func TestSystemCallScheduleForks(t *testing.T) {
+	beta := &params.ChainConfig{ChainID: big.NewInt(2019)}
+	dev := &params.ChainConfig{ChainID: big.NewInt(1024)}
+
+	tests := []struct {
+		config    *params.ChainConfig
+		number    int64
+		recipient common.Address
+		fee3      *big.Int
+	}{
+		{dev, 0, params.FoundationAccount1, oneSero},
+		{dev, 299999, params.FoundationAccount1, oneSero},
+		{dev, 300000, params.FoundationAccount2, oneSero},
+		{beta, 0, params.FoundationAccount1, new(big.Int).Mul(oneSero, big.NewInt(10000))},
+		{beta, 300000, params.FoundationAccount2, new(big.Int).Mul(oneSero, big.NewInt(10000))},
+	}
+	for i, tt := range tests {
+		schedule := tt.config.SystemCalls(big.NewInt(tt.number))
+		if schedule.FeeRecipient != tt.recipient {
+			t.Errorf("test %d: fee recipient mismatch", i)
+		}
+		if fee := schedule.TokenFee("ABC"); fee.Cmp(tt.fee3) != 0 {
+			t.Errorf("test %d: token fee mismatch: have %v, want %v", i, fee, tt.fee3)
+		}
+		for topic, name := range vm.SystemTopics {
+			if _, ok := schedule.Costs[name]; !ok {
+				t.Errorf("test %d: no cost scheduled for system call %s (%x)", i, name, topic)
+			}
+		}
+	}
+}
+
+func TestSystemCallBalanceOf(t *testing.T) {
+	c := new(sysCode)
+	c.uint(0, big.NewInt(32))
+	c.str(32, "SERO")
+
+	ret, _, _, err := runSystemCall(c.log(params.SysBalanceOf, 0, 32), big.NewInt(12345), new(Config))
+	if err != nil {
+		t.Fatalf("execution failed: %v", err)
+	}
+	if balance := new(big.Int).SetBytes(ret); balance.Cmp(big.NewInt(12345)) != 0 {
+		t.Errorf("balance mismatch: have %v, want 12345", balance)
+	}
+}
+
+func TestSystemCallCallInfo(t *testing.T) {
+	for _, name := range []string{params.SysCurrency, params.SysCategory, params.SysTicket} {
+		c := new(sysCode)
+		ret, _, _, err := runSystemCall(c.log(name, 0, 32), big.NewInt(1), new(Config))
+		if err != nil {
+			t.Fatalf("%s: execution failed: %v", name, err)
+		}
+		want := make([]byte, 32)
+		if name == params.SysCurrency {
+			copy(want, "SERO")
+		}
+		if !bytes.Equal(ret, want) {
+			t.Errorf("%s: result mismatch: have %x, want %x", name, ret, want)
+		}
+	}
+}
+
+func TestSystemCallSend(t *testing.T) {
+	c := new(sysCode)
+	c.word(0, systemCallContract[:20]) // unknown recipient
+	c.uint(32, big.NewInt(160))
+	c.uint(64, big.NewInt(1))
+	c.uint(96, big.NewInt(192))
+	c.uint(128, new(big.Int))
+	c.str(160, "SERO")
+	c.str(192, "")
+
+	ret, _, statedb, err := runSystemCall(c.log(params.SysSend, 0, 160), big.NewInt(10), new(Config))
+	if err != nil {
+		t.Fatalf("execution failed: %v", err)
+	}
+	if ret[159] != 0 {
+		t.Errorf("send to an unknown recipient succeeded")
+	}
+	if balance := statedb.GetBalance(systemCallContract, "SERO"); balance.Cmp(big.NewInt(10)) != 0 {
+		t.Errorf("balance mismatch: have %v, want 10", balance)
+	}
+}
+
+func TestSystemCallAllotTicket(t *testing.T) {
+	c := new(sysCode)
+	c.uint(0, new(big.Int))
+	c.uint(32, new(big.Int))
+	c.uint(64, big.NewInt(96))
+	c.str(96, "VIP")
+
+	// Ticket allotment is disabled, it returns the zero ticket
+	ret, _, _, err := runSystemCall(c.log(params.SysAllotTicket, 0, 96), new(big.Int), new(Config))
+	if err != nil {
+		t.Fatalf("execution failed: %v", err)
+	}
+	if !bytes.Equal(ret[64:], make([]byte, 32)) {
+		t.Errorf("ticket allotted: %x", ret[64:])
+	}
+}
+
+// callValuesTracer records the call values set through setCallValues.
+type callValuesTracer struct {
+	*vm.StructLogger
+	values []*assets.Asset
+}
+
+func (t *callValuesTracer) CaptureEnter(env *vm.EVM, snapshot int) error  { return nil }
+func (t *callValuesTracer) CaptureRevert(env *vm.EVM, snapshot int) error { return nil }
+func (t *callValuesTracer) CaptureTransfer(env *vm.EVM, kind string, from common.Address, to common.Address, asset *assets.Asset) error {
+	return nil
+}
+func (t *callValuesTracer) CaptureIssue(env *vm.EVM, contract common.Address, asset *assets.Asset) error {
+	return nil
+}
+func (t *callValuesTracer) CaptureCallValues(env *vm.EVM, contract common.Address, asset *assets.Asset) error {
+	t.values = append(t.values, asset)
+	return nil
+}
+func (t *callValuesTracer) CaptureTxOut(env *vm.EVM, to common.Address, asset *assets.Asset) error {
+	return nil
+}
+
+func TestSystemCallSetCallValues(t *testing.T) {
+	c := new(sysCode)
+	c.uint(0, big.NewInt(128))
+	c.uint(32, big.NewInt(7))
+	c.uint(64, big.NewInt(192))
+	c.uint(96, new(big.Int))
+	c.str(128, "sero")
+	c.str(192, "")
+
+	tracer := &callValuesTracer{StructLogger: vm.NewStructLogger(nil)}
+	cfg := &Config{EVMConfig: vm.Config{Debug: true, Tracer: tracer}}
+	if _, _, _, err := runSystemCall(c.log(params.SysSetCallValues, 0, 128), new(big.Int), cfg); err != nil {
+		t.Fatalf("execution failed: %v", err)
+	}
+	if len(tracer.values) != 1 {
+		t.Fatalf("call values mismatch: have %d, want 1", len(tracer.values))
+	}
+	tkn := tracer.values[0].Tkn
+	if tkn == nil || common.BytesToString(tkn.Currency[:]) != "SERO" || tkn.Value.ToIntRef().Cmp(big.NewInt(7)) != 0 {
+		t.Errorf("call values mismatch: %+v", tracer.values[0])
+	}
+}
+
+func TestSystemCallSetTokenRate(t *testing.T) {
+	rateCode := func(name string) []byte {
+		c := new(sysCode)
+		c.uint(0, big.NewInt(96))
+		c.uint(32, big.NewInt(1))
+		c.uint(64, big.NewInt(1))
+		c.str(96, name)
+		return c.log(params.SysSetTokenRate, 0, 96)
+	}
+	// Rates can only be set on tokens owned by the contract
+	ret, _, _, err := runSystemCall(rateCode("ABC"), new(big.Int), new(Config))
+	if err != nil {
+		t.Fatalf("execution failed: %v", err)
+	}
+	if ret[95] != 0 {
+		t.Errorf("rate set on a token not owned")
+	}
+	// Illegal names abort the execution
+	for _, name := range []string{"", "abc", strings.Repeat("A", 33)} {
+		if _, _, _, err := runSystemCall(rateCode(name), new(big.Int), new(Config)); err == nil {
+			t.Errorf("%q: illegal token name accepted", name)
+		}
+	}
+}
+
+func TestSystemCallPkgs(t *testing.T) {
+	c := new(sysCode)
+	c.word(0, common.LeftPadBytes([]byte{1}, 32))
+	ret, _, _, err := runSystemCall(c.log(params.SysClosePkg, 0, 256), new(big.Int), new(Config))
+	if err != nil {
+		t.Fatalf("closePkg: execution failed: %v", err)
+	}
+	if !bytes.Equal(ret, make([]byte, 256)) {
+		t.Errorf("closePkg: unknown package closed: %x", ret)
+	}
+
+	c = new(sysCode)
+	c.word(0, common.LeftPadBytes([]byte{1}, 32))
+	c.word(32, systemCallContract[:20])
+	if _, _, _, err := runSystemCall(c.log(params.SysTransferPkg, 0, 64), new(big.Int), new(Config)); err != vm.ErrToAddressError {
+		t.Errorf("transferPkg: error mismatch: have %v, want %v", err, vm.ErrToAddressError)
+	}
+}
+
+func TestSystemCallDataLength(t *testing.T) {
+	for topic, name := range vm.SystemTopics {
+		c := new(sysCode)
+		if _, _, _, err := runSystemCall(c.log(name, 0, 31), new(big.Int), new(Config)); err != vm.ErrCodeInvalid {
+			t.Errorf("%s (%x): error mismatch on short data: have %v, want %v", name, topic, err, vm.ErrCodeInvalid)
+		}
+	}
+}
+
+// TestSystemCallGas checks that system calls not moving assets are priced as
+// a plain log plus their scheduled cost.
+func TestSystemCallGas(t *testing.T) {
+	plain := new(sysCode)
+	plain.push(common.Hex2Bytes("01"))
+	plain.push(big.NewInt(32).Bytes())
+	plain.push(new(big.Int).Bytes())
+	plain.code = append(plain.code, byte(vm.LOG1), byte(vm.STOP))
+	_, plainGas, _, err := runSystemCall(plain.code, new(big.Int), new(Config))
+	if err != nil {
+		t.Fatalf("plain log failed: %v", err)
+	}
+
+	schedule := params.TestChainConfig.SystemCalls(new(big.Int))
+	for _, name := range []string{params.SysCurrency, params.SysCategory, params.SysTicket} {
+		c := new(sysCode)
+		topic := systemTopic(name)
+		c.push(topic[:])
+		c.push(big.NewInt(32).Bytes())
+		c.push(new(big.Int).Bytes())
+		c.code = append(c.code, byte(vm.LOG1), byte(vm.STOP))
+
+		_, gas, _, err := runSystemCall(c.code, new(big.Int), &Config{ChainConfig: params.TestChainConfig})
+		if err != nil {
+			t.Fatalf("%s: execution failed: %v", name, err)
+		}
+		if want := plainGas + schedule.Cost(name).Gas; gas != want {
+			t.Errorf("%s: gas mismatch: have %d, want %d", name, gas, want)
+		}
+	}
+}
+
+func TestStructLoggerAssetLogs(t *testing.T) {
+	total := big.NewInt(1000000)
+
+	tracer := vm.NewStructLogger(nil)
+	cfg := &Config{EVMConfig: vm.Config{Debug: true, Tracer: tracer}}
+	if _, _, _, err := runSystemCall(issueTokenCode("ABC", total), oneSero, cfg); err != nil {
+		t.Fatalf("execution failed: %v", err)
+	}
+	var issued bool
+	for _, log := range tracer.AssetLogs() {
+		if log.Reverted {
+			t.Errorf("%s log flagged as reverted", log.Kind)
+		}
+		if log.Kind != vm.AssetLogIssue {
+			continue
+		}
+		issued = true
+		if tkn := log.Asset.Tkn; tkn == nil || common.BytesToString(tkn.Currency[:]) != "ABC" || tkn.Value.ToIntRef().Cmp(total) != 0 {
+			t.Errorf("issue log mismatch: %+v", log.Asset)
+		}
+		if log.To == nil || *log.To != systemCallContract {
+			t.Errorf("issue log recipient mismatch: %v", log.To)
+		}
+	}
+	if !issued {
+		t.Errorf("no issue log captured")
+	}
+}
+
+func TestStructLoggerAssetLogsReverted(t *testing.T) {
+	// PUSH1 0 PUSH1 0 REVERT
+	code := []byte{byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.REVERT)}
+
+	tracer := vm.NewStructLogger(nil)
+	cfg := &Config{EVMConfig: vm.Config{Debug: true, Tracer: tracer}}
+	if _, _, _, err := runSystemCall(code, big.NewInt(10), cfg); err == nil {
+		t.Fatalf("reverted execution succeeded")
+	}
+	logs := tracer.AssetLogs()
+	if len(logs) == 0 {
+		t.Fatalf("no asset log captured")
+	}
+	if logs[0].Kind != vm.TransferCall || logs[0].To == nil || *logs[0].To != systemCallContract {
+		t.Errorf("transfer log mismatch: %+v", logs[0])
+	}
+	for _, log := range logs {
+		if !log.Reverted {
+			t.Errorf("%s log not flagged as reverted", log.Kind)
+		}
+	}
+}
@@ -24,6 +24,7 @@ import (
 
 	"github.com/sero-cash/go-sero/common"
 	"github.com/sero-cash/go-sero/core/types"
+	"github.com/sero-cash/go-sero/zero/txs/assets"
 )
 
 // NotFound is returned by API methods if the requested item does not exist.
@@ -117,7 +118,8 @@ type CallMsg struct {
 	To       *common.Address // the destination contract (nil for contract creation)
 	Gas      uint64          // if 0, the call executes with near-infinite gas
 	GasPrice *big.Int        // wei <-> gas exchange ratio
-	Value    *big.Int        // amount of wei sent along with the call
+	Value    *big.Int        // amount of SERO sent along with the call
+	Asset    *assets.Asset   // token and ticket sent along with the call, overrides Value
 	Data     []byte          // input data, usually an ABI-encoded contract method invocation
 }
 
@@ -28,6 +28,7 @@ import (
 
 	"github.com/sero-cash/go-sero/zero/txtool/flight"
 	"github.com/sero-cash/go-sero/zero/txtool/prepare"
+	"github.com/sero-cash/go-sero/zero/txtool/verify"
 
 	"github.com/sero-cash/go-sero/zero/txtool"
 
@@ -38,6 +39,7 @@ import (
 	"github.com/sero-cash/go-sero/common/address"
 
 	"github.com/sero-cash/go-sero/zero/wallet/lstate"
+	"github.com/sero-cash/go-sero/zero/wallet/stakeservice"
 
 	"github.com/sero-cash/go-sero/zero/txs"
 
@@ -443,6 +445,46 @@ func (s *PrivateAccountAPI) ImportTk(tk address.AccountAddress) (address.Account
 	return acc.Address, err
 }
 
+// ViewBundleArgs is the scope of an exported view bundle.
+type ViewBundleArgs struct {
+	At     *hexutil.Uint64 `json:"at"`     // Block of the account if nil
+	Expiry hexutil.Uint64  `json:"expiry"` // No limit if 0
+	PKrs   []PKrAddress    `json:"pkrs"`   // All the PKrs of the account if empty
+}
+
+// ExportViewBundle signs a view bundle of an account for the auditors, its tk
+// scoped by args.
+func (s *PrivateAccountAPI) ExportViewBundle(addr address.AccountAddress, password string, args *ViewBundleArgs) (*keystore.ViewBundle, error) {
+	ks := fetchKeystore(s.am)
+	account, err := ks.Find(accounts.Account{Address: addr})
+	if err != nil {
+		return nil, err
+	}
+	if args == nil {
+		args = new(ViewBundleArgs)
+	}
+	at := account.At
+	if args.At != nil {
+		at = uint64(*args.At)
+	}
+	pkrs := make([]keys.PKr, len(args.PKrs))
+	for i := range args.PKrs {
+		pkrs[i] = *args.PKrs[i].ToPKr()
+	}
+	return ks.ExportViewBundle(account, password, at, pkrs, uint64(args.Expiry))
+}
+
+// ImportViewBundle adds the watch-only account of a view bundle, indexed by
+// the exchange within the scope of the bundle.
+func (s *PrivateAccountAPI) ImportViewBundle(bundle keystore.ViewBundle) (address.AccountAddress, error) {
+	ex := exchange.CurrentExchange()
+	if ex == nil {
+		return address.AccountAddress{}, errors.New("view bundles are indexed by the exchange, start it with --exchange")
+	}
+	acc, err := ex.ImportViewBundle(fetchKeystore(s.am), &bundle)
+	return acc.Address, err
+}
+
 func (s *PrivateAccountAPI) ImportMnemonic(mnemonic string, password string) (address.AccountAddress, error) {
 	_, err := bip39.MnemonicToByteArray(mnemonic)
 	if err != nil {
@@ -463,6 +505,75 @@ func (s *PrivateAccountAPI) ImportMnemonic(mnemonic string, password string) (ad
 	return acc.Address, err
 }
 
+// NewDerivedAccount derives from the seed of master the account of path, either
+// an account index or an absolute path, and stores it encrypted with password.
+func (s *PrivateAccountAPI) NewDerivedAccount(master address.AccountAddress, path string, password string) (address.AccountAddress, error) {
+	derivPath, err := keystore.ParseDerivedPath(path)
+	if err != nil {
+		return address.AccountAddress{}, err
+	}
+	acc, err := fetchKeystore(s.am).DeriveAccount(accounts.Account{Address: master}, password, derivPath)
+	if err != nil {
+		return address.AccountAddress{}, err
+	}
+	if lst := lstate.CurrentLState(); lst != nil {
+		lst.AddAccount(acc.Tk.ToUint512())
+	}
+	return acc.Address, nil
+}
+
+// defaultDerivationGap is the number of consecutive unused accounts ending a
+// scan of the derived accounts.
+const defaultDerivationGap = 20
+
+// ScanDerivedAccounts stores the accounts derived from master up to gap
+// consecutive unused ones, an account is used if the exchange saw an out of it
+// or the stake service a share. The exchange scans the chain in the background,
+// the accounts it finds are stored then, only those of the stake service are
+// returned.
+func (s *PrivateAccountAPI) ScanDerivedAccounts(master address.AccountAddress, password string, gap *int) ([]address.AccountAddress, error) {
+	limit := defaultDerivationGap
+	if gap != nil {
+		limit = *gap
+	}
+	ks := fetchKeystore(s.am)
+	masterAccount := accounts.Account{Address: master}
+
+	var derived []accounts.Account
+	if ex := exchange.CurrentExchange(); ex != nil {
+		err := ex.ScanDerived(ks, masterAccount, password, limit, func(accs []accounts.Account) {
+			if lst := lstate.CurrentLState(); lst != nil {
+				for _, acc := range accs {
+					lst.AddAccount(acc.Tk.ToUint512())
+				}
+			}
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	if service := stakeservice.CurrentStakeService(); service != nil {
+		accs, err := service.ScanDerived(ks, masterAccount, password, limit)
+		if err != nil {
+			return nil, err
+		}
+		derived = append(derived, accs...)
+	}
+	seen := make(map[address.AccountAddress]bool)
+	addrs := []address.AccountAddress{}
+	for _, acc := range derived {
+		if seen[acc.Address] {
+			continue
+		}
+		seen[acc.Address] = true
+		addrs = append(addrs, acc.Address)
+		if lst := lstate.CurrentLState(); lst != nil {
+			lst.AddAccount(acc.Tk.ToUint512())
+		}
+	}
+	return addrs, nil
+}
+
 // UnlockAccount will unlock the account associated with the given address with
 // the given password for duration seconds. If duration is nil it will use a
 // default of 300 seconds. It returns an indication if the account was unlocked.
@@ -563,14 +674,7 @@ func (s *PrivateAccountAPI) signTransaction(ctx context.Context, args SendTxArgs
 			return
 		}
 		log.Info("ToTxParam", "utxos", len(pretx.Ins))
-		seed, err := wallet.GetSeedWithPassphrase(passwd)
-		if err != nil {
-			exchange.CurrentExchange().ClearTxParam(pretx)
-			e = err
-			return
-		}
-		sk := keys.Seed2Sk(seed.SeedToUint256())
-		gtx, err := flight.SignTx(&sk, pretx)
+		gtx, err := signTxParam(wallet, account, passwd, pretx)
 		if err != nil {
 			exchange.CurrentExchange().ClearTxParam(pretx)
 			e = err
@@ -585,6 +689,25 @@ func (s *PrivateAccountAPI) signTransaction(ctx context.Context, args SendTxArgs
 
 }
 
+// signTxParam signs a param with the seed of the account decrypted by passwd,
+// or delegates the signing to the wallets keeping their seed like the
+// external signers, which ask for the password themselves.
+func signTxParam(wallet accounts.Wallet, account accounts.Account, passwd string, param *txtool.GTxParam) (*txtool.GTx, error) {
+	if signer, ok := wallet.(accounts.TxParamSigner); ok {
+		return signer.SignTxParam(account, param)
+	}
+	seed, err := wallet.GetSeedWithPassphrase(passwd)
+	if err != nil {
+		return nil, err
+	}
+	sk := keys.Seed2Sk(seed.SeedToUint256())
+	gtx, err := flight.SignTx(&sk, param)
+	if err != nil {
+		return nil, err
+	}
+	return &gtx, nil
+}
+
 // SendTransaction will create a transaction from the given arguments and
 // tries to sign it with the key associated with args.To. If the given passwd isn't
 // able to decrypt the key it fails.
@@ -736,8 +859,21 @@ func (s *PublicBlockChainAPI) GenPKr(ctx context.Context, Pk PKAddress) (common.
 }
 
 type Balance struct {
-	Tkn map[string]*hexutil.Big   `json:"tkn"`
-	Tkt map[string][]*common.Hash `json:"tkt"`
+	Tkn      map[string]*hexutil.Big   `json:"tkn"`
+	Tkt      map[string][]*common.Hash `json:"tkt"`
+	Decimals map[string]hexutil.Uint   `json:"decimals,omitempty"`
+}
+
+// withDecimals fills in the decimals of the tokens held in a balance.
+func (self Balance) withDecimals(ctx context.Context, b Backend) Balance {
+	if len(self.Tkn) > 0 {
+		currencies := make([]string, 0, len(self.Tkn))
+		for currency := range self.Tkn {
+			currencies = append(currencies, currency)
+		}
+		self.Decimals = tokensDecimals(ctx, b, currencies)
+	}
+	return self
 }
 
 func GetBalanceFromAccounts(tk *keys.Uint512) (result Balance) {
@@ -817,12 +953,12 @@ func (s *PublicBlockChainAPI) GetBalance(ctx context.Context, addr common.Addres
 		if len(tkn) > 0 {
 			result.Tkn = tkn
 		}
-		return result, state.Error()
+		return result.withDecimals(ctx, s.b), state.Error()
 	} else {
 
 		if seroparam.IsExchange() {
 			exchangBalance := s.b.GetBalances(*address.ToUint512())
-			return GetBalanceFromExchange(exchangBalance), nil
+			return GetBalanceFromExchange(exchangBalance).withDecimals(ctx, s.b), nil
 		} else {
 			// Look up the wallet containing the requested abi
 			account := accounts.Account{Address: *address}
@@ -835,7 +971,7 @@ func (s *PublicBlockChainAPI) GetBalance(ctx context.Context, addr common.Addres
 			seed := wallet.Accounts()[0].Tk
 
 			result = GetBalanceFromAccounts(seed.ToUint512())
-			return result, state.Error()
+			return result.withDecimals(ctx, s.b), state.Error()
 		}
 	}
 
@@ -932,6 +1068,7 @@ func (s *PublicBlockChainAPI) WatchPkg(ctx context.Context, id keys.Uint256, key
 	if err != nil {
 		return nil, err
 	}
+	exchange.CurrentExchange().RevealPkg(id, pkg_o.Asset)
 	wallets := s.b.AccountManager().Wallets()
 	pkg := map[string]interface{}{}
 	pkg["id"] = id
@@ -1314,11 +1451,6 @@ func (s *PublicBlockChainAPI) EstimateGas(ctx context.Context, args CallArgs) (h
 }
 
 func (s *PublicBlockChainAPI) GetDecimal(ctx context.Context, tokenName string) (*hexutil.Uint, error) {
-
-	state, _, err := s.b.StateAndHeaderByNumber(ctx, -1)
-	if err != nil {
-		return nil, err
-	}
 	if tokenName == "" {
 		return nil, errors.New("tokenName can not be empty!")
 	} else {
@@ -1327,39 +1459,12 @@ func (s *PublicBlockChainAPI) GetDecimal(ctx context.Context, tokenName string)
 
 		}
 	}
-	contractAddress := state.GetContrctAddressByToken(tokenName)
-	empty := common.Address{}
-	if contractAddress == empty {
-		return nil, errors.New(tokenName + "not exists!")
-	}
-	contractAddr := address.BytesToAccount(contractAddress[:64])
-	callArgs := CallArgs{
-		To: &contractAddress,
-	}
-	decimals := NewSRC20Decimal(contractAddr, tokenName)
-	for _, d := range decimals {
-		data, err := d.Pack()
-		if err != nil {
-			log.Info("SRC20Decimal", "pack", d.method, err)
-			continue
-		}
-		callArgs.Data = data
-		res, _, failed, err := s.doCall(ctx, callArgs, rpc.LatestBlockNumber, vm.Config{}, 0)
-		if err != nil || failed {
-			log.Info("SRC20Decimal", "docall", err)
-			continue
-		}
-		decimal, err := d.Unpack(res)
-		if err != nil {
-			log.Info("SRC20Decimal", "unpack", err)
-			continue
-		}
-		result := hexutil.Uint(*decimal)
-		log.Info("GetDecimal", "contract", contractAddr.String(), "method", d.method, "decimal", *decimal)
-		return &result, nil
-
+	decimal, err := tokenDecimals(ctx, s.b, tokenName)
+	if err != nil {
+		return nil, err
 	}
-	return nil, errors.New("contract not support SER20 decimals")
+	result := hexutil.Uint(decimal)
+	return &result, nil
 }
 
 // ExecutionResult groups all structured logs emitted by the EVM
@@ -1370,6 +1475,22 @@ type ExecutionResult struct {
 	Failed      bool           `json:"failed"`
 	ReturnValue string         `json:"returnValue"`
 	StructLogs  []StructLogRes `json:"structLogs"`
+	AssetLogs   []AssetLogRes  `json:"assetLogs,omitempty"`
+}
+
+// AssetLogRes stores a currency or ticket movement emitted by the EVM while
+// replaying a transaction in debug mode
+type AssetLogRes struct {
+	Step     int             `json:"step"`
+	Kind     string          `json:"kind"`
+	From     *common.Address `json:"from,omitempty"`
+	To       *common.Address `json:"to,omitempty"`
+	Currency string          `json:"currency,omitempty"`
+	Amount   *hexutil.Big    `json:"amount,omitempty"`
+	Category string          `json:"category,omitempty"`
+	Ticket   *common.Hash    `json:"ticket,omitempty"`
+	Depth    int             `json:"depth"`
+	Reverted bool            `json:"reverted,omitempty"`
 }
 
 // StructLogRes stores a structured log emitted by the EVM while replaying a
@@ -1423,6 +1544,31 @@ func FormatLogs(logs []vm.StructLog) []StructLogRes {
 	return formatted
 }
 
+// FormatAssetLogs formats the EVM currency and ticket movements for json output
+func FormatAssetLogs(logs []vm.AssetLog) []AssetLogRes {
+	formatted := make([]AssetLogRes, len(logs))
+	for index, move := range logs {
+		formatted[index] = AssetLogRes{
+			Step:     move.Step,
+			Kind:     move.Kind,
+			From:     move.From,
+			To:       move.To,
+			Depth:    move.Depth,
+			Reverted: move.Reverted,
+		}
+		if tkn := move.Asset.Tkn; tkn != nil {
+			formatted[index].Currency = common.BytesToString(tkn.Currency[:])
+			formatted[index].Amount = (*hexutil.Big)(tkn.Value.ToIntRef())
+		}
+		if tkt := move.Asset.Tkt; tkt != nil {
+			ticket := common.BytesToHash(tkt.Value[:])
+			formatted[index].Category = common.BytesToString(tkt.Category[:])
+			formatted[index].Ticket = &ticket
+		}
+	}
+	return formatted
+}
+
 // RPCMarshalBlock converts the given block to the RPC output which depends on fullTx. If inclTx is true transactions are
 // returned. When fullTx is true the returned block contains full transaction details, otherwise it will only contain
 // transaction hashes.
@@ -2115,6 +2261,20 @@ func (s *PublicTransactionPoolAPI) CommitTx(ctx context.Context, args *txtool.GT
 	return s.b.CommitTx(args)
 }
 
+// VerifyTx runs the verification of a signed transaction against the latest
+// state without submitting it, reporting the outcome of every proof.
+func (s *PublicTransactionPoolAPI) VerifyTx(ctx context.Context, args *txtool.GTx) (*verify.Report, error) {
+	state, header, err := s.b.StateAndHeaderByNumber(ctx, rpc.LatestBlockNumber)
+	if state == nil || err != nil {
+		return nil, err
+	}
+	tx := types.NewTxWithGTx(uint64(args.Gas), args.GasPrice.ToInt(), &args.Tx)
+	ehash := tx.Ehash()
+	report := verify.Inspect(&ehash, tx.GetZZSTX(), header.Number.Uint64()+1)
+	report.CheckState(tx.GetZZSTX(), state.NextZState())
+	return report, nil
+}
+
 func (s *PublicTransactionPoolAPI) ReSendTransaction(ctx context.Context, txhash common.Hash) (common.Hash, error) {
 
 	pending, err := s.b.GetPoolTransactions()
@@ -98,6 +98,25 @@ func (s *PublicExchangeAPI) GetBalances(ctx context.Context, address PKAddress)
 	return result
 }
 
+type TokenBalance struct {
+	Value    *Big          `json:"value"`
+	Decimals *hexutil.Uint `json:"decimals"`
+}
+
+func (s *PublicExchangeAPI) GetTokenBalances(ctx context.Context, address PKAddress) map[string]TokenBalance {
+	result := map[string]TokenBalance{}
+	balances := s.b.GetBalances(address.ToUint512())
+	for k, v := range balances {
+		balance := TokenBalance{Value: (*Big)(v)}
+		if decimals, err := tokenDecimals(ctx, s.b, k); err == nil {
+			d := hexutil.Uint(decimals)
+			balance.Decimals = &d
+		}
+		result[k] = balance
+	}
+	return result
+}
+
 type ReceptionArgs struct {
 	Addr     MixAdrress
 	Currency Smbol
@@ -524,3 +543,104 @@ func (s *PublicExchangeAPI) Pk2Pkr(ctx context.Context, pk PKAddress, index *key
 	copy(pkrAddress[:], pkr[:])
 	return pkrAddress, nil
 }
+
+type PkgEvent struct {
+	Id        keys.Uint256
+	Kind      string
+	Num       uint64
+	BlockHash keys.Uint256
+	From      PKrAddress
+	To        PKrAddress
+	Asset     map[string]interface{} `json:",omitempty"`
+}
+
+func newPkgEvent(ev *exchange.PkgEvent) PkgEvent {
+	event := PkgEvent{Id: ev.Id, Kind: ev.Kind.String(), Num: ev.Num, BlockHash: ev.BlockHash, From: pkrToPKrAddress(ev.From), To: pkrToPKrAddress(ev.To)}
+	if ev.Asset != nil {
+		event.Asset = map[string]interface{}{}
+		if ev.Asset.Tkn != nil {
+			event.Asset["Tkn"] = map[string]interface{}{"Currency": common.BytesToString(ev.Asset.Tkn.Currency[:]), "Value": (*Big)(ev.Asset.Tkn.Value.ToIntRef())}
+		}
+		if ev.Asset.Tkt != nil {
+			event.Asset["Tkt"] = map[string]interface{}{"Category": common.BytesToString(ev.Asset.Tkt.Category[:]), "Value": ev.Asset.Tkt.Value}
+		}
+	}
+	return event
+}
+
+// GetPkgEvents returns the creation, transfers and close of a package followed by the exchange.
+func (s *PublicExchangeAPI) GetPkgEvents(ctx context.Context, id keys.Uint256) (events []PkgEvent, err error) {
+	exchangeInstance := exchange.CurrentExchange()
+	if exchangeInstance == nil {
+		return nil, errors.New("exchange mode no start")
+	}
+	evs, err := exchangeInstance.GetPkgEvents(id)
+	if err != nil {
+		return
+	}
+	for i := range evs {
+		events = append(events, newPkgEvent(&evs[i]))
+	}
+	return
+}
+
+// GetPkgEventsByPk returns count events of the packages of a PK, from the offset-th one.
+func (s *PublicExchangeAPI) GetPkgEventsByPk(ctx context.Context, address PKAddress, offset, count uint64) (events []PkgEvent, err error) {
+	exchangeInstance := exchange.CurrentExchange()
+	if exchangeInstance == nil {
+		return nil, errors.New("exchange mode no start")
+	}
+	if count == 0 || count > 1000 {
+		return nil, errors.New("count must be between 1 and 1000")
+	}
+	evs, err := exchangeInstance.GetPkgEventsByPk(address.ToUint512(), offset, count)
+	if err != nil {
+		return
+	}
+	for i := range evs {
+		events = append(events, newPkgEvent(&evs[i]))
+	}
+	return
+}
+
+// PkgEvents notifies the packages arriving to the PKs of the exchange and
+// closed by them, or only the ones of address if given.
+func (s *PublicExchangeAPI) PkgEvents(ctx context.Context, address *PKAddress) (*rpc.Subscription, error) {
+	exchangeInstance := exchange.CurrentExchange()
+	if exchangeInstance == nil {
+		return nil, errors.New("exchange mode no start")
+	}
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+
+	rpcSub := notifier.CreateSubscription()
+
+	go func() {
+		notifies := make(chan exchange.PkgNotify, 128)
+		notifySub := exchangeInstance.SubscribePkgNotify(notifies)
+
+		for {
+			select {
+			case notify := <-notifies:
+				if address != nil && notify.PK != address.ToUint512() {
+					continue
+				}
+				pk := PKAddress(notify.PK)
+				notifier.Notify(rpcSub.ID, map[string]interface{}{
+					"PK":    pk,
+					"Event": newPkgEvent(&notify.Event),
+				})
+			case <-rpcSub.Err():
+				notifySub.Unsubscribe()
+				return
+			case <-notifier.Closed():
+				notifySub.Unsubscribe()
+				return
+			}
+		}
+	}()
+
+	return rpcSub, nil
+}
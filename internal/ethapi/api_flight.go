@@ -17,6 +17,7 @@ import (
 	"github.com/sero-cash/go-sero/zero/txtool/flight"
 
 	"github.com/sero-cash/go-sero/zero/txtool"
+	"github.com/sero-cash/go-sero/zero/wallet/pending"
 )
 
 type PublicFlightAPI struct {
@@ -76,6 +77,75 @@ func (s *PublicFlightAPI) CommitTx(ctx context.Context, args *txtool.GTx) error
 	return s.exchange.CommitTx(ctx, args)
 }
 
+func (s *PublicFlightAPI) GenTx(ctx context.Context, param PreTxParamArgs, sk keys.Uint512) (hash keys.Uint256, e error) {
+	store := pending.CurrentPendingStore()
+	if store == nil {
+		e = pending.ErrStoreNotReady
+		return
+	}
+	for _, root := range param.Ins {
+		if store.IsLocked(root) {
+			e = errors.Errorf("root %v is used by a pending tx", hexutil.Encode(root[:]))
+			return
+		}
+	}
+	preTxParam := param.ToParam()
+	p, err := flight.GenTxParam(&preTxParam, keys.Sk2Tk(&sk))
+	if err != nil {
+		e = err
+		return
+	}
+	gtx, err := flight.SignTx(&sk, &p)
+	if err != nil {
+		e = err
+		return
+	}
+	if _, e = store.Put(&gtx, param.Ins); e != nil {
+		return
+	}
+	hash = gtx.Hash
+	return
+}
+
+func (s *PublicFlightAPI) GetPendingTx(ctx context.Context, txhash keys.Uint256) (*pending.PendingTx, error) {
+	store := pending.CurrentPendingStore()
+	if store == nil {
+		return nil, pending.ErrStoreNotReady
+	}
+	return store.Get(txhash)
+}
+
+func (s *PublicFlightAPI) ListPendingTx(ctx context.Context) ([]*pending.PendingTx, error) {
+	store := pending.CurrentPendingStore()
+	if store == nil {
+		return nil, pending.ErrStoreNotReady
+	}
+	return store.List(), nil
+}
+
+func (s *PublicFlightAPI) CancelPendingTx(ctx context.Context, txhash keys.Uint256) error {
+	store := pending.CurrentPendingStore()
+	if store == nil {
+		return pending.ErrStoreNotReady
+	}
+	return store.Cancel(txhash)
+}
+
+func (s *PublicFlightAPI) CommitPendingTx(ctx context.Context, txhash keys.Uint256) error {
+	store := pending.CurrentPendingStore()
+	if store == nil {
+		return pending.ErrStoreNotReady
+	}
+	ptx, err := store.Get(txhash)
+	if err != nil {
+		return err
+	}
+	if err := s.exchange.CommitTx(ctx, &ptx.Tx); err != nil {
+		return err
+	}
+	return store.Committed(txhash)
+}
+
 func (s *PublicFlightAPI) Trace2Root(ctx context.Context, tk TKAddress, trace keys.Uint256, base keys.Uint256) (root keys.Uint256, e error) {
 	if r := flight.Trace2Root(tk.ToUint512().NewRef(), &trace, &base); r != nil {
 		root = *r
@@ -0,0 +1,200 @@
+package ethapi
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"strings"
+
+	"github.com/sero-cash/go-sero/common"
+	"github.com/sero-cash/go-sero/common/address"
+	"github.com/sero-cash/go-sero/common/hexutil"
+	"github.com/sero-cash/go-sero/core/assetindex"
+	"github.com/sero-cash/go-sero/core/types"
+	"github.com/sero-cash/go-sero/core/vm"
+	"github.com/sero-cash/go-sero/log"
+	"github.com/sero-cash/go-sero/rpc"
+)
+
+// maxRegistryPage is the maximum number of entries returned by a registry listing.
+const maxRegistryPage = 100
+
+type RegistryAsset struct {
+	Kind          string         `json:"kind"`
+	Name          string         `json:"name"`
+	Contract      common.Address `json:"contract"`
+	RegisterBlock hexutil.Uint64 `json:"registerBlock"`
+	RegisterTx    common.Hash    `json:"registerTx"`
+	Supply        *hexutil.Big   `json:"supply"`
+	Decimals      *hexutil.Uint  `json:"decimals"`
+}
+
+func newRegistryAsset(info *assetindex.Info) RegistryAsset {
+	asset := RegistryAsset{
+		Kind:          info.Kind,
+		Name:          info.Name,
+		Contract:      info.Contract,
+		RegisterBlock: hexutil.Uint64(info.RegisterBlock),
+		RegisterTx:    info.RegisterTx,
+	}
+	if info.Supply != nil {
+		asset.Supply = (*hexutil.Big)(new(big.Int).Set(info.Supply))
+	}
+	if info.HasDecimals {
+		decimals := hexutil.Uint(info.Decimals)
+		asset.Decimals = &decimals
+	}
+	return asset
+}
+
+type PublicRegistryAPI struct {
+	b Backend
+}
+
+func (s *PublicRegistryAPI) Counts() (map[string]hexutil.Uint64, error) {
+	registry := s.b.AssetRegistry()
+	if err := registry.Status(); err != nil {
+		return nil, err
+	}
+	return map[string]hexutil.Uint64{
+		types.AssetToken:  hexutil.Uint64(registry.Count(types.AssetToken)),
+		types.AssetTicket: hexutil.Uint64(registry.Count(types.AssetTicket)),
+	}, nil
+}
+
+func (s *PublicRegistryAPI) Tokens(ctx context.Context, start hexutil.Uint64, count hexutil.Uint64) ([]RegistryAsset, error) {
+	return s.list(types.AssetToken, start, count)
+}
+
+func (s *PublicRegistryAPI) Tickets(ctx context.Context, start hexutil.Uint64, count hexutil.Uint64) ([]RegistryAsset, error) {
+	return s.list(types.AssetTicket, start, count)
+}
+
+func (s *PublicRegistryAPI) list(kind string, start hexutil.Uint64, count hexutil.Uint64) ([]RegistryAsset, error) {
+	if count > maxRegistryPage {
+		count = maxRegistryPage
+	}
+	infos, err := s.b.AssetRegistry().List(kind, uint64(start), uint64(count))
+	if err != nil {
+		return nil, err
+	}
+	assets := make([]RegistryAsset, len(infos))
+	for i, info := range infos {
+		assets[i] = newRegistryAsset(info)
+	}
+	return assets, nil
+}
+
+func (s *PublicRegistryAPI) GetToken(ctx context.Context, currency string) (*RegistryAsset, error) {
+	info, err := s.b.AssetRegistry().Get(types.AssetToken, currency)
+	if err != nil {
+		return nil, err
+	}
+	asset := newRegistryAsset(info)
+	if asset.Decimals == nil {
+		if decimals, err := tokenDecimals(ctx, s.b, info.Name); err == nil {
+			d := hexutil.Uint(decimals)
+			asset.Decimals = &d
+		}
+	}
+	return &asset, nil
+}
+
+func (s *PublicRegistryAPI) GetTicket(ctx context.Context, category string) (*RegistryAsset, error) {
+	info, err := s.b.AssetRegistry().Get(types.AssetTicket, category)
+	if err != nil {
+		return nil, err
+	}
+	asset := newRegistryAsset(info)
+	return &asset, nil
+}
+
+func (s *PublicRegistryAPI) GetByContract(ctx context.Context, contract common.Address) ([]RegistryAsset, error) {
+	registry := s.b.AssetRegistry()
+	if err := registry.Status(); err != nil {
+		return nil, err
+	}
+	infos := registry.ByContract(contract)
+	assets := make([]RegistryAsset, len(infos))
+	for i, info := range infos {
+		assets[i] = newRegistryAsset(info)
+	}
+	return assets, nil
+}
+
+// errNoDecimals is returned when the issuing contract of a token doesn't
+// report its decimals.
+var errNoDecimals = errors.New("contract not support SER20 decimals")
+
+// tokenDecimals returns the decimals of a token currency. Decimals cached in the
+// asset registry are used when known, otherwise they are asked to the issuing
+// contract once, and its answer is cached, misses included.
+func tokenDecimals(ctx context.Context, b Backend, currency string) (uint8, error) {
+	currency = strings.ToUpper(currency)
+	registry := b.AssetRegistry()
+	if decimals, ok := registry.Decimals(currency); ok {
+		return decimals, nil
+	}
+
+	state, _, err := b.StateAndHeaderByNumber(ctx, rpc.LatestBlockNumber)
+	if err != nil {
+		return 0, err
+	}
+	contractAddress := state.GetContrctAddressByToken(currency)
+	if contractAddress == (common.Address{}) {
+		return 0, errors.New(currency + " not exists!")
+	}
+	if decimals, supported, ok := registry.ContractDecimals(currency, contractAddress); ok {
+		if !supported {
+			return 0, errNoDecimals
+		}
+		return decimals, nil
+	}
+
+	contractAddr := address.BytesToAccount(contractAddress[:64])
+	callArgs := CallArgs{
+		To: &contractAddress,
+	}
+	api := NewPublicBlockChainAPI(b)
+	for _, d := range NewSRC20Decimal(contractAddr, currency) {
+		data, err := d.Pack()
+		if err != nil {
+			log.Info("SRC20Decimal", "pack", d.method, err)
+			continue
+		}
+		callArgs.Data = data
+		res, _, failed, err := api.doCall(ctx, callArgs, rpc.LatestBlockNumber, vm.Config{}, 0)
+		if err != nil {
+			// The call didn't run, don't cache the miss
+			return 0, err
+		}
+		if failed {
+			log.Info("SRC20Decimal", "docall", "failed")
+			continue
+		}
+		decimal, err := d.Unpack(res)
+		if err != nil {
+			log.Info("SRC20Decimal", "unpack", err)
+			continue
+		}
+		log.Info("GetDecimal", "contract", contractAddr.String(), "method", d.method, "decimal", *decimal)
+		if err := registry.SetContractDecimals(currency, contractAddress, *decimal, true); err != nil {
+			log.Debug("GetDecimal token not indexed yet", "currency", currency, "err", err)
+		}
+		return *decimal, nil
+	}
+	registry.SetContractDecimals(currency, contractAddress, 0, false)
+	return 0, errNoDecimals
+}
+
+// tokensDecimals returns the decimals of the given token currencies, skipping
+// the ones whose decimals cannot be determined.
+func tokensDecimals(ctx context.Context, b Backend, currencies []string) map[string]hexutil.Uint {
+	result := map[string]hexutil.Uint{}
+	for _, currency := range currencies {
+		if decimals, err := tokenDecimals(ctx, b, currency); err == nil {
+			result[currency] = hexutil.Uint(decimals)
+		}
+	}
+	return result
+}
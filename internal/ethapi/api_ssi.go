@@ -3,6 +3,7 @@ package ethapi
 import (
 	"context"
 
+	"github.com/sero-cash/go-sero/zero/wallet/pending"
 	"github.com/sero-cash/go-sero/zero/wallet/ssi"
 
 	"github.com/sero-cash/go-sero/zero/txtool"
@@ -35,11 +36,22 @@ func (s *PublicSSIAPI) GetTx(ctx context.Context, txhash keys.Uint256) (tx *txto
 	return ssi.SSI_Inst.GetTx(txhash)
 }
 
+func (s *PublicSSIAPI) ListTx(ctx context.Context) ([]*pending.PendingTx, error) {
+	return ssi.SSI_Inst.ListTx()
+}
+
+func (s *PublicSSIAPI) CancelTx(ctx context.Context, txhash keys.Uint256) error {
+	return ssi.SSI_Inst.CancelTx(txhash)
+}
+
 func (s *PublicSSIAPI) CommitTx(ctx context.Context, txhash keys.Uint256) (e error) {
 	if tx, err := ssi.SSI_Inst.GetTx(txhash); err != nil {
 		e = err
 		return
 	} else {
-		return s.b.CommitTx(tx)
+		if e = s.b.CommitTx(tx); e != nil {
+			return
+		}
+		return ssi.SSI_Inst.CommittedTx(txhash)
 	}
 }
@@ -0,0 +1,24 @@
+package ethapi
+
+import (
+	"github.com/sero-cash/go-czero-import/keys"
+	"github.com/sero-cash/go-sero/zero/txtool"
+)
+
+// PublicWitnessAPI serves the anchors of the roots registered to the witness
+// service, clients of pruned nodes build their transactions with them.
+type PublicWitnessAPI struct {
+	b Backend
+}
+
+func (s *PublicWitnessAPI) Register(roots []keys.Uint256) error {
+	return s.b.RegisterWitness(roots)
+}
+
+func (s *PublicWitnessAPI) Unregister(roots []keys.Uint256) error {
+	return s.b.UnregisterWitness(roots)
+}
+
+func (s *PublicWitnessAPI) GetAnchor(roots []keys.Uint256) ([]txtool.Witness, error) {
+	return s.b.GetWitness(roots)
+}
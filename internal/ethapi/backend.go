@@ -35,6 +35,7 @@ import (
 	"github.com/sero-cash/go-sero/common"
 	"github.com/sero-cash/go-sero/consensus"
 	"github.com/sero-cash/go-sero/core"
+	"github.com/sero-cash/go-sero/core/assetindex"
 	"github.com/sero-cash/go-sero/core/state"
 	"github.com/sero-cash/go-sero/core/types"
 	"github.com/sero-cash/go-sero/core/vm"
@@ -55,6 +56,7 @@ type Backend interface {
 	PeerCount() uint
 	SuggestPrice(ctx context.Context) (*big.Int, error)
 	ChainDb() serodb.Database
+	AssetRegistry() *assetindex.Registry
 	EventMux() *event.TypeMux
 	AccountManager() *accounts.Manager
 
@@ -102,6 +104,11 @@ type Backend interface {
 	//Light node api
 	GetOutByPKr(pkrs []keys.PKr, start, end uint64) (br light.BlockOutResp, e error)
 	CheckNil(Nils []keys.Uint256) (nilResps []light.NilValue, e error)
+
+	//Witness api
+	RegisterWitness(roots []keys.Uint256) error
+	UnregisterWitness(roots []keys.Uint256) error
+	GetWitness(roots []keys.Uint256) ([]txtool.Witness, error)
 }
 
 func GetAPIs(apiBackend Backend) []rpc.API {
@@ -125,6 +132,12 @@ func GetAPIs(apiBackend Backend) []rpc.API {
 			Service:   &PublicLightNodeApi{apiBackend},
 			Public:    true,
 		},
+		{
+			Namespace: "witness",
+			Version:   "1.0",
+			Service:   &PublicWitnessAPI{apiBackend},
+			Public:    true,
+		},
 		{
 			Namespace: "ssi",
 			Version:   "1.0",
@@ -149,6 +162,12 @@ func GetAPIs(apiBackend Backend) []rpc.API {
 			Service:   &PublicExchangeAPI{apiBackend},
 			Public:    true,
 		},
+		{
+			Namespace: "registry",
+			Version:   "1.0",
+			Service:   &PublicRegistryAPI{apiBackend},
+			Public:    true,
+		},
 		{
 			Namespace: "sero",
 			Version:   "1.0",
@@ -33,9 +33,12 @@ var Modules = map[string]string{
 	"ssi":        SSI_JS,
 	"exchange":   Exchange_JS,
 	"light":      LightNode_JS,
+	"witness":    Witness_JS,
 	"stake":      Stake_JS,
 	"flight":     Flight_JS,
 	"local":      Local_JS,
+	"registry":   Registry_JS,
+	"ethash":     Ethash_JS,
 }
 
 const Chequebook_JS = `
@@ -411,6 +414,10 @@ web3._extend({
 			params: 2,
 			inputFormatter: [web3._extend.formatters.inputAddressFormatter, null]
 		}),
+		new web3._extend.Method({
+			name: 'stratumWorkers',
+			call: 'sero_stratumWorkers'
+		}),
 		new web3._extend.Method({
 			name: 'resend',
 			call: 'sero_resend',
@@ -429,6 +436,11 @@ web3._extend({
 			params: 1,
 			inputFormatter: [web3._extend.formatters.inputTransactionFormatter]
 		}),
+		new web3._extend.Method({
+			name: 'verifyTx',
+			call: 'sero_verifyTx',
+			params: 1
+		}),
         new web3._extend.Method({
 			name: 'getBlockTotalRewardByNumber',
 			call: 'sero_getBlockTotalRewardByNumber',
@@ -524,6 +536,21 @@ web3._extend({
 			name: 'getHashrate',
 			call: 'miner_getHashrate'
 		}),
+		new web3._extend.Method({
+			name: 'getBlockTemplate',
+			call: 'miner_getBlockTemplate'
+		}),
+		new web3._extend.Method({
+			name: 'submitBlock',
+			call: 'miner_submitBlock',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'history',
+			call: 'miner_history',
+			params: 2,
+			inputFormatter: [web3._extend.utils.fromDecimal, web3._extend.utils.fromDecimal]
+		}),
 	],
 	properties: []
 });
@@ -583,6 +610,29 @@ web3._extend({
 			call: 'personal_deriveAccount',
 			params: 3
 		}),
+		new web3._extend.Method({
+			name: 'newDerivedAccount',
+			call: 'personal_newDerivedAccount',
+			params: 3,
+			inputFormatter: [web3._extend.formatters.inputAddressFormatter, null, null]
+		}),
+		new web3._extend.Method({
+			name: 'scanDerivedAccounts',
+			call: 'personal_scanDerivedAccounts',
+			params: 3,
+			inputFormatter: [web3._extend.formatters.inputAddressFormatter, null, null]
+		}),
+		new web3._extend.Method({
+			name: 'exportViewBundle',
+			call: 'personal_exportViewBundle',
+			params: 3,
+			inputFormatter: [web3._extend.formatters.inputAddressFormatter, null, null]
+		}),
+		new web3._extend.Method({
+			name: 'importViewBundle',
+			call: 'personal_importViewBundle',
+			params: 1
+		}),
 		new web3._extend.Method({
 			name: 'signTransaction',
 			call: 'personal_signTransaction',
@@ -712,6 +762,16 @@ web3._extend({
 			call: 'ssi_getTx',
 			params: 1
 		}),
+		new web3._extend.Method({
+			name: 'listtx',
+			call: 'ssi_listTx',
+			params: 0
+		}),
+		new web3._extend.Method({
+			name: 'canceltx',
+			call: 'ssi_cancelTx',
+			params: 1
+		}),
 		new web3._extend.Method({
 			name: 'committx',
 			call: 'ssi_commitTx',
@@ -730,6 +790,11 @@ web3._extend({
 			call: 'exchange_getLockedBalances',
 			params: 1
 		}),
+		new web3._extend.Method({
+			name: 'getTokenBalances',
+			call: 'exchange_getTokenBalances',
+			params: 1
+		}),
 		new web3._extend.Method({
 			name: 'getMaxAvailable',
 			call: 'exchange_getMaxAvailable',
@@ -794,6 +859,16 @@ web3._extend({
 			name: 'signTxWithSk',
 			call: 'exchange_signTxWithSk',
             params: 2
+		}),
+		new web3._extend.Method({
+			name: 'getPkgEvents',
+			call: 'exchange_getPkgEvents',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'getPkgEventsByPk',
+			call: 'exchange_getPkgEventsByPk',
+			params: 3
 		})
 	]
 });
@@ -908,6 +983,29 @@ web3._extend({
 });
 `
 
+const Witness_JS = `
+web3._extend({
+	property: 'witness',
+	methods: [
+		new web3._extend.Method({
+			name: 'register',
+			call: 'witness_register',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'unregister',
+			call: 'witness_unregister',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'getAnchor',
+			call: 'witness_getAnchor',
+			params: 1
+		}),
+	]
+});
+`
+
 const Flight_JS = `
 web3._extend({
 	property: 'flight',
@@ -951,6 +1049,31 @@ web3._extend({
 			name: 'getTxReceipt',
 			call: 'flight_getTxReceipt',
 			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'genTx',
+			call: 'flight_genTx',
+			params: 2
+		}),
+		new web3._extend.Method({
+			name: 'getPendingTx',
+			call: 'flight_getPendingTx',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'listPendingTx',
+			call: 'flight_listPendingTx',
+			params: 0
+		}),
+		new web3._extend.Method({
+			name: 'cancelPendingTx',
+			call: 'flight_cancelPendingTx',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'commitPendingTx',
+			call: 'flight_commitPendingTx',
+			params: 1
 		})
 	]
 });
@@ -1023,3 +1146,60 @@ web3._extend({
 	]
 });
 `
+
+const Registry_JS = `
+web3._extend({
+	property: 'registry',
+	methods: [
+		new web3._extend.Method({
+			name: 'tokens',
+			call: 'registry_tokens',
+			params: 2
+		}),
+		new web3._extend.Method({
+			name: 'tickets',
+			call: 'registry_tickets',
+			params: 2
+		}),
+		new web3._extend.Method({
+			name: 'getToken',
+			call: 'registry_getToken',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'getTicket',
+			call: 'registry_getTicket',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'getByContract',
+			call: 'registry_getByContract',
+			params: 1
+		})
+	],
+	properties: [
+		new web3._extend.Property({
+			name: 'counts',
+			getter: 'registry_counts'
+		})
+	]
+});
+`
+
+const Ethash_JS = `
+web3._extend({
+	property: 'ethash',
+	methods: [
+		new web3._extend.Method({
+			name: 'verifyDags',
+			call: 'ethash_verifyDags'
+		}),
+	],
+	properties: [
+		new web3._extend.Property({
+			name: 'dagStatus',
+			getter: 'ethash_dagStatus'
+		})
+	]
+});
+`
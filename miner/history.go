@@ -0,0 +1,236 @@
+package miner
+
+import (
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/sero-cash/go-sero/common"
+	"github.com/sero-cash/go-sero/consensus/ethash"
+	"github.com/sero-cash/go-sero/core"
+	"github.com/sero-cash/go-sero/core/types"
+	"github.com/sero-cash/go-sero/log"
+	"github.com/sero-cash/go-sero/params"
+	"github.com/sero-cash/go-sero/rlp"
+	"github.com/sero-cash/go-sero/serodb"
+	"github.com/sero-cash/go-sero/zero/stake"
+	"github.com/sero-cash/go-sero/zero/utils"
+)
+
+// maxHistoryRange is the largest range of numbers of a history query.
+const maxHistoryRange = 100000
+
+var minedPrefix = []byte("MINER$MINED$")
+
+// MinedStatus is the outcome of a locally mined block.
+type MinedStatus uint8
+
+const (
+	MinedPending   MinedStatus = iota // Not deep enough to be confirmed yet
+	MinedCanonical                    // Reached the canonical chain
+	MinedOrphaned                     // Became a side fork or its parent was replaced
+	MinedLostVotes                    // Dropped by the lottery for missing votes
+	MinedFailed                       // Dropped by the lottery failing to select the shares or stopped
+)
+
+func (self MinedStatus) String() string {
+	switch self {
+	case MinedPending:
+		return "pending"
+	case MinedCanonical:
+		return "canonical"
+	case MinedOrphaned:
+		return "orphaned"
+	case MinedLostVotes:
+		return "lostVotes"
+	case MinedFailed:
+		return "failed"
+	default:
+		return fmt.Sprintf("unknown(%d)", int(self))
+	}
+}
+
+// MinedBlock is the record of a locally mined block, the rewards are those of
+// the block were it canonical.
+type MinedBlock struct {
+	Number      uint64
+	Hash        common.Hash // Empty if the block was never written
+	PosHash     common.Hash
+	Time        uint64
+	PowReward   *big.Int // Reward of the coinbase, fees excluded
+	Fees        *big.Int
+	SoloReward  *big.Int // Stake reward of a solo vote, see StakeCurrentReward
+	PoolReward  *big.Int // Stake reward of a pool vote, see StakeCurrentReward
+	StakeReward *big.Int // Stake reward of the votes of the block
+	Waited      bool     // Whether the consensus required the votes
+	Received    uint32   // Votes received by the lottery
+	Votes       uint32   // Votes of the block included
+	ParentVotes uint32   // Votes of the parent included
+	Status      MinedStatus
+}
+
+// minedHistory keeps the records of the locally mined blocks in the chain
+// database, indexed by number. The status of the written blocks not confirmed
+// before a restart is derived from the canonical chain once they are deep
+// enough.
+type minedHistory struct {
+	db     serodb.Database
+	chain  *core.BlockChain
+	config *params.ChainConfig
+	depth  uint64
+	mu     sync.Mutex
+}
+
+func newMinedHistory(db serodb.Database, chain *core.BlockChain, config *params.ChainConfig, depth uint64) *minedHistory {
+	return &minedHistory{db: db, chain: chain, config: config, depth: depth}
+}
+
+func minedKey(number uint64) []byte {
+	return append(append([]byte{}, minedPrefix...), utils.EncodeNumber(number)...)
+}
+
+func (self *minedHistory) get(number uint64) (records []*MinedBlock) {
+	data, err := self.db.Get(minedKey(number))
+	if err != nil {
+		return nil
+	}
+	if err := rlp.DecodeBytes(data, &records); err != nil {
+		log.Error("Invalid mined block records", "number", number, "err", err)
+		return nil
+	}
+	return
+}
+
+func (self *minedHistory) put(number uint64, records []*MinedBlock) {
+	data, err := rlp.EncodeToBytes(records)
+	if err != nil {
+		log.Error("Failed to encode mined block records", "number", number, "err", err)
+		return
+	}
+	if err := self.db.Put(minedKey(number), data); err != nil {
+		log.Error("Failed to write mined block records", "number", number, "err", err)
+	}
+}
+
+// record adds the record of a block mined on the state of work, stats are the
+// decisions of its lottery if it had one.
+func (self *minedHistory) record(work *Work, block *types.Block, stats *lotteryStats, status MinedStatus) {
+	header := block.Header()
+	rec := &MinedBlock{
+		Number:      block.NumberU64(),
+		PosHash:     header.HashPos(),
+		Time:        header.Time.Uint64(),
+		PowReward:   self.powReward(header),
+		Fees:        new(big.Int).SetUint64(work.gasReward),
+		SoloReward:  new(big.Int),
+		PoolReward:  new(big.Int),
+		StakeReward: new(big.Int),
+		Votes:       uint32(len(header.CurrentVotes)),
+		ParentVotes: uint32(len(header.ParentVotes)),
+		Status:      status,
+	}
+	if status != MinedLostVotes && status != MinedOrphaned {
+		rec.Hash = block.Hash()
+	}
+	if stats != nil {
+		rec.Waited = stats.waited
+		rec.Received = uint32(stats.received)
+	}
+	if len(header.CurrentVotes) > 0 || len(header.ParentVotes) > 0 {
+		rec.SoloReward, rec.PoolReward = stake.NewStakeState(work.state.Copy()).StakeCurrentReward(block.Number())
+		for _, vote := range header.CurrentVotes {
+			rec.StakeReward.Add(rec.StakeReward, voteReward(vote, rec.SoloReward, rec.PoolReward))
+		}
+		// The votes of the parent are rewarded two thirds
+		for _, vote := range header.ParentVotes {
+			reward := voteReward(vote, rec.SoloReward, rec.PoolReward)
+			rec.StakeReward.Add(rec.StakeReward, reward.Sub(reward, new(big.Int).Div(reward, big.NewInt(3))))
+		}
+	}
+
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	self.put(rec.Number, append(self.get(rec.Number), rec))
+}
+
+// powReward returns the proof of work reward of a block from the state of its
+// parent.
+func (self *minedHistory) powReward(header *types.Header) *big.Int {
+	parent := self.chain.GetHeader(header.ParentHash, header.Number.Uint64()-1)
+	if parent == nil {
+		log.Warn("Unknown parent of mined block", "number", header.Number, "parent", header.ParentHash)
+		return new(big.Int)
+	}
+	statedb, err := self.chain.StateAt(parent)
+	if err != nil {
+		log.Warn("Missing state of mined block parent", "number", header.Number, "parent", header.ParentHash, "err", err)
+		return new(big.Int)
+	}
+	return ethash.BlockReward(self.config, statedb, header)
+}
+
+func voteReward(vote types.HeaderVote, solo, pool *big.Int) *big.Int {
+	if vote.IsPool {
+		return new(big.Int).Set(pool)
+	}
+	return new(big.Int).Set(solo)
+}
+
+// confirm sets the status of a written block once it is deep enough.
+func (self *minedHistory) confirm(number uint64, hash common.Hash, canonical bool) {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+
+	records := self.get(number)
+	for _, rec := range records {
+		if rec.Hash == hash {
+			if canonical {
+				rec.Status = MinedCanonical
+			} else {
+				rec.Status = MinedOrphaned
+			}
+			self.put(number, records)
+			return
+		}
+	}
+}
+
+// derive sets the status of the pending written records of a number deep
+// enough from the canonical chain, it reports whether one was set.
+func (self *minedHistory) derive(number uint64, records []*MinedBlock) (changed bool) {
+	for _, rec := range records {
+		if rec.Status != MinedPending || rec.Hash == (common.Hash{}) {
+			continue
+		}
+		if header := self.chain.GetHeaderByNumber(number); header != nil && header.Hash() == rec.Hash {
+			rec.Status = MinedCanonical
+		} else {
+			rec.Status = MinedOrphaned
+		}
+		changed = true
+	}
+	return
+}
+
+// list returns the records of the blocks mined between from and to included.
+func (self *minedHistory) list(from, to uint64) ([]*MinedBlock, error) {
+	if to < from {
+		return nil, fmt.Errorf("invalid range %d-%d", from, to)
+	}
+	if to-from >= maxHistoryRange {
+		return nil, fmt.Errorf("range %d-%d exceeds %d blocks", from, to, maxHistoryRange)
+	}
+	self.mu.Lock()
+	defer self.mu.Unlock()
+
+	head := self.chain.CurrentHeader().Number.Uint64()
+	records := []*MinedBlock{}
+	for number := from; number <= to; number++ {
+		recs := self.get(number)
+		if number+self.depth <= head && self.derive(number, recs) {
+			self.put(number, recs)
+		}
+		records = append(records, recs...)
+	}
+	return records, nil
+}
@@ -0,0 +1,54 @@
+package miner
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/sero-cash/go-sero/common"
+	"github.com/sero-cash/go-sero/consensus/ethash"
+	"github.com/sero-cash/go-sero/core"
+	"github.com/sero-cash/go-sero/core/state"
+	"github.com/sero-cash/go-sero/params"
+)
+
+// TestHistoryStatus checks that the records left pending by a restart get the
+// status of their block once it is deep enough.
+func TestHistoryStatus(t *testing.T) {
+	w, genesis, closeWorker := newTestWorker(t)
+	defer closeWorker()
+	blocks, _ := core.GenerateChain(params.TestChainConfig, genesis, ethash.NewFaker(), w.chainDb, 7, nil)
+	if _, err := w.chain.InsertChain(blocks); err != nil {
+		t.Fatal(err)
+	}
+
+	history := newMinedHistory(w.chainDb, w.chain, params.TestChainConfig, miningLogAtDepth)
+	history.record(&Work{}, blocks[0], nil, MinedPending)
+	history.put(1, append(history.get(1), &MinedBlock{Number: 1, Hash: common.Hash{1}, Status: MinedPending}))
+	history.record(&Work{}, blocks[1], nil, MinedPending)
+	history.record(&Work{}, blocks[4], nil, MinedPending)
+
+	// The reward is the one taken from the reward pool of the first blocks
+	parent, _ := w.chain.StateAt(genesis.Header())
+	statedb, _ := w.chain.StateAt(blocks[0].Header())
+	paid := new(big.Int).Sub(parent.GetBalance(state.EmptyAddress, "SERO"), statedb.GetBalance(state.EmptyAddress, "SERO"))
+	if rec := history.get(1)[0]; rec.Hash != blocks[0].Hash() || rec.PowReward.Cmp(paid) != 0 || paid.Sign() == 0 {
+		t.Fatalf("record mismatch: hash %x, reward %v, want %v", rec.Hash, rec.PowReward, paid)
+	}
+	records, err := history.list(1, 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []MinedStatus{MinedCanonical, MinedOrphaned, MinedCanonical, MinedPending}
+	if len(records) != len(want) {
+		t.Fatalf("%d records, want %d", len(records), len(want))
+	}
+	for i, rec := range records {
+		if rec.Status != want[i] {
+			t.Errorf("record %d of block %d: status %v, want %v", i, rec.Number, rec.Status, want[i])
+		}
+	}
+	// The derived status is kept
+	if rec := history.get(2)[0]; rec.Status != MinedCanonical {
+		t.Fatalf("derived status not written: %v", rec.Status)
+	}
+}
@@ -1,19 +1,104 @@
 package miner
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/sero-cash/go-czero-import/keys"
 
 	"github.com/sero-cash/go-sero/log"
+	"github.com/sero-cash/go-sero/metrics"
 	"github.com/sero-cash/go-sero/zero/utils"
 
 	"github.com/sero-cash/go-sero/common"
+	"github.com/sero-cash/go-sero/core"
 	"github.com/sero-cash/go-sero/core/state"
 	"github.com/sero-cash/go-sero/core/types"
 	"github.com/sero-cash/go-sero/zero/stake"
 )
 
+// lotteryOptionalWait is how long the votes of a block which doesn't require
+// them are waited for.
+const lotteryOptionalWait = time.Second
+
+var (
+	lotteryWaitedCounter  = metrics.NewRegisteredCounter("miner/lottery/waited", nil)
+	lotteryTimeoutCounter = metrics.NewRegisteredCounter("miner/lottery/timeout", nil)
+	lotteryReceivedMeter  = metrics.NewRegisteredMeter("miner/lottery/received", nil)
+	lotteryIncludedMeter  = metrics.NewRegisteredMeter("miner/lottery/included", nil)
+	lotteryWaitTimer      = metrics.NewRegisteredTimer("miner/lottery/wait", nil)
+)
+
+// LotteryFallback is what the lotter does with a block still missing votes at
+// the deadline.
+type LotteryFallback int
+
+const (
+	FallbackDrop    LotteryFallback = iota // Drop the block
+	FallbackMinimum                        // Seal the block if it has the votes required by the consensus
+)
+
+func (self LotteryFallback) String() string {
+	switch self {
+	case FallbackDrop:
+		return "drop"
+	case FallbackMinimum:
+		return "minimum"
+	default:
+		return fmt.Sprintf("unknown(%d)", int(self))
+	}
+}
+
+func ParseLotteryFallback(s string) (LotteryFallback, error) {
+	switch s {
+	case "drop":
+		return FallbackDrop, nil
+	case "minimum":
+		return FallbackMinimum, nil
+	default:
+		return FallbackDrop, fmt.Errorf("unknown lottery fallback %q, want drop or minimum", s)
+	}
+}
+
+// LotteryConfig are the settings of the collection of the votes of the mined
+// blocks.
+type LotteryConfig struct {
+	Deadline time.Duration   // Longest wait for the votes of a block
+	Fallback LotteryFallback // What is done with a block missing votes at the deadline
+}
+
+var DefaultLotteryConfig = LotteryConfig{
+	Deadline: 5 * time.Minute,
+	Fallback: FallbackDrop,
+}
+
+// lotteryStats are the decisions of a lotter, they are logged with the mined
+// block.
+type lotteryStats struct {
+	waited         bool // Whether the consensus required the votes
+	received       int  // Votes received for the block
+	timeout        bool // Whether the deadline passed
+	replaced       bool // Whether the parent was replaced
+	included       int  // Votes of the block included
+	parentIncluded int  // Votes of the parent included
+	elapsed        time.Duration
+}
+
+// logCtx returns the stats as log context, none if the block had no lottery.
+func (self *lotteryStats) logCtx() []interface{} {
+	if self == nil {
+		return nil
+	}
+	return []interface{}{
+		"waited", self.waited,
+		"received", self.received,
+		"timeout", self.timeout,
+		"votes", self.included,
+		"parentVotes", self.parentIncluded,
+		"lottery", common.PrettyDuration(self.elapsed),
+	}
+}
+
 type Lotter struct {
 	worker *worker
 	state  *state.StateDB
@@ -23,6 +108,8 @@ type Lotter struct {
 	lottery            types.Lottery
 	currentHeaderVotes []types.HeaderVote
 	parentHeaderVotes  []types.HeaderVote
+
+	stats lotteryStats
 }
 
 func newLotter(worker *worker, block *types.Block, db *state.StateDB) (ret Lotter) {
@@ -128,10 +215,15 @@ func (self *Lotter) wait() bool {
 	if !needWait {
 		log.Info("not need pos")
 	}
+	config := self.worker.lotteryConfig()
 
 	parentBlock := self.worker.chain.GetBlockByHash(self.block.ParentHash())
 
 	startTime := time.Now()
+	defer func() {
+		self.stats.elapsed = time.Since(startTime)
+		lotteryWaitTimer.Update(self.stats.elapsed)
+	}()
 
 	idx, shares, err := self.stake.SeleteShare(self.block.HashPos())
 	if err != nil {
@@ -139,43 +231,41 @@ func (self *Lotter) wait() bool {
 		return false
 	}
 	filter := NewVotesFilter(self.stake, idx, shares, self.block, parentBlock)
-	count := 0
-	for {
-		currentHeader := self.worker.chain.CurrentHeader()
-		if currentHeader.Hash() != self.block.ParentHash() {
-			return false
-		}
 
-		if time.Since(startTime) > 5*time.Minute {
-			return false
+	key := voteKey{self.block.NumberU64(), self.block.HashPos()}
+	want := stake.MaxVoteCount
+	if dev, ok := self.worker.engine.(devEngine); ok {
+		for _, vote := range dev.Votes(self.stake, self.block, parentBlock, idx, shares) {
+			self.worker.pendingVote.add(vote)
 		}
-
-		if count == 0 {
-			time.Sleep(1 * time.Second)
-		} else {
-			time.Sleep(100 * time.Millisecond)
+		// On the dev network no more votes than the selected shares arrive
+		if len(filter.filters) < want {
+			want = len(filter.filters)
 		}
-		count++
+	}
+	headCh := make(chan core.ChainHeadEvent, chainHeadChanSize)
+	headSub := self.worker.chain.SubscribeChainHeadEvent(headCh)
+	defer headSub.Unsubscribe()
 
-		key := voteKey{self.block.NumberU64(), self.block.HashPos()}
+	self.stats.waited = needWait
+	if needWait {
+		lotteryWaitedCounter.Inc(1)
+	}
+	collect := func() int {
 		votes := self.worker.pendingVote.getMyPending(key)
-
 		dels := filter.RunFilter(votes)
 		self.worker.pendingVote.deleteVotes(key, dels)
-
-		sels := filter.result()
-		if len(sels) > 2 || !needWait {
-			break
-		}
+		self.stats.received += len(dels)
+		lotteryReceivedMeter.Mark(int64(len(dels)))
+		return len(filter.result())
+	}
+	if self.worker.chain.CurrentHeader().Hash() != self.block.ParentHash() {
+		self.stats.replaced = true
+		return false
+	}
+	if !self.collectVotes(collect, want, needWait, config, headCh, headSub.Err()) {
+		return false
 	}
-
-	parentVoteKey := voteKey{parentBlock.NumberU64(), parentBlock.HashPos()}
-	parentVoteSet := self.worker.pendingVote.getMyPending(parentVoteKey)
-
-	pidx, pshares := stake.SeleteBlockShare(self.worker.chain.GetDB(), parentBlock.Hash())
-	ppBlock := self.worker.chain.GetBlockByHash(parentBlock.ParentHash())
-	parentfilter := NewVotesFilter(self.stake, pidx, pshares, parentBlock, ppBlock)
-	parentfilter.RunFilter(parentVoteSet)
 
 	voteNumMap := map[keys.Uint512]bool{}
 	for _, vote := range filter.result() {
@@ -185,12 +275,75 @@ func (self *Lotter) wait() bool {
 		} else {
 			voteNumMap[vote.Sign]=true
 			self.currentHeaderVotes = append(self.currentHeaderVotes, types.HeaderVote{vote.ShareId, vote.IsPool, vote.Sign})
-			if len(self.currentHeaderVotes) == 3 {
+			if len(self.currentHeaderVotes) == stake.MaxVoteCount {
 				break
 			}
 		}
 	}
 
+	self.parentHeaderVotes = self.worker.parentVotes(self.stake, parentBlock)
+	self.stats.included = len(self.currentHeaderVotes)
+	self.stats.parentIncluded = len(self.parentHeaderVotes)
+	lotteryIncludedMeter.Mark(int64(self.stats.included + self.stats.parentIncluded))
+	return true
+}
+
+// collectVotes waits until collect, returning the number of the votes of the
+// block selected so far, reaches want. The votes aren't required by the
+// consensus without needWait but blocks with more votes win the forks, they are
+// waited for a little while. Otherwise they are waited for up to the deadline of
+// the config, whose fallback then tells whether the block is sealed. The wait
+// ends with a failure once the parent of the block isn't the head anymore.
+func (self *Lotter) collectVotes(collect func() int, want int, needWait bool, config LotteryConfig, heads <-chan core.ChainHeadEvent, headErr <-chan error) bool {
+	key := voteKey{self.block.NumberU64(), self.block.HashPos()}
+	voteCh, unsubscribe := self.worker.pendingVote.subscribe(key)
+	defer unsubscribe()
+
+	deadline := config.Deadline
+	if !needWait {
+		deadline = lotteryOptionalWait
+	}
+	timer := time.NewTimer(deadline)
+	defer timer.Stop()
+
+	for count := collect(); count < want; count = collect() {
+		select {
+		case <-voteCh:
+		case ev := <-heads:
+			if ev.Block.Hash() != self.block.ParentHash() {
+				log.Debug("Lotter parent replaced", "block", self.block.NumberU64(), "head", ev.Block.Hash())
+				self.stats.replaced = true
+				return false
+			}
+		case <-timer.C:
+			if !needWait {
+				return true
+			}
+			self.stats.timeout = true
+			lotteryTimeoutCounter.Inc(1)
+			if config.Fallback == FallbackMinimum && count >= stake.ValidVoteCount {
+				return true
+			}
+			log.Info("Lotter dropped the block", "block", self.block.NumberU64(), "poshash", self.block.HashPos(), "votes", count, "fallback", config.Fallback)
+			return false
+		case <-headErr:
+			return false
+		}
+	}
+	return true
+}
+
+// parentVotes returns the pending votes of the parent block which are missing
+// from its header.
+func (self *worker) parentVotes(state *stake.StakeState, parentBlock *types.Block) (ret []types.HeaderVote) {
+	parentVoteKey := voteKey{parentBlock.NumberU64(), parentBlock.HashPos()}
+	parentVoteSet := self.pendingVote.getMyPending(parentVoteKey)
+
+	pidx, pshares := stake.SeleteBlockShare(self.chain.GetDB(), parentBlock.Hash())
+	ppBlock := self.chain.GetBlockByHash(parentBlock.ParentHash())
+	parentfilter := NewVotesFilter(state, pidx, pshares, parentBlock, ppBlock)
+	parentfilter.RunFilter(parentVoteSet)
+
 	parentVotes := parentfilter.result()
 	if len(parentVotes) > 0 {
 		//log.Info("parentVotes", "block", self.block.NumberU64(), "voteIds", pidx)
@@ -212,11 +365,11 @@ func (self *Lotter) wait() bool {
 				continue
 			} else {
 				//log.Info("pos parentVotes", "posHash", vote.PosHash, "block", vote.ParentNum+1, "share", vote.ShareId, "idx", vote.Idx)
-				self.parentHeaderVotes = append(self.parentHeaderVotes, types.HeaderVote{vote.ShareId, vote.IsPool, vote.Sign})
+				ret = append(ret, types.HeaderVote{vote.ShareId, vote.IsPool, vote.Sign})
 				voteMap[vote.Sign] = true
 				voteNumMap[vote.ShareId] -= 1
 			}
 		}
 	}
-	return true
+	return
 }
@@ -0,0 +1,112 @@
+package miner
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/sero-cash/go-czero-import/keys"
+	"github.com/sero-cash/go-sero/common"
+	"github.com/sero-cash/go-sero/core"
+	"github.com/sero-cash/go-sero/core/types"
+	"github.com/sero-cash/go-sero/zero/stake"
+)
+
+// newTestLotter returns a lotter of a block of number 5, along with a collect
+// counting the shares it got votes of.
+func newTestLotter() (*Lotter, func() int) {
+	block := types.NewBlockWithHeader(&types.Header{Number: big.NewInt(5), ParentHash: common.Hash{1}})
+	lotter := &Lotter{worker: &worker{pendingVote: newPendingVote()}, block: block}
+	key := voteKey{block.NumberU64(), block.HashPos()}
+	return lotter, func() int {
+		return len(lotter.worker.pendingVote.getMyPending(key))
+	}
+}
+
+func addTestVote(lotter *Lotter, share byte) {
+	lotter.worker.pendingVote.add(&types.Vote{
+		ParentNum: lotter.block.NumberU64() - 1,
+		PosHash:   lotter.block.HashPos(),
+		ShareId:   common.Hash{share},
+		Sign:      keys.Uint512{share},
+	})
+}
+
+// collectTestVotes collects the votes required by the consensus, returning
+// whether the block is sealed.
+func collectTestVotes(lotter *Lotter, collect func() int, config LotteryConfig, heads <-chan core.ChainHeadEvent) bool {
+	return lotter.collectVotes(collect, stake.MaxVoteCount, true, config, heads, make(chan error))
+}
+
+func TestLotteryVotes(t *testing.T) {
+	lotter, collect := newTestLotter()
+	go func() {
+		for i := byte(1); i <= stake.MaxVoteCount; i++ {
+			time.Sleep(10 * time.Millisecond)
+			addTestVote(lotter, i)
+		}
+	}()
+	config := LotteryConfig{Deadline: time.Minute, Fallback: FallbackDrop}
+	if !collectTestVotes(lotter, collect, config, nil) {
+		t.Fatalf("block with all its votes dropped")
+	}
+	if lotter.stats.timeout || collect() != stake.MaxVoteCount {
+		t.Errorf("votes collected: %d, timeout %v", collect(), lotter.stats.timeout)
+	}
+}
+
+func TestLotteryDeadline(t *testing.T) {
+	tests := []struct {
+		fallback LotteryFallback
+		votes    byte
+		sealed   bool
+	}{
+		{FallbackDrop, stake.ValidVoteCount, false},
+		{FallbackMinimum, stake.ValidVoteCount, true},
+		{FallbackMinimum, stake.ValidVoteCount - 1, false},
+	}
+	for _, tt := range tests {
+		lotter, collect := newTestLotter()
+		for i := byte(1); i <= tt.votes; i++ {
+			addTestVote(lotter, i)
+		}
+		config := LotteryConfig{Deadline: 20 * time.Millisecond, Fallback: tt.fallback}
+		if sealed := collectTestVotes(lotter, collect, config, nil); sealed != tt.sealed {
+			t.Errorf("%v fallback with %d votes: sealed %v, want %v", tt.fallback, tt.votes, sealed, tt.sealed)
+		}
+		if !lotter.stats.timeout {
+			t.Errorf("%v fallback with %d votes: deadline not reported", tt.fallback, tt.votes)
+		}
+	}
+}
+
+// TestLotterySuperseded checks that the lotter stops waiting once another block
+// supersedes the parent of its block, releasing its subscription.
+func TestLotterySuperseded(t *testing.T) {
+	lotter, collect := newTestLotter()
+	heads := make(chan core.ChainHeadEvent)
+	done := make(chan bool)
+	go func() {
+		done <- collectTestVotes(lotter, collect, LotteryConfig{Deadline: time.Minute, Fallback: FallbackMinimum}, heads)
+	}()
+	head := types.NewBlockWithHeader(&types.Header{Number: big.NewInt(4), ParentHash: common.Hash{2}})
+	select {
+	case heads <- core.ChainHeadEvent{Block: head}:
+	case <-time.After(time.Second):
+		t.Fatalf("lotter not waiting for the head")
+	}
+	select {
+	case sealed := <-done:
+		if sealed || !lotter.stats.replaced {
+			t.Errorf("superseded block: sealed %v, replaced %v", sealed, lotter.stats.replaced)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("lotter still waiting for the votes of a superseded block")
+	}
+	pending := &lotter.worker.pendingVote
+	pending.pendingVoteMu.RLock()
+	defer pending.pendingVoteMu.RUnlock()
+	if len(pending.waiters) != 0 {
+		t.Errorf("%d waiters left", len(pending.waiters))
+	}
+}
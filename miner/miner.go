@@ -193,6 +193,32 @@ func (self *Miner) SetExtra(extra []byte) error {
 	return nil
 }
 
+// SetLottery sets how the votes of the mined blocks are collected.
+func (self *Miner) SetLottery(config LotteryConfig) {
+	if config.Deadline <= 0 {
+		log.Warn("Sanitizing invalid lottery deadline", "provided", config.Deadline, "updated", DefaultLotteryConfig.Deadline)
+		config.Deadline = DefaultLotteryConfig.Deadline
+	}
+	self.worker.setLottery(config)
+}
+
+// BlockTemplate returns the candidate block of the worker for the block
+// builders, the miner has to be started.
+func (self *Miner) BlockTemplate() (*BlockTemplate, error) {
+	return self.worker.blockTemplate()
+}
+
+// SubmitBlock inserts a block assembled by a block builder.
+func (self *Miner) SubmitBlock(block *types.Block) error {
+	return self.worker.submitBlock(block)
+}
+
+// History returns the records of the blocks mined between from and to
+// included.
+func (self *Miner) History(from, to uint64) ([]*MinedBlock, error) {
+	return self.worker.history.list(from, to)
+}
+
 // Pending returns the currently pending block and associated state.
 func (self *Miner) Pending() (*types.Block, *state.StateDB) {
 	return self.worker.pending()
@@ -35,13 +35,37 @@ func (self voteSet) copy() (ret voteSet) {
 type pendingVote struct {
 	pendingVoteMu sync.RWMutex
 	pendingVote   map[voteKey]voteSet
+	waiters       map[voteKey]map[chan struct{}]struct{}
 }
 
 func newPendingVote() (ret pendingVote) {
 	ret.pendingVote = make(map[voteKey]voteSet)
+	ret.waiters = make(map[voteKey]map[chan struct{}]struct{})
 	return ret
 }
 
+// subscribe returns a channel signaled when votes of key arrive, the signals of
+// the votes arriving together are merged.
+func (self *pendingVote) subscribe(key voteKey) (ch chan struct{}, unsubscribe func()) {
+	self.pendingVoteMu.Lock()
+	defer self.pendingVoteMu.Unlock()
+
+	ch = make(chan struct{}, 1)
+	if _, ok := self.waiters[key]; !ok {
+		self.waiters[key] = make(map[chan struct{}]struct{})
+	}
+	self.waiters[key][ch] = struct{}{}
+	unsubscribe = func() {
+		self.pendingVoteMu.Lock()
+		defer self.pendingVoteMu.Unlock()
+		delete(self.waiters[key], ch)
+		if len(self.waiters[key]) == 0 {
+			delete(self.waiters, key)
+		}
+	}
+	return
+}
+
 func (self *pendingVote) add(vote *types.Vote) {
 	self.pendingVoteMu.Lock()
 	defer self.pendingVoteMu.Unlock()
@@ -64,6 +88,13 @@ func (self *pendingVote) add(vote *types.Vote) {
 		ss = vs[vote.ShareId]
 	}
 	ss[vote.Sign] = *vote
+
+	for ch := range self.waiters[key] {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
 }
 
 func (self *pendingVote) deleteVotes(key voteKey, votes []types.Vote) {
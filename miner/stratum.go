@@ -0,0 +1,639 @@
+package miner
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sero-cash/go-sero/common"
+	"github.com/sero-cash/go-sero/common/hexutil"
+	"github.com/sero-cash/go-sero/consensus"
+	"github.com/sero-cash/go-sero/consensus/ethash"
+	"github.com/sero-cash/go-sero/core/types"
+	"github.com/sero-cash/go-sero/log"
+)
+
+const (
+	stratumVersion = "EthereumStratum/1.0.0"
+
+	// extranonceSize is the number of leading nonce bytes set by the server,
+	// the miners of a session search the remaining ones.
+	extranonceSize = 2
+
+	stratumMaxLine      = 4096
+	stratumReadTimeout  = 10 * time.Minute
+	stratumWriteTimeout = 10 * time.Second
+	stratumQueue        = 32 // Messages queued to a session before it is dropped as stalled
+	stratumJobsKept     = 8
+	stratumRateWindow   = 10 * time.Minute
+	stratumRetarget     = 8 // Number of shares between two retargets of a worker
+)
+
+// DefaultStratumShareTime is the share interval the worker difficulties are
+// retargeted to by default.
+const DefaultStratumShareTime = 10 * time.Second
+
+var (
+	errStratumUnsubscribed = errors.New("not subscribed")
+	errStratumUnauthorized = errors.New("not authorized")
+	errStratumParams       = errors.New("invalid params")
+	errStratumJob          = errors.New("job not found")
+	errStratumDuplicate    = errors.New("duplicate share")
+	errStratumFull         = errors.New("no extranonce left")
+)
+
+// StratumConfig are the settings of the stratum server.
+type StratumConfig struct {
+	Addr       string        // TCP address the server listens on
+	Difficulty *big.Int      // Initial share difficulty of the workers
+	ShareTime  time.Duration // Share interval the worker difficulties are retargeted to, 0 keeps them
+}
+
+// shareVerifier is implemented by the engines able to check a proof-of-work
+// against a share difficulty, see ethash.VerifyShare.
+type shareVerifier interface {
+	VerifyShare(header *types.Header, difficulty *big.Int) (digest common.Hash, block bool, err error)
+}
+
+type stratumJob struct {
+	id     string
+	work   *Work
+	shares map[uint64]struct{}
+}
+
+type workerRate struct {
+	times []time.Time
+	diffs []*big.Int
+}
+
+func (self *workerRate) add(diff *big.Int) {
+	self.times = append(self.times, time.Now())
+	self.diffs = append(self.diffs, diff)
+}
+
+func (self *workerRate) rate() uint64 {
+	for len(self.times) > 0 && time.Since(self.times[0]) > stratumRateWindow {
+		self.times, self.diffs = self.times[1:], self.diffs[1:]
+	}
+	sum := new(big.Int)
+	for _, diff := range self.diffs {
+		sum.Add(sum, diff)
+	}
+	return sum.Div(sum, big.NewInt(int64(stratumRateWindow/time.Second))).Uint64()
+}
+
+// Stratum is a mining agent serving the work to pool miners over TCP with the
+// EthereumStratum/1.0 protocol, adapted to ProgPoW:
+//
+//	mining.subscribe                 -> [["mining.notify", session, "EthereumStratum/1.0.0"], extranonce]
+//	mining.authorize [worker, pass]  -> true
+//	mining.suggest_difficulty [diff] -> true
+//	mining.submit [worker, job, nonce] -> true, nonce is the hex of the bytes after the extranonce
+//	mining.set_difficulty [diff]                                 (notification)
+//	mining.notify [job, seedHash, powHash, clean, blockNumber]   (notification)
+//
+// Difficulties are absolute, the PoW value of a share of difficulty d is at
+// most 2^256/d. The block number is sent since ProgPoW depends on its period.
+type Stratum struct {
+	config   StratumConfig
+	verifier shareVerifier
+
+	mu       sync.Mutex
+	listener net.Listener
+	sessions map[*stratumSession]struct{}
+	jobs     map[string]*stratumJob
+	jobIds   []string
+	current  *stratumJob
+	jobSeq   uint64
+	nonceSeq uint16
+	nonces   map[uint16]struct{} // Extranonces of the sessions
+
+	quitCh   chan struct{}
+	workCh   chan *Work
+	returnCh chan<- *Result
+
+	hashrateMu sync.Mutex
+	hashrate   map[string]*workerRate
+
+	running int32 // running indicates whether the agent is active. Call atomically
+}
+
+func NewStratum(engine consensus.Engine, config StratumConfig) (*Stratum, error) {
+	verifier, ok := engine.(shareVerifier)
+	if !ok {
+		return nil, errors.New("consensus engine can't verify shares")
+	}
+	if config.Difficulty == nil || config.Difficulty.Sign() <= 0 {
+		return nil, fmt.Errorf("invalid share difficulty %v", config.Difficulty)
+	}
+	return &Stratum{
+		config:   config,
+		verifier: verifier,
+		sessions: make(map[*stratumSession]struct{}),
+		nonces:   make(map[uint16]struct{}),
+		jobs:     make(map[string]*stratumJob),
+		hashrate: make(map[string]*workerRate),
+	}, nil
+}
+
+func (self *Stratum) Work() chan<- *Work {
+	return self.workCh
+}
+
+func (self *Stratum) SetReturnCh(returnCh chan<- *Result) {
+	self.returnCh = returnCh
+}
+
+func (self *Stratum) Start() {
+	if !atomic.CompareAndSwapInt32(&self.running, 0, 1) {
+		return
+	}
+	listener, err := net.Listen("tcp", self.config.Addr)
+	if err != nil {
+		log.Error("Stratum failed to listen", "addr", self.config.Addr, "err", err)
+		atomic.StoreInt32(&self.running, 0)
+		return
+	}
+	log.Info("Stratum server started", "addr", listener.Addr())
+	self.listener = listener
+	self.quitCh = make(chan struct{})
+	self.workCh = make(chan *Work, 1)
+	go self.loop(self.workCh, self.quitCh)
+	go self.accept(listener)
+}
+
+func (self *Stratum) Stop() {
+	if !atomic.CompareAndSwapInt32(&self.running, 1, 0) {
+		return
+	}
+	close(self.quitCh)
+	close(self.workCh)
+	self.listener.Close()
+
+	self.mu.Lock()
+	for session := range self.sessions {
+		session.close()
+	}
+	self.current = nil
+	self.jobs = make(map[string]*stratumJob)
+	self.jobIds = nil
+	self.mu.Unlock()
+}
+
+// GetHashRate returns the hashrate of all the workers computed from their
+// accepted shares.
+func (self *Stratum) GetHashRate() (tot int64) {
+	for _, rate := range self.Workers() {
+		tot += int64(rate)
+	}
+	return
+}
+
+// Workers returns the hashrate of each worker computed from its accepted
+// shares.
+func (self *Stratum) Workers() map[string]uint64 {
+	self.hashrateMu.Lock()
+	defer self.hashrateMu.Unlock()
+
+	rates := make(map[string]uint64)
+	for worker, rate := range self.hashrate {
+		if r := rate.rate(); r > 0 {
+			rates[worker] = r
+		} else {
+			delete(self.hashrate, worker)
+		}
+	}
+	return rates
+}
+
+// NotifyVote resends the current job when the votes of its parent arrive, the
+// lottery of the parent is running and the miners keep working on the job.
+func (self *Stratum) NotifyVote(vote *types.Vote) {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	if self.current != nil && vote.ParentNum+1 == self.current.work.Block.NumberU64()-1 {
+		self.broadcast(self.current, false)
+	}
+}
+
+func (self *Stratum) loop(workCh chan *Work, quitCh chan struct{}) {
+	for {
+		select {
+		case <-quitCh:
+			return
+		case work := <-workCh:
+			if work != nil {
+				self.newJob(work)
+			}
+		}
+	}
+}
+
+// newJob makes a job of a work and sends it to the sessions.
+func (self *Stratum) newJob(work *Work) {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+
+	self.jobSeq++
+	job := &stratumJob{
+		id:     strconv.FormatUint(self.jobSeq, 16),
+		work:   work,
+		shares: make(map[uint64]struct{}),
+	}
+	clean := self.current == nil || self.current.work.Block.NumberU64() != work.Block.NumberU64()
+	self.jobs[job.id] = job
+	self.jobIds = append(self.jobIds, job.id)
+	if len(self.jobIds) > stratumJobsKept {
+		delete(self.jobs, self.jobIds[0])
+		self.jobIds = self.jobIds[1:]
+	}
+	self.current = job
+	self.broadcast(job, clean)
+}
+
+func (self *Stratum) accept(listener net.Listener) {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		session, err := self.newSession(conn)
+		if err != nil {
+			log.Warn("Stratum refused miner", "remote", conn.RemoteAddr(), "err", err)
+			conn.Close()
+			continue
+		}
+		go session.serve()
+	}
+}
+
+// newSession registers the session of a connection with an extranonce no
+// other session uses.
+func (self *Stratum) newSession(conn net.Conn) (*stratumSession, error) {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+
+	if len(self.nonces) > int(^uint16(0)) {
+		return nil, errStratumFull
+	}
+	for {
+		self.nonceSeq++
+		if _, ok := self.nonces[self.nonceSeq]; !ok {
+			break
+		}
+	}
+	session := &stratumSession{
+		stratum:    self,
+		conn:       conn,
+		out:        make(chan interface{}, stratumQueue),
+		closed:     make(chan struct{}),
+		extranonce: self.nonceSeq,
+		difficulty: new(big.Int).Set(self.config.Difficulty),
+	}
+	self.nonces[session.extranonce] = struct{}{}
+	self.sessions[session] = struct{}{}
+	go session.write()
+	return session, nil
+}
+
+// broadcast queues a job to every subscribed session, mu is held.
+func (self *Stratum) broadcast(job *stratumJob, clean bool) {
+	for session := range self.sessions {
+		if session.isAuthorized() {
+			session.notify(job, clean)
+		}
+	}
+}
+
+func (self *Stratum) remove(session *stratumSession) {
+	self.mu.Lock()
+	if _, ok := self.sessions[session]; ok {
+		delete(self.sessions, session)
+		delete(self.nonces, session.extranonce)
+	}
+	self.mu.Unlock()
+}
+
+// submit checks a share of a session and returns the sealed block to the
+// worker if it meets the block difficulty.
+func (self *Stratum) submit(session *stratumSession, jobId string, nonce uint64, difficulty *big.Int) error {
+	self.mu.Lock()
+	job := self.jobs[jobId]
+	if job == nil {
+		self.mu.Unlock()
+		return errStratumJob
+	}
+	if _, ok := job.shares[nonce]; ok {
+		self.mu.Unlock()
+		return errStratumDuplicate
+	}
+	job.shares[nonce] = struct{}{}
+	self.mu.Unlock()
+
+	header := job.work.Block.Header()
+	header.Nonce = types.EncodeNonce(nonce)
+	digest, sealed, err := self.verifier.VerifyShare(header, difficulty)
+	if err != nil {
+		return err
+	}
+	self.hashrateMu.Lock()
+	rate := self.hashrate[session.worker]
+	if rate == nil {
+		rate = &workerRate{}
+		self.hashrate[session.worker] = rate
+	}
+	rate.add(difficulty)
+	self.hashrateMu.Unlock()
+
+	if sealed {
+		header.MixDigest = digest
+		block := job.work.Block.WithSeal(header)
+		log.Info("Stratum share seals a block", "number", block.NumberU64(), "worker", session.worker)
+		self.returnCh <- &Result{job.work.Copy(), block}
+	}
+	return nil
+}
+
+type stratumRequest struct {
+	Id     json.RawMessage `json:"id"`
+	Method string          `json:"method"`
+	Params []interface{}   `json:"params"`
+}
+
+type stratumResponse struct {
+	Id     json.RawMessage `json:"id"`
+	Result interface{}     `json:"result"`
+	Error  interface{}     `json:"error"`
+}
+
+type stratumNotification struct {
+	Id     interface{}   `json:"id"`
+	Method string        `json:"method"`
+	Params []interface{} `json:"params"`
+}
+
+// stratumSession is the connection of a miner. The messages to the miner are
+// queued and written by their own goroutine, so that a stalled miner holds
+// back no other.
+type stratumSession struct {
+	stratum *Stratum
+	conn    net.Conn
+
+	out       chan interface{}
+	closed    chan struct{}
+	closeOnce sync.Once
+
+	mu           sync.Mutex
+	extranonce   uint16
+	subscribed   bool
+	worker       string
+	difficulty   *big.Int
+	shares       int
+	lastRetarget time.Time
+}
+
+func (self *stratumSession) serve() {
+	defer self.stratum.remove(self)
+	defer self.close()
+
+	reader := bufio.NewReaderSize(self.conn, stratumMaxLine)
+	for {
+		self.conn.SetReadDeadline(time.Now().Add(stratumReadTimeout))
+		line, isPrefix, err := reader.ReadLine()
+		if err != nil {
+			return
+		}
+		if isPrefix {
+			log.Debug("Stratum request too long", "remote", self.conn.RemoteAddr())
+			return
+		}
+		if len(line) == 0 {
+			continue
+		}
+		var req stratumRequest
+		if err := json.Unmarshal(line, &req); err != nil {
+			log.Debug("Stratum invalid request", "remote", self.conn.RemoteAddr(), "err", err)
+			return
+		}
+		result, then, err := self.handle(&req)
+		resp := stratumResponse{Id: req.Id, Result: result}
+		if err != nil {
+			resp.Result = nil
+			resp.Error = []interface{}{20, err.Error(), nil}
+		}
+		if !self.send(&resp) {
+			return
+		}
+		if then != nil {
+			then()
+		}
+	}
+}
+
+// handle answers a request, then is run once the answer is queued.
+func (self *stratumSession) handle(req *stratumRequest) (result interface{}, then func(), err error) {
+	switch req.Method {
+	case "mining.subscribe":
+		self.mu.Lock()
+		self.subscribed = true
+		self.mu.Unlock()
+		extranonce := make([]byte, extranonceSize)
+		binary.BigEndian.PutUint16(extranonce, self.extranonce)
+		session := fmt.Sprintf("%x", self.extranonce)
+		return []interface{}{
+			[]interface{}{"mining.notify", session, stratumVersion},
+			hexutil.Encode(extranonce)[2:],
+		}, nil, nil
+
+	case "mining.extranonce.subscribe":
+		return true, nil, nil
+
+	case "mining.authorize":
+		worker, ok := stringParam(req.Params, 0)
+		if !ok {
+			return nil, nil, errStratumParams
+		}
+		self.mu.Lock()
+		if !self.subscribed {
+			self.mu.Unlock()
+			return nil, nil, errStratumUnsubscribed
+		}
+		self.worker = worker
+		self.mu.Unlock()
+
+		// Answer first so that the miner knows it's authorized when the job comes
+		return true, func() {
+			self.setDifficulty()
+			self.stratum.mu.Lock()
+			if job := self.stratum.current; job != nil {
+				self.notify(job, true)
+			}
+			self.stratum.mu.Unlock()
+		}, nil
+
+	case "mining.suggest_difficulty":
+		if len(req.Params) < 1 {
+			return nil, nil, errStratumParams
+		}
+		diff, ok := req.Params[0].(float64)
+		if !ok || diff < 1 {
+			return nil, nil, errStratumParams
+		}
+		self.mu.Lock()
+		self.difficulty, _ = new(big.Float).SetFloat64(diff).Int(nil)
+		if self.difficulty.Cmp(self.stratum.config.Difficulty) < 0 {
+			self.difficulty.Set(self.stratum.config.Difficulty)
+		}
+		self.mu.Unlock()
+		return true, self.setDifficulty, nil
+
+	case "mining.submit":
+		if !self.isAuthorized() {
+			return nil, nil, errStratumUnauthorized
+		}
+		jobId, ok1 := stringParam(req.Params, 1)
+		suffix, ok2 := stringParam(req.Params, 2)
+		if !ok1 || !ok2 {
+			return nil, nil, errStratumParams
+		}
+		nonce, err := self.nonce(suffix)
+		if err != nil {
+			return nil, nil, err
+		}
+		self.mu.Lock()
+		difficulty := self.difficulty
+		self.mu.Unlock()
+		if err := self.stratum.submit(self, jobId, nonce, difficulty); err != nil {
+			log.Debug("Stratum share rejected", "worker", self.worker, "job", jobId, "err", err)
+			return nil, nil, err
+		}
+		return true, self.retarget, nil
+
+	default:
+		return nil, nil, fmt.Errorf("method %v not supported", req.Method)
+	}
+}
+
+// nonce returns the full nonce of a share from the bytes the miner searched.
+func (self *stratumSession) nonce(suffix string) (uint64, error) {
+	data, err := hexutil.Decode("0x" + suffix)
+	if err != nil || len(data) != 8-extranonceSize {
+		return 0, errStratumParams
+	}
+	nonce := make([]byte, 8)
+	binary.BigEndian.PutUint16(nonce, self.extranonce)
+	copy(nonce[extranonceSize:], data)
+	return binary.BigEndian.Uint64(nonce), nil
+}
+
+// retarget moves the share difficulty of the session toward a share every
+// ShareTime.
+func (self *stratumSession) retarget() {
+	target := self.stratum.config.ShareTime
+	if target == 0 {
+		return
+	}
+	self.mu.Lock()
+	self.shares++
+	if self.lastRetarget.IsZero() {
+		self.lastRetarget = time.Now()
+	}
+	if self.shares < stratumRetarget {
+		self.mu.Unlock()
+		return
+	}
+	elapsed := time.Since(self.lastRetarget)
+	diff := new(big.Int).Mul(self.difficulty, big.NewInt(int64(target)*int64(self.shares)))
+	diff.Div(diff, big.NewInt(int64(elapsed)+1))
+	if diff.Cmp(self.stratum.config.Difficulty) < 0 {
+		diff.Set(self.stratum.config.Difficulty)
+	}
+	self.difficulty = diff
+	self.shares = 0
+	self.lastRetarget = time.Now()
+	self.mu.Unlock()
+
+	self.setDifficulty()
+}
+
+func (self *stratumSession) isAuthorized() bool {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	return self.worker != ""
+}
+
+func (self *stratumSession) setDifficulty() {
+	self.mu.Lock()
+	diff, _ := new(big.Float).SetInt(self.difficulty).Float64()
+	self.mu.Unlock()
+	self.send(&stratumNotification{Method: "mining.set_difficulty", Params: []interface{}{diff}})
+}
+
+func (self *stratumSession) notify(job *stratumJob, clean bool) {
+	block := job.work.Block
+	self.send(&stratumNotification{
+		Method: "mining.notify",
+		Params: []interface{}{
+			job.id,
+			common.BytesToHash(ethash.SeedHash(block.NumberU64())).Hex(),
+			block.Header().HashPow().Hex(),
+			clean,
+			hexutil.Uint64(block.NumberU64()),
+		},
+	})
+}
+
+// send queues a message to the miner, the session is closed if the miner
+// doesn't keep up with its queue.
+func (self *stratumSession) send(msg interface{}) bool {
+	select {
+	case self.out <- msg:
+		return true
+	case <-self.closed:
+		return false
+	default:
+		log.Debug("Stratum miner stalled", "remote", self.conn.RemoteAddr())
+		self.close()
+		return false
+	}
+}
+
+// write writes the queued messages to the miner.
+func (self *stratumSession) write() {
+	enc := json.NewEncoder(self.conn)
+	for {
+		select {
+		case msg := <-self.out:
+			self.conn.SetWriteDeadline(time.Now().Add(stratumWriteTimeout))
+			if err := enc.Encode(msg); err != nil {
+				self.close()
+				return
+			}
+		case <-self.closed:
+			return
+		}
+	}
+}
+
+func (self *stratumSession) close() {
+	self.closeOnce.Do(func() {
+		close(self.closed)
+		self.conn.Close()
+	})
+}
+
+func stringParam(params []interface{}, i int) (string, bool) {
+	if i >= len(params) {
+		return "", false
+	}
+	s, ok := params[i].(string)
+	return s, ok
+}
@@ -0,0 +1,238 @@
+package miner
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/sero-cash/go-sero/common"
+	"github.com/sero-cash/go-sero/core/state"
+	"github.com/sero-cash/go-sero/core/types"
+	"github.com/sero-cash/go-sero/serodb"
+)
+
+// testVerifier seals the shares of the nonce block and accepts the others.
+type testVerifier struct {
+	block uint64
+}
+
+func (self *testVerifier) VerifyShare(header *types.Header, difficulty *big.Int) (common.Hash, bool, error) {
+	if header.Nonce.Uint64()&0xff == 0xff {
+		return common.Hash{}, false, errors.New("invalid share")
+	}
+	return common.Hash{1}, header.Nonce.Uint64() == self.block, nil
+}
+
+func newTestStratum(block uint64) (*Stratum, chan *Result) {
+	results := make(chan *Result, 1)
+	stratum := &Stratum{
+		config:   StratumConfig{Difficulty: big.NewInt(1000)},
+		verifier: &testVerifier{block},
+		sessions: make(map[*stratumSession]struct{}),
+		nonces:   make(map[uint16]struct{}),
+		jobs:     make(map[string]*stratumJob),
+		hashrate: make(map[string]*workerRate),
+	}
+	stratum.SetReturnCh(results)
+	return stratum, results
+}
+
+func newTestWork(t *testing.T, number int64) *Work {
+	header := &types.Header{Number: big.NewInt(number), Difficulty: big.NewInt(1000)}
+	statedb, err := state.New(state.NewDatabase(serodb.NewMemDatabase()), header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &Work{Block: types.NewBlockWithHeader(header), state: statedb}
+}
+
+type testMiner struct {
+	t      *testing.T
+	conn   net.Conn
+	reader *bufio.Reader
+	id     int
+}
+
+func newTestMiner(t *testing.T, stratum *Stratum) (*testMiner, *stratumSession) {
+	server, client := net.Pipe()
+	session, err := stratum.newSession(server)
+	if err != nil {
+		t.Fatal(err)
+	}
+	go session.serve()
+	return &testMiner{t: t, conn: client, reader: bufio.NewReader(client)}, session
+}
+
+func (self *testMiner) call(method string, params ...interface{}) {
+	self.id++
+	self.conn.SetWriteDeadline(time.Now().Add(time.Second))
+	if err := json.NewEncoder(self.conn).Encode(map[string]interface{}{"id": self.id, "method": method, "params": params}); err != nil {
+		self.t.Fatalf("%s: %v", method, err)
+	}
+}
+
+func (self *testMiner) read() map[string]interface{} {
+	self.conn.SetReadDeadline(time.Now().Add(time.Second))
+	line, err := self.reader.ReadBytes('\n')
+	if err != nil {
+		self.t.Fatalf("read: %v", err)
+	}
+	msg := make(map[string]interface{})
+	if err := json.Unmarshal(line, &msg); err != nil {
+		self.t.Fatal(err)
+	}
+	return msg
+}
+
+// expect reads the answer to the last call.
+func (self *testMiner) expect() (interface{}, interface{}) {
+	msg := self.read()
+	if id, _ := msg["id"].(float64); int(id) != self.id {
+		self.t.Fatalf("answer to %d expected, got %v", self.id, msg)
+	}
+	return msg["result"], msg["error"]
+}
+
+// expectNotification reads a notification of method.
+func (self *testMiner) expectNotification(method string) []interface{} {
+	msg := self.read()
+	if msg["method"] != method {
+		self.t.Fatalf("%s expected, got %v", method, msg)
+	}
+	return msg["params"].([]interface{})
+}
+
+func TestStratumSession(t *testing.T) {
+	stratum, results := newTestStratum(0)
+	miner, session := newTestMiner(t, stratum)
+	defer miner.conn.Close()
+
+	miner.call("mining.submit", "worker", "1", "000000000001")
+	if _, err := miner.expect(); err == nil {
+		t.Fatalf("share of an unauthorized miner accepted")
+	}
+	miner.call("mining.authorize", "worker", "x")
+	if _, err := miner.expect(); err == nil {
+		t.Fatalf("unsubscribed miner authorized")
+	}
+
+	miner.call("mining.subscribe")
+	result, err := miner.expect()
+	if err != nil {
+		t.Fatalf("subscribe: %v", err)
+	}
+	extranonce := result.([]interface{})[1].(string)
+	if want := "0001"; extranonce != want {
+		t.Fatalf("extranonce mismatch: have %v, want %v", extranonce, want)
+	}
+
+	miner.call("mining.authorize", "worker", "x")
+	if result, err := miner.expect(); result != true {
+		t.Fatalf("authorize: %v", err)
+	}
+	if params := miner.expectNotification("mining.set_difficulty"); params[0] != float64(1000) {
+		t.Fatalf("difficulty mismatch: have %v, want 1000", params[0])
+	}
+
+	// A job goes to the authorized miners
+	work := newTestWork(t, 5)
+	stratum.newJob(work)
+	params := miner.expectNotification("mining.notify")
+	job := params[0].(string)
+	if powHash := work.Block.Header().HashPow().Hex(); params[2] != powHash || params[3] != true || params[4] != "0x5" {
+		t.Fatalf("job mismatch: have %v, want pow hash %v of block 5", params, powHash)
+	}
+
+	// Shares are checked, the block sealing one is returned
+	miner.call("mining.submit", "worker", job, "0000000000ff")
+	if _, err := miner.expect(); err == nil {
+		t.Fatalf("invalid share accepted")
+	}
+	miner.call("mining.submit", "worker", "ff", "000000000002")
+	if _, err := miner.expect(); err == nil {
+		t.Fatalf("share of an unknown job accepted")
+	}
+	nonce := make([]byte, 8)
+	binary.BigEndian.PutUint16(nonce, session.extranonce)
+	nonce[7] = 2
+	stratum.verifier.(*testVerifier).block = binary.BigEndian.Uint64(nonce)
+	miner.call("mining.submit", "worker", job, "000000000002")
+	if result, err := miner.expect(); result != true {
+		t.Fatalf("submit: %v", err)
+	}
+	select {
+	case result := <-results:
+		if result.Block.Nonce() != binary.BigEndian.Uint64(nonce) || result.Block.MixDigest() != (common.Hash{1}) {
+			t.Fatalf("sealed block mismatch: nonce %x, mix digest %x", result.Block.Nonce(), result.Block.MixDigest())
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("sealed block not returned")
+	}
+	miner.call("mining.submit", "worker", job, "000000000002")
+	if _, err := miner.expect(); err == nil {
+		t.Fatalf("duplicate share accepted")
+	}
+	if rate := stratum.Workers()["worker"]; rate == 0 {
+		t.Fatalf("hashrate of the worker not accounted")
+	}
+}
+
+// TestStratumStalledMiner checks that a miner not reading its messages holds
+// back neither the jobs nor the other miners, and is dropped.
+func TestStratumStalledMiner(t *testing.T) {
+	stratum, _ := newTestStratum(0)
+	stalled, stalledSession := newTestMiner(t, stratum)
+	defer stalled.conn.Close()
+	miner, _ := newTestMiner(t, stratum)
+	defer miner.conn.Close()
+
+	for _, m := range []*testMiner{stalled, miner} {
+		m.call("mining.subscribe")
+		m.expect()
+		m.call("mining.authorize", "worker", "x")
+		m.expect()
+		m.expectNotification("mining.set_difficulty")
+	}
+	// The stalled miner stops reading, the other gets every job in time
+	for i := 0; i < 2*stratumQueue; i++ {
+		stratum.newJob(newTestWork(t, int64(i+1)))
+		miner.expectNotification("mining.notify")
+	}
+	select {
+	case <-stalledSession.closed:
+	case <-time.After(time.Second):
+		t.Fatalf("stalled miner not dropped")
+	}
+}
+
+func TestStratumExtranonces(t *testing.T) {
+	stratum, _ := newTestStratum(0)
+	for i := 0; i <= int(^uint16(0)); i++ {
+		stratum.nonces[uint16(i)] = struct{}{}
+	}
+	server, client := net.Pipe()
+	defer client.Close()
+	if _, err := stratum.newSession(server); err != errStratumFull {
+		t.Fatalf("session with no extranonce left: have %v, want %v", err, errStratumFull)
+	}
+
+	// Released extranonces are given again, never one in use
+	delete(stratum.nonces, 7)
+	session, err := stratum.newSession(server)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if session.extranonce != 7 {
+		t.Fatalf("extranonce mismatch: have %d, want 7", session.extranonce)
+	}
+	stratum.remove(session)
+	if _, ok := stratum.nonces[7]; ok {
+		t.Fatalf("extranonce of a removed session not released")
+	}
+	session.close()
+}
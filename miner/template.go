@@ -0,0 +1,116 @@
+package miner
+
+import (
+	"errors"
+	"math/big"
+	"sync/atomic"
+
+	"github.com/sero-cash/go-czero-import/seroparam"
+	"github.com/sero-cash/go-sero/common"
+	"github.com/sero-cash/go-sero/core"
+	"github.com/sero-cash/go-sero/core/types"
+	"github.com/sero-cash/go-sero/core/vm"
+	"github.com/sero-cash/go-sero/log"
+	"github.com/sero-cash/go-sero/zero/stake"
+)
+
+var (
+	errNotMining  = errors.New("miner not started")
+	errNoTemplate = errors.New("no block template yet")
+	errStaleBlock = errors.New("block isn't on top of the head")
+)
+
+// BlockTemplate is the candidate block of the worker with what a block builder
+// needs to assemble its own block.
+type BlockTemplate struct {
+	Block         *types.Block       // Unsealed candidate block
+	Fees          []*big.Int         // Fee paid by each transaction of the block
+	ParentVotes   []types.HeaderVote // Votes of the parent missing from its header
+	ParentPosHash common.Hash        // Pos hash of the parent, signed by the votes with the pos hash of the block
+	StakeHash     common.Hash        // Hash of the stake state of the block
+}
+
+// blockTemplate returns the template of the current work.
+func (self *worker) blockTemplate() (*BlockTemplate, error) {
+	self.currentMu.Lock()
+	defer self.currentMu.Unlock()
+
+	if atomic.LoadInt32(&self.mining) == 0 {
+		return nil, errNotMining
+	}
+	// The work committed before the miner started pays no coinbase
+	work := self.current
+	if work == nil || work.Block == nil || work.header.Coinbase == (common.Address{}) {
+		return nil, errNoTemplate
+	}
+	stakeState := stake.NewStakeState(work.state.Copy())
+	template := &BlockTemplate{
+		Block:     work.Block,
+		StakeHash: stakeState.StateHash(),
+	}
+	for i, tx := range work.txs {
+		fee := new(big.Int).SetUint64(work.receipts[i].GasUsed)
+		template.Fees = append(template.Fees, fee.Mul(fee, tx.GasPrice()))
+	}
+	parent := self.chain.GetBlockByHash(work.Block.ParentHash())
+	if parent == nil {
+		return nil, errStaleBlock
+	}
+	template.ParentPosHash = parent.HashPos()
+	if work.Block.NumberU64() >= seroparam.SIP4() {
+		template.ParentVotes = self.parentVotes(stakeState, parent)
+	}
+	return template, nil
+}
+
+// submitBlock inserts a block assembled by a block builder on top of the head.
+// A sealed block without votes goes through the lottery of the worker first,
+// its insertion is then asynchronous.
+func (self *worker) submitBlock(block *types.Block) error {
+	parent := self.chain.CurrentBlock()
+	if block.ParentHash() != parent.Hash() {
+		return errStaleBlock
+	}
+	if block.NumberU64() < seroparam.SIP4() || len(block.Header().CurrentVotes) > 0 {
+		return self.insertBlock(block)
+	}
+	if err := self.engine.VerifyHeader(self.chain, block.Header(), true); err != nil {
+		return err
+	}
+	// The shares voting for the block are selected from its state
+	statedb, err := self.chain.StateAt(parent.Header())
+	if err != nil {
+		return err
+	}
+	if err := stake.NewStakeState(statedb).ProcessBeforeApply(self.chain, block.Header()); err != nil {
+		return err
+	}
+	if _, _, _, err := self.chain.Processor().Process(block, statedb, vm.Config{}); err != nil {
+		return err
+	}
+	lotter := newLotter(self, block, statedb)
+	self.voter.AddLottery(&types.Lottery{block.ParentHash(), block.NumberU64() - 1, block.HashPos()})
+
+	log.Info("Broadcast Lottery", "poshash", block.HashPos(), "block", block.NumberU64())
+
+	go func() {
+		if lotter.wait() {
+			block.SetVotes(lotter.currentHeaderVotes, lotter.parentHeaderVotes)
+			// The hash of the block changes with its votes
+			voted := block.WithSeal(block.Header())
+			if err := self.insertBlock(voted); err != nil {
+				log.Warn("Failed to insert submitted block", "number", voted.Number(), "hash", voted.Hash(), "err", err)
+			}
+		}
+	}()
+	return nil
+}
+
+func (self *worker) insertBlock(block *types.Block) error {
+	if _, err := self.chain.InsertChain(types.Blocks{block}); err != nil {
+		return err
+	}
+	self.mux.Post(core.NewMinedBlockEvent{Block: block})
+	log.Info("Inserted submitted block", "number", block.Number(), "hash", block.Hash(), "txs", len(block.Transactions()), "votes", len(block.Header().CurrentVotes))
+	return nil
+}
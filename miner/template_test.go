@@ -0,0 +1,122 @@
+package miner
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sero-cash/go-czero-import/cpt"
+	"github.com/sero-cash/go-sero/accounts"
+	"github.com/sero-cash/go-sero/common"
+	"github.com/sero-cash/go-sero/common/address"
+	"github.com/sero-cash/go-sero/consensus/ethash"
+	"github.com/sero-cash/go-sero/core"
+	"github.com/sero-cash/go-sero/core/types"
+	"github.com/sero-cash/go-sero/core/vm"
+	"github.com/sero-cash/go-sero/event"
+	"github.com/sero-cash/go-sero/params"
+	"github.com/sero-cash/go-sero/serodb"
+)
+
+type testBackend struct {
+	db     serodb.Database
+	chain  *core.BlockChain
+	txPool *core.TxPool
+}
+
+func (self *testBackend) AccountManager() *accounts.Manager { return nil }
+func (self *testBackend) BlockChain() *core.BlockChain      { return self.chain }
+func (self *testBackend) TxPool() *core.TxPool              { return self.txPool }
+func (self *testBackend) ChainDb() serodb.Database          { return self.db }
+
+type testVoter struct {
+	feed event.Feed
+}
+
+func (self *testVoter) SubscribeWorkerVoteEvent(ch chan<- core.NewVoteEvent) event.Subscription {
+	return self.feed.Subscribe(ch)
+}
+func (self *testVoter) SendLotteryEvent(lottery *types.Lottery) {}
+func (self *testVoter) SendVoteEvent(vote *types.Vote)          {}
+func (self *testVoter) AddLottery(lottery *types.Lottery)       {}
+
+func newTestWorker(t *testing.T) (*worker, *types.Block, func()) {
+	cpt.ZeroInit("", cpt.NET_Dev)
+	db := serodb.NewMemDatabase()
+	genesis := (&core.Genesis{Config: params.TestChainConfig}).MustCommit(db)
+	chain, err := core.NewBlockChain(db, nil, params.TestChainConfig, ethash.NewFaker(), vm.Config{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	poolConfig := core.DefaultTxPoolConfig
+	poolConfig.Journal = ""
+	backend := &testBackend{db: db, chain: chain, txPool: core.NewTxPool(poolConfig, params.TestChainConfig, chain)}
+	w := newWorker(params.TestChainConfig, ethash.NewFaker(), address.AccountAddress{1}, &testVoter{}, backend, new(event.TypeMux))
+	return w, genesis, func() {
+		backend.txPool.Stop()
+		chain.Stop()
+	}
+}
+
+// waitTemplate returns the template of the block number once the worker has
+// committed its work.
+func waitTemplate(t *testing.T, w *worker, number uint64) *BlockTemplate {
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		if template, err := w.blockTemplate(); err == nil && template.Block.NumberU64() == number {
+			return template
+		}
+	}
+	t.Fatalf("no template of block %d", number)
+	return nil
+}
+
+func TestBlockTemplate(t *testing.T) {
+	w, genesis, closeWorker := newTestWorker(t)
+	defer closeWorker()
+
+	// The work committed while not mining has no coinbase
+	if _, err := w.blockTemplate(); err != errNotMining {
+		t.Fatalf("template of a stopped miner: have %v, want %v", err, errNotMining)
+	}
+	w.start()
+	if _, err := w.blockTemplate(); err != errNoTemplate {
+		t.Fatalf("template of the work without coinbase: have %v, want %v", err, errNoTemplate)
+	}
+	w.commitNewWork()
+	template := waitTemplate(t, w, 1)
+	if template.Block.ParentHash() != genesis.Hash() || template.Block.Coinbase() == (common.Address{}) {
+		t.Fatalf("template mismatch: parent %x, coinbase %x", template.Block.ParentHash(), template.Block.Coinbase())
+	}
+	if template.ParentPosHash != genesis.HashPos() || len(template.Fees) != 0 {
+		t.Fatalf("template mismatch: parent pos hash %x, %d fees", template.ParentPosHash, len(template.Fees))
+	}
+}
+
+func TestSubmitBlock(t *testing.T) {
+	w, _, closeWorker := newTestWorker(t)
+	defer closeWorker()
+	w.start()
+	w.commitNewWork()
+
+	// The sealed template goes on top of the head
+	block := waitTemplate(t, w, 1).Block
+	sealed := block.WithSeal(block.Header())
+	if err := w.submitBlock(sealed); err != nil {
+		t.Fatalf("submit: %v", err)
+	}
+	if head := w.chain.CurrentBlock(); head.Hash() != sealed.Hash() {
+		t.Fatalf("head mismatch: have %d %x, want 1 %x", head.NumberU64(), head.Hash(), sealed.Hash())
+	}
+	if err := w.submitBlock(sealed); err != errStaleBlock {
+		t.Fatalf("block below the head: have %v, want %v", err, errStaleBlock)
+	}
+
+	// A block not matching its state is rejected
+	header := waitTemplate(t, w, 2).Block.Header()
+	header.Root = common.Hash{1}
+	if err := w.submitBlock(types.NewBlockWithHeader(header)); err == nil {
+		t.Fatalf("block with a wrong state root inserted")
+	}
+	if head := w.chain.CurrentBlock(); head.Hash() != sealed.Hash() {
+		t.Fatalf("head moved to %d %x", head.NumberU64(), head.Hash())
+	}
+}
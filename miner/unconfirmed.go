@@ -44,17 +44,19 @@ type unconfirmedBlock struct {
 // used by the miner to provide logs to the user when a previously mined block
 // has a high enough guarantee to not be reorged out of the canonical chain.
 type unconfirmedBlocks struct {
-	chain  headerRetriever // Blockchain to verify canonical status through
-	depth  uint            // Depth after which to discard previous blocks
-	blocks *ring.Ring      // Block infos to allow canonical chain cross checks
-	lock   sync.RWMutex    // Protects the fields from concurrent access
+	chain   headerRetriever // Blockchain to verify canonical status through
+	depth   uint            // Depth after which to discard previous blocks
+	history *minedHistory   // Records of the mined blocks, updated with their status
+	blocks  *ring.Ring      // Block infos to allow canonical chain cross checks
+	lock    sync.RWMutex    // Protects the fields from concurrent access
 }
 
 // newUnconfirmedBlocks returns new data structure to track currently unconfirmed blocks.
-func newUnconfirmedBlocks(chain headerRetriever, depth uint) *unconfirmedBlocks {
+func newUnconfirmedBlocks(chain headerRetriever, depth uint, history *minedHistory) *unconfirmedBlocks {
 	return &unconfirmedBlocks{
-		chain: chain,
-		depth: depth,
+		chain:   chain,
+		depth:   depth,
+		history: history,
 	}
 }
 
@@ -102,8 +104,14 @@ func (set *unconfirmedBlocks) Shift(height uint64) {
 			log.Warn("Failed to retrieve header of mined block", "number", next.index, "hash", next.hash)
 		case header.Hash() == next.hash:
 			log.Info("🔗 block reached canonical chain", "number", next.index, "hash", next.hash)
+			if set.history != nil {
+				set.history.confirm(next.index, next.hash, true)
+			}
 		default:
 			log.Info("⑂ block  became a side fork", "number", next.index, "hash", next.hash)
+			if set.history != nil {
+				set.history.confirm(next.index, next.hash, false)
+			}
 		}
 		// Drop the block out of the ring
 		if set.blocks.Value == set.blocks.Next().Value {
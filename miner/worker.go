@@ -68,6 +68,22 @@ type Agent interface {
 	GetHashRate() int64
 }
 
+// voteNotifier is implemented by the agents which push their work again when
+// the votes of the lottery arrive.
+type voteNotifier interface {
+	NotifyVote(vote *types.Vote)
+}
+
+// devEngine is implemented by the engines of the development network, they
+// seal without proof of work and vote with the local shares.
+type devEngine interface {
+	// Period returns the seconds between the blocks, 0 seals a block as soon
+	// as there are transactions.
+	Period() uint64
+	// Votes signs the votes of the selected shares held by the local accounts.
+	Votes(state *stake.StakeState, block, parent *types.Block, idxs []uint32, shares []*stake.Share) []*types.Vote
+}
+
 // Work is the workers current environment and holds
 // all of the current state information
 type Work struct {
@@ -89,6 +105,8 @@ type Work struct {
 	errHandledTxs []*types.Transaction
 
 	gasReward uint64
+
+	lottery *lotteryStats // Decisions of the lotter which collected the votes
 }
 
 func (self *Work) Copy() (ret *Work) {
@@ -136,6 +154,7 @@ type worker struct {
 
 	coinbase address.AccountAddress
 	extra    []byte
+	lottery  LotteryConfig
 
 	currentMu sync.Mutex
 	current   *Work
@@ -145,6 +164,7 @@ type worker struct {
 	snapshotState *state.StateDB
 
 	unconfirmed *unconfirmedBlocks // set of locally mined blocks pending canonicalness confirmations
+	history     *minedHistory      // records of the locally mined blocks
 
 	// atomic status counters
 	mining int32
@@ -179,10 +199,12 @@ func newWorker(config *params.ChainConfig, engine consensus.Engine, coinbase add
 		proc:        sero.BlockChain().Validator(),
 		coinbase:    coinbase,
 		agents:      make(map[Agent]struct{}),
-		unconfirmed: newUnconfirmedBlocks(sero.BlockChain(), miningLogAtDepth),
+		history:     newMinedHistory(sero.ChainDb(), sero.BlockChain(), config, miningLogAtDepth),
 		voter:       voter,
 		pendingVote: newPendingVote(),
+		lottery:     DefaultLotteryConfig,
 	}
+	worker.unconfirmed = newUnconfirmedBlocks(sero.BlockChain(), miningLogAtDepth, worker.history)
 	// Subscribe NewTxsEvent for tx pool
 	worker.txsSub = sero.TxPool().SubscribeNewTxsEvent(worker.txsCh)
 	worker.voteSub = worker.voter.SubscribeWorkerVoteEvent(worker.voteCh)
@@ -210,6 +232,18 @@ func (self *worker) setExtra(extra []byte) {
 	self.extra = extra
 }
 
+func (self *worker) setLottery(config LotteryConfig) {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	self.lottery = config
+}
+
+func (self *worker) lotteryConfig() LotteryConfig {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	return self.lottery
+}
+
 func (self *worker) pending() (*types.Block, *state.StateDB) {
 	if atomic.LoadInt32(&self.mining) == 0 {
 		// return a snapshot to avoid contention on currentMu mutex
@@ -313,6 +347,9 @@ func (self *worker) update() {
 				self.current.commitTransactions(self.mux, txset, self.chain, addr)
 				self.updateSnapshot()
 				self.currentMu.Unlock()
+			} else if dev, ok := self.engine.(devEngine); ok && dev.Period() == 0 {
+				// The dev engine only seals the blocks with transactions
+				self.commitNewWork()
 			}
 			// System stopped
 		case <-self.txsSub.Err():
@@ -343,7 +380,14 @@ func (self *worker) powResultLoop() {
 				go func() {
 					if lotter.wait() {
 						result.Block.SetVotes(lotter.currentHeaderVotes, lotter.parentHeaderVotes)
+						result.Work.lottery = &lotter.stats
 						self.recv <- result
+					} else if lotter.stats.timeout {
+						self.history.record(result.Work, result.Block, &lotter.stats, MinedLostVotes)
+					} else if lotter.stats.replaced {
+						self.history.record(result.Work, result.Block, &lotter.stats, MinedOrphaned)
+					} else {
+						self.history.record(result.Work, result.Block, &lotter.stats, MinedFailed)
 					}
 				}()
 			}
@@ -372,6 +416,7 @@ func (self *worker) voteLoop() {
 			log.Trace("worker voteLoop", "posHash", vote.PosHash, "block", vote.ParentNum+1, "share", vote.ShareId, "idx", vote.Idx)
 
 			self.pendingVote.add(vote)
+			self.notifyVote(vote)
 
 		case <-self.voteSub.Err():
 			return
@@ -423,9 +468,21 @@ func (self *worker) resultLoop() {
 			self.chain.PostChainEvents(events, logs)
 
 			// Insert the block into the set of pending ones to resultLoop for confirmations
+			self.history.record(work, block, work.lottery, MinedPending)
 			self.unconfirmed.Insert(block.NumberU64(), block.Hash())
-			log.Info(fmt.Sprintf("mined new block done in %v, number = %v, txs = %v", time.Since(work.createdAt), block.NumberU64(), len(block.Body().Transactions)))
+			log.Info(fmt.Sprintf("mined new block done in %v, number = %v, txs = %v", time.Since(work.createdAt), block.NumberU64(), len(block.Body().Transactions)), work.lottery.logCtx()...)
+
+		}
+	}
+}
 
+// notifyVote passes a vote to the agents interested in it.
+func (self *worker) notifyVote(vote *types.Vote) {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	for agent := range self.agents {
+		if notifier, ok := agent.(voteNotifier); ok {
+			notifier.NotifyVote(vote)
 		}
 	}
 }
@@ -344,6 +344,11 @@ func (api *PublicDebugAPI) Metrics(raw bool) (map[string]interface{}, error) {
 					"Overall": float64(metric.Count()),
 				}
 
+			case metrics.Gauge:
+				root[name] = map[string]interface{}{
+					"Value": float64(metric.Value()),
+				}
+
 			case metrics.Meter:
 				root[name] = map[string]interface{}{
 					"AvgRate01Min": metric.Rate1(),
@@ -394,6 +399,11 @@ func (api *PublicDebugAPI) Metrics(raw bool) (map[string]interface{}, error) {
 					"Overall": float64(metric.Count()),
 				}
 
+			case metrics.Gauge:
+				root[name] = map[string]interface{}{
+					"Value": float64(metric.Value()),
+				}
+
 			case metrics.Meter:
 				root[name] = map[string]interface{}{
 					"Avg01Min": format(metric.Rate1()*60, metric.Rate1()),
@@ -26,6 +26,7 @@ import (
 	"strings"
 
 	"github.com/sero-cash/go-sero/accounts"
+	"github.com/sero-cash/go-sero/accounts/external"
 	"github.com/sero-cash/go-sero/accounts/keystore"
 	"github.com/sero-cash/go-sero/common"
 	"github.com/sero-cash/go-sero/crypto"
@@ -83,6 +84,10 @@ type Config struct {
 	// scrypt KDF at the expense of security.
 	UseLightweightKDF bool `toml:",omitempty"`
 
+	// ExternalSigner is the IPC path or HTTP URL of an external signer, whose
+	// accounts are added to the ones of the keystore.
+	ExternalSigner string `toml:",omitempty"`
+
 	// NoUSB disables hardware wallet monitoring and connectivity.
 	NoUSB bool `toml:",omitempty"`
 
@@ -425,5 +430,12 @@ func makeAccountManager(conf *Config) (*accounts.Manager, string, error) {
 	backends := []accounts.Backend{
 		keystore.NewKeyStore(keydir, scryptN, scryptP),
 	}
+	if conf.ExternalSigner != "" {
+		signer, err := external.NewExternalBackend(conf.ExternalSigner)
+		if err != nil {
+			return nil, "", fmt.Errorf("error connecting to external signer: %v", err)
+		}
+		backends = append(backends, signer)
+	}
 	return accounts.NewManager(backends...), ephemeral, nil
 }
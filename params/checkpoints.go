@@ -0,0 +1,26 @@
+package params
+
+import (
+	"github.com/sero-cash/go-sero/common"
+	"github.com/sero-cash/go-sero/common/hexutil"
+)
+
+// ZeroCheckpoint is a signed record of a block and of its state, full nodes
+// skip the verification of zero proofs below it and light nodes anchor their
+// chain on it, see zero/checkpoint.
+type ZeroCheckpoint struct {
+	Number    uint64        `json:"number"`
+	Hash      common.Hash   `json:"hash"`
+	Root      common.Hash   `json:"root"`      // State root of the block
+	ZRoot     common.Hash   `json:"zroot"`     // Root of the merkle tree of the zero state outputs
+	StakeHash common.Hash   `json:"stakeHash"` // Commitment of the shares and pools of the stake state
+	Sig       hexutil.Bytes `json:"sig"`
+}
+
+// CheckpointSigners are the hex encoded public keys trusted to sign zero
+// checkpoints.
+var CheckpointSigners = []string{}
+
+// BetanetCheckpoints are the zero checkpoints of the beta network, more are
+// loaded from the checkpoint directory.
+var BetanetCheckpoints = []ZeroCheckpoint{}
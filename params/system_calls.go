@@ -0,0 +1,213 @@
+// copyright 2018 The sero.cash Authors
+// This file is part of the go-sero library.
+//
+// The go-sero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-sero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-sero library. If not, see <http://www.gnu.org/licenses/>.
+
+package params
+
+import (
+	"math/big"
+	"regexp"
+	"strings"
+
+	"github.com/sero-cash/go-sero/common"
+)
+
+// Names of the SERO system calls, contracts trigger them by emitting a log
+// with the matching topic.
+const (
+	SysIssueToken    = "issueToken"
+	SysSend          = "send"
+	SysBalanceOf     = "balanceOf"
+	SysAllotTicket   = "allotTicket"
+	SysCurrency      = "currency"
+	SysCategory      = "category"
+	SysTicket        = "ticket"
+	SysSetCallValues = "setCallValues"
+	SysSetTokenRate  = "setTokenRate"
+	SysClosePkg      = "closePkg"
+	SysTransferPkg   = "transferPkg"
+)
+
+// SystemCallCost is the gas pricing of a system call. The log emitted to
+// trigger the call is priced like any LOG opcode, and the gas reserved for the
+// call by the LOG gas function is handed back once the call is done.
+type SystemCallCost struct {
+	Gas     uint64 // Gas charged on top of the LOG opcode pricing
+	Stipend uint64 // Gas added to the gas forwarded to the recipient of an asset
+}
+
+// SystemCallSchedule holds the fees, gas costs and naming rules of the system
+// calls during one phase of a chain.
+//
+// The returned SystemCallSchedule's fields shouldn't, under any circumstances,
+// be changed.
+type SystemCallSchedule struct {
+	Costs map[string]SystemCallCost
+
+	// Token and ticket category names
+	NamePattern  *regexp.Regexp
+	ReservedName string // Names containing it cannot be registered
+
+	// TokenFees is the SERO fee paid to register a token, indexed by the name
+	// length minus one. The last fee applies to any longer name.
+	TokenFees    []*big.Int
+	FeeRecipient common.Address
+}
+
+// TokenFee returns the fee paid to register a token name.
+func (s *SystemCallSchedule) TokenFee(name string) *big.Int {
+	i := len(name) - 1
+	if i < 0 {
+		i = 0
+	}
+	if i >= len(s.TokenFees) {
+		i = len(s.TokenFees) - 1
+	}
+	return s.TokenFees[i]
+}
+
+// ValidName returns whether a token or ticket category name is well formed.
+func (s *SystemCallSchedule) ValidName(name string) bool {
+	return s.NamePattern.MatchString(name)
+}
+
+// ReservedNameUsed returns whether a name contains the reserved name.
+func (s *SystemCallSchedule) ReservedNameUsed(name string) bool {
+	return strings.Contains(name, s.ReservedName)
+}
+
+// Cost returns the gas pricing of a system call.
+func (s *SystemCallSchedule) Cost(name string) SystemCallCost {
+	return s.Costs[name]
+}
+
+// Foundation accounts receiving the token registration fees.
+var (
+	FoundationAccount1 = common.Base58ToAddress("hcZCikh7h3By7FBoDhCj8a6swKukeZRKm5Xg1s9RStPTNV7GzE3rJDzJsDwCWy9p86A8amMdCHgF7jKaMfSt1zfaBEbqkapuveHyFko2V9ZKisMC5qMp4VacYnApXokr4ea")
+	FoundationAccount2 = common.Base58ToAddress("5niHmAcSoDzaekKTUpLR3qkQf6djC7AGhnJnuPr8w7ArqQzhxyhEf61Rp68WhpoYo57r5q8CVsLopTJ9uc5VS92fRSHsjBqY9rqMJfQ4DBMw5QyXvT4oyeF7P9sb7ruvwZD")
+)
+
+var (
+	systemCallCosts = map[string]SystemCallCost{
+		SysIssueToken:    {},
+		SysSend:          {Stipend: CallStipend},
+		SysBalanceOf:     {},
+		SysAllotTicket:   {Stipend: CallStipend},
+		SysCurrency:      {},
+		SysCategory:      {},
+		SysTicket:        {},
+		SysSetCallValues: {},
+		SysSetTokenRate:  {},
+		SysClosePkg:      {},
+		SysTransferPkg:   {},
+	}
+
+	systemCallNamePattern = regexp.MustCompile("^[A-Z][A-Z0-9_]{0,31}$")
+
+	tokenFeeBase = new(big.Int).SetUint64(1e+17)
+
+	// flatTokenFees charges one SERO for any token name.
+	flatTokenFees = []*big.Int{new(big.Int).Mul(big.NewInt(10), tokenFeeBase)}
+
+	// tieredTokenFees makes short token names more expensive.
+	tieredTokenFees = []*big.Int{
+		new(big.Int).Mul(big.NewInt(10000000), tokenFeeBase),
+		new(big.Int).Mul(big.NewInt(5000000), tokenFeeBase),
+		new(big.Int).Mul(big.NewInt(100000), tokenFeeBase),
+		new(big.Int).Mul(big.NewInt(10000), tokenFeeBase),
+		new(big.Int).Mul(big.NewInt(100), tokenFeeBase),
+		new(big.Int).Mul(big.NewInt(10), tokenFeeBase),
+		new(big.Int).Mul(big.NewInt(1), tokenFeeBase),
+	}
+)
+
+// Variables containing the system call schedules of the different phases.
+var (
+	SystemCallsFlatFee = &SystemCallSchedule{
+		Costs:        systemCallCosts,
+		NamePattern:  systemCallNamePattern,
+		ReservedName: "SERO",
+		TokenFees:    flatTokenFees,
+		FeeRecipient: FoundationAccount1,
+	}
+
+	SystemCallsFlatFeeFoundation2 = &SystemCallSchedule{
+		Costs:        systemCallCosts,
+		NamePattern:  systemCallNamePattern,
+		ReservedName: "SERO",
+		TokenFees:    flatTokenFees,
+		FeeRecipient: FoundationAccount2,
+	}
+
+	SystemCallsTieredFee = &SystemCallSchedule{
+		Costs:        systemCallCosts,
+		NamePattern:  systemCallNamePattern,
+		ReservedName: "SERO",
+		TokenFees:    tieredTokenFees,
+		FeeRecipient: FoundationAccount1,
+	}
+
+	SystemCallsTieredFeeFoundation2 = &SystemCallSchedule{
+		Costs:        systemCallCosts,
+		NamePattern:  systemCallNamePattern,
+		ReservedName: "SERO",
+		TokenFees:    tieredTokenFees,
+		FeeRecipient: FoundationAccount2,
+	}
+)
+
+// SystemCallFork activates a system call schedule at a block.
+type SystemCallFork struct {
+	Block    uint64
+	Schedule *SystemCallSchedule
+}
+
+var (
+	// defaultSystemCallForks is used by the chains missing from systemCallForks.
+	defaultSystemCallForks = []SystemCallFork{
+		{0, SystemCallsFlatFee},
+		{300000, SystemCallsFlatFeeFoundation2},
+	}
+
+	// systemCallForks holds the system call phases of a chain by chain ID, in
+	// ascending block order.
+	systemCallForks = map[uint64][]SystemCallFork{
+		2019: {
+			{0, SystemCallsTieredFee},
+			{300000, SystemCallsTieredFeeFoundation2},
+		},
+	}
+)
+
+// SystemCalls returns the system call schedule in force at the given block.
+//
+// The returned SystemCallSchedule's fields shouldn't, under any circumstances,
+// be changed.
+func (c *ChainConfig) SystemCalls(num *big.Int) *SystemCallSchedule {
+	forks := defaultSystemCallForks
+	if c.ChainID != nil {
+		if chainForks, ok := systemCallForks[c.ChainID.Uint64()]; ok {
+			forks = chainForks
+		}
+	}
+	schedule := forks[0].Schedule
+	for _, fork := range forks[1:] {
+		if num == nil || num.Cmp(new(big.Int).SetUint64(fork.Block)) < 0 {
+			break
+		}
+		schedule = fork.Schedule
+	}
+	return schedule
+}
@@ -128,6 +128,19 @@ func (api *PublicMinerAPI) SubmitHashrate(hashrate hexutil.Uint64, id common.Has
 	return true
 }
 
+// StratumWorkers returns the hashrate of each worker of the stratum server
+// computed from its accepted shares.
+func (api *PublicMinerAPI) StratumWorkers() (map[string]hexutil.Uint64, error) {
+	if api.e.stratum == nil {
+		return nil, errors.New("stratum server not started")
+	}
+	workers := make(map[string]hexutil.Uint64)
+	for worker, rate := range api.e.stratum.Workers() {
+		workers[worker] = hexutil.Uint64(rate)
+	}
+	return workers, nil
+}
+
 // PrivateMinerAPI provides private RPC methods to control the miner.
 // These methods can be abused by external users and must be considered insecure for use by untrusted users.
 type PrivateMinerAPI struct {
@@ -211,6 +224,92 @@ func (api *PrivateMinerAPI) GetHashrate() uint64 {
 	return uint64(api.s.miner.HashRate())
 }
 
+// GetBlockTemplate returns the candidate block of the miner: the header fields,
+// the transactions with their fees, the votes of the parent missing from its
+// header and the rlp of the unsealed block. The votes of the block itself are
+// only known once it is sealed. The miner has to be started first, with zero
+// threads to leave the sealing to the block builder.
+func (api *PrivateMinerAPI) GetBlockTemplate() (map[string]interface{}, error) {
+	template, err := api.s.Miner().BlockTemplate()
+	if err != nil {
+		return nil, err
+	}
+	fields, err := ethapi.RPCMarshalBlock(template.Block, false, false)
+	if err != nil {
+		return nil, err
+	}
+	txs := make([]map[string]interface{}, len(template.Block.Transactions()))
+	for i, tx := range template.Block.Transactions() {
+		txs[i] = map[string]interface{}{
+			"hash":     tx.Hash(),
+			"gas":      hexutil.Uint64(tx.Gas()),
+			"gasPrice": (*hexutil.Big)(tx.GasPrice()),
+			"fee":      (*hexutil.Big)(template.Fees[i]),
+		}
+	}
+	block, err := rlp.EncodeToBytes(template.Block)
+	if err != nil {
+		return nil, err
+	}
+	fields["transactions"] = txs
+	fields["parentVotes"] = template.ParentVotes
+	fields["parentPosHash"] = template.ParentPosHash
+	fields["stakeHash"] = template.StakeHash
+	fields["hashPow"] = template.Block.Header().HashPow()
+	fields["block"] = hexutil.Bytes(block)
+	return fields, nil
+}
+
+// SubmitBlock inserts the rlp of a block assembled by a block builder on top of
+// the head through the normal insert path. A sealed block without votes is
+// inserted once the votes of its lottery are collected, the result only tells
+// whether it was accepted for the lottery.
+func (api *PrivateMinerAPI) SubmitBlock(data hexutil.Bytes) (bool, error) {
+	block := new(types.Block)
+	if err := rlp.DecodeBytes(data, block); err != nil {
+		return false, err
+	}
+	if err := api.s.Miner().SubmitBlock(block); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// History returns the records of the blocks mined locally between from and to
+// included, to defaults to the current block. The rewards are those paid if
+// the block is canonical, the stake reward of the votes is paid by the next
+// block.
+func (api *PrivateMinerAPI) History(from hexutil.Uint64, to *hexutil.Uint64) ([]map[string]interface{}, error) {
+	last := api.s.BlockChain().CurrentBlock().NumberU64()
+	if to != nil {
+		last = uint64(*to)
+	}
+	records, err := api.s.Miner().History(uint64(from), last)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]map[string]interface{}, len(records))
+	for i, rec := range records {
+		result[i] = map[string]interface{}{
+			"number":      hexutil.Uint64(rec.Number),
+			"hash":        rec.Hash,
+			"posHash":     rec.PosHash,
+			"timestamp":   hexutil.Uint64(rec.Time),
+			"powReward":   (*hexutil.Big)(rec.PowReward),
+			"fees":        (*hexutil.Big)(rec.Fees),
+			"soloReward":  (*hexutil.Big)(rec.SoloReward),
+			"poolReward":  (*hexutil.Big)(rec.PoolReward),
+			"stakeReward": (*hexutil.Big)(rec.StakeReward),
+			"waited":      rec.Waited,
+			"received":    hexutil.Uint64(rec.Received),
+			"votes":       hexutil.Uint64(rec.Votes),
+			"parentVotes": hexutil.Uint64(rec.ParentVotes),
+			"status":      rec.Status.String(),
+		}
+	}
+	return result, nil
+}
+
 // PrivateAdminAPI is the collection of Sero full node-related APIs
 // exposed over the private admin endpoint.
 type PrivateAdminAPI struct {
@@ -38,6 +38,7 @@ import (
 	"github.com/sero-cash/go-sero/accounts"
 	"github.com/sero-cash/go-sero/common"
 	"github.com/sero-cash/go-sero/core"
+	"github.com/sero-cash/go-sero/core/assetindex"
 	"github.com/sero-cash/go-sero/core/bloombits"
 	"github.com/sero-cash/go-sero/core/rawdb"
 	"github.com/sero-cash/go-sero/core/state"
@@ -236,6 +237,10 @@ func (b *SeroAPIBackend) ChainDb() serodb.Database {
 	return b.sero.ChainDb()
 }
 
+func (b *SeroAPIBackend) AssetRegistry() *assetindex.Registry {
+	return b.sero.assetRegistry
+}
+
 func (b *SeroAPIBackend) EventMux() *event.TypeMux {
 	return b.sero.EventMux()
 }
@@ -355,3 +360,24 @@ func (b *SeroAPIBackend) CheckNil(Nils []keys.Uint256)  (nilResps []light.NilVal
 	}
 	return b.sero.lightNode.CheckNil(Nils)
 }
+
+func (b *SeroAPIBackend) RegisterWitness(roots []keys.Uint256) error {
+	if b.sero.witnesses == nil {
+		return errors.New("not start witness")
+	}
+	return b.sero.witnesses.Register(roots)
+}
+
+func (b *SeroAPIBackend) UnregisterWitness(roots []keys.Uint256) error {
+	if b.sero.witnesses == nil {
+		return errors.New("not start witness")
+	}
+	return b.sero.witnesses.Unregister(roots)
+}
+
+func (b *SeroAPIBackend) GetWitness(roots []keys.Uint256) ([]txtool.Witness, error) {
+	if b.sero.witnesses == nil {
+		return nil, errors.New("not start witness")
+	}
+	return b.sero.witnesses.GetAnchor(roots)
+}
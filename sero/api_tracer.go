@@ -574,15 +574,25 @@ func (api *PrivateDebugAPI) traceTx(ctx context.Context, message core.Message, v
 				return nil, err
 			}
 		}
-		// Constuct the JavaScript tracer to execute with
-		if tracer, err = tracers.New(*config.Tracer); err != nil {
-			return nil, err
+		// Constuct the built-in asset tracer or the JavaScript tracer to execute with
+		var stopper interface {
+			Stop(err error)
+		}
+		if *config.Tracer == tracers.AssetTracerName {
+			assetTracer := tracers.NewAssetTracer()
+			tracer, stopper = assetTracer, assetTracer
+		} else {
+			jsTracer, err := tracers.New(*config.Tracer)
+			if err != nil {
+				return nil, err
+			}
+			tracer, stopper = jsTracer, jsTracer
 		}
 		// Handle timeouts and RPC cancellations
 		deadlineCtx, cancel := context.WithTimeout(ctx, timeout)
 		go func() {
 			<-deadlineCtx.Done()
-			tracer.(*tracers.Tracer).Stop(errors.New("execution timeout"))
+			stopper.Stop(errors.New("execution timeout"))
 		}()
 		defer cancel()
 
@@ -607,11 +617,15 @@ func (api *PrivateDebugAPI) traceTx(ctx context.Context, message core.Message, v
 			Failed:      failed,
 			ReturnValue: fmt.Sprintf("%x", ret),
 			StructLogs:  ethapi.FormatLogs(tracer.StructLogs()),
+			AssetLogs:   ethapi.FormatAssetLogs(tracer.AssetLogs()),
 		}, nil
 
 	case *tracers.Tracer:
 		return tracer.GetResult()
 
+	case *tracers.AssetTracer:
+		return tracer.GetResult()
+
 	default:
 		panic(fmt.Sprintf("bad tracer type %T", tracer))
 	}
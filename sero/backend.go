@@ -27,11 +27,14 @@ import (
 	"sync/atomic"
 
 	"github.com/sero-cash/go-sero/voter"
+	"github.com/sero-cash/go-sero/zero/wallet/pending"
 	"github.com/sero-cash/go-sero/zero/wallet/stakeservice"
 
 	"github.com/sero-cash/go-sero/zero/txtool"
 	"github.com/sero-cash/go-sero/zero/zconfig"
 
+	"github.com/sero-cash/go-sero/zero/checkpoint"
+
 	"github.com/sero-cash/go-sero/zero/wallet/lstate"
 
 	"github.com/sero-cash/go-sero/internal/ethapi"
@@ -44,8 +47,10 @@ import (
 	"github.com/sero-cash/go-sero/common/address"
 	"github.com/sero-cash/go-sero/common/hexutil"
 	"github.com/sero-cash/go-sero/consensus"
+	"github.com/sero-cash/go-sero/consensus/dev"
 	"github.com/sero-cash/go-sero/consensus/ethash"
 	"github.com/sero-cash/go-sero/core"
+	"github.com/sero-cash/go-sero/core/assetindex"
 	"github.com/sero-cash/go-sero/core/bloombits"
 	"github.com/sero-cash/go-sero/core/rawdb"
 	"github.com/sero-cash/go-sero/core/types"
@@ -63,6 +68,7 @@ import (
 	"github.com/sero-cash/go-sero/sero/gasprice"
 	"github.com/sero-cash/go-sero/serodb"
 	"github.com/sero-cash/go-sero/zero/wallet/light"
+	"github.com/sero-cash/go-sero/zero/wallet/witness"
 )
 
 type LesServer interface {
@@ -86,6 +92,7 @@ type Sero struct {
 	blockchain      *core.BlockChain
 	exchange        *exchange.Exchange
 	lightNode       *light.LightNode
+	witnesses       *witness.Witnesses
 	protocolManager *ProtocolManager
 	lesServer       LesServer
 
@@ -99,9 +106,13 @@ type Sero struct {
 	bloomRequests chan chan *bloombits.Retrieval // Channel receiving bloom data retrieval requests
 	bloomIndexer  *core.ChainIndexer             // Bloom indexer operating during block imports
 
+	assetIndexer  *core.ChainIndexer   // Token and ticket registry indexer operating during block imports
+	assetRegistry *assetindex.Registry // Token and ticket registry maintained by the asset indexer
+
 	APIBackend *SeroAPIBackend
 
 	miner    *miner.Miner
+	stratum  *miner.Stratum
 	gasPrice *big.Int
 	serobase address.AccountAddress
 
@@ -135,13 +146,20 @@ func New(ctx *node.ServiceContext, config *Config) (*Sero, error) {
 	}
 	log.Info("Initialised chain configuration", "config", chainConfig)
 
+	var checkpoints []params.ZeroCheckpoint
+	if genesisHash == params.MainnetGenesisHash {
+		checkpoints = params.BetanetCheckpoints
+	}
+	zconfig.Init_Checkpoint_dir()
+	checkpoint.Init(zconfig.Checkpoint_dir(), checkpoints, config.CheckpointSigners)
+
 	sero := &Sero{
 		config:         config,
 		chainDb:        chainDb,
 		chainConfig:    chainConfig,
 		eventMux:       ctx.EventMux,
 		accountManager: ctx.AccountManager,
-		engine:         CreateConsensusEngine(ctx, &config.Ethash, chainConfig, chainDb),
+		engine:         CreateConsensusEngine(ctx, &config.Ethash, config.Dev, chainConfig, chainDb),
 		shutdownChan:   make(chan bool),
 		networkID:      config.NetworkId,
 		gasPrice:       config.GasPrice,
@@ -149,6 +167,7 @@ func New(ctx *node.ServiceContext, config *Config) (*Sero, error) {
 		bloomRequests:  make(chan chan *bloombits.Retrieval),
 		bloomIndexer:   NewBloomIndexer(chainDb, params.BloomBitsBlocks),
 	}
+	sero.assetIndexer, sero.assetRegistry = assetindex.NewIndexer(chainDb)
 
 	log.Info("Initialising Sero protocol", "versions", ProtocolVersions, "network", config.NetworkId)
 
@@ -161,7 +180,7 @@ func New(ctx *node.ServiceContext, config *Config) (*Sero, error) {
 	}
 	var (
 		vmConfig    = vm.Config{EnablePreimageRecording: config.EnablePreimageRecording}
-		cacheConfig = &core.CacheConfig{Disabled: config.NoPruning, TrieNodeLimit: config.TrieCache, TrieTimeLimit: config.TrieTimeout}
+		cacheConfig = &core.CacheConfig{Disabled: config.NoPruning, TrieNodeLimit: config.TrieCache, TrieTimeLimit: config.TrieTimeout, ZeroKeep: config.ZeroKeep}
 	)
 	sero.blockchain, err = core.NewBlockChain(chainDb, cacheConfig, sero.chainConfig, sero.engine, vmConfig, sero.accountManager)
 
@@ -177,10 +196,11 @@ func New(ctx *node.ServiceContext, config *Config) (*Sero, error) {
 		rawdb.WriteChainConfig(chainDb, genesisHash, chainConfig)
 	}
 	sero.bloomIndexer.Start(sero.blockchain)
+	sero.assetIndexer.Start(sero.blockchain)
 
-	//if config.TxPool.Journal != "" {
-	//	config.TxPool.Journal = ctx.ResolvePath(config.TxPool.Journal)
-	//}
+	if config.TxPool.Journal != "" {
+		config.TxPool.Journal = ctx.ResolvePath(config.TxPool.Journal)
+	}
 	sero.txPool = core.NewTxPool(config.TxPool, sero.chainConfig, sero.blockchain)
 
 	sero.voter = voter.NewVoter(sero.chainConfig, sero.blockchain, sero)
@@ -190,6 +210,22 @@ func New(ctx *node.ServiceContext, config *Config) (*Sero, error) {
 	}
 	sero.miner = miner.New(sero, sero.chainConfig, sero.EventMux(), sero.voter, sero.engine)
 	sero.miner.SetExtra(makeExtraData(config.ExtraData))
+	fallback, err := miner.ParseLotteryFallback(config.LotteryFallback)
+	if err != nil {
+		return nil, err
+	}
+	sero.miner.SetLottery(miner.LotteryConfig{Deadline: config.LotteryDeadline, Fallback: fallback})
+	if config.StratumAddr != "" {
+		sero.stratum, err = miner.NewStratum(sero.engine, miner.StratumConfig{
+			Addr:       config.StratumAddr,
+			Difficulty: new(big.Int).SetUint64(config.StratumDifficulty),
+			ShareTime:  miner.DefaultStratumShareTime,
+		})
+		if err != nil {
+			return nil, err
+		}
+		sero.miner.Register(sero.stratum)
+	}
 
 	sero.APIBackend = &SeroAPIBackend{sero, nil}
 	gpoParams := config.GPO
@@ -205,11 +241,18 @@ func New(ctx *node.ServiceContext, config *Config) (*Sero, error) {
 
 	stakeservice.NewStakeService(zconfig.Stake_dir(), sero.blockchain, sero.accountManager)
 
+	pending.NewPendingStore(zconfig.Pending_dir(), pending.DefaultExpiration, sero.txPool)
+
 	//init light
 	if config.StartLight {
 		sero.lightNode = light.NewLightNode(zconfig.Light_dir(), sero.txPool, sero.blockchain.GetDB())
 	}
 
+	//init witness
+	if config.StartWitness {
+		sero.witnesses = witness.NewWitnesses(zconfig.Witness_dir())
+	}
+
 	return sero, nil
 }
 
@@ -243,7 +286,12 @@ func CreateDB(ctx *node.ServiceContext, config *Config, name string) (serodb.Dat
 }
 
 // CreateConsensusEngine creates the required type of consensus engine instance for an Sero service
-func CreateConsensusEngine(ctx *node.ServiceContext, config *ethash.Config, chainConfig *params.ChainConfig, db serodb.Database) consensus.Engine { // If proof-of-authority is requested, set it up
+func CreateConsensusEngine(ctx *node.ServiceContext, config *ethash.Config, devConfig *dev.Config, chainConfig *params.ChainConfig, db serodb.Database) consensus.Engine { // If proof-of-authority is requested, set it up
+	// The development network seals without proof-of-work
+	if devConfig != nil {
+		log.Warn("Dev engine used", "period", devConfig.Period)
+		return dev.New(*devConfig, ctx.AccountManager)
+	}
 	// Otherwise assume proof-of-work
 	switch config.PowMode {
 	case ethash.ModeFake:
@@ -424,6 +472,11 @@ func (s *Sero) Start(srvr *p2p.Server) error {
 	// Start the bloom bits servicing goroutines
 	s.startBloomHandlers()
 
+	// Prepare the ethash caches and datasets ahead of the epochs
+	if engine, ok := s.engine.(dagPregenerator); ok {
+		go s.dagLoop(engine)
+	}
+
 	// Start the RPC service
 	s.netRPCService = ethapi.NewPublicNetAPI(srvr, s.NetVersion())
 
@@ -443,10 +496,35 @@ func (s *Sero) Start(srvr *p2p.Server) error {
 	return nil
 }
 
+// dagPregenerator is an engine preparing the data of its proof of work before
+// the blocks need it.
+type dagPregenerator interface {
+	Pregenerate(block uint64, mining bool)
+}
+
+// dagLoop pregenerates the data of the engine from the head and on each new
+// head, until the chain stops.
+func (s *Sero) dagLoop(engine dagPregenerator) {
+	heads := make(chan core.ChainHeadEvent, 10)
+	sub := s.blockchain.SubscribeChainHeadEvent(heads)
+	defer sub.Unsubscribe()
+
+	engine.Pregenerate(s.blockchain.CurrentBlock().NumberU64(), s.IsMining())
+	for {
+		select {
+		case head := <-heads:
+			engine.Pregenerate(head.Block.NumberU64(), s.IsMining())
+		case <-sub.Err():
+			return
+		}
+	}
+}
+
 // Stop implements node.Service, terminating all internal goroutines used by the
 // Sero protocol.
 func (s *Sero) Stop() error {
 	s.bloomIndexer.Close()
+	s.assetIndexer.Close()
 	s.blockchain.Stop()
 	s.protocolManager.Stop()
 	if s.lesServer != nil {
@@ -27,13 +27,19 @@ import (
 	"github.com/sero-cash/go-sero/common/address"
 
 	"github.com/sero-cash/go-sero/common/hexutil"
+	"github.com/sero-cash/go-sero/consensus/dev"
 	"github.com/sero-cash/go-sero/consensus/ethash"
 	"github.com/sero-cash/go-sero/core"
+	"github.com/sero-cash/go-sero/miner"
 	"github.com/sero-cash/go-sero/params"
 	"github.com/sero-cash/go-sero/sero/downloader"
 	"github.com/sero-cash/go-sero/sero/gasprice"
 )
 
+// MinZeroKeep is the least number of blocks whose zero state is kept when
+// pruning, deeper reorganisations aren't expected.
+const MinZeroKeep = 1024
+
 // DefaultConfig contains default settings for use on the Sero main net.
 var DefaultConfig = Config{
 	SyncMode: downloader.FullSync,
@@ -51,6 +57,10 @@ var DefaultConfig = Config{
 	TrieTimeout:   60 * time.Minute,
 	GasPrice:      big.NewInt(params.Gta),
 
+	LotteryDeadline:   miner.DefaultLotteryConfig.Deadline,
+	LotteryFallback:   miner.DefaultLotteryConfig.Fallback.String(),
+	StratumDifficulty: 100000000,
+
 	TxPool: core.DefaultTxPoolConfig,
 	GPO: gasprice.Config{
 		Blocks:     20,
@@ -84,6 +94,10 @@ type Config struct {
 	SyncMode  downloader.SyncMode
 	NoPruning bool
 
+	// Number of recent blocks whose zero state shortcuts are kept, 0 keeps
+	// all. The indexers skip the accounts dated before the pruned blocks.
+	ZeroKeep uint64 `toml:",omitempty"`
+
 	MineMode  bool
 
 	StartExchange bool
@@ -91,6 +105,12 @@ type Config struct {
 
 	StartLight bool
 
+	// Maintains the witnesses of registered roots, see zero/wallet/witness
+	StartWitness bool
+
+	// Public keys trusted to sign zero checkpoints on top of the params ones
+	CheckpointSigners []string `toml:",omitempty"`
+
 	// Light client options
 	LightServ  int `toml:",omitempty"` // Maximum percentage of time allowed for serving LES requests
 	LightPeers int `toml:",omitempty"` // Maximum number of LES client peers
@@ -108,9 +128,20 @@ type Config struct {
 	ExtraData    []byte                 `toml:",omitempty"`
 	GasPrice     *big.Int
 
+	// Lottery options, see miner.LotteryConfig
+	LotteryDeadline time.Duration `toml:",omitempty"` // Longest wait for the votes of a mined block
+	LotteryFallback string        `toml:",omitempty"` // What is done with a block missing votes at the deadline
+
+	// Stratum server options
+	StratumAddr       string `toml:",omitempty"` // TCP address of the stratum server, empty disables it
+	StratumDifficulty uint64 `toml:",omitempty"` // Initial share difficulty of the stratum workers
+
 	// Ethash options
 	Ethash ethash.Config
 
+	// Dev engine options, the dev engine replaces ethash if set
+	Dev *dev.Config `toml:",omitempty"`
+
 	// Transaction pool options
 	TxPool core.TxPoolConfig
 
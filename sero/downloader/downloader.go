@@ -34,6 +34,7 @@ import (
 	"github.com/sero-cash/go-sero/metrics"
 	"github.com/sero-cash/go-sero/params"
 	"github.com/sero-cash/go-sero/serodb"
+	"github.com/sero-cash/go-sero/zero/checkpoint"
 )
 
 var (
@@ -192,6 +193,10 @@ type BlockChain interface {
 
 	// InsertReceiptChain inserts a batch of receipts into the local chain.
 	InsertReceiptChain(types.Blocks, []types.Receipts) (int, error)
+
+	// TrustCheckpoint sets the chain of the blocks being downloaded which
+	// leads to a checkpoint.
+	TrustCheckpoint(cp *checkpoint.Checkpoint, from uint64, hashes []common.Hash)
 }
 
 // New creates a new downloader to fetch hashes and blocks from remote peers.
@@ -1166,6 +1171,8 @@ func (d *Downloader) fetchParts(errCancel error, deliveryCh chan dataPack, deliv
 func (d *Downloader) processHeaders(origin uint64, pivot uint64, td *big.Int) error {
 	// Keep a count of uncertain headers to roll back
 	rollback := []*types.Header{}
+	// Hashes of the last linked headers, the chain leading to a checkpoint
+	linked := []common.Hash{}
 	defer func() {
 		if len(rollback) > 0 {
 			// Flatten the headers and roll them back
@@ -1261,6 +1268,28 @@ func (d *Downloader) processHeaders(origin uint64, pivot uint64, td *big.Int) er
 				}
 				chunk := headers[:limit]
 
+				// Drop peers going against a checkpoint. The linked headers
+				// processed before a matching one are the ancestors of its
+				// block, only the last of them are kept as the blocks further
+				// down are imported by the time the checkpoint is reached.
+				for _, header := range chunk {
+					if err := checkpoint.CheckHeader(header); err != nil {
+						log.Debug("Checkpoint mismatch", "number", header.Number, "hash", header.Hash(), "err", err)
+						return errInvalidChain
+					}
+					if d.mode != FullSync {
+						continue
+					}
+					if n := len(linked); n > 0 && header.ParentHash != linked[n-1] {
+						linked = linked[:0]
+					}
+					if linked = append(linked, header.Hash()); len(linked) > 2*maxQueuedHeaders {
+						linked = append(linked[:0:0], linked[len(linked)-maxQueuedHeaders:]...)
+					}
+					if cp := checkpoint.Get(header.Number.Uint64()); cp != nil {
+						d.blockchain.TrustCheckpoint(cp, header.Number.Uint64()+1-uint64(len(linked)), linked)
+					}
+				}
 				// In case of header only syncing, validate the chunk immediately
 				if d.mode == FastSync || d.mode == LightSync {
 					// Collect the yet unknown headers to mark them as uncertain
@@ -0,0 +1,176 @@
+// copyright 2018 The sero.cash Authors
+// This file is part of the go-sero library.
+//
+// The go-sero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-sero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-sero library. If not, see <http://www.gnu.org/licenses/>.
+
+package tracers
+
+import (
+	"encoding/json"
+	"sync/atomic"
+	"time"
+
+	"github.com/sero-cash/go-sero/common"
+	"github.com/sero-cash/go-sero/common/hexutil"
+	"github.com/sero-cash/go-sero/core/vm"
+	"github.com/sero-cash/go-sero/zero/txs/assets"
+)
+
+// AssetTracerName is the name selecting the built-in asset tracer in a trace
+// configuration.
+const AssetTracerName = "assetTracer"
+
+// Kinds of asset movements besides the vm transfer kinds.
+const (
+	MoveIssue      = "issue"      // Tokens issued or ticket created by a contract
+	MoveCallValues = "callValues" // Asset attached to the next call of a contract
+	MoveTxOut      = "txOut"      // Zero-state output created for an account
+)
+
+// AssetMove is a single currency or ticket movement of a transaction.
+type AssetMove struct {
+	Type     string          `json:"type"`
+	From     *common.Address `json:"from,omitempty"`
+	To       *common.Address `json:"to,omitempty"`
+	Currency string          `json:"currency,omitempty"`
+	Amount   *hexutil.Big    `json:"amount,omitempty"`
+	Category string          `json:"category,omitempty"`
+	Ticket   *common.Hash    `json:"ticket,omitempty"`
+}
+
+func newAssetMove(kind string, from *common.Address, to *common.Address, asset *assets.Asset) *AssetMove {
+	move := &AssetMove{Type: kind, From: from, To: to}
+	if asset.Tkn != nil {
+		move.Currency = common.BytesToString(asset.Tkn.Currency[:])
+		move.Amount = (*hexutil.Big)(asset.Tkn.Value.ToIntRef())
+	}
+	if asset.Tkt != nil {
+		ticket := common.BytesToHash(asset.Tkt.Value[:])
+		move.Category = common.BytesToString(asset.Tkt.Category[:])
+		move.Ticket = &ticket
+	}
+	return move
+}
+
+// AssetTracer is a Go tracer listing the currency and ticket movements of a
+// transaction, including the zero-state outputs it creates. Movements made in
+// reverted call frames are dropped.
+type AssetTracer struct {
+	moves  []*AssetMove
+	frames map[int]int // Number of movements when a call frame was entered, by snapshot
+	err    error
+
+	interrupt uint32 // Atomic flag to signal execution interruption
+	reason    error  // Textual reason for the interruption
+}
+
+// NewAssetTracer creates a new asset tracer.
+func NewAssetTracer() *AssetTracer {
+	return &AssetTracer{
+		moves:  []*AssetMove{},
+		frames: make(map[int]int),
+	}
+}
+
+// Stop terminates execution of the tracer at the first opportune moment.
+func (t *AssetTracer) Stop(err error) {
+	t.reason = err
+	atomic.StoreUint32(&t.interrupt, 1)
+}
+
+// CaptureStart implements the vm.Tracer interface.
+func (t *AssetTracer) CaptureStart(from common.Address, to common.Address, create bool, input []byte, gas uint64, asset *assets.Asset) error {
+	return nil
+}
+
+// CaptureState implements the vm.Tracer interface, aborting the execution if
+// the tracer was stopped.
+func (t *AssetTracer) CaptureState(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, memory *vm.Memory, stack *vm.Stack, contract *vm.Contract, depth int, err error) error {
+	if t.err == nil && atomic.LoadUint32(&t.interrupt) > 0 {
+		t.err = t.reason
+		env.Cancel()
+	}
+	return nil
+}
+
+// CaptureFault implements the vm.Tracer interface.
+func (t *AssetTracer) CaptureFault(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, memory *vm.Memory, stack *vm.Stack, contract *vm.Contract, depth int, err error) error {
+	return nil
+}
+
+// CaptureEnd implements the vm.Tracer interface.
+func (t *AssetTracer) CaptureEnd(output []byte, gasUsed uint64, d time.Duration, err error) error {
+	return nil
+}
+
+// CaptureEnter implements the vm.AssetTracer interface.
+func (t *AssetTracer) CaptureEnter(env *vm.EVM, snapshot int) error {
+	t.frames[snapshot] = len(t.moves)
+	return nil
+}
+
+// CaptureRevert implements the vm.AssetTracer interface, dropping the movements
+// of the reverted call frame and of the frames it entered.
+func (t *AssetTracer) CaptureRevert(env *vm.EVM, snapshot int) error {
+	count, ok := t.frames[snapshot]
+	if !ok {
+		return nil
+	}
+	t.moves = t.moves[:count]
+
+	for id := range t.frames {
+		if id >= snapshot {
+			delete(t.frames, id)
+		}
+	}
+	return nil
+}
+
+// CaptureTransfer implements the vm.AssetTracer interface.
+func (t *AssetTracer) CaptureTransfer(env *vm.EVM, kind string, from common.Address, to common.Address, asset *assets.Asset) error {
+	t.moves = append(t.moves, newAssetMove(kind, &from, &to, asset))
+	return nil
+}
+
+// CaptureIssue implements the vm.AssetTracer interface.
+func (t *AssetTracer) CaptureIssue(env *vm.EVM, contract common.Address, asset *assets.Asset) error {
+	t.moves = append(t.moves, newAssetMove(MoveIssue, nil, &contract, asset))
+	return nil
+}
+
+// CaptureCallValues implements the vm.AssetTracer interface.
+func (t *AssetTracer) CaptureCallValues(env *vm.EVM, contract common.Address, asset *assets.Asset) error {
+	t.moves = append(t.moves, newAssetMove(MoveCallValues, &contract, nil, asset))
+	return nil
+}
+
+// CaptureTxOut implements the vm.AssetTracer interface.
+func (t *AssetTracer) CaptureTxOut(env *vm.EVM, to common.Address, asset *assets.Asset) error {
+	t.moves = append(t.moves, newAssetMove(MoveTxOut, nil, &to, asset))
+	return nil
+}
+
+// Moves returns the asset movements traced so far.
+func (t *AssetTracer) Moves() []*AssetMove {
+	return t.moves
+}
+
+// GetResult returns the JSON encoded list of asset movements, or the error
+// that stopped the tracer.
+func (t *AssetTracer) GetResult() (json.RawMessage, error) {
+	if t.err != nil {
+		return nil, t.err
+	}
+	return json.Marshal(t.moves)
+}
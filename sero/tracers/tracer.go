@@ -300,13 +300,17 @@ type Tracer struct {
 	ctx map[string]interface{} // Transaction context gathered throughout execution
 	err error                  // Error, if one has occurred
 
+	assetHooks map[string]bool // Optional asset movement functions exposed by the tracer object
+
 	interrupt uint32 // Atomic flag to signal execution interruption
 	reason    error  // Textual reason for the interruption
 }
 
 // New instantiates a new tracer instance. code specifies a Javascript snippet,
 // which must evaluate to an expression returning an object with 'step', 'fault'
-// and 'result' functions.
+// and 'result' functions. The object may also expose 'enter', 'revert',
+// 'transfer', 'issue', 'callValues' and 'txOut' functions to follow the asset
+// movements of the execution.
 func New(code string) (*Tracer, error) {
 	// Resolve any tracers by name and assemble the tracer object
 	if tracer, ok := tracer(code); ok {
@@ -410,6 +414,15 @@ func New(code string) (*Tracer, error) {
 	}
 	tracer.vm.Pop()
 
+	// The asset movement functions are optional, only call the exposed ones
+	tracer.assetHooks = make(map[string]bool)
+	for _, hook := range assetHooks {
+		if tracer.vm.GetPropString(tracer.tracerObject, hook) && tracer.vm.IsFunction(-1) {
+			tracer.assetHooks[hook] = true
+		}
+		tracer.vm.Pop()
+	}
+
 	// Tracer is valid, inject the big int library to access large numbers
 	tracer.vm.EvalString(bigIntegerJS)
 	tracer.vm.PutGlobalString("bigInt")
@@ -571,6 +584,104 @@ func (jst *Tracer) CaptureEnd(output []byte, gasUsed uint64, t time.Duration, er
 	return nil
 }
 
+// assetHooks are the optional tracer functions called with the asset movements,
+// see vm.AssetTracer. They receive a movement object and the db.
+var assetHooks = []string{"enter", "revert", "transfer", "issue", "callValues", "txOut"}
+
+// callAsset calls an asset movement function of the tracer, if exposed, with a
+// movement object built from fields.
+func (jst *Tracer) callAsset(env *vm.EVM, hook string, fields map[string]interface{}) {
+	if jst.err != nil || !jst.assetHooks[hook] {
+		return
+	}
+	jst.dbWrapper.db = env.StateDB
+
+	obj := jst.vm.PushObject()
+	for key, val := range fields {
+		switch val := val.(type) {
+		case int:
+			jst.vm.PushInt(val)
+		case string:
+			jst.vm.PushString(val)
+		case []byte:
+			ptr := jst.vm.PushFixedBuffer(len(val))
+			copy(makeSlice(ptr, uint(len(val))), val)
+		case *big.Int:
+			pushBigInt(val, jst.vm)
+		default:
+			panic(fmt.Sprintf("unsupported type: %T", val))
+		}
+		jst.vm.PutPropString(obj, key)
+	}
+	jst.vm.PutPropString(jst.stateObject, "asset")
+
+	if _, err := jst.call(hook, "asset", "db"); err != nil {
+		jst.err = wrapError(hook, err)
+	}
+}
+
+// assetFields returns the movement object fields describing an asset.
+func assetFields(kind string, from *common.Address, to *common.Address, asset *assets.Asset) map[string]interface{} {
+	fields := map[string]interface{}{"type": kind}
+	if from != nil {
+		fields["from"] = from.Bytes()
+	}
+	if to != nil {
+		fields["to"] = to.Bytes()
+	}
+	if asset.Tkn != nil {
+		fields["currency"] = common.BytesToString(asset.Tkn.Currency[:])
+		fields["value"] = asset.Tkn.Value.ToIntRef()
+	}
+	if asset.Tkt != nil {
+		fields["category"] = common.BytesToString(asset.Tkt.Category[:])
+		fields["ticket"] = common.CopyBytes(asset.Tkt.Value[:])
+	}
+	return fields
+}
+
+// CaptureEnter implements the vm.AssetTracer interface, calling the 'enter'
+// function with the snapshot of the call frame.
+func (jst *Tracer) CaptureEnter(env *vm.EVM, snapshot int) error {
+	jst.callAsset(env, "enter", map[string]interface{}{"snapshot": snapshot})
+	return nil
+}
+
+// CaptureRevert implements the vm.AssetTracer interface, calling the 'revert'
+// function with the snapshot of the reverted call frame.
+func (jst *Tracer) CaptureRevert(env *vm.EVM, snapshot int) error {
+	jst.callAsset(env, "revert", map[string]interface{}{"snapshot": snapshot})
+	return nil
+}
+
+// CaptureTransfer implements the vm.AssetTracer interface, calling the
+// 'transfer' function.
+func (jst *Tracer) CaptureTransfer(env *vm.EVM, kind string, from common.Address, to common.Address, asset *assets.Asset) error {
+	jst.callAsset(env, "transfer", assetFields(kind, &from, &to, asset))
+	return nil
+}
+
+// CaptureIssue implements the vm.AssetTracer interface, calling the 'issue'
+// function.
+func (jst *Tracer) CaptureIssue(env *vm.EVM, contract common.Address, asset *assets.Asset) error {
+	jst.callAsset(env, "issue", assetFields(MoveIssue, nil, &contract, asset))
+	return nil
+}
+
+// CaptureCallValues implements the vm.AssetTracer interface, calling the
+// 'callValues' function.
+func (jst *Tracer) CaptureCallValues(env *vm.EVM, contract common.Address, asset *assets.Asset) error {
+	jst.callAsset(env, "callValues", assetFields(MoveCallValues, &contract, nil, asset))
+	return nil
+}
+
+// CaptureTxOut implements the vm.AssetTracer interface, calling the 'txOut'
+// function.
+func (jst *Tracer) CaptureTxOut(env *vm.EVM, to common.Address, asset *assets.Asset) error {
+	jst.callAsset(env, "txOut", assetFields(MoveTxOut, nil, &to, asset))
+	return nil
+}
+
 // GetResult calls the Javascript 'result' function and returns its value, or any accumulated error
 func (jst *Tracer) GetResult() (json.RawMessage, error) {
 	// Transform the context into a JavaScript object and inject into the state
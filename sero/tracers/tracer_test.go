@@ -0,0 +1,91 @@
+// copyright 2018 The sero.cash Authors
+// This file is part of the go-sero library.
+//
+// The go-sero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-sero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-sero library. If not, see <http://www.gnu.org/licenses/>.
+
+package tracers
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/sero-cash/go-sero/common"
+	"github.com/sero-cash/go-sero/core/state"
+	"github.com/sero-cash/go-sero/core/vm"
+	"github.com/sero-cash/go-sero/core/vm/runtime"
+	"github.com/sero-cash/go-sero/serodb"
+)
+
+// runTrace executes code with value SERO attached under a JavaScript tracer,
+// returning the tracer result.
+func runTrace(t *testing.T, code string, contract []byte, value int64) json.RawMessage {
+	tracer, err := New(code)
+	if err != nil {
+		t.Fatalf("failed to create tracer: %v", err)
+	}
+	statedb, _ := state.New(state.NewDatabase(serodb.NewMemDatabase()), nil)
+	address := common.BytesToAddress([]byte("contract"))
+	statedb.CreateAccount(address)
+	statedb.SetCode(address, contract)
+
+	cfg := &runtime.Config{
+		State:     statedb,
+		Value:     big.NewInt(value),
+		EVMConfig: vm.Config{Debug: true, Tracer: tracer},
+	}
+	runtime.Call(address, nil, cfg)
+
+	res, err := tracer.GetResult()
+	if err != nil {
+		t.Fatalf("failed to retrieve trace result: %v", err)
+	}
+	return res
+}
+
+func TestTracerAssetHooks(t *testing.T) {
+	// PUSH1 0 PUSH1 0 REVERT
+	contract := []byte{byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.REVERT)}
+	code := `{
+		moves: [],
+		step: function() {},
+		fault: function() {},
+		enter: function(move) { this.moves.push("enter"); },
+		revert: function(move) { this.moves.push("revert"); },
+		transfer: function(move) { this.moves.push(move.type + ":" + move.currency + ":" + move.value); },
+		result: function() { return this.moves; }
+	}`
+	var moves []string
+	if err := json.Unmarshal(runTrace(t, code, contract, 10), &moves); err != nil {
+		t.Fatalf("failed to decode trace result: %v", err)
+	}
+	want := []string{"enter", vm.TransferCall + ":SERO:10", "revert"}
+	if len(moves) != len(want) {
+		t.Fatalf("moves mismatch: have %v, want %v", moves, want)
+	}
+	for i := range want {
+		if moves[i] != want[i] {
+			t.Errorf("move %d mismatch: have %s, want %s", i, moves[i], want[i])
+		}
+	}
+}
+
+func TestTracerWithoutAssetHooks(t *testing.T) {
+	contract := []byte{byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.REVERT)}
+	code := `{steps: 0, step: function() { this.steps++; }, fault: function() {}, result: function() { return this.steps; }}`
+
+	if res := string(runTrace(t, code, contract, 10)); res != "3" {
+		t.Errorf("steps mismatch: have %s, want 3", res)
+	}
+}
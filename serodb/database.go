@@ -91,6 +91,32 @@ func NewLDBDatabase(file string, cache int, handles int) (*LDBDatabase, error) {
 	}, nil
 }
 
+// NewLDBDatabaseReadOnly opens a LevelDB for reading only, it fails instead of
+// recovering a corrupted database.
+func NewLDBDatabaseReadOnly(file string, cache int, handles int) (*LDBDatabase, error) {
+	if cache < 16 {
+		cache = 16
+	}
+	if handles < 16 {
+		handles = 16
+	}
+	db, err := leveldb.OpenFile(file, &opt.Options{
+		OpenFilesCacheCapacity: handles,
+		BlockCacheCapacity:     cache / 2 * opt.MiB,
+		Filter:                 filter.NewBloomFilter(10),
+		ErrorIfMissing:         true,
+		ReadOnly:               true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &LDBDatabase{
+		fn:  file,
+		db:  db,
+		log: log.New("database", file),
+	}, nil
+}
+
 // Path returns the path to the database directory.
 func (db *LDBDatabase) Path() string {
 	return db.fn
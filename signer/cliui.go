@@ -0,0 +1,55 @@
+package signer
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/sero-cash/go-sero/console"
+)
+
+// CommandlineUI approves the requests on the terminal of the signer.
+type CommandlineUI struct {
+	mu sync.Mutex
+}
+
+func NewCommandlineUI() *CommandlineUI {
+	return &CommandlineUI{}
+}
+
+// ApproveTx implements UI, printing the summary and prompting for the
+// approval and the password of a locked account.
+func (ui *CommandlineUI) ApproveTx(request *SignRequest) (SignResponse, error) {
+	ui.mu.Lock()
+	defer ui.mu.Unlock()
+
+	fmt.Println("-------- Transaction signing request --------")
+	fmt.Print(request.Summary)
+	fmt.Println("---------------------------------------------")
+	approved, err := console.Stdin.PromptConfirm("Approve?")
+	if err != nil || !approved {
+		return SignResponse{}, err
+	}
+	response := SignResponse{Approved: true}
+	if request.Locked {
+		if response.Password, err = console.Stdin.PromptPassword("Password: "); err != nil {
+			return SignResponse{}, err
+		}
+	}
+	return response, nil
+}
+
+// ShowInfo implements UI.
+func (ui *CommandlineUI) ShowInfo(message string) {
+	ui.mu.Lock()
+	defer ui.mu.Unlock()
+
+	fmt.Println("INFO:", message)
+}
+
+// ShowError implements UI.
+func (ui *CommandlineUI) ShowError(message string) {
+	ui.mu.Lock()
+	defer ui.mu.Unlock()
+
+	fmt.Println("ERROR:", message)
+}
@@ -0,0 +1,221 @@
+package signer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Limits are the values allowed of each currency, the currencies missing are
+// not allowed at all.
+type Limits map[string]*big.Int
+
+// Rules are the limits enforced on the transactions to sign, the ones breaking
+// them are refused before being submitted for approval. Values are JSON
+// numbers in the smallest unit of their currency. Tickets aren't limited.
+type Rules struct {
+	// Destinations are the limits per transaction of the outputs to each PKr,
+	// given in base58.
+	Destinations map[string]Limits `json:"destinations"`
+
+	// Default are the limits per transaction of the outputs to the PKrs
+	// missing from Destinations, which are refused if nil.
+	Default Limits `json:"default"`
+
+	// Daily caps the total spent by the signer in each currency over the last
+	// 24 hours, fees and commands included. The currencies missing are not
+	// capped.
+	Daily Limits `json:"daily"`
+
+	// Cmds are the commands allowed, by their names in the summaries.
+	Cmds []string `json:"cmds"`
+
+	// Create allows the contract commands creating a contract, which have no
+	// destination to check. They are refused otherwise, even with the
+	// contract command allowed.
+	Create bool `json:"create"`
+
+	// AutoApprove signs without asking the UI the transactions respecting the
+	// rules, if their account is unlocked. Only the requests of the IPC
+	// endpoint are signed so, the HTTP ones are always submitted to the UI.
+	AutoApprove bool `json:"autoApprove"`
+}
+
+// LoadRules reads the rules of a JSON file.
+func LoadRules(file string) (*Rules, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	rules := new(Rules)
+	if err := json.Unmarshal(data, rules); err != nil {
+		return nil, fmt.Errorf("invalid rules file %s: %v", file, err)
+	}
+	return rules, nil
+}
+
+// Check tells why a transaction breaks the rules, given the totals already
+// spent over the last 24 hours.
+func (r *Rules) Check(summary *Summary, spentToday map[string]*big.Int) error {
+	sent := make(map[string]map[string]*big.Int)
+	send := func(to string, asset *Asset) {
+		if asset.Currency == "" {
+			return
+		}
+		if sent[to] == nil {
+			sent[to] = make(map[string]*big.Int)
+		}
+		if total, ok := sent[to][asset.Currency]; ok {
+			total.Add(total, asset.Value)
+		} else {
+			sent[to][asset.Currency] = new(big.Int).Set(asset.Value)
+		}
+	}
+	for i := range summary.Receptions {
+		send(summary.Receptions[i].To, &summary.Receptions[i].Asset)
+	}
+	for _, cmd := range summary.Cmds {
+		if !r.allowsCmd(cmd.Name) {
+			return fmt.Errorf("command %s is not allowed", cmd.Name)
+		}
+		if cmd.Name == CmdContract && cmd.To == "" && !r.Create {
+			return fmt.Errorf("contract creation is not allowed")
+		}
+		if cmd.To != "" && cmd.Asset != nil {
+			send(cmd.To, cmd.Asset)
+		}
+	}
+	for to, totals := range sent {
+		limits, ok := r.Destinations[to]
+		if !ok {
+			if r.Default == nil {
+				return fmt.Errorf("destination %s is not allowed", to)
+			}
+			limits = r.Default
+		}
+		for currency, total := range totals {
+			if err := limits.check(currency, total); err != nil {
+				return fmt.Errorf("sending to %s: %v", to, err)
+			}
+		}
+	}
+	for currency, spent := range summary.Spent {
+		if _, capped := r.Daily[currency]; !capped {
+			continue
+		}
+		total := new(big.Int).Set(spent)
+		if today, ok := spentToday[currency]; ok {
+			total.Add(total, today)
+		}
+		if err := r.Daily.check(currency, total); err != nil {
+			return fmt.Errorf("daily cap: %v", err)
+		}
+	}
+	return nil
+}
+
+func (r *Rules) allowsCmd(name string) bool {
+	for _, cmd := range r.Cmds {
+		if cmd == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (l Limits) check(currency string, value *big.Int) error {
+	limit, ok := l[currency]
+	if !ok {
+		return fmt.Errorf("%s is not allowed", currency)
+	}
+	if value.Cmp(limit) > 0 {
+		return fmt.Errorf("%v %s exceeds the limit of %v", value, currency, limit)
+	}
+	return nil
+}
+
+// spending is a value spent by a signed transaction.
+type spending struct {
+	Time     int64    `json:"time"`
+	Currency string   `json:"currency"`
+	Value    *big.Int `json:"value"`
+}
+
+// ledger records the values spent over the last 24 hours for the daily caps,
+// in a file surviving the restarts of the signer.
+type ledger struct {
+	file      string
+	spendings []spending
+	mu        sync.Mutex
+}
+
+func openLedger(file string) (*ledger, error) {
+	l := &ledger{file: file}
+	if file == "" {
+		return l, nil
+	}
+	data, err := ioutil.ReadFile(file)
+	if os.IsNotExist(err) {
+		return l, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &l.spendings); err != nil {
+		return nil, fmt.Errorf("invalid ledger file %s: %v", file, err)
+	}
+	return l, nil
+}
+
+// spentSince returns the totals spent in each currency since the cutoff,
+// forgetting the older spendings.
+func (l *ledger) spentSince(cutoff time.Time) map[string]*big.Int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	totals := make(map[string]*big.Int)
+	recent := l.spendings[:0]
+	for _, s := range l.spendings {
+		if s.Time < cutoff.Unix() {
+			continue
+		}
+		recent = append(recent, s)
+		if total, ok := totals[s.Currency]; ok {
+			total.Add(total, s.Value)
+		} else {
+			totals[s.Currency] = new(big.Int).Set(s.Value)
+		}
+	}
+	l.spendings = recent
+	return totals
+}
+
+// record adds the totals spent by a transaction and saves the ledger.
+func (l *ledger) record(now time.Time, spent map[string]*big.Int) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for currency, value := range spent {
+		l.spendings = append(l.spendings, spending{Time: now.Unix(), Currency: currency, Value: value})
+	}
+	if l.file == "" {
+		return nil
+	}
+	data, err := json.Marshal(l.spendings)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(l.file), 0700); err != nil {
+		return err
+	}
+	tmp := l.file + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, l.file)
+}
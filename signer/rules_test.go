@@ -0,0 +1,92 @@
+package signer
+
+import (
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func sero(value int64) *Asset {
+	return &Asset{Currency: "SERO", Value: big.NewInt(value)}
+}
+
+func TestRulesCheck(t *testing.T) {
+	rules := &Rules{
+		Destinations: map[string]Limits{"known": {"SERO": big.NewInt(100)}},
+		Daily:        Limits{"SERO": big.NewInt(150)},
+		Cmds:         []string{CmdBuyShare},
+	}
+	tests := []struct {
+		summary *Summary
+		today   int64
+		ok      bool
+	}{
+		{&Summary{Receptions: []Transfer{{"known", *sero(60)}, {"known", *sero(40)}}}, 0, true},
+		{&Summary{Receptions: []Transfer{{"known", *sero(60)}, {"known", *sero(41)}}}, 0, false},
+		{&Summary{Receptions: []Transfer{{"unknown", *sero(1)}}}, 0, false},
+		{&Summary{Receptions: []Transfer{{"known", Asset{Currency: "OTHER", Value: big.NewInt(1)}}}}, 0, false},
+		{&Summary{Cmds: []Command{{Name: CmdBuyShare, Asset: sero(10)}}}, 0, true},
+		{&Summary{Cmds: []Command{{Name: CmdClosePool}}}, 0, false},
+		{&Summary{Spent: map[string]*big.Int{"SERO": big.NewInt(100)}}, 50, true},
+		{&Summary{Spent: map[string]*big.Int{"SERO": big.NewInt(100)}}, 51, false},
+		{&Summary{Spent: map[string]*big.Int{"OTHER": big.NewInt(1000)}}, 0, true},
+	}
+	for i, test := range tests {
+		today := map[string]*big.Int{"SERO": big.NewInt(test.today)}
+		if err := rules.Check(test.summary, today); (err == nil) != test.ok {
+			t.Errorf("test %d: got error %v, want ok %v", i, err, test.ok)
+		}
+	}
+
+	rules.Default = Limits{"SERO": big.NewInt(5)}
+	if err := rules.Check(&Summary{Receptions: []Transfer{{"unknown", *sero(5)}}}, nil); err != nil {
+		t.Errorf("default limit refused: %v", err)
+	}
+	if err := rules.Check(&Summary{Receptions: []Transfer{{"unknown", *sero(6)}}}, nil); err == nil {
+		t.Errorf("default limit exceeded without error")
+	}
+
+	// Contract creations have no destination, they need to be allowed apart
+	rules.Cmds = append(rules.Cmds, CmdContract)
+	if err := rules.Check(&Summary{Cmds: []Command{{Name: CmdContract, To: "known", Asset: sero(1)}}}, nil); err != nil {
+		t.Errorf("contract call refused: %v", err)
+	}
+	creation := &Summary{Cmds: []Command{{Name: CmdContract, Asset: sero(1000)}}}
+	if err := rules.Check(creation, nil); err == nil {
+		t.Errorf("contract creation accepted without being allowed")
+	}
+	rules.Create = true
+	if err := rules.Check(creation, nil); err != nil {
+		t.Errorf("allowed contract creation refused: %v", err)
+	}
+}
+
+func TestLedger(t *testing.T) {
+	dir, err := ioutil.TempDir("", "signer-ledger")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "ledger.json")
+
+	l, err := openLedger(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	l.record(now.Add(-25*time.Hour), map[string]*big.Int{"SERO": big.NewInt(7)})
+	l.record(now.Add(-time.Hour), map[string]*big.Int{"SERO": big.NewInt(3)})
+	l.record(now, map[string]*big.Int{"SERO": big.NewInt(4)})
+
+	reopened, err := openLedger(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	spent := reopened.spentSince(now.Add(-24 * time.Hour))
+	if spent["SERO"].Int64() != 7 {
+		t.Errorf("spent %v SERO in the last day, want 7", spent["SERO"])
+	}
+}
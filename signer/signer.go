@@ -0,0 +1,173 @@
+// Package signer implements a standalone signer of SERO transaction params.
+//
+// The signer holds a keystore outside of gero, decodes the params it is asked
+// to sign into summaries, refuses the ones breaking its rules and submits the
+// others for approval to its UI. gero delegates the signing to it by the
+// backend of accounts/external.
+package signer
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/sero-cash/go-czero-import/keys"
+	"github.com/sero-cash/go-sero/accounts"
+	"github.com/sero-cash/go-sero/accounts/keystore"
+	"github.com/sero-cash/go-sero/log"
+	"github.com/sero-cash/go-sero/rpc"
+	"github.com/sero-cash/go-sero/zero/txtool"
+	"github.com/sero-cash/go-sero/zero/txtool/flight"
+)
+
+var (
+	ErrUnknownFrom = errors.New("no account of the signer owns the param")
+	ErrRejected    = errors.New("request rejected")
+)
+
+// SignRequest is a transaction param submitted for approval.
+type SignRequest struct {
+	Summary *Summary
+	Locked  bool // The account is locked, the approval must give its password
+}
+
+// SignResponse is the approval of a request.
+type SignResponse struct {
+	Approved bool
+	Password string
+}
+
+// UI is the user interface approving the requests of the signer.
+type UI interface {
+	// ApproveTx asks to approve the signing of a transaction param.
+	ApproveTx(request *SignRequest) (SignResponse, error)
+
+	// ShowInfo displays an information to the user.
+	ShowInfo(message string)
+
+	// ShowError displays an error to the user.
+	ShowError(message string)
+}
+
+// Signer signs the transaction params with the accounts of a keystore.
+type Signer struct {
+	ks     *keystore.KeyStore
+	ui     UI
+	rules  *Rules // Nil submits every request for approval
+	ledger *ledger
+
+	mu sync.Mutex // Serializes the requests, for the approvals and the daily caps
+}
+
+// New creates a signer of the accounts of ks, recording the values spent for
+// the daily caps in ledgerFile.
+func New(ks *keystore.KeyStore, ui UI, rules *Rules, ledgerFile string) (*Signer, error) {
+	ledger, err := openLedger(ledgerFile)
+	if err != nil {
+		return nil, err
+	}
+	return &Signer{ks: ks, ui: ui, rules: rules, ledger: ledger}, nil
+}
+
+// APIs returns the RPC services of the signer. The requests they receive are
+// only signed by the rules without approval if autoApprove is set, which
+// must be kept to the endpoints reachable by trusted processes only.
+func (s *Signer) APIs(autoApprove bool) []rpc.API {
+	return []rpc.API{
+		{
+			Namespace: "signer",
+			Version:   "1.0",
+			Service:   &API{s, autoApprove},
+			Public:    true,
+		},
+	}
+}
+
+// owner returns the account owning the params sent from pkr.
+func (s *Signer) owner(pkr *keys.PKr) (accounts.Account, error) {
+	for _, account := range s.ks.Accounts() {
+		if keys.IsMyPKr(account.Tk.ToUint512(), pkr) {
+			return account, nil
+		}
+	}
+	return accounts.Account{}, ErrUnknownFrom
+}
+
+func (s *Signer) summarize(param *txtool.GTxParam) (accounts.Account, *Summary, error) {
+	account, err := s.owner(&param.From.PKr)
+	if err != nil {
+		return account, nil, err
+	}
+	return account, Summarize(account.Address.Base58(), account.Tk.ToUint512(), param), nil
+}
+
+// SignTxParam checks a param against the rules, gets it approved and signs
+// it. The approval is only given by the rules if autoApprove is set.
+func (s *Signer) SignTxParam(param *txtool.GTxParam, autoApprove bool) (*txtool.GTx, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	account, summary, err := s.summarize(param)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if s.rules != nil {
+		if err := s.rules.Check(summary, s.ledger.spentSince(now.Add(-24*time.Hour))); err != nil {
+			log.Warn("Signing request refused by the rules", "account", summary.Account, "err", err)
+			s.ui.ShowError("Refused: " + err.Error() + "\n" + summary.String())
+			return nil, err
+		}
+	}
+	seed, err := s.ks.GetSeed(account)
+	if err != nil || s.rules == nil || !s.rules.AutoApprove || !autoApprove {
+		response, err := s.ui.ApproveTx(&SignRequest{Summary: summary, Locked: seed == nil})
+		if err != nil {
+			return nil, err
+		}
+		if !response.Approved {
+			return nil, ErrRejected
+		}
+		if seed == nil {
+			if seed, err = s.ks.GetSeedWithPassphrase(account, response.Password); err != nil {
+				return nil, err
+			}
+		}
+	} else {
+		s.ui.ShowInfo("Signed by the rules:\n" + summary.String())
+	}
+
+	sk := keys.Seed2Sk(seed.SeedToUint256())
+	gtx, err := flight.SignTx(&sk, param)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.ledger.record(now, summary.Spent); err != nil {
+		log.Error("Failed to record the spending", "err", err)
+	}
+	log.Info("Signed transaction param", "account", summary.Account, "hash", gtx.Hash)
+	return &gtx, nil
+}
+
+// API is the RPC service of the signer, exposed in the signer namespace.
+type API struct {
+	signer      *Signer
+	autoApprove bool
+}
+
+// List returns the accounts of the signer.
+func (api *API) List() []accounts.Account {
+	return api.signer.ks.Accounts()
+}
+
+// Summarize returns the summary of a param, as submitted for approval.
+func (api *API) Summarize(param txtool.GTxParam) (*Summary, error) {
+	_, summary, err := api.signer.summarize(&param)
+	return summary, err
+}
+
+// SignTxParam signs a param once checked against the rules and approved,
+// the stake commands included.
+func (api *API) SignTxParam(param txtool.GTxParam) (*txtool.GTx, error) {
+	return api.signer.SignTxParam(&param, api.autoApprove)
+}
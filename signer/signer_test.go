@@ -0,0 +1,63 @@
+package signer
+
+import (
+	"io/ioutil"
+	"math/big"
+	"os"
+	"testing"
+
+	"github.com/sero-cash/go-czero-import/cpt"
+	"github.com/sero-cash/go-sero/accounts/keystore"
+	"github.com/sero-cash/go-sero/zero/txtool"
+	"github.com/sero-cash/go-sero/zero/txtool/prepare"
+)
+
+// rejectingUI rejects the requests submitted for approval, counting them.
+type rejectingUI struct {
+	requests int
+}
+
+func (ui *rejectingUI) ApproveTx(request *SignRequest) (SignResponse, error) {
+	ui.requests++
+	return SignResponse{}, nil
+}
+
+func (ui *rejectingUI) ShowInfo(message string)  {}
+func (ui *rejectingUI) ShowError(message string) {}
+
+// TestAutoApproveScope checks that the rules only sign without approval the
+// requests of the APIs given auto-approval.
+func TestAutoApproveScope(t *testing.T) {
+	cpt.ZeroInit("", cpt.NET_Dev)
+	dir, err := ioutil.TempDir("", "signer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	ks := keystore.NewKeyStore(dir, keystore.LightScryptN, keystore.LightScryptP)
+	account, err := ks.NewAccount("", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ks.Unlock(account, ""); err != nil {
+		t.Fatal(err)
+	}
+	ui := new(rejectingUI)
+	s, err := New(ks, ui, &Rules{AutoApprove: true}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	param := txtool.GTxParam{GasPrice: new(big.Int)}
+	param.From.PKr = prepare.CreatePkr(account.Address.ToUint512(), 1)
+
+	// The HTTP endpoint gets the APIs without auto-approval
+	api := s.APIs(false)[0].Service.(*API)
+	if _, err := api.SignTxParam(param); err != ErrRejected || ui.requests != 1 {
+		t.Fatalf("request without auto-approval: have %v after %d approvals, want %v after 1", err, ui.requests, ErrRejected)
+	}
+	api = s.APIs(true)[0].Service.(*API)
+	api.SignTxParam(param)
+	if ui.requests != 1 {
+		t.Fatalf("request with auto-approval submitted for approval")
+	}
+}
@@ -0,0 +1,230 @@
+package signer
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+
+	"github.com/btcsuite/btcutil/base58"
+	"github.com/sero-cash/go-czero-import/keys"
+	"github.com/sero-cash/go-sero/common/hexutil"
+	"github.com/sero-cash/go-sero/zero/txs/assets"
+	"github.com/sero-cash/go-sero/zero/txtool"
+	"github.com/sero-cash/go-sero/zero/txtool/flight"
+	"github.com/sero-cash/go-sero/zero/utils"
+)
+
+// Names of the commands of a transaction, as written in the rules.
+const (
+	CmdBuyShare    = "buyShare"
+	CmdRegistPool  = "registPool"
+	CmdClosePool   = "closePool"
+	CmdContract    = "contract"
+	CmdPkgCreate   = "pkgCreate"
+	CmdPkgTransfer = "pkgTransfer"
+	CmdPkgClose    = "pkgClose"
+)
+
+// Asset is the readable content of an asset, a token and or a ticket.
+type Asset struct {
+	Currency string        `json:"currency,omitempty"`
+	Value    *big.Int      `json:"value,omitempty"`
+	Category string        `json:"category,omitempty"`
+	Ticket   *keys.Uint256 `json:"ticket,omitempty"`
+}
+
+func newAsset(asset *assets.Asset) (ret Asset) {
+	if asset.Tkn != nil {
+		ret.Currency = utils.Uint256ToCurrency(&asset.Tkn.Currency)
+		ret.Value = asset.Tkn.Value.ToInt()
+	}
+	if asset.Tkt != nil {
+		ret.Category = utils.Uint256ToCurrency(&asset.Tkt.Category)
+		ticket := asset.Tkt.Value
+		ret.Ticket = &ticket
+	}
+	return
+}
+
+func (a Asset) String() string {
+	var parts []string
+	if a.Currency != "" {
+		parts = append(parts, fmt.Sprintf("%v %s", a.Value, a.Currency))
+	}
+	if a.Ticket != nil {
+		parts = append(parts, fmt.Sprintf("ticket %s %s", a.Category, hexutil.Encode(a.Ticket[:])))
+	}
+	if len(parts) == 0 {
+		return "nothing"
+	}
+	return strings.Join(parts, " and ")
+}
+
+// Transfer is an asset sent to another account.
+type Transfer struct {
+	To    string `json:"to"`
+	Asset Asset  `json:"asset"`
+}
+
+// Command is the readable content of a command of a transaction.
+type Command struct {
+	Name   string `json:"name"`
+	To     string `json:"to,omitempty"`
+	Asset  *Asset `json:"asset,omitempty"`
+	Detail string `json:"detail,omitempty"`
+}
+
+func (c Command) String() string {
+	s := c.Name
+	if c.Asset != nil {
+		s += " " + c.Asset.String()
+	}
+	if c.To != "" {
+		s += " to " + c.To
+	}
+	if c.Detail != "" {
+		s += " (" + c.Detail + ")"
+	}
+	return s
+}
+
+// Summary is the readable content of a transaction param, submitted for
+// approval and checked against the rules. Values are in the smallest unit of
+// their currency.
+type Summary struct {
+	Account    string     `json:"account"` // Address of the signing account
+	Gas        uint64     `json:"gas"`
+	GasPrice   *big.Int   `json:"gasPrice"`
+	Fee        Asset      `json:"fee"`
+	Ins        []Asset    `json:"ins"`        // Decoded inputs
+	Receptions []Transfer `json:"receptions"` // Outputs to other accounts
+	Change     []Asset    `json:"change"`     // Outputs back to the signing account
+	Cmds       []Command  `json:"cmds"`
+
+	// Spent is the total of each currency leaving the account: the fee, the
+	// receptions and the values of the commands.
+	Spent map[string]*big.Int `json:"spent"`
+}
+
+func pkrString(pkr *keys.PKr) string {
+	return base58.Encode(pkr[:])
+}
+
+// Summarize decodes a transaction param with the tk of the signing account,
+// telling the outputs to other accounts from the change.
+func Summarize(account string, tk *keys.Uint512, param *txtool.GTxParam) *Summary {
+	s := &Summary{
+		Account:  account,
+		Gas:      param.Gas,
+		GasPrice: param.GasPrice,
+		Fee:      newAsset(&assets.Asset{Tkn: &param.Fee}),
+		Spent:    make(map[string]*big.Int),
+	}
+	s.spend(s.Fee)
+
+	outs := make([]txtool.Out, len(param.Ins))
+	for i, in := range param.Ins {
+		outs[i] = in.Out
+	}
+	for _, out := range flight.DecOut(tk, outs) {
+		s.Ins = append(s.Ins, newAsset(&out.Asset))
+	}
+	for i := range param.Outs {
+		out := &param.Outs[i]
+		asset := newAsset(&out.Asset)
+		if keys.IsMyPKr(tk, &out.PKr) {
+			s.Change = append(s.Change, asset)
+			continue
+		}
+		s.Receptions = append(s.Receptions, Transfer{To: pkrString(&out.PKr), Asset: asset})
+		s.spend(asset)
+	}
+
+	cmds := &param.Cmds
+	if cmd := cmds.BuyShare; cmd != nil {
+		asset := Asset{Currency: "SERO", Value: cmd.Value.ToInt()}
+		detail := "vote " + pkrString(&cmd.Vote)
+		if cmd.Pool != nil {
+			detail += ", pool " + hexutil.Encode(cmd.Pool[:])
+		}
+		s.Cmds = append(s.Cmds, Command{Name: CmdBuyShare, Asset: &asset, Detail: detail})
+		s.spend(asset)
+	}
+	if cmd := cmds.RegistPool; cmd != nil {
+		asset := Asset{Currency: "SERO", Value: cmd.Value.ToInt()}
+		detail := fmt.Sprintf("vote %s, fee rate %d", pkrString(&cmd.Vote), cmd.FeeRate)
+		s.Cmds = append(s.Cmds, Command{Name: CmdRegistPool, Asset: &asset, Detail: detail})
+		s.spend(asset)
+	}
+	if cmds.ClosePool != nil {
+		s.Cmds = append(s.Cmds, Command{Name: CmdClosePool})
+	}
+	if cmd := cmds.Contract; cmd != nil {
+		asset := newAsset(&cmd.Asset)
+		c := Command{Name: CmdContract, Asset: &asset, Detail: fmt.Sprintf("%d bytes of data", len(cmd.Data))}
+		if cmd.To != nil {
+			c.To = pkrString(cmd.To)
+		} else {
+			c.Detail = "creation, " + c.Detail
+		}
+		s.Cmds = append(s.Cmds, c)
+		s.spend(asset)
+	}
+	if cmd := cmds.PkgCreate; cmd != nil {
+		asset := newAsset(&cmd.Asset)
+		c := Command{Name: CmdPkgCreate, Asset: &asset, Detail: "id " + hexutil.Encode(cmd.Id[:])}
+		if !keys.IsMyPKr(tk, &cmd.PKr) {
+			c.To = pkrString(&cmd.PKr)
+		}
+		s.Cmds = append(s.Cmds, c)
+		s.spend(asset)
+	}
+	if cmd := cmds.PkgTransfer; cmd != nil {
+		s.Cmds = append(s.Cmds, Command{Name: CmdPkgTransfer, To: pkrString(&cmd.PKr), Detail: "id " + hexutil.Encode(cmd.Id[:])})
+	}
+	if cmd := cmds.PkgClose; cmd != nil {
+		s.Cmds = append(s.Cmds, Command{Name: CmdPkgClose, Detail: "id " + hexutil.Encode(cmd.Id[:])})
+	}
+	return s
+}
+
+func (s *Summary) spend(asset Asset) {
+	if asset.Currency == "" {
+		return
+	}
+	if spent, ok := s.Spent[asset.Currency]; ok {
+		spent.Add(spent, asset.Value)
+	} else {
+		s.Spent[asset.Currency] = new(big.Int).Set(asset.Value)
+	}
+}
+
+func (s *Summary) String() string {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "Account:   %s\n", s.Account)
+	fmt.Fprintf(&b, "Fee:       %s (gas %d at %v)\n", s.Fee, s.Gas, s.GasPrice)
+	fmt.Fprintf(&b, "Inputs:    %d\n", len(s.Ins))
+	for _, in := range s.Ins {
+		fmt.Fprintf(&b, "           %s\n", in)
+	}
+	for _, reception := range s.Receptions {
+		fmt.Fprintf(&b, "Send:      %s to %s\n", reception.Asset, reception.To)
+	}
+	for _, change := range s.Change {
+		fmt.Fprintf(&b, "Change:    %s\n", change)
+	}
+	for _, cmd := range s.Cmds {
+		fmt.Fprintf(&b, "Command:   %s\n", cmd)
+	}
+	currencies := make([]string, 0, len(s.Spent))
+	for currency := range s.Spent {
+		currencies = append(currencies, currency)
+	}
+	sort.Strings(currencies)
+	for _, currency := range currencies {
+		fmt.Fprintf(&b, "Spent:     %v %s\n", s.Spent[currency], currency)
+	}
+	return b.String()
+}
@@ -1 +1,207 @@
 package checkpoint
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/sero-cash/go-sero/common"
+	"github.com/sero-cash/go-sero/common/hexutil"
+	"github.com/sero-cash/go-sero/core/state"
+	"github.com/sero-cash/go-sero/core/types"
+	"github.com/sero-cash/go-sero/crypto"
+	"github.com/sero-cash/go-sero/log"
+	"github.com/sero-cash/go-sero/params"
+	"github.com/sero-cash/go-sero/rlp"
+	"github.com/sero-cash/go-sero/zero/stake"
+)
+
+var (
+	ErrUnknownSigner = errors.New("checkpoint signed by an untrusted key")
+	ErrMismatch      = errors.New("block doesn't match the checkpoint")
+)
+
+// Checkpoint is a trusted record of a block and of its state.
+type Checkpoint params.ZeroCheckpoint
+
+// New returns the unsigned checkpoint of a block, statedb is the state after
+// the block was processed.
+func New(header *types.Header, statedb *state.StateDB) *Checkpoint {
+	return &Checkpoint{
+		Number:    header.Number.Uint64(),
+		Hash:      header.Hash(),
+		Root:      header.Root,
+		ZRoot:     zroot(statedb),
+		StakeHash: stake.NewStakeState(statedb).StateHash(),
+	}
+}
+
+func zroot(statedb *state.StateDB) common.Hash {
+	return common.Hash(statedb.CurrentZState().State.MTree.CurrentRoot())
+}
+
+// SigHash returns the hash signed by the checkpoint signers.
+func (self *Checkpoint) SigHash() (h common.Hash) {
+	b, _ := rlp.EncodeToBytes([]interface{}{
+		self.Number,
+		self.Hash,
+		self.Root,
+		self.ZRoot,
+		self.StakeHash,
+	})
+	return crypto.Keccak256Hash(b)
+}
+
+func (self *Checkpoint) Sign(prv *ecdsa.PrivateKey) (e error) {
+	hash := self.SigHash()
+	self.Sig, e = crypto.Sign(hash[:], prv)
+	return
+}
+
+// Signer returns the public key which signed the checkpoint.
+func (self *Checkpoint) Signer() ([]byte, error) {
+	hash := self.SigHash()
+	return crypto.Ecrecover(hash[:], self.Sig)
+}
+
+// Check compares a processed block with the checkpoint of its number if there
+// is one.
+func Check(header *types.Header, statedb *state.StateDB) error {
+	cp := Get(header.Number.Uint64())
+	if cp == nil {
+		return nil
+	}
+	if cp.Hash != header.Hash() || cp.Root != header.Root {
+		return fmt.Errorf("%v: block %d is %x, checkpoint %x", ErrMismatch, cp.Number, header.Hash(), cp.Hash)
+	}
+	if zroot := zroot(statedb); cp.ZRoot != zroot {
+		return fmt.Errorf("%v: zero state root of block %d is %x, checkpoint %x", ErrMismatch, cp.Number, zroot, cp.ZRoot)
+	}
+	if hash := stake.NewStakeState(statedb).StateHash(); cp.StakeHash != hash {
+		return fmt.Errorf("%v: stake state of block %d is %x, checkpoint %x", ErrMismatch, cp.Number, hash, cp.StakeHash)
+	}
+	return nil
+}
+
+// CheckHeader compares a header with the checkpoint of its number if there is
+// one, it is all a node which doesn't hold the state can check.
+func CheckHeader(header *types.Header) error {
+	return CheckHash(header.Number.Uint64(), header.Hash())
+}
+
+func CheckHash(num uint64, hash common.Hash) error {
+	if cp := Get(num); cp != nil && cp.Hash != hash {
+		return fmt.Errorf("%v: block %d is %x, checkpoint %x", ErrMismatch, num, hash, cp.Hash)
+	}
+	return nil
+}
+
+var (
+	lock        sync.RWMutex
+	checkpoints []*Checkpoint
+	signers     [][]byte
+)
+
+// Init loads the builtin checkpoints and the ones found in the json files of
+// dir, only the checkpoints signed by one of the params signers or of the
+// extra signers are kept.
+func Init(dir string, builtin []params.ZeroCheckpoint, extraSigners []string) {
+	lock.Lock()
+	defer lock.Unlock()
+
+	signers = nil
+	for _, signer := range append(append([]string{}, params.CheckpointSigners...), extraSigners...) {
+		if pub, err := hexutil.Decode(signer); err != nil {
+			log.Warn("Invalid checkpoint signer", "signer", signer, "err", err)
+		} else {
+			signers = append(signers, pub)
+		}
+	}
+
+	checkpoints = nil
+	for i := range builtin {
+		cp := Checkpoint(builtin[i])
+		add(&cp)
+	}
+	files, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+	for _, file := range files {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			log.Warn("Failed to read checkpoints", "file", file, "err", err)
+			continue
+		}
+		var cps []Checkpoint
+		if err := json.Unmarshal(data, &cps); err != nil {
+			log.Warn("Failed to parse checkpoints", "file", file, "err", err)
+			continue
+		}
+		for i := range cps {
+			add(&cps[i])
+		}
+	}
+	sort.Slice(checkpoints, func(i, j int) bool {
+		return checkpoints[i].Number < checkpoints[j].Number
+	})
+	if len(checkpoints) > 0 {
+		latest := checkpoints[len(checkpoints)-1]
+		log.Info("Loaded zero checkpoints", "count", len(checkpoints), "number", latest.Number, "hash", latest.Hash)
+	}
+}
+
+func add(cp *Checkpoint) {
+	if err := verify(cp); err != nil {
+		log.Warn("Ignoring checkpoint", "number", cp.Number, "hash", cp.Hash, "err", err)
+		return
+	}
+	for _, v := range checkpoints {
+		if v.Number == cp.Number {
+			if v.Hash != cp.Hash {
+				log.Error("Conflicting checkpoints", "number", cp.Number, "hash", v.Hash, "other", cp.Hash)
+			}
+			return
+		}
+	}
+	checkpoints = append(checkpoints, cp)
+}
+
+func verify(cp *Checkpoint) error {
+	pub, err := cp.Signer()
+	if err != nil {
+		return err
+	}
+	for _, signer := range signers {
+		if bytes.Equal(signer, pub) {
+			return nil
+		}
+	}
+	return ErrUnknownSigner
+}
+
+// Get returns the checkpoint of the block num, nil if there is none.
+func Get(num uint64) *Checkpoint {
+	lock.RLock()
+	defer lock.RUnlock()
+	i := sort.Search(len(checkpoints), func(i int) bool {
+		return checkpoints[i].Number >= num
+	})
+	if i < len(checkpoints) && checkpoints[i].Number == num {
+		return checkpoints[i]
+	}
+	return nil
+}
+
+// Latest returns the checkpoint with the highest number, nil if there is none.
+func Latest() *Checkpoint {
+	lock.RLock()
+	defer lock.RUnlock()
+	if len(checkpoints) == 0 {
+		return nil
+	}
+	return checkpoints[len(checkpoints)-1]
+}
@@ -0,0 +1,70 @@
+package checkpoint
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sero-cash/go-sero/common"
+	"github.com/sero-cash/go-sero/common/hexutil"
+	"github.com/sero-cash/go-sero/crypto"
+	"github.com/sero-cash/go-sero/params"
+)
+
+func signed(t *testing.T, num uint64, hash common.Hash) (*Checkpoint, string) {
+	key, _ := crypto.GenerateKey()
+	cp := &Checkpoint{Number: num, Hash: hash}
+	if err := cp.Sign(key); err != nil {
+		t.Fatalf("failed to sign: %v", err)
+	}
+	return cp, hexutil.Encode(crypto.FromECDSAPub(&key.PublicKey))
+}
+
+func TestInit(t *testing.T) {
+	dir, err := ioutil.TempDir("", "checkpoint")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	trusted, signer := signed(t, 100, common.Hash{1})
+	untrusted, _ := signed(t, 200, common.Hash{2})
+	data, _ := json.Marshal([]*Checkpoint{trusted, untrusted})
+	if err := ioutil.WriteFile(filepath.Join(dir, "checkpoints.json"), data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	Init(dir, nil, []string{signer})
+
+	if cp := Get(100); cp == nil || cp.Hash != trusted.Hash {
+		t.Fatalf("trusted checkpoint not loaded: %v", cp)
+	}
+	if cp := Get(200); cp != nil {
+		t.Fatalf("untrusted checkpoint loaded: %v", cp)
+	}
+	if cp := Latest(); cp == nil || cp.Number != 100 {
+		t.Fatalf("latest checkpoint mismatch: %v", cp)
+	}
+	if err := CheckHash(100, common.Hash{1}); err != nil {
+		t.Errorf("matching block rejected: %v", err)
+	}
+	if err := CheckHash(100, common.Hash{3}); err == nil {
+		t.Errorf("mismatching block accepted")
+	}
+	if err := CheckHash(101, common.Hash{3}); err != nil {
+		t.Errorf("block without checkpoint rejected: %v", err)
+	}
+}
+
+func TestInitBuiltin(t *testing.T) {
+	cp, signer := signed(t, 100, common.Hash{1})
+	Init("", []params.ZeroCheckpoint{params.ZeroCheckpoint(*cp)}, []string{signer})
+	if Get(100) == nil {
+		t.Fatalf("builtin checkpoint not loaded")
+	}
+	Init("", []params.ZeroCheckpoint{params.ZeroCheckpoint(*cp)}, nil)
+	if Get(100) != nil {
+		t.Fatalf("checkpoint of an untrusted signer loaded")
+	}
+}
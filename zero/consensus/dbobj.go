@@ -20,6 +20,11 @@ func makeBlockName(pre string, num uint64, hash *common.Hash) (ret []byte) {
 	return
 }
 
+// BlockRecordsKey returns the database key of the records of a block.
+func (self DBObj) BlockRecordsKey(num uint64, hash *common.Hash) []byte {
+	return makeBlockName(self.Pre, num, hash)
+}
+
 func (self DBObj) setBlockRecords(batch serodb.Putter, num uint64, hash *common.Hash, records []*Record) {
 	if b, err := rlp.EncodeToBytes(&records); err != nil {
 		panic(err)
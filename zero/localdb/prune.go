@@ -0,0 +1,46 @@
+package localdb
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/sero-cash/go-czero-import/keys"
+	"github.com/sero-cash/go-sero/serodb"
+	"github.com/sero-cash/go-sero/zero/utils"
+)
+
+var (
+	prunedKey = []byte("$SERO_ZSTATE_PRUNED$")
+
+	ErrPruned = errors.New("zero state of the block is pruned")
+)
+
+// GetPrunedNum returns the lowest block whose shortcut is kept, blocks below
+// it have been pruned.
+func GetPrunedNum(db serodb.Getter) uint64 {
+	data, _ := db.Get(prunedKey)
+	return utils.DecodeNumber(data)
+}
+
+func PutPrunedNum(db serodb.Putter, num uint64) {
+	if err := db.Put(prunedKey, utils.EncodeNumber(num)); err != nil {
+		panic(err)
+	}
+}
+
+// CheckPruned returns ErrPruned if the shortcut of the block num has been
+// pruned.
+func CheckPruned(db serodb.Getter, num uint64) error {
+	if pruned := GetPrunedNum(db); num < pruned {
+		return fmt.Errorf("%v: block %d, zero state kept from block %d", ErrPruned, num, pruned)
+	}
+	return nil
+}
+
+// DeleteBlock removes the shortcut of a block, the roots and the nils it
+// refers to are kept.
+func DeleteBlock(db serodb.Deleter, num uint64, hash *keys.Uint256) {
+	if err := db.Delete(BlockKey(num, hash)); err != nil {
+		panic(err)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"math/big"
+	"sort"
 
 	"github.com/sero-cash/go-sero/consensus/ethash"
 
@@ -286,7 +287,7 @@ type selectShare struct {
 	Shares []common.Hash
 }
 
-func blockVotesKey(hash common.Hash) []byte {
+func BlockVotesKey(hash common.Hash) []byte {
 	return append(blockVotesPrefix, hash[:]...)
 }
 
@@ -306,14 +307,14 @@ func (self *StakeState) RecordVotes(batch serodb.Batch, block *types.Block) erro
 		log.Crit("Failed to RLP encode blockVotes", "err", err)
 	}
 
-	if err := batch.Put(blockVotesKey(block.Hash()), data); err != nil {
+	if err := batch.Put(BlockVotesKey(block.Hash()), data); err != nil {
 		log.Crit("Failed to store blockVotes to number mapping", "err", err)
 	}
 	return nil
 }
 
 func SeleteBlockShare(getter serodb.Getter, block common.Hash) (idx []uint32, shares []*Share) {
-	data, _ := getter.Get(blockVotesKey(block))
+	data, _ := getter.Get(BlockVotesKey(block))
 	if len(data) == 0 {
 		return
 	}
@@ -417,6 +418,64 @@ func (self *StakeState) updateStakePool(pool *StakePool) {
 	self.stakePoolObj.AddObj(pool)
 }
 
+type stateShare struct {
+	Key   common.Hash
+	Num   uint32
+	State common.Hash
+}
+
+// StateHash returns the commitment of the stake state in checkpoints: the
+// shares of the share tree in order, with their number of tickets left and
+// their state, the state of the pools they were bought from and the number of
+// the shares not in the tree yet.
+func (self *StakeState) StateHash() common.Hash {
+	shares := []stateShare{}
+	pools := make(map[common.Hash]bool)
+
+	stack := []common.Hash{}
+	key := self.GetStakeState(rootKey)
+	for key != emptyHash || len(stack) > 0 {
+		for key != emptyHash {
+			stack = append(stack, key)
+			key = self.GetStakeState((&SNode{key: key}).leftKey())
+		}
+		key, stack = stack[len(stack)-1], stack[:len(stack)-1]
+		node := (&SNode{key: key}).init(self)
+
+		item := stateShare{Key: key, Num: node.num}
+		if share := self.GetShare(key); share != nil {
+			item.State = common.BytesToHash(share.State())
+			if share.PoolId != nil {
+				pools[*share.PoolId] = true
+			}
+		}
+		shares = append(shares, item)
+		key = self.GetStakeState(node.rightKey())
+	}
+
+	ids := []common.Hash{}
+	for id := range pools {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		return cmp(ids[i], ids[j]) < 0
+	})
+	poolStates := []common.Hash{}
+	for _, id := range ids {
+		if pool := self.GetStakePool(id); pool != nil {
+			poolStates = append(poolStates, common.BytesToHash(pool.State()))
+		} else {
+			poolStates = append(poolStates, emptyHash)
+		}
+	}
+
+	b, err := rlp.EncodeToBytes([]interface{}{self.getNewShareNum(), shares, ids, poolStates})
+	if err != nil {
+		panic(err)
+	}
+	return crypto.Keccak256Hash(b)
+}
+
 func (self *StakeState) NeedTwoVote(num uint64) bool {
 	window_size := getStatisticsMissWindow()
 	if num > seroparam.SIP4()+window_size {
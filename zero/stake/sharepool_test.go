@@ -114,3 +114,55 @@ func TestPosDif(t *testing.T) {
 	fmt.Println(amount)
 	fmt.Println(state.CaleAvgPrice(amount))
 }
+
+func TestStateHash(t *testing.T) {
+	state, _ := newState()
+	empty := state.StateHash()
+
+	var pkr keys.PKr
+	copy(pkr[:], crypto.Keccak512([]byte("123")))
+	poolId := crypto.Keccak256Hash(pkr[:])
+	share1 := &Share{PKr: pkr, Value: big.NewInt(10000), InitNum: 10, PoolId: &poolId}
+	share2 := &Share{PKr: pkr, Value: big.NewInt(10001), InitNum: 5}
+	state.AddPendingShare(share1)
+	state.AddPendingShare(share2)
+	pending := state.StateHash()
+	if pending == empty {
+		t.Fatalf("pending shares not committed")
+	}
+	state.insertSharePool(share1)
+	state.insertSharePool(share2)
+	inserted := state.StateHash()
+	if inserted == pending {
+		t.Fatalf("share tree not committed")
+	}
+
+	// A share changing without the tree changing
+	share1.addIncome(big.NewInt(1))
+	state.updateShare(share1)
+	income := state.StateHash()
+	if income == inserted {
+		t.Fatalf("share state not committed")
+	}
+
+	// And a pool of a share
+	state.AddStakePool(&StakePool{PKr: pkr, Amount: big.NewInt(1), Fee: 2500})
+	if state.StateHash() == income {
+		t.Fatalf("pool state not committed")
+	}
+
+	// The same shares inserted in another order give the same hash
+	other, _ := newState()
+	share3 := &Share{PKr: pkr, Value: big.NewInt(10001), InitNum: 5}
+	share4 := &Share{PKr: pkr, Value: big.NewInt(10000), InitNum: 10, PoolId: &poolId}
+	other.AddPendingShare(share3)
+	other.AddPendingShare(share4)
+	other.insertSharePool(share3)
+	other.insertSharePool(share4)
+	share4.addIncome(big.NewInt(1))
+	other.updateShare(share4)
+	other.AddStakePool(&StakePool{PKr: pkr, Amount: big.NewInt(1), Fee: 2500})
+	if other.StateHash() != state.StateHash() {
+		t.Fatalf("hash depends on the insertion order")
+	}
+}
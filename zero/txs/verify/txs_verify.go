@@ -45,6 +45,10 @@ func CheckUint(i *utils.U256) bool {
 	}
 }
 
+// Verify is the former verifier of zero transactions, kept to be checked
+// against the verification engine by the differential tests.
+//
+// Deprecated: use the zero/txtool/verify package.
 func Verify(s *stx.T, state *zstate.ZState) (e error) {
 
 	t := utils.TR_enter("Miner-Verify-----Pre")
@@ -146,6 +146,76 @@ func (self *MerkleTree) GetPaths(value keys.Uint256) (pos uint64, paths [DEPTH]k
 	return
 }
 
+// LeafRoot returns the root of the tree right after the leaf value was
+// appended, the root its out is recorded under. The left brothers of its path
+// were complete then and the right ones empty, so it is rebuilt from the
+// current nodes. ok is false if the leaf is not in the tree.
+func (self *MerkleTree) LeafRoot(value keys.Uint256) (root keys.Uint256, ok bool) {
+	leafIndex := keys.Uint256_To_Uint64(self.db.GetState(leafKey(value).NewRef()).NewRef())
+	if leafIndex == 0 {
+		return
+	}
+	treeIndex := keys.Uint256_To_Uint64(self.db.GetState(treeKey(value).NewRef()).NewRef())
+	if self.db.GetState(indexPathKey(leafIndex, treeIndex).NewRef()) != value {
+		return
+	}
+
+	current_value := value
+	depth := toDepth(leafIndex)
+	for leafIndex != 1 {
+		brotherIndex := brother(leafIndex)
+		var brotherValue keys.Uint256
+		if brotherIndex > leafIndex {
+			brotherValue = cpt.EmptyRoots()[depth]
+		} else {
+			brotherValue = self.db.GetState(indexPathKey(brotherIndex, treeIndex).NewRef())
+			if brotherValue == keys.Empty_Uint256 {
+				return
+			}
+		}
+
+		if leafIndex%2 == 0 {
+			current_value = Combine(&current_value, &brotherValue)
+		} else {
+			current_value = Combine(&brotherValue, &current_value)
+		}
+
+		leafIndex = parent(leafIndex)
+		depth++
+	}
+	return current_value, true
+}
+
+// GetTreeIndex returns the index of the tree holding a leaf.
+func (self *MerkleTree) GetTreeIndex(value keys.Uint256) uint64 {
+	return keys.Uint256_To_Uint64(self.db.GetState(treeKey(value).NewRef()).NewRef())
+}
+
+// Frontier returns the index of the current tree, its number of leaves and the
+// latest left node of each depth, the nodes the next leaves are combined with.
+func (self *MerkleTree) Frontier() (tree uint64, size uint64, left [DEPTH]keys.Uint256) {
+	tree = self.geCurrentTreeIndex()
+	index := self.getCurrentLeafIndex()
+	size = index - startIndex
+	if size == 0 {
+		return
+	}
+	index--
+	for depth := 0; depth < DEPTH; depth++ {
+		if index%2 == 1 {
+			index--
+		}
+		left[depth] = self.db.GetState(indexPathKey(index, tree).NewRef())
+		index = parent(index)
+	}
+	return
+}
+
+// CurrentRoot returns the root of the tree the outputs are appended to.
+func (self *MerkleTree) CurrentRoot() keys.Uint256 {
+	return self.db.GetState(indexPathKey(1, self.geCurrentTreeIndex()).NewRef())
+}
+
 func (self *MerkleTree) nextLeafIndex() uint64 {
 	leafIndex := self.getCurrentLeafIndex()
 	if leafIndex == cap {
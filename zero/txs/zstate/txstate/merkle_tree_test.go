@@ -76,3 +76,26 @@ func TestOutTree(t *testing.T) {
 		}
 	}
 }
+
+func TestLeafRoot(t *testing.T) {
+	cpt.ZeroInit("", 0)
+
+	ft := consensus.NewFakeTri()
+	outState := NewMerkleTree(&TreeState{db: &ft})
+
+	roots := []keys.Uint256{}
+	for i := 1; i <= 100; i++ {
+		value := crypto.Keccak256Hash(big.NewInt(int64(i)).Bytes()).HashToUint256()
+		roots = append(roots, outState.AppendLeaf(*value))
+	}
+	for i, root := range roots {
+		value := crypto.Keccak256Hash(big.NewInt(int64(i + 1)).Bytes()).HashToUint256()
+		if got, ok := outState.LeafRoot(*value); !ok || got != root {
+			t.Fatalf("leaf %d: root mismatch, ok %v", i+1, ok)
+		}
+	}
+	missing := crypto.Keccak256Hash([]byte("missing")).HashToUint256()
+	if _, ok := outState.LeafRoot(*missing); ok {
+		t.Fatalf("missing leaf found")
+	}
+}
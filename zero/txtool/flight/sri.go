@@ -23,6 +23,7 @@ import (
 
 	"github.com/sero-cash/go-czero-import/keys"
 	"github.com/sero-cash/go-sero/zero/localdb"
+	"github.com/sero-cash/go-sero/zero/wallet/witness"
 )
 
 type SRI struct {
@@ -55,8 +56,12 @@ func GetOut(root *keys.Uint256, num uint64) (out *localdb.RootState) {
 	}
 }
 
-func GetBlock(num uint64, hash *common.Hash) (ret *localdb.Block) {
-	ret = localdb.GetBlock(txtool.Ref_inst.Bc.GetDB(), num, hash.HashToUint256())
+func GetBlock(num uint64, hash *common.Hash) (ret *localdb.Block, e error) {
+	db := txtool.Ref_inst.Bc.GetDB()
+	if e = localdb.CheckPruned(db, num); e != nil {
+		return
+	}
+	ret = localdb.GetBlock(db, num, hash.HashToUint256())
 	if ret == nil {
 		temp_state := txtool.Ref_inst.Bc.CurrentState(hash)
 		if temp_state == nil {
@@ -82,7 +87,11 @@ func (self *SRI) GetBlocksInfoByDelay(start uint64, count uint64, delay uint64)
 			num := start + i
 			chain_block := txtool.Ref_inst.Bc.GetBlockByNumber(num)
 			hash := chain_block.Hash()
-			local_block := GetBlock(num, &hash)
+			local_block, err := GetBlock(num, &hash)
+			if err != nil {
+				e = err
+				return
+			}
 			if local_block != nil {
 				block := txtool.Block{}
 				block.Hash = *hash.HashToUint256()
@@ -121,6 +130,9 @@ func (self *SRI) GetBlocksInfo(start uint64, count uint64) (blocks []txtool.Bloc
 }
 
 func (self *SRI) GetAnchor(roots []keys.Uint256) (wits []txtool.Witness, e error) {
+	if ws := witness.Current(); ws != nil && ws.Has(roots) {
+		return ws.GetAnchor(roots)
+	}
 	state := txtool.Ref_inst.CurrentState()
 	if state != nil {
 		for _, root := range roots {
@@ -0,0 +1,45 @@
+package verify_test
+
+import (
+	"testing"
+
+	"github.com/sero-cash/go-czero-import/seroparam"
+	legacy "github.com/sero-cash/go-sero/zero/txs/verify"
+	"github.com/sero-cash/go-sero/zero/txtool/verify"
+)
+
+// forks returns the heights of the forks changing the rules of the verifier.
+func forks() []uint64 {
+	return []uint64{seroparam.SIP1(), seroparam.SIP2(), seroparam.SIP3(), seroparam.SIP4(), seroparam.VP0()}
+}
+
+// forkHeights returns the heights on both sides of each fork.
+func forkHeights() (heights []uint64) {
+	for _, fork := range forks() {
+		heights = append(heights, fork-1, fork)
+	}
+	return
+}
+
+// TestDifferential checks the verifier driven by the fork rule sets against
+// the former verifier of zero/txs/verify until it is retired: both have to
+// accept or reject the same txs, on both sides of each fork. The former
+// verifier isn't given the ehash, the fixture breaking it is left out.
+func TestDifferential(t *testing.T) {
+	for _, num := range forkHeights() {
+		for _, tt := range verifyTests {
+			if tt.name == "ehash" {
+				continue
+			}
+			f := newFixture(t, num)
+			tt.mutate(t, f)
+			tx := f.tx
+			engine := verify.Verify(&f.ehash, &tx, f.state)
+			tx = f.tx
+			former := legacy.Verify(&tx, f.state)
+			if (engine == nil) != (former == nil) {
+				t.Errorf("%s at %d: verifier and former verifier differ: %v, %v", tt.name, num, engine, former)
+			}
+		}
+	}
+}
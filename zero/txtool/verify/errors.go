@@ -0,0 +1,134 @@
+package verify
+
+import (
+	"fmt"
+
+	"github.com/sero-cash/go-czero-import/keys"
+	"github.com/sero-cash/go-sero/common/hexutil"
+	"github.com/sero-cash/go-sero/log"
+	"github.com/sero-cash/go-sero/zero/txs/stx"
+)
+
+// ErrorCode classifies why a zero transaction failed the verification.
+type ErrorCode int
+
+const (
+	ErrCodeTx           ErrorCode = iota + 1 // Malformed transaction, signature or sizes
+	ErrCodeFee                               // Invalid fee
+	ErrCodeBalance                           // Inputs and outputs don't balance
+	ErrCodeProof                             // Invalid zero-knowledge proof or input signature
+	ErrCodeNil                               // Input already spent
+	ErrCodeRootNotFound                      // Input or anchor not found in the zero state
+	ErrCodePkg                               // Invalid package description
+	ErrCodeCmd                               // Invalid command description
+)
+
+var errorCodeNames = map[ErrorCode]string{
+	ErrCodeTx:           "tx",
+	ErrCodeFee:          "fee",
+	ErrCodeBalance:      "balance",
+	ErrCodeProof:        "proof",
+	ErrCodeNil:          "nil",
+	ErrCodeRootNotFound: "root-not-found",
+	ErrCodePkg:          "pkg",
+	ErrCodeCmd:          "cmd",
+}
+
+func (code ErrorCode) String() string {
+	if name, ok := errorCodeNames[code]; ok {
+		return name
+	}
+	return fmt.Sprintf("unknown(%d)", int(code))
+}
+
+// Parts of a transaction an error can point to.
+const (
+	InputO  = "o_in"
+	InputZ  = "z_in"
+	OutputO = "o_out"
+	OutputZ = "z_out"
+	Pkg     = "pkg"
+)
+
+// VerifyError is the error returned by the verification of a zero transaction.
+// Input and Index locate the offending input or output when there is one.
+type VerifyError struct {
+	Code   ErrorCode
+	Input  string
+	Index  int
+	Reason string
+	Hash   keys.Uint256
+}
+
+func (self *VerifyError) Error() string {
+	if self.Input != "" {
+		return fmt.Sprintf("Verify Tx Error: code=%v, %v[%v], resean=%v , hash=%v", self.Code, self.Input, self.Index, self.Reason, hexutil.Encode(self.Hash[:]))
+	}
+	return fmt.Sprintf("Verify Tx Error: code=%v, resean=%v , hash=%v", self.Code, self.Reason, hexutil.Encode(self.Hash[:]))
+}
+
+// VerifyErrorData is the JSON form of a VerifyError, sent back as the data of
+// JSON-RPC errors.
+type VerifyErrorData struct {
+	Code   int           `json:"code"`
+	Name   string        `json:"name"`
+	Input  string        `json:"input,omitempty"`
+	Index  *int          `json:"index,omitempty"`
+	Reason string        `json:"reason"`
+	Hash   hexutil.Bytes `json:"hash"`
+}
+
+// ErrorData implements rpc.DataError.
+func (self *VerifyError) ErrorData() interface{} {
+	data := VerifyErrorData{
+		Code:   int(self.Code),
+		Name:   self.Code.String(),
+		Input:  self.Input,
+		Reason: self.Reason,
+		Hash:   self.Hash[:],
+	}
+	if self.Input != "" {
+		index := self.Index
+		data.Index = &index
+	}
+	return &data
+}
+
+// ErrorCodeOf returns the code of a verification error, 0 if e is not one.
+func ErrorCodeOf(e error) ErrorCode {
+	if ve, ok := e.(*VerifyError); ok {
+		return ve.Code
+	}
+	return 0
+}
+
+func report(ve *VerifyError, tx *stx.T) error {
+	ve.Hash = tx.ToHash()
+	if ve.Input != "" {
+		log.Error("Verify Tx Error", "code", ve.Code, "input", ve.Input, "index", ve.Index, "reason", ve.Reason, "hash", hexutil.Encode(ve.Hash[:]))
+	} else {
+		log.Error("Verify Tx Error", "code", ve.Code, "reason", ve.Reason, "hash", hexutil.Encode(ve.Hash[:]))
+	}
+	return ve
+}
+
+func ReportError(code ErrorCode, str string, tx *stx.T) (e error) {
+	return report(&VerifyError{Code: code, Index: -1, Reason: str}, tx)
+}
+
+func ReportInputError(code ErrorCode, input string, index int, str string, tx *stx.T) (e error) {
+	return report(&VerifyError{Code: code, Input: input, Index: index, Reason: str}, tx)
+}
+
+// procError is returned by the proof verification procs, it is reported with
+// the transaction hash once the procs are done.
+func procError(code ErrorCode, input string, index int, err error) *VerifyError {
+	return &VerifyError{Code: code, Input: input, Index: index, Reason: err.Error()}
+}
+
+func reportProcError(e error, tx *stx.T) error {
+	if ve, ok := e.(*VerifyError); ok {
+		return report(ve, tx)
+	}
+	return ReportError(ErrCodeProof, e.Error(), tx)
+}
@@ -14,6 +14,7 @@ import (
 var verify_input_o_procs_pool = utils.NewProcsPool(func() int { return zconfig.G_v_thread_num })
 
 type verify_input_o_desc struct {
+	index    int
 	hash_z   keys.Uint256
 	src      localdb.OutState
 	in       stx.In_S
@@ -29,8 +30,8 @@ func (self *verify_input_o_desc) Run() error {
 	g.Sign = self.in.Sign
 	g.Pkr = *self.src.ToPKr()
 	if err := cpt.VerifyInputS(&g); err != nil {
-		self.e = err
-		return err
+		self.e = procError(ErrCodeProof, InputO, self.index, err)
+		return self.e
 	} else {
 		self.asset_cc = self.src.Out_O.ToAssetCC()
 		return nil
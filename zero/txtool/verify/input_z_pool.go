@@ -9,12 +9,13 @@ import (
 var verify_input_procs_pool = utils.NewProcsPool(func() int { return zconfig.G_v_thread_num })
 
 type verify_input_desc struct {
-	desc cpt.InputVerifyDesc
+	desc  cpt.InputVerifyDesc
+	index int
 }
 
 func (self *verify_input_desc) Run() error {
 	if err := cpt.VerifyInput(&self.desc); err != nil {
-		return err
+		return procError(ErrCodeProof, InputZ, self.index, err)
 	} else {
 		return nil
 	}
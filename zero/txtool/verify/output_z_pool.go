@@ -13,21 +13,22 @@ import (
 var verify_output_procs_pool = utils.NewProcsPool(func() int { return zconfig.G_v_thread_num })
 
 type verify_output_desc struct {
-	desc cpt.OutputVerifyDesc
-	pkr  keys.PKr
-	e    error
+	desc  cpt.OutputVerifyDesc
+	pkr   keys.PKr
+	index int
+	e     error
 }
 
 func (self *verify_output_desc) Run() error {
 	if keys.PKrValid(&self.pkr) {
 		if err := cpt.VerifyOutput(&self.desc); err != nil {
-			self.e = err
-			return err
+			self.e = procError(ErrCodeProof, OutputZ, self.index, err)
+			return self.e
 		} else {
 			return nil
 		}
 	} else {
-		self.e = errors.New("z_out pkr is invalid !")
+		self.e = procError(ErrCodeTx, OutputZ, self.index, errors.New("z_out pkr is invalid !"))
 		return self.e
 	}
 }
@@ -15,8 +15,8 @@ type verify_pkg_desc struct {
 
 func (self *verify_pkg_desc) Run() error {
 	if err := cpt.VerifyPkg(&self.desc); err != nil {
-		self.e = err
-		return err
+		self.e = procError(ErrCodeProof, Pkg, 0, err)
+		return self.e
 	} else {
 		return nil
 	}
@@ -0,0 +1,94 @@
+package verify
+
+import (
+	"github.com/sero-cash/go-czero-import/keys"
+	"github.com/sero-cash/go-sero/common/hexutil"
+	"github.com/sero-cash/go-sero/zero/txs/stx"
+	"github.com/sero-cash/go-sero/zero/txs/zstate"
+)
+
+// ProofResult is the outcome of the proof of one input or output.
+type ProofResult struct {
+	Input string           `json:"input"`
+	Index int              `json:"index"`
+	Valid bool             `json:"valid"`
+	Error *VerifyErrorData `json:"error,omitempty"`
+}
+
+// Report is the detailed outcome of the verification of a transaction, unlike
+// Verify it goes on after a failed proof so that every proof is reported.
+type Report struct {
+	Num          hexutil.Uint64   `json:"num"`
+	Hash         hexutil.Bytes    `json:"hash"`
+	Proofs       []ProofResult    `json:"proofs"`
+	Static       *VerifyErrorData `json:"static,omitempty"`
+	StateChecked bool             `json:"stateChecked"`
+	State        *VerifyErrorData `json:"state,omitempty"`
+	Valid        bool             `json:"valid"`
+}
+
+func errorData(e error, tx *stx.T) *VerifyErrorData {
+	if e == nil {
+		return nil
+	}
+	ve, ok := e.(*VerifyError)
+	if !ok {
+		ve = &VerifyError{Code: ErrCodeProof, Index: -1, Reason: e.Error()}
+	}
+	ve.Hash = tx.ToHash()
+	return ve.ErrorData().(*VerifyErrorData)
+}
+
+// Inspect runs the static verification of a transaction under the rules of
+// the block num and checks each of its proofs.
+func Inspect(ehash *keys.Uint256, tx *stx.T, num uint64) (report *Report) {
+	hash := tx.ToHash()
+	report = &Report{Num: hexutil.Uint64(num), Hash: hash[:]}
+
+	for i, in_z := range tx.Desc_Z.Ins {
+		g := verify_input_desc{index: i}
+		g.desc.Nil = in_z.Nil
+		g.desc.Anchor = in_z.Anchor
+		g.desc.AssetCM = in_z.AssetCM
+		g.desc.Proof = in_z.Proof
+		report.addProof(InputZ, i, g.Run(), tx)
+	}
+	for i, out_z := range tx.Desc_Z.Outs {
+		g := verify_output_desc{index: i}
+		g.desc.AssetCM = out_z.AssetCM
+		g.desc.RPK = out_z.RPK
+		g.pkr = out_z.PKr
+		g.desc.OutCM = out_z.OutCM
+		g.desc.Proof = out_z.Proof
+		g.desc.Height = num
+		report.addProof(OutputZ, i, g.Run(), tx)
+	}
+	if create := tx.Desc_Pkg.Create; create != nil {
+		g := verify_pkg_desc{}
+		g.desc.AssetCM = create.Pkg.AssetCM
+		g.desc.PkgCM = create.Pkg.PkgCM
+		g.desc.Proof = create.Proof
+		report.addProof(Pkg, 0, g.Run(), tx)
+	}
+
+	report.Static = errorData(VerifyWithoutState(ehash, tx, num), tx)
+	report.Valid = report.Static == nil
+	return
+}
+
+func (self *Report) addProof(input string, index int, e error, tx *stx.T) {
+	self.Proofs = append(self.Proofs, ProofResult{
+		Input: input,
+		Index: index,
+		Valid: e == nil,
+		Error: errorData(e, tx),
+	})
+}
+
+// CheckState runs the verification depending on the zero state: spent nils,
+// unknown roots and anchors, package states, input signatures and balance.
+func (self *Report) CheckState(tx *stx.T, state *zstate.ZState) {
+	self.StateChecked = true
+	self.State = errorData(VerifyWithState(tx, state), tx)
+	self.Valid = self.Static == nil && self.State == nil
+}
@@ -0,0 +1,54 @@
+package verify
+
+import (
+	"github.com/sero-cash/go-czero-import/seroparam"
+)
+
+// Rules are the verification rules of zero transactions in force at a block.
+// Every fork changing the outcome of the verification is listed here, the
+// verifiers never look at the fork heights themselves.
+type Rules struct {
+	Num uint64
+
+	IsSIP1 bool // Output proofs are bound to the block height
+	IsSIP2 bool // O inputs are spent by nil instead of root, up to MAX_Z_OUT_LENGTH_SIP2 z outputs
+	IsSIP3 bool // Output proofs of the SIP3 circuit
+	IsSIP4 bool // O outputs are forbidden, transaction commands are enabled
+	IsVP0  bool // The number of O inputs is limited to MAX_O_INS_LENGTH
+
+	MaxOIns  int // Maximum number of O inputs, 0 for no limit
+	MaxOOuts int // Maximum number of O outputs, including the fee and the command asset
+	MaxZOuts int // Maximum number of z outputs, including the created package
+}
+
+// RulesAt returns the verification rules in force at the block num.
+func RulesAt(num uint64) (rules Rules) {
+	rules = Rules{
+		Num:      num,
+		IsSIP1:   num >= seroparam.SIP1(),
+		IsSIP2:   num >= seroparam.SIP2(),
+		IsSIP3:   num >= seroparam.SIP3(),
+		IsSIP4:   num >= seroparam.SIP4(),
+		IsVP0:    num >= seroparam.VP0(),
+		MaxOOuts: seroparam.MAX_O_OUT_LENGTH,
+		MaxZOuts: seroparam.MAX_Z_OUT_LENGTH_OLD,
+	}
+	if rules.IsSIP2 {
+		rules.MaxZOuts = seroparam.MAX_Z_OUT_LENGTH_SIP2
+	}
+	if rules.IsVP0 {
+		rules.MaxOIns = seroparam.MAX_O_INS_LENGTH
+	}
+	return
+}
+
+// key returns the set of enabled forks, two heights with the same key verify
+// any transaction the same way.
+func (self *Rules) key() (key uint8) {
+	for i, enabled := range []bool{self.IsSIP1, self.IsSIP2, self.IsSIP3, self.IsSIP4, self.IsVP0} {
+		if enabled {
+			key |= 1 << uint(i)
+		}
+	}
+	return
+}
@@ -0,0 +1,20 @@
+// Package verify is the verification engine of zero transactions, shared by
+// the tx pool, the block processor and the offline tools. The rules depending
+// on the block height are gathered in Rules.
+package verify
+
+import (
+	"github.com/sero-cash/go-czero-import/keys"
+	"github.com/sero-cash/go-sero/zero/txs/stx"
+	"github.com/sero-cash/go-sero/zero/txs/zstate"
+)
+
+// Verify runs the full verification of a zero transaction against the zero
+// state it is applied to, the static checks and proofs under the rules of the
+// state height followed by the checks depending on the state.
+func Verify(ehash *keys.Uint256, tx *stx.T, state *zstate.ZState) (e error) {
+	if e = VerifyWithoutState(ehash, tx, state.Num()); e != nil {
+		return
+	}
+	return VerifyWithState(tx, state)
+}
@@ -0,0 +1,46 @@
+package verify
+
+import (
+	"github.com/hashicorp/golang-lru"
+	"github.com/sero-cash/go-czero-import/keys"
+	"github.com/sero-cash/go-sero/metrics"
+	"github.com/sero-cash/go-sero/zero/txs/stx"
+)
+
+// verifiedCacheSize is the number of statically verified transactions kept,
+// enough for a full tx pool and the blocks importing its transactions.
+const verifiedCacheSize = 16384
+
+var (
+	verifiedCacheHitMeter  = metrics.NewRegisteredMeter("zero/verify/cache/hit", nil)
+	verifiedCacheMissMeter = metrics.NewRegisteredMeter("zero/verify/cache/miss", nil)
+)
+
+// verifiedTxs holds the transactions which passed VerifyWithoutState, shared by
+// the tx pool and the block import so that proofs are only checked once.
+var verifiedTxs, _ = lru.New(verifiedCacheSize)
+
+// verifiedKey identifies a statically verified transaction. The rules the
+// verification depends on are part of the key, a transaction verified before
+// a fork has to be verified again under the new rules.
+type verifiedKey struct {
+	hash  keys.Uint256
+	ehash keys.Uint256
+	rules uint8
+}
+
+// VerifyWithoutStateCached is VerifyWithoutState skipping the transactions
+// already verified under the rules of the block num.
+func VerifyWithoutStateCached(ehash *keys.Uint256, tx *stx.T, num uint64) (e error) {
+	rules := RulesAt(num)
+	key := verifiedKey{tx.ToHash(), *ehash, rules.key()}
+	if verifiedTxs.Contains(key) {
+		verifiedCacheHitMeter.Mark(1)
+		return
+	}
+	verifiedCacheMissMeter.Mark(1)
+	if e = VerifyWithoutState(ehash, tx, num); e == nil {
+		verifiedTxs.Add(key, struct{}{})
+	}
+	return
+}
@@ -0,0 +1,53 @@
+package verify_test
+
+import (
+	"testing"
+
+	"github.com/sero-cash/go-czero-import/seroparam"
+	"github.com/sero-cash/go-sero/zero/txtool/verify"
+)
+
+// The hash of a tx is computed once, a tx changed after it's verified keeps the
+// key it's cached under: it passes on a hit and fails once verified again.
+
+func TestVerifyCacheHit(t *testing.T) {
+	num := seroparam.SIP4()
+	f := newFixture(t, num)
+	if err := verify.VerifyWithoutStateCached(&f.ehash, &f.tx, num); err != nil {
+		t.Fatalf("valid tx rejected: %v", err)
+	}
+	f.tx.Sign[0] ^= 1
+	if err := verify.VerifyWithoutState(&f.ehash, &f.tx, num); err == nil {
+		t.Fatalf("changed tx verified")
+	}
+	// Any height with the same rules hits the cache
+	for _, at := range []uint64{num, num + 1} {
+		if err := verify.VerifyWithoutStateCached(&f.ehash, &f.tx, at); err != nil {
+			t.Errorf("tx verified again at %d: %v", at, err)
+		}
+	}
+}
+
+func TestVerifyCacheRules(t *testing.T) {
+	for _, fork := range forks() {
+		f := newFixture(t, fork-1)
+		if err := verify.VerifyWithoutStateCached(&f.ehash, &f.tx, fork-1); err != nil {
+			t.Fatalf("valid tx rejected at %d: %v", fork-1, err)
+		}
+		f.tx.Sign[0] ^= 1
+		if err := verify.VerifyWithoutStateCached(&f.ehash, &f.tx, fork); err == nil {
+			t.Errorf("tx verified before the fork at %d not verified again after it", fork)
+		}
+	}
+}
+
+func TestVerifyCacheFailure(t *testing.T) {
+	num := seroparam.SIP4()
+	f := newFixture(t, num)
+	f.tx.Sign[0] ^= 1
+	for i := 0; i < 2; i++ {
+		if err := verify.VerifyWithoutStateCached(&f.ehash, &f.tx, num); err == nil {
+			t.Fatalf("invalid tx verified on call %d", i)
+		}
+	}
+}
@@ -2,8 +2,9 @@ package verify
 
 func (self *verifyWithoutStateCtx) ProcessVerifyProof() {
 
-	for _, in_z := range self.tx.Desc_Z.Ins {
+	for i, in_z := range self.tx.Desc_Z.Ins {
 		g := verify_input_desc{}
+		g.index = i
 		g.desc.Nil = in_z.Nil
 		g.desc.Anchor = in_z.Anchor
 		g.desc.AssetCM = in_z.AssetCM
@@ -11,14 +12,15 @@ func (self *verifyWithoutStateCtx) ProcessVerifyProof() {
 		self.zin_proof_proc.StartProc(&g)
 	}
 
-	for _, out_z := range self.tx.Desc_Z.Outs {
+	for i, out_z := range self.tx.Desc_Z.Outs {
 		g := verify_output_desc{}
+		g.index = i
 		g.desc.AssetCM = out_z.AssetCM
 		g.desc.RPK = out_z.RPK
 		g.pkr = out_z.PKr
 		g.desc.OutCM = out_z.OutCM
 		g.desc.Proof = out_z.Proof
-		g.desc.Height = self.num
+		g.desc.Height = self.rules.Num
 		self.zout_proof_proc.StartProc(&g)
 	}
 
@@ -38,16 +40,19 @@ func (self *verifyWithoutStateCtx) ProcessVerifyProof() {
 func (self *verifyWithoutStateCtx) WaitVerifyProof() (e error) {
 	if self.zin_proof_proc.HasProc() {
 		if e = self.zin_proof_proc.End(); e != nil {
+			e = reportProcError(e, self.tx)
 			return
 		}
 	}
 	if self.zout_proof_proc.HasProc() {
 		if e = self.zout_proof_proc.End(); e != nil {
+			e = reportProcError(e, self.tx)
 			return
 		}
 	}
 	if self.pkg_proof_proc.HasProc() {
 		if e = self.pkg_proof_proc.End(); e != nil {
+			e = reportProcError(e, self.tx)
 			return
 		}
 	}
@@ -5,7 +5,6 @@ import (
 
 	"github.com/sero-cash/go-czero-import/cpt"
 	"github.com/sero-cash/go-czero-import/keys"
-	"github.com/sero-cash/go-czero-import/seroparam"
 	"github.com/sero-cash/go-sero/common/hexutil"
 	"github.com/sero-cash/go-sero/zero/txs/stx"
 	"github.com/sero-cash/go-sero/zero/txs/zstate"
@@ -14,6 +13,7 @@ import (
 
 type verifyWithStateCtx struct {
 	tx             *stx.T
+	rules          Rules
 	state          *zstate.ZState
 	hash           keys.Uint256
 	oin_proof_proc *utils.Procs
@@ -24,6 +24,7 @@ func VerifyWithState(tx *stx.T, state *zstate.ZState) (e error) {
 	hash_z := tx.ToHash_for_sign()
 	ctx := verifyWithStateCtx{}
 	ctx.tx = tx
+	ctx.rules = RulesAt(state.Num())
 	ctx.state = state
 	ctx.balance_desc.Hash = hash_z
 	return ctx.Verify()
@@ -44,27 +45,28 @@ func (self *verifyWithStateCtx) verifyFee() (e error) {
 }
 
 func (self *verifyWithStateCtx) verifyOs() (e error) {
-	for _, in_o := range self.tx.Desc_O.Ins {
-		if self.state.Num() >= seroparam.SIP2() {
+	for i, in_o := range self.tx.Desc_O.Ins {
+		if self.rules.IsSIP2 {
 			if ok := self.state.State.HasIn(&in_o.Nil); ok {
-				e = ReportError("txs.verify in_o already in nils", self.tx)
+				e = ReportInputError(ErrCodeNil, InputO, i, "txs.verify in_o already in nils", self.tx)
 				return
 			}
 		} else {
 			if ok := self.state.State.HasIn(&in_o.Root); ok {
-				e = ReportError("txs.verify in_o already in roots", self.tx)
+				e = ReportInputError(ErrCodeNil, InputO, i, "txs.verify in_o already in roots", self.tx)
 				return
 			} else {
 			}
 		}
 		if src := self.state.State.GetOut(&in_o.Root); src != nil {
 			desc := verify_input_o_desc{}
+			desc.index = i
 			desc.in = in_o
 			desc.hash_z = self.balance_desc.Hash
 			desc.src = *src
 			self.oin_proof_proc.StartProc(&desc)
 		} else {
-			e = ReportError("txs.Verify: in_o not find in the outs!", self.tx)
+			e = ReportInputError(ErrCodeRootNotFound, InputO, i, "txs.Verify: in_o not find in the outs!", self.tx)
 			return
 		}
 	}
@@ -85,6 +87,7 @@ func (self *verifyWithStateCtx) Wait() (e error) {
 				self.balance_desc.Oin_accs = append(self.balance_desc.Oin_accs, desc.asset_cc[:]...)
 			}
 		} else {
+			e = reportProcError(e, self.tx)
 			return
 		}
 	}
@@ -92,14 +95,14 @@ func (self *verifyWithStateCtx) Wait() (e error) {
 }
 
 func (self *verifyWithStateCtx) verifyZs() (e error) {
-	for _, in_z := range self.tx.Desc_Z.Ins {
+	for i, in_z := range self.tx.Desc_Z.Ins {
 		self.balance_desc.Zin_acms = append(self.balance_desc.Zin_acms, in_z.AssetCM[:]...)
 		if ok := self.state.State.HasIn(&in_z.Nil); ok {
-			e = ReportError("txs.verify in already in nils", self.tx)
+			e = ReportInputError(ErrCodeNil, InputZ, i, "txs.verify in already in nils", self.tx)
 			return
 		} else {
 			if out := self.state.State.GetOut(&in_z.Anchor); out == nil {
-				e = ReportError("txs.verify can not find out for anchor", self.tx)
+				e = ReportInputError(ErrCodeRootNotFound, InputZ, i, "txs.verify can not find out for anchor", self.tx)
 				return
 			} else {
 			}
@@ -114,7 +117,7 @@ func (self *verifyWithStateCtx) verifyZs() (e error) {
 func (self *verifyWithStateCtx) verifyPkg() (e error) {
 	if self.tx.Desc_Pkg.Create != nil {
 		if pg := self.state.Pkgs.GetPkgById(&self.tx.Desc_Pkg.Create.Id); pg != nil {
-			e = ReportError(fmt.Sprintf("pkg id already exists %v", hexutil.Encode(self.tx.Desc_Pkg.Create.Id[:])), self.tx)
+			e = ReportError(ErrCodePkg, fmt.Sprintf("pkg id already exists %v", hexutil.Encode(self.tx.Desc_Pkg.Create.Id[:])), self.tx)
 			return
 		} else {
 			self.balance_desc.Zout_acms = append(self.balance_desc.Zout_acms, self.tx.Desc_Pkg.Create.Pkg.AssetCM[:]...)
@@ -123,12 +126,12 @@ func (self *verifyWithStateCtx) verifyPkg() (e error) {
 
 	if self.tx.Desc_Pkg.Transfer != nil {
 		if pg := self.state.Pkgs.GetPkgById(&self.tx.Desc_Pkg.Transfer.Id); pg == nil || pg.Closed {
-			e = ReportError(fmt.Sprintf("Can not find pkg of the id %v", hexutil.Encode(self.tx.Desc_Pkg.Transfer.Id[:])), self.tx)
+			e = ReportError(ErrCodePkg, fmt.Sprintf("Can not find pkg of the id %v", hexutil.Encode(self.tx.Desc_Pkg.Transfer.Id[:])), self.tx)
 			return
 		} else {
 			if keys.VerifyPKr(&self.balance_desc.Hash, &self.tx.Desc_Pkg.Transfer.Sign, &pg.Pack.PKr) {
 			} else {
-				e = ReportError(fmt.Sprintf("Can not verify pkg sign of the id %v", hexutil.Encode(self.tx.Desc_Pkg.Transfer.Id[:])), self.tx)
+				e = ReportError(ErrCodePkg, fmt.Sprintf("Can not verify pkg sign of the id %v", hexutil.Encode(self.tx.Desc_Pkg.Transfer.Id[:])), self.tx)
 				return
 			}
 		}
@@ -136,13 +139,13 @@ func (self *verifyWithStateCtx) verifyPkg() (e error) {
 
 	if self.tx.Desc_Pkg.Close != nil {
 		if pg := self.state.Pkgs.GetPkgById(&self.tx.Desc_Pkg.Close.Id); pg == nil || pg.Closed {
-			e = ReportError(fmt.Sprintf("Can not find pkg of the id %v", hexutil.Encode(self.tx.Desc_Pkg.Close.Id[:])), self.tx)
+			e = ReportError(ErrCodePkg, fmt.Sprintf("Can not find pkg of the id %v", hexutil.Encode(self.tx.Desc_Pkg.Close.Id[:])), self.tx)
 			return
 		} else {
 			if keys.VerifyPKr(&self.balance_desc.Hash, &self.tx.Desc_Pkg.Close.Sign, &pg.Pack.PKr) {
 				self.balance_desc.Zin_acms = append(self.balance_desc.Zin_acms, pg.Pack.Pkg.AssetCM[:]...)
 			} else {
-				e = ReportError(fmt.Sprintf("Can not verify pkg sign of the id %v", hexutil.Encode(self.tx.Desc_Pkg.Close.Id[:])), self.tx)
+				e = ReportError(ErrCodePkg, fmt.Sprintf("Can not verify pkg sign of the id %v", hexutil.Encode(self.tx.Desc_Pkg.Close.Id[:])), self.tx)
 				return
 			}
 		}
@@ -161,7 +164,7 @@ func (self *verifyWithStateCtx) verifyBalance() (e error) {
 	self.balance_desc.Bcr = self.tx.Bcr
 	self.balance_desc.Bsign = self.tx.Bsign
 	if err := cpt.VerifyBalance(&self.balance_desc); err != nil {
-		e = err
+		e = ReportError(ErrCodeBalance, err.Error(), self.tx)
 		return
 	}
 	return
@@ -1,7 +1,6 @@
 package verify
 
 import (
-	"errors"
 	"fmt"
 
 	"github.com/sero-cash/go-czero-import/keys"
@@ -12,7 +11,7 @@ import (
 
 type verifyWithoutStateCtx struct {
 	tx              *stx.T
-	num             uint64
+	rules           Rules
 	hash            keys.Uint256
 	oout_count      int
 	oin_count       int
@@ -24,12 +23,12 @@ type verifyWithoutStateCtx struct {
 
 func VerifyWithoutState(ehash *keys.Uint256, tx *stx.T, num uint64) (e error) {
 	if *ehash != tx.Ehash {
-		e = ReportError("ehash error", tx)
+		e = ReportError(ErrCodeTx, "ehash error", tx)
 		return
 	}
 	ctx := verifyWithoutStateCtx{}
 	ctx.tx = tx
-	ctx.num = num
+	ctx.rules = RulesAt(num)
 	return ctx.Verify()
 }
 
@@ -49,7 +48,7 @@ func (self *verifyWithoutStateCtx) clear() {
 
 func (self *verifyWithoutStateCtx) verifyFee() (e error) {
 	if !CheckUint(&self.tx.Fee.Value) {
-		e = errors.New("txs.verify check fee too big")
+		e = ReportError(ErrCodeFee, "txs.verify check fee too big", self.tx)
 		return
 	}
 	self.tx.ToFeeCC()
@@ -59,20 +58,20 @@ func (self *verifyWithoutStateCtx) verifyFee() (e error) {
 
 func (self *verifyWithoutStateCtx) verifyFrom() (e error) {
 	if !keys.PKrValid(&self.tx.From) {
-		e = ReportError("txs.verify from is invalid", self.tx)
+		e = ReportError(ErrCodeTx, "txs.verify from is invalid", self.tx)
 		return
 	}
 	if !keys.VerifyPKr(&self.hash, &self.tx.Sign, &self.tx.From) {
-		e = ReportError("txs.verify from verify failed", self.tx)
+		e = ReportError(ErrCodeTx, "txs.verify from verify failed", self.tx)
 		return
 	}
 	return
 }
 
 func (self *verifyWithoutStateCtx) verifyOs() (e error) {
-	if self.num >= seroparam.SIP4() {
+	if self.rules.IsSIP4 {
 		if len(self.tx.Desc_O.Outs) > 0 {
-			e = ReportError("after SIP4, o_outs can not used", self.tx)
+			e = ReportError(ErrCodeTx, "after SIP4, o_outs can not used", self.tx)
 			return
 		}
 	}
@@ -80,16 +79,16 @@ func (self *verifyWithoutStateCtx) verifyOs() (e error) {
 		self.oout_count++
 		if out.Asset.Tkn != nil {
 			if !CheckUint(&out.Asset.Tkn.Value) {
-				e = ReportError("o_out tkn value invalid", self.tx)
+				e = ReportInputError(ErrCodeTx, OutputO, i, "o_out tkn value invalid", self.tx)
 				return
 			}
 		}
 		self.tx.Desc_O.Outs[i].ToAssetCC()
 	}
 
-	if self.num >= seroparam.VP0() {
-		if len(self.tx.Desc_O.Ins) > seroparam.MAX_O_INS_LENGTH {
-			e = ReportError(fmt.Sprintf("txs.verify O ins length > %v, current is %v", seroparam.MAX_O_INS_LENGTH, len(self.tx.Desc_O.Ins)), self.tx)
+	if self.rules.MaxOIns > 0 {
+		if len(self.tx.Desc_O.Ins) > self.rules.MaxOIns {
+			e = ReportError(ErrCodeTx, fmt.Sprintf("txs.verify O ins length > %v, current is %v", self.rules.MaxOIns, len(self.tx.Desc_O.Ins)), self.tx)
 			return
 		}
 	}
@@ -100,10 +99,10 @@ func (self *verifyWithoutStateCtx) verifyOs() (e error) {
 }
 
 func (self *verifyWithoutStateCtx) verifyZs() (e error) {
-	for _, out := range self.tx.Desc_Z.Outs {
+	for i, out := range self.tx.Desc_Z.Outs {
 		self.zout_count++
 		if !keys.PKrValid(&out.PKr) {
-			e = ReportError("z_out pkr invalid", self.tx)
+			e = ReportInputError(ErrCodeTx, OutputZ, i, "z_out pkr invalid", self.tx)
 			return
 		}
 	}
@@ -112,11 +111,11 @@ func (self *verifyWithoutStateCtx) verifyZs() (e error) {
 
 func (self *verifyWithoutStateCtx) verifyPkg() (e error) {
 	if self.tx.Desc_Cmd.Count() > 0 && self.tx.Desc_Pkg.Count() > 0 {
-		e = ReportError("pkg and cmd desc only exists one", self.tx)
+		e = ReportError(ErrCodePkg, "pkg and cmd desc only exists one", self.tx)
 		return
 	}
 	if !self.tx.Desc_Pkg.Valid() {
-		e = ReportError("pkg desc is invalid", self.tx)
+		e = ReportError(ErrCodePkg, "pkg desc is invalid", self.tx)
 		return
 	}
 	if self.tx.Desc_Pkg.Create != nil {
@@ -126,21 +125,21 @@ func (self *verifyWithoutStateCtx) verifyPkg() (e error) {
 }
 
 func (self *verifyWithoutStateCtx) verifyCmds() (e error) {
-	if self.num < seroparam.SIP4() {
+	if !self.rules.IsSIP4 {
 		if self.tx.Desc_Cmd.Count() > 0 {
-			e = ReportError("can not use tx cmd until SIP4", self.tx)
+			e = ReportError(ErrCodeCmd, "can not use tx cmd until SIP4", self.tx)
 		}
 		return
 	}
 	if !self.tx.Desc_Cmd.Valid() {
-		e = ReportError("cmd desc is invalid", self.tx)
+		e = ReportError(ErrCodeCmd, "cmd desc is invalid", self.tx)
 		return
 	}
 	if asset := self.tx.Desc_Cmd.OutAsset(); asset != nil {
 		self.oout_count++
 		if asset.Tkn != nil {
 			if !CheckUint(&asset.Tkn.Value) {
-				e = ReportError("cmd asset tkn value invalid", self.tx)
+				e = ReportError(ErrCodeCmd, "cmd asset tkn value invalid", self.tx)
 				return
 			}
 		}
@@ -148,17 +147,17 @@ func (self *verifyWithoutStateCtx) verifyCmds() (e error) {
 	}
 	if pkr := self.tx.Desc_Cmd.ToPkr(); pkr != nil {
 		if !keys.PKrValid(pkr) {
-			e = ReportError("cmd pkr invalid", self.tx)
+			e = ReportError(ErrCodeCmd, "cmd pkr invalid", self.tx)
 			return
 		}
 	}
 	if self.tx.Desc_Cmd.RegistPool != nil {
 		if self.tx.Desc_Cmd.RegistPool.FeeRate > seroparam.HIGHEST_STAKING_NODE_FEE_RATE {
-			e = ReportError(fmt.Sprintf("regist pool the fee rate must < %v%%", seroparam.HIGHEST_STAKING_NODE_FEE_RATE), self.tx)
+			e = ReportError(ErrCodeCmd, fmt.Sprintf("regist pool the fee rate must < %v%%", seroparam.HIGHEST_STAKING_NODE_FEE_RATE), self.tx)
 			return
 		}
 		if self.tx.Desc_Cmd.RegistPool.FeeRate < seroparam.LOWEST_STAKING_NODE_FEE_RATE {
-			e = ReportError(fmt.Sprintf("regist pool fee must >= %v%%", seroparam.LOWEST_STAKING_NODE_FEE_RATE/100), self.tx)
+			e = ReportError(ErrCodeCmd, fmt.Sprintf("regist pool fee must >= %v%%", seroparam.LOWEST_STAKING_NODE_FEE_RATE/100), self.tx)
 			return
 		}
 	}
@@ -166,7 +165,7 @@ func (self *verifyWithoutStateCtx) verifyCmds() (e error) {
 		if self.tx.Desc_Cmd.Contract.To != nil {
 			empty := keys.PKr{}
 			if *self.tx.Desc_Cmd.Contract.To == empty {
-				e = ReportError("contract target can not be zero", self.tx)
+				e = ReportError(ErrCodeCmd, "contract target can not be zero", self.tx)
 				return
 			}
 		}
@@ -203,20 +202,13 @@ func (self *verifyWithoutStateCtx) Verify() (e error) {
 		return
 	}
 
-	if self.oout_count > seroparam.MAX_O_OUT_LENGTH {
-		e = ReportError("oout count > 10", self.tx)
+	if self.oout_count > self.rules.MaxOOuts {
+		e = ReportError(ErrCodeTx, fmt.Sprintf("oout count > %v", self.rules.MaxOOuts), self.tx)
 		return
 	}
-	if self.num >= seroparam.SIP2() {
-		if self.zout_count > seroparam.MAX_Z_OUT_LENGTH_SIP2 {
-			e = ReportError("verify error: out_size > 500", self.tx)
-			return
-		}
-	} else {
-		if self.zout_count > seroparam.MAX_Z_OUT_LENGTH_OLD {
-			e = ReportError("verify error: out_size > 6", self.tx)
-			return
-		}
+	if self.zout_count > self.rules.MaxZOuts {
+		e = ReportError(ErrCodeTx, fmt.Sprintf("verify error: out_size > %v", self.rules.MaxZOuts), self.tx)
+		return
 	}
 
 	if e = self.WaitVerifyProof(); e != nil {
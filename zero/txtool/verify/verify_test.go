@@ -0,0 +1,216 @@
+package verify_test
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/sero-cash/go-czero-import/keys"
+	"github.com/sero-cash/go-czero-import/seroparam"
+	"github.com/sero-cash/go-sero/core/state"
+	"github.com/sero-cash/go-sero/core/types"
+	"github.com/sero-cash/go-sero/serodb"
+	"github.com/sero-cash/go-sero/zero/localdb"
+	"github.com/sero-cash/go-sero/zero/txs/assets"
+	"github.com/sero-cash/go-sero/zero/txs/stx"
+	"github.com/sero-cash/go-sero/zero/txs/zstate"
+	"github.com/sero-cash/go-sero/zero/txtool"
+	"github.com/sero-cash/go-sero/zero/txtool/generate"
+	"github.com/sero-cash/go-sero/zero/txtool/verify"
+	"github.com/sero-cash/go-sero/zero/utils"
+)
+
+type account struct {
+	sk  keys.Uint512
+	pkr keys.PKr
+}
+
+func newAccount(i byte) (a account) {
+	seed := keys.Uint256{i}
+	a.sk = keys.Seed2Sk(&seed)
+	pk := keys.Sk2PK(&a.sk)
+	rnd := keys.Uint256{i}
+	a.pkr = keys.Addr2PKr(&pk, &rnd)
+	return
+}
+
+// sign signs the transaction again after a change of its content.
+func (a *account) sign(t *testing.T, tx *stx.T) {
+	hash := tx.ToHash_for_sign()
+	sign, err := keys.SignPKrBySk(&a.sk, &hash, &tx.From)
+	if err != nil {
+		t.Fatalf("failed to sign tx: %v", err)
+	}
+	tx.Sign = sign
+}
+
+func newZState(t *testing.T, num uint64) *zstate.ZState {
+	statedb, err := state.New(state.NewDatabase(serodb.NewMemDatabase()), &types.Header{Number: new(big.Int).SetUint64(num)})
+	if err != nil {
+		t.Fatalf("failed to create state: %v", err)
+	}
+	return statedb.CurrentZState()
+}
+
+// fund adds an O output of value SERO owned by the account to the state.
+func (a *account) fund(st *zstate.ZState, value uint64) txtool.GIn {
+	tkn := assets.Token{Currency: utils.CurrencyToUint256("SERO"), Value: utils.NewU256(value)}
+	out := stx.Out_O{Addr: a.pkr, Asset: assets.Asset{Tkn: &tkn}}
+	root := st.State.AddOut(&out, nil, &keys.Uint256{})
+	return txtool.GIn{Out: txtool.Out{Root: root, State: localdb.RootState{OS: *st.State.GetOut(&root)}}}
+}
+
+// fixture is a transaction spending an O output of the state it is verified
+// against, signed by the generator.
+type fixture struct {
+	ehash keys.Uint256
+	tx    stx.T
+	state *zstate.ZState
+}
+
+func newFixture(t *testing.T, num uint64) *fixture {
+	from := newAccount(1)
+	st := newZState(t, num)
+	param := txtool.GTxParam{
+		Gas:      25000,
+		GasPrice: big.NewInt(1000000000),
+		Fee:      assets.Token{Currency: utils.CurrencyToUint256("SERO"), Value: utils.NewU256(10)},
+		From:     txtool.Kr{PKr: from.pkr},
+		Ins:      []txtool.GIn{from.fund(st, 10)},
+	}
+	copy(param.From.SKr[:], from.sk[:])
+	copy(param.Ins[0].SKr[:], from.sk[:])
+
+	tx, _, _, err := generate.GenTx(&param)
+	if err != nil {
+		t.Fatalf("failed to generate tx: %v", err)
+	}
+	return &fixture{ehash: tx.Ehash, tx: tx, state: st}
+}
+
+// verifyTests are the fixtures of the verifier, valid or breaking one of its
+// rules, with the code of the error expected at a height.
+var verifyTests = []struct {
+	name   string
+	mutate func(t *testing.T, f *fixture)
+	code   func(num uint64) verify.ErrorCode // 0 for a valid tx
+}{
+	{
+		name:   "valid",
+		mutate: func(t *testing.T, f *fixture) {},
+		code:   func(uint64) verify.ErrorCode { return 0 },
+	},
+	{
+		name:   "ehash",
+		mutate: func(t *testing.T, f *fixture) { f.ehash[0] ^= 1 },
+		code:   func(uint64) verify.ErrorCode { return verify.ErrCodeTx },
+	},
+	{
+		name:   "fee tampered",
+		mutate: func(t *testing.T, f *fixture) { f.tx.Fee.Value = utils.NewU256(9) },
+		code:   func(uint64) verify.ErrorCode { return verify.ErrCodeTx },
+	},
+	{
+		name: "foreign from",
+		mutate: func(t *testing.T, f *fixture) {
+			f.tx.From = newAccount(2).pkr
+		},
+		code: func(uint64) verify.ErrorCode { return verify.ErrCodeTx },
+	},
+	{
+		name:   "input sign",
+		mutate: func(t *testing.T, f *fixture) { f.tx.Desc_O.Ins[0].Sign[0] ^= 1 },
+		code:   func(uint64) verify.ErrorCode { return verify.ErrCodeProof },
+	},
+	{
+		name:   "balance sign",
+		mutate: func(t *testing.T, f *fixture) { f.tx.Bsign[0] ^= 1 },
+		code:   func(uint64) verify.ErrorCode { return verify.ErrCodeBalance },
+	},
+	{
+		name: "unknown root",
+		mutate: func(t *testing.T, f *fixture) {
+			f.state = newZState(t, f.state.Num())
+		},
+		code: func(uint64) verify.ErrorCode { return verify.ErrCodeRootNotFound },
+	},
+	{
+		name: "spent",
+		mutate: func(t *testing.T, f *fixture) {
+			if err := f.state.AddStx(&f.tx); err != nil {
+				t.Fatalf("failed to apply tx: %v", err)
+			}
+			f.state.Update()
+		},
+		code: func(uint64) verify.ErrorCode { return verify.ErrCodeNil },
+	},
+	{
+		name: "o_out",
+		mutate: func(t *testing.T, f *fixture) {
+			from := newAccount(1)
+			tkn := assets.Token{Currency: utils.CurrencyToUint256("SERO"), Value: utils.NewU256(1)}
+			f.tx.Desc_O.Outs = []stx.Out_O{{Addr: from.pkr, Asset: assets.Asset{Tkn: &tkn}}}
+			from.sign(t, &f.tx)
+		},
+		code: func(num uint64) verify.ErrorCode {
+			if num >= seroparam.SIP4() {
+				return verify.ErrCodeTx
+			}
+			// The input signature no longer matches the tx
+			return verify.ErrCodeProof
+		},
+	},
+	{
+		name: "contract to zero",
+		mutate: func(t *testing.T, f *fixture) {
+			from := newAccount(1)
+			f.tx.Desc_Cmd.Contract = &stx.ContractCmd{To: &keys.PKr{}}
+			from.sign(t, &f.tx)
+		},
+		code: func(uint64) verify.ErrorCode { return verify.ErrCodeCmd },
+	},
+}
+
+func TestVerify(t *testing.T) {
+	heights := []uint64{0, seroparam.SIP2(), seroparam.SIP4(), seroparam.VP0()}
+	for _, num := range heights {
+		for _, tt := range verifyTests {
+			f := newFixture(t, num)
+			tt.mutate(t, f)
+			err := verify.Verify(&f.ehash, &f.tx, f.state)
+
+			want := tt.code(num)
+			if want == 0 {
+				if err != nil {
+					t.Errorf("%s at %d: verification failed: %v", tt.name, num, err)
+				}
+				continue
+			}
+			ve, ok := err.(*verify.VerifyError)
+			if !ok {
+				t.Errorf("%s at %d: expected a VerifyError, got %v", tt.name, num, err)
+				continue
+			}
+			if ve.Code != want {
+				t.Errorf("%s at %d: code mismatch: have %v (%s), want %v", tt.name, num, ve.Code, ve.Reason, want)
+			}
+		}
+	}
+}
+
+func TestErrorData(t *testing.T) {
+	f := newFixture(t, 0)
+	f.tx.Desc_O.Ins[0].Sign[0] ^= 1
+
+	ve, ok := verify.Verify(&f.ehash, &f.tx, f.state).(*verify.VerifyError)
+	if !ok {
+		t.Fatalf("expected a VerifyError")
+	}
+	data := ve.ErrorData().(*verify.VerifyErrorData)
+	if data.Name != verify.ErrCodeProof.String() || data.Input != verify.InputO || data.Index == nil || *data.Index != 0 {
+		t.Errorf("unexpected error data: %+v", data)
+	}
+	if hash := f.tx.ToHash(); !bytes.Equal(data.Hash, hash[:]) {
+		t.Errorf("tx hash mismatch: have %x, want %x", data.Hash, hash)
+	}
+}
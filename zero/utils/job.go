@@ -0,0 +1,34 @@
+package utils
+
+import (
+	"sync/atomic"
+
+	"github.com/robfig/cron"
+)
+
+// AddJob runs the function on the cron spec, skipping the ticks happening
+// while the previous run is still going.
+func AddJob(spec string, run RunFunc) *cron.Cron {
+	c := cron.New()
+	c.AddJob(spec, &RunJob{run: run})
+	c.Start()
+	return c
+}
+
+type (
+	RunFunc func()
+)
+
+type RunJob struct {
+	runing int32
+	run    RunFunc
+}
+
+func (r *RunJob) Run() {
+	if !atomic.CompareAndSwapInt32(&r.runing, 0, 1) {
+		return
+	}
+	defer atomic.StoreInt32(&r.runing, 0)
+
+	r.run()
+}
@@ -95,10 +95,13 @@ func NewProcsPool(numget func() int) (ret ProcsPool) {
 
 func (self *ProcsPool) GetProcs() (ret *Procs) {
 	ret = self.pool.Get().(*Procs)
-	ret.Runs = []Proc{}
 	if ret == nil {
 		panic(fmt.Errorf("GetProcsFromPool error: fetch nil!"))
 	}
+	ret.Runs = []Proc{}
+	// The procs may come back from a failed verification
+	ret.E = nil
+	ret.ERun = nil
 	return
 }
 
@@ -0,0 +1,149 @@
+package exchange
+
+import (
+	"bytes"
+	"errors"
+
+	"github.com/sero-cash/go-czero-import/keys"
+	"github.com/sero-cash/go-czero-import/seroparam"
+	"github.com/sero-cash/go-sero/accounts"
+	"github.com/sero-cash/go-sero/accounts/keystore"
+	"github.com/sero-cash/go-sero/log"
+	"github.com/sero-cash/go-sero/rlp"
+	"github.com/sero-cash/go-sero/zero/localdb"
+	"github.com/sero-cash/go-sero/zero/txtool"
+	"github.com/sero-cash/go-sero/zero/txtool/flight"
+)
+
+var errDerivedScanRunning = errors.New("derived accounts of master already being scanned")
+
+var derivedPrefix = []byte("DERIVED")
+
+func derivedKey(tk *keys.Uint512) []byte {
+	return append(derivedPrefix, tk[:]...)
+}
+
+// derivedOuts is the progress of the scan of a derived account.
+type derivedOuts struct {
+	Num  uint64 // Blocks read up to, included
+	Used bool
+}
+
+// blocksReader reads count blocks from start.
+type blocksReader func(start, count uint64) ([]txtool.Block, error)
+
+// ScanDerived starts in the background to add to the keystore the accounts
+// derived from master up to gap consecutive accounts without any out in the
+// confirmed blocks, the exchange then indexes them as the other accounts. One
+// scan of master runs at once, done is called with the stored accounts.
+func (self *Exchange) ScanDerived(ks *keystore.KeyStore, master accounts.Account, passphrase string, gap int, done func([]accounts.Account)) error {
+	master, err := ks.Find(master)
+	if err != nil {
+		return err
+	}
+	if err := localdb.CheckPruned(txtool.Ref_inst.Bc.GetDB(), master.At); err != nil {
+		return err
+	}
+	if _, running := self.derivedScans.LoadOrStore(master.Address, true); running {
+		return errDerivedScanRunning
+	}
+	go func() {
+		defer self.derivedScans.Delete(master.Address)
+
+		derived, err := ks.ScanDerived(master, passphrase, gap, func(candidates []accounts.Account) ([]bool, error) {
+			stable := txtool.Ref_inst.GetDelayedNum(seroparam.DefaultConfirmedBlock())
+			return self.receivedOuts(master.At, stable, candidates, flight.SRI_Inst.GetBlocksInfo)
+		})
+		if err != nil {
+			log.Error("Exchange failed to scan derived accounts", "master", master.Address, "err", err)
+		}
+		log.Info("Exchange scanned derived accounts", "master", master.Address, "accounts", len(derived))
+		if done != nil {
+			done(derived)
+		}
+	}()
+	return nil
+}
+
+func (self *Exchange) getDerivedOuts(tk *keys.Uint512) *derivedOuts {
+	data, err := self.db.Get(derivedKey(tk))
+	if err != nil {
+		return nil
+	}
+	outs := new(derivedOuts)
+	if err := rlp.Decode(bytes.NewReader(data), outs); err != nil {
+		log.Error("Invalid derived account progress", "err", err)
+		return nil
+	}
+	return outs
+}
+
+// receivedOuts tells which accounts received an out from the block from up to
+// stable. The progress of each account is kept, an account already scanned is
+// only read in the blocks after those it was scanned up to.
+func (self *Exchange) receivedOuts(from, stable uint64, candidates []accounts.Account, read blocksReader) ([]bool, error) {
+	used := make([]bool, len(candidates))
+	tks := make([]*keys.Uint512, len(candidates))
+	starts := make([]uint64, len(candidates))
+	first := stable + 1
+	for i, candidate := range candidates {
+		tks[i] = candidate.Tk.ToUint512()
+		starts[i] = from
+		if outs := self.getDerivedOuts(tks[i]); outs != nil {
+			used[i], starts[i] = outs.Used, outs.Num+1
+		}
+		if !used[i] && starts[i] < first {
+			first = starts[i]
+		}
+	}
+	// save records the accounts read up to num
+	save := func(num uint64) error {
+		batch := self.db.NewBatch()
+		for i, tk := range tks {
+			if starts[i] > num {
+				continue
+			}
+			data, err := rlp.EncodeToBytes(&derivedOuts{Num: num, Used: used[i]})
+			if err != nil {
+				return err
+			}
+			batch.Put(derivedKey(tk), data)
+		}
+		return batch.Write()
+	}
+	for start := first; start <= stable; start += fetchCount {
+		blocks, err := read(start, fetchCount)
+		if err != nil {
+			return nil, err
+		}
+		last := uint64(0)
+		for _, block := range blocks {
+			num := uint64(block.Num)
+			if num > stable {
+				break
+			}
+			last = num
+			for _, out := range block.Outs {
+				var pkr keys.PKr
+				if out.State.OS.Out_Z != nil {
+					pkr = out.State.OS.Out_Z.PKr
+				}
+				if out.State.OS.Out_O != nil {
+					pkr = out.State.OS.Out_O.Addr
+				}
+				for i, tk := range tks {
+					if !used[i] && num >= starts[i] && keys.IsMyPKr(tk, &pkr) {
+						used[i] = true
+					}
+				}
+			}
+		}
+		if last < start {
+			break
+		}
+		if err := save(last); err != nil {
+			return nil, err
+		}
+	}
+	return used, nil
+}
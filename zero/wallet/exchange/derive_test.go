@@ -0,0 +1,81 @@
+package exchange
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/sero-cash/go-czero-import/keys"
+	"github.com/sero-cash/go-sero/accounts"
+	"github.com/sero-cash/go-sero/common/address"
+	"github.com/sero-cash/go-sero/common/hexutil"
+	"github.com/sero-cash/go-sero/serodb"
+	"github.com/sero-cash/go-sero/zero/localdb"
+	"github.com/sero-cash/go-sero/zero/txs/stx"
+	"github.com/sero-cash/go-sero/zero/txtool"
+)
+
+func newTestCandidate(i byte) (accounts.Account, keys.PKr) {
+	tk := keys.Seed2Tk(&keys.Uint256{i})
+	pk := keys.Tk2Pk(&tk)
+	return accounts.Account{Tk: address.BytesToAccount(tk[:])}, keys.Addr2PKr(&pk, nil)
+}
+
+func TestReceivedOutsResumes(t *testing.T) {
+	dir, err := ioutil.TempDir("", "exchange-derive")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	db, err := serodb.NewLDBDatabase(dir, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	ex := &Exchange{db: db}
+
+	first, firstPKr := newTestCandidate(1)
+	second, secondPKr := newTestCandidate(2)
+	outs := map[uint64]keys.PKr{5: firstPKr, 12: secondPKr}
+	var reads []uint64
+	read := func(start, count uint64) (blocks []txtool.Block, err error) {
+		reads = append(reads, start)
+		for num := start; num < start+count && num <= 20; num++ {
+			block := txtool.Block{Num: hexutil.Uint64(num)}
+			if pkr, ok := outs[num]; ok {
+				block.Outs = []txtool.Out{{State: localdb.RootState{OS: localdb.OutState{Out_O: &stx.Out_O{Addr: pkr}}}}}
+			}
+			blocks = append(blocks, block)
+		}
+		return
+	}
+
+	// The out of the second account comes after the blocks scanned for it
+	used, err := ex.receivedOuts(1, 10, []accounts.Account{first, second}, read)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !used[0] || used[1] || len(reads) != 1 || reads[0] != 1 {
+		t.Fatalf("first scan mismatch: used %v, reads %v", used, reads)
+	}
+
+	// Scanned again, the accounts are only read in the new blocks
+	reads = nil
+	used, err = ex.receivedOuts(1, 20, []accounts.Account{first, second}, read)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !used[0] || !used[1] || len(reads) != 1 || reads[0] != 11 {
+		t.Fatalf("resumed scan mismatch: used %v, reads %v", used, reads)
+	}
+	reads = nil
+	if used, _ = ex.receivedOuts(1, 20, []accounts.Account{first, second}, read); !used[0] || !used[1] || len(reads) != 0 {
+		t.Fatalf("scanned accounts read again: used %v, reads %v", used, reads)
+	}
+
+	// A new account is read from the start
+	third, _ := newTestCandidate(3)
+	if used, _ = ex.receivedOuts(1, 20, []accounts.Account{third}, read); used[0] || len(reads) != 1 || reads[0] != 1 {
+		t.Fatalf("new account scan mismatch: used %v, reads %v", used, reads)
+	}
+}
@@ -8,18 +8,14 @@ import (
 	"sort"
 	"strings"
 	"sync"
-	"sync/atomic"
 	"time"
 
-	"github.com/sero-cash/go-sero/common/address"
-
 	"github.com/sero-cash/go-sero/zero/txtool"
 	"github.com/sero-cash/go-sero/zero/txtool/flight"
 	"github.com/sero-cash/go-sero/zero/txtool/prepare"
 
 	"github.com/sero-cash/go-sero/common/hexutil"
 
-	"github.com/robfig/cron"
 	"github.com/sero-cash/go-czero-import/cpt"
 	"github.com/sero-cash/go-czero-import/keys"
 	"github.com/sero-cash/go-sero/accounts"
@@ -30,7 +26,9 @@ import (
 	"github.com/sero-cash/go-sero/log"
 	"github.com/sero-cash/go-sero/rlp"
 	"github.com/sero-cash/go-sero/serodb"
+	"github.com/sero-cash/go-sero/zero/localdb"
 	"github.com/sero-cash/go-sero/zero/txs/assets"
+	"github.com/sero-cash/go-sero/zero/txs/zstate"
 	"github.com/sero-cash/go-sero/zero/utils"
 )
 
@@ -118,11 +116,16 @@ type Exchange struct {
 	usedFlag sync.Map
 	numbers  sync.Map
 
-	feed    event.Feed
-	updater event.Subscription        // Wallet update subscriptions for all backends
-	update  chan accounts.WalletEvent // Subscription sink for backend wallet changes
-	quit    chan chan error
-	lock    sync.RWMutex
+	derivedScans sync.Map // Masters whose derived accounts are being scanned
+	views        sync.Map // Scopes of the watch-only accounts imported from view bundles, by pk
+
+	feed       event.Feed
+	updater    event.Subscription        // Wallet update subscriptions for all backends
+	update     chan accounts.WalletEvent // Subscription sink for backend wallet changes
+	replace    chan core.TxReplacedEvent // Subscription sink for replaced pool transactions
+	replaceSub event.Subscription
+	quit       chan chan error
+	lock       sync.RWMutex
 }
 
 var current_exchange *Exchange
@@ -155,14 +158,28 @@ func NewExchange(dbpath string, txPool *core.TxPool, accountManager *accounts.Ma
 	for _, w := range accountManager.Wallets() {
 		exchange.initWallet(w)
 	}
+	if txtool.Ref_inst.Bc != nil && txtool.Ref_inst.Bc.IsValid() {
+		var state *zstate.ZState
+		exchange.migratePkgIndex(func(id *keys.Uint256) *localdb.ZPkg {
+			if state == nil {
+				state = txtool.Ref_inst.CurrentState()
+			}
+			return state.Pkgs.GetPkgById(id)
+		})
+	}
 
 	exchange.pkrAccounts = sync.Map{}
 	exchange.usedFlag = sync.Map{}
 
-	AddJob("0/10 * * * * ?", exchange.fetchBlockInfo)
+	if txPool != nil {
+		exchange.replace = make(chan core.TxReplacedEvent, 16)
+		exchange.replaceSub = txPool.SubscribeTxReplacedEvent(exchange.replace)
+	}
+
+	utils.AddJob("0/10 * * * * ?", exchange.fetchBlockInfo)
 
 	if autoMerge {
-		AddJob("0 0/5 * * * ?", exchange.merge)
+		utils.AddJob("0 0/5 * * * ?", exchange.merge)
 	}
 
 	go exchange.updateAccount()
@@ -181,6 +198,9 @@ func (self *Exchange) initWallet(w accounts.Wallet) {
 		account.mainPkr = prepare.CreatePkr(account.pk, 1)
 		account.isChanged = true
 		account.nextMergeTime = time.Now()
+		if view := self.loadView(account.pk); view != nil {
+			self.views.LoadOrStore(*account.pk, view)
+		}
 		self.accounts.Store(*account.pk, &account)
 
 		if num := self.starNum(account.pk); num > w.Accounts()[0].At {
@@ -190,6 +210,11 @@ func (self *Exchange) initWallet(w accounts.Wallet) {
 		}
 
 		log.Info("Add PK", "address", w.Accounts()[0].Address, "At", self.GetCurrencyNumber(*account.pk))
+		if txtool.Ref_inst.Bc != nil {
+			if err := localdb.CheckPruned(txtool.Ref_inst.Bc.GetDB(), self.GetCurrencyNumber(*account.pk)); err != nil {
+				log.Error("Exchange won't index the account, run it on a node keeping its blocks", "address", w.Accounts()[0].Address, "err", err)
+			}
+		}
 	}
 }
 
@@ -207,8 +232,16 @@ func (self *Exchange) updateAccount() {
 		self.lock.Lock()
 		self.updater.Unsubscribe()
 		self.updater = nil
+		if self.replaceSub != nil {
+			self.replaceSub.Unsubscribe()
+			self.replaceSub = nil
+		}
 		self.lock.Unlock()
 	}()
+	var replaceErr <-chan error
+	if self.replaceSub != nil {
+		replaceErr = self.replaceSub.Err()
+	}
 
 	// Loop until termination
 	for {
@@ -226,6 +259,15 @@ func (self *Exchange) updateAccount() {
 			}
 			self.lock.Unlock()
 
+		case ev := <-self.replace:
+			// A transaction of ours was replaced in the pool, unlock the
+			// utxos the replacement doesn't spend
+			self.releaseReplaced(ev.Old, ev.New)
+
+		case <-replaceErr:
+			// The tx pool stopped, no more replacements will arrive
+			replaceErr = nil
+
 		case errc := <-self.quit:
 			// Manager terminating, return
 			errc <- nil
@@ -294,6 +336,48 @@ func (self *Exchange) ClearUsedFlagForPK(pk *keys.Uint512) (count int) {
 	return
 }
 
+// releaseReplaced clears the used flag of the utxos spent by a replaced
+// transaction and not by its replacement.
+func (self *Exchange) releaseReplaced(old *types.Transaction, tx *types.Transaction) {
+	spent := map[keys.Uint256]bool{}
+	for _, root := range self.txRoots(tx) {
+		spent[root] = true
+	}
+	count := 0
+	for _, root := range self.txRoots(old) {
+		if !spent[root] {
+			count += self.ClearUsedFlagForRoot(root)
+		}
+	}
+	if count > 0 {
+		log.Info("Exchange released utxos of replaced tx", "hash", old.Hash(), "replacement", tx.Hash(), "count", count)
+	}
+}
+
+// txRoots returns the roots of the indexed utxos spent by a transaction.
+func (self *Exchange) txRoots(tx *types.Transaction) (roots []keys.Uint256) {
+	stxt := tx.GetZZSTX()
+	if stxt == nil {
+		return
+	}
+	var nils []keys.Uint256
+	for _, in := range stxt.Desc_O.Ins {
+		nils = append(nils, in.Root)
+	}
+	for _, in := range stxt.Desc_Z.Ins {
+		nils = append(nils, in.Nil)
+	}
+	for _, Nil := range nils {
+		// "NIL" + nil/root => "PK" + PK + currency + root
+		if value, _ := self.db.Get(nilKey(Nil)); len(value) >= 130 {
+			var root keys.Uint256
+			copy(root[:], value[98:130])
+			roots = append(roots, root)
+		}
+	}
+	return
+}
+
 func (self *Exchange) ClearUsedFlagForRoot(root keys.Uint256) (count int) {
 	if _, flag := self.usedFlag.Load(root); flag {
 		self.usedFlag.Delete(root)
@@ -481,6 +565,7 @@ func (self *Exchange) GenTxWithSign(param prepare.PreTxParam) (pretx *txtool.GTx
 		return
 	}
 	tx.Hash = tx.Tx.ToHash()
+	self.revealPkgCmds(&param.Cmds)
 	log.Info("Exchange genTx success")
 	return
 }
@@ -526,22 +611,28 @@ func (self *Exchange) genTx(utxos prepare.Utxos, account *Account, refundTo *key
 		return
 	}
 
-	var seed *address.Seed
-	if seed, e = account.wallet.GetSeed(); e != nil {
+	if tx, e = signTx(account.wallet, txParam); e != nil {
 		self.ClearTxParam(txParam)
-		return
 	}
+	return
+}
 
+// signTx signs a param with the seed of the wallet, or delegates the signing
+// to the wallets keeping their seed like the external signers.
+func signTx(wallet accounts.Wallet, txParam *txtool.GTxParam) (*txtool.GTx, error) {
+	if signer, ok := wallet.(accounts.TxParamSigner); ok {
+		return signer.SignTxParam(wallet.Accounts()[0], txParam)
+	}
+	seed, err := wallet.GetSeed()
+	if err != nil {
+		return nil, err
+	}
 	sk := keys.Seed2Sk(seed.SeedToUint256())
 	gtx, err := flight.SignTx(&sk, txParam)
 	if err != nil {
-		self.ClearTxParam(txParam)
-		e = err
-		return
-	} else {
-		tx = &gtx
-		return
+		return nil, err
 	}
+	return &gtx, nil
 }
 
 func (self *Exchange) commitTx(tx *txtool.GTx) (err error) {
@@ -691,9 +782,15 @@ func (self *Exchange) fetchBlockInfo() {
 	for {
 		indexs := map[uint64][]keys.Uint512{}
 		orders := uint64Slice{}
+		// The accounts dated before the pruned blocks can't be indexed, they
+		// don't hold back the others
+		pruned := localdb.GetPrunedNum(txtool.Ref_inst.Bc.GetDB())
 		self.numbers.Range(func(key, value interface{}) bool {
 			pk := key.(keys.Uint512)
 			num := value.(uint64)
+			if num < pruned || self.viewExpired(pk, num) {
+				return true
+			}
 			if list, ok := indexs[num]; ok {
 				indexs[num] = append(list, pk)
 			} else {
@@ -732,6 +829,10 @@ func (self *Exchange) fetchBlockInfo() {
 
 func (self *Exchange) fetchAndIndexUtxo(start, countBlock uint64, pks []keys.Uint512) (count int) {
 
+	if err := localdb.CheckPruned(txtool.Ref_inst.Bc.GetDB(), start); err != nil {
+		log.Error("Exchange can't index pruned blocks, run it on a node keeping them", "err", err)
+		return
+	}
 	blocks, err := flight.SRI_Inst.GetBlocksInfo(start, countBlock)
 	if err != nil {
 		log.Info("Exchange GetBlocksInfo", "error", err)
@@ -763,6 +864,9 @@ func (self *Exchange) fetchAndIndexUtxo(start, countBlock uint64, pks []keys.Uin
 			if !ok {
 				continue
 			}
+			if view := self.ViewOf(*account.pk); view != nil && !view.Allows(&pkr, out.State.Num) {
+				continue
+			}
 
 			key := PkKey{PK: *account.pk, Num: out.State.Num}
 			dout := DecOuts([]txtool.Out{out}, &account.skr)[0]
@@ -821,6 +925,7 @@ func (self *Exchange) fetchAndIndexUtxo(start, countBlock uint64, pks []keys.Uin
 	batch := self.db.NewBatch()
 
 	self.indexPkgs(pks, batch, blocks)
+	notifies := self.indexPkgEvents(pks, batch, blocks)
 
 	var roots []keys.Uint256
 	if len(utxosMap) > 0 || len(nils) > 0 {
@@ -843,6 +948,9 @@ func (self *Exchange) fetchAndIndexUtxo(start, countBlock uint64, pks []keys.Uin
 		for _, pk := range pks {
 			self.numbers.Store(pk, num)
 		}
+		for _, notify := range notifies {
+			self.feed.Send(notify)
+		}
 	}
 
 	for _, root := range roots {
@@ -1129,10 +1237,12 @@ func (self *Exchange) Merge(pk *keys.Uint512, currency string, force bool) (coun
 		return
 	}
 
-	seed, err := account.wallet.GetSeed()
-	if err != nil || seed == nil {
-		e = errors.New("account is locked")
-		return
+	if _, ok := account.wallet.(accounts.TxParamSigner); !ok {
+		seed, err := account.wallet.GetSeed()
+		if err != nil || seed == nil {
+			e = errors.New("account is locked")
+			return
+		}
 	}
 
 	var mu MergeUtxos
@@ -1261,33 +1371,3 @@ func utxoPkKey(pk keys.Uint512, currency []byte, root *keys.Uint256) []byte {
 func utxoKey(number uint64, pk keys.Uint512) []byte {
 	return append(utxoPrefix, append(utils.EncodeNumber(number), pk[:]...)...)
 }
-
-func AddJob(spec string, run RunFunc) *cron.Cron {
-	c := cron.New()
-	c.AddJob(spec, &RunJob{run: run})
-	c.Start()
-	return c
-}
-
-type (
-	RunFunc func()
-)
-
-type RunJob struct {
-	runing int32
-	run    RunFunc
-}
-
-func (r *RunJob) Run() {
-	x := atomic.LoadInt32(&r.runing)
-	if x == 1 {
-		return
-	}
-
-	atomic.StoreInt32(&r.runing, 1)
-	defer func() {
-		atomic.StoreInt32(&r.runing, 0)
-	}()
-
-	r.run()
-}
@@ -1,19 +1,29 @@
 package exchange
 
 import (
+	"bytes"
+	"io"
+
 	"github.com/sero-cash/go-czero-import/keys"
 	"github.com/sero-cash/go-sero/log"
 	"github.com/sero-cash/go-sero/rlp"
 	"github.com/sero-cash/go-sero/serodb"
 	"github.com/sero-cash/go-sero/zero/localdb"
 	"github.com/sero-cash/go-sero/zero/txtool"
+	"github.com/sero-cash/go-sero/zero/utils"
 )
 
 var (
 	pk_from_id_2_id_KeyPrefix = []byte("PK_FROM_ID_2_ID")
 	id_2_pkg_KeyPrefix        = []byte("ID_2_PKG")
+	pkgIndexVersionKey        = []byte("PKG_INDEX_VERSION")
 )
 
+// pkgIndexVersion is the version of the index of the packages by PK. Before 1
+// the packages were recorded without their content and their PKs, and the ones
+// sent by a PK were indexed under the PK receiving them.
+const pkgIndexVersion = 1
+
 func pk_from_id_2_id_Key(pk *keys.Uint512, from *bool, id *keys.Uint256) []byte {
 	ret := append(pk_from_id_2_id_KeyPrefix, pk[:]...)
 	if from != nil {
@@ -31,8 +41,36 @@ func pk_from_id_2_id_Key(pk *keys.Uint512, from *bool, id *keys.Uint256) []byte
 
 type Pkg struct {
 	z    localdb.ZPkg
-	to   *keys.Uint512 `rlp: nil`
-	from *keys.Uint512 `rlp: nil`
+	to   *keys.Uint512
+	from *keys.Uint512
+}
+
+// pkgRLP is the encoding of a Pkg.
+type pkgRLP struct {
+	Z    localdb.ZPkg
+	To   *keys.Uint512 `rlp:"nil"`
+	From *keys.Uint512 `rlp:"nil"`
+}
+
+func (self *Pkg) EncodeRLP(w io.Writer) error {
+	return rlp.Encode(w, &pkgRLP{self.z, self.to, self.from})
+}
+
+func (self *Pkg) DecodeRLP(s *rlp.Stream) error {
+	raw, e := s.Raw()
+	if e != nil {
+		return e
+	}
+	// The records of the older versions are empty
+	if bytes.Equal(raw, rlp.EmptyList) {
+		return nil
+	}
+	var dec pkgRLP
+	if e := rlp.DecodeBytes(raw, &dec); e != nil {
+		return e
+	}
+	self.z, self.to, self.from = dec.Z, dec.To, dec.From
+	return nil
 }
 
 func id_2_pkg_key(id *keys.Uint256) []byte {
@@ -87,7 +125,7 @@ func (self *Exchange) indexPkgs(pks []keys.Uint512, batch serodb.Batch, blocks [
 				}
 				if p.from != nil {
 					from := true
-					batch.Delete(pk_from_id_2_id_Key(p.to, &from, &p.z.Pack.Id))
+					batch.Delete(pk_from_id_2_id_Key(p.from, &from, &p.z.Pack.Id))
 				}
 				batch.Delete(id_2_pkg_key(&p.z.Pack.Id))
 			}
@@ -101,28 +139,92 @@ func (self *Exchange) indexPkgs(pks []keys.Uint512, batch serodb.Batch, blocks [
 			if p.from != nil || p.to != nil {
 				if !pkg.Closed {
 					p.z = pkg
-					if bs, e := rlp.EncodeToBytes(&p); e == nil {
-						if p.to != nil {
-							from := false
-							if e := batch.Put(pk_from_id_2_id_Key(p.to, &from, &p.z.Pack.Id), p.z.Pack.Id[:]); e != nil {
-								panic(e)
-							}
-						}
-						if p.from != nil {
-							from := true
-							if e := batch.Put(pk_from_id_2_id_Key(p.to, &from, &p.z.Pack.Id), p.z.Pack.Id[:]); e != nil {
-								panic(e)
-							}
-						}
-						if e := batch.Put(id_2_pkg_key(&p.z.Pack.Id), bs); e != nil {
-							panic(e)
-						}
-					} else {
-						panic(e)
-					}
+					putPkg(batch, &p)
 				}
 			}
 		}
 	}
 	return
 }
+
+// putPkg records a package and indexes it under the PKs receiving and sending
+// it.
+func putPkg(batch serodb.Batch, p *Pkg) {
+	if bs, e := rlp.EncodeToBytes(p); e == nil {
+		if p.to != nil {
+			from := false
+			if e := batch.Put(pk_from_id_2_id_Key(p.to, &from, &p.z.Pack.Id), p.z.Pack.Id[:]); e != nil {
+				panic(e)
+			}
+		}
+		if p.from != nil {
+			from := true
+			if e := batch.Put(pk_from_id_2_id_Key(p.from, &from, &p.z.Pack.Id), p.z.Pack.Id[:]); e != nil {
+				panic(e)
+			}
+		}
+		if e := batch.Put(id_2_pkg_key(&p.z.Pack.Id), bs); e != nil {
+			panic(e)
+		}
+	} else {
+		panic(e)
+	}
+}
+
+// migratePkgIndex indexes again the packages recorded by the older versions,
+// from their state given by pkgById.
+func (self *Exchange) migratePkgIndex(pkgById func(id *keys.Uint256) *localdb.ZPkg) {
+	if bs, e := self.db.Get(pkgIndexVersionKey); e == nil && utils.DecodeNumber(bs) >= pkgIndexVersion {
+		return
+	}
+	batch := self.db.NewBatch()
+	ids := map[keys.Uint256]bool{}
+	iterator := self.db.NewIteratorWithPrefix(pk_from_id_2_id_KeyPrefix)
+	for iterator.Next() {
+		batch.Delete(append([]byte{}, iterator.Key()...))
+		if value := iterator.Value(); len(value) == 32 {
+			id := keys.Uint256{}
+			copy(id[:], value)
+			ids[id] = true
+		}
+	}
+	iterator.Release()
+	iterator = self.db.NewIteratorWithPrefix(id_2_pkg_KeyPrefix)
+	for iterator.Next() {
+		key := iterator.Key()
+		batch.Delete(append([]byte{}, key...))
+		if value := key[len(id_2_pkg_KeyPrefix):]; len(value) == 32 {
+			id := keys.Uint256{}
+			copy(id[:], value)
+			ids[id] = true
+		}
+	}
+	iterator.Release()
+
+	count := 0
+	for id := range ids {
+		pkg := pkgById(&id)
+		if pkg == nil || pkg.Closed {
+			continue
+		}
+		p := Pkg{z: *pkg}
+		if account := self.getAccountByPkr(pkg.Pack.PKr); account != nil {
+			p.to = account.pk
+		}
+		if account := self.getAccountByPkr(pkg.From); account != nil {
+			p.from = account.pk
+		}
+		if p.from != nil || p.to != nil {
+			putPkg(batch, &p)
+			count++
+		}
+	}
+	if e := batch.Put(pkgIndexVersionKey, utils.EncodeNumber(pkgIndexVersion)); e != nil {
+		panic(e)
+	}
+	if e := batch.Write(); e != nil {
+		log.Error("Exchange migrate pkg index", "error", e)
+		return
+	}
+	log.Info("Exchange migrated pkg index", "version", pkgIndexVersion, "pkgs", count)
+}
@@ -0,0 +1,320 @@
+package exchange
+
+import (
+	"github.com/sero-cash/go-czero-import/keys"
+	"github.com/sero-cash/go-sero/event"
+	"github.com/sero-cash/go-sero/log"
+	"github.com/sero-cash/go-sero/rlp"
+	"github.com/sero-cash/go-sero/serodb"
+	"github.com/sero-cash/go-sero/zero/localdb"
+	"github.com/sero-cash/go-sero/zero/txs/assets"
+	"github.com/sero-cash/go-sero/zero/txtool"
+	"github.com/sero-cash/go-sero/zero/txtool/prepare"
+	"github.com/sero-cash/go-sero/zero/utils"
+)
+
+type PkgEventKind uint8
+
+const (
+	PkgCreated PkgEventKind = iota
+	PkgTransferred
+	PkgClosed
+)
+
+func (k PkgEventKind) String() string {
+	switch k {
+	case PkgCreated:
+		return "create"
+	case PkgTransferred:
+		return "transfer"
+	case PkgClosed:
+		return "close"
+	default:
+		return "unknown"
+	}
+}
+
+// PkgEvent is a step of the lifecycle of a package: its creation by From to
+// To, a hop of its ownership from From to To, or its close by its owner.
+type PkgEvent struct {
+	Id        keys.Uint256
+	Kind      PkgEventKind
+	Num       uint64
+	BlockHash keys.Uint256
+	From      keys.PKr // Creator, previous owner or closing owner, zero if the previous owner is unknown
+	To        keys.PKr // Owner after the event
+
+	Asset *assets.Asset `rlp:"-"` // Content of the package, if revealed to the exchange
+}
+
+// PkgNotify is sent to the subscribers when a package arrives to a tracked PK
+// or a package it owns is closed.
+type PkgNotify struct {
+	PK    keys.Uint512
+	Event PkgEvent
+}
+
+var (
+	pkgEventPrefix   = []byte("EVENT_PKG")
+	pkPkgEventPrefix = []byte("EVENT_PK_PKG")
+	pkgAssetPrefix   = []byte("ASSET_PKG")
+
+	pendingPkgAssetPrefix = []byte("PENDING_ASSET_PKG")
+)
+
+// pkgEventKey = id + num + kind, the events of a package sorted by block
+func pkgEventKey(id *keys.Uint256, num uint64, kind PkgEventKind) []byte {
+	key := append(pkgEventPrefix, id[:]...)
+	key = append(key, utils.EncodeNumber(num)...)
+	return append(key, byte(kind))
+}
+
+// pkPkgEventKey = pk + num + id + kind, the events of the packages of a PK sorted by block
+func pkPkgEventKey(pk *keys.Uint512, ev *PkgEvent) []byte {
+	key := append(pkPkgEventPrefix, pk[:]...)
+	key = append(key, utils.EncodeNumber(ev.Num)...)
+	key = append(key, ev.Id[:]...)
+	return append(key, byte(ev.Kind))
+}
+
+func pkgAssetKey(id *keys.Uint256) []byte {
+	return append(pkgAssetPrefix, id[:]...)
+}
+
+// pendingPkgAssetKey = id + kind, the content of a package created or closed by
+// a transaction of the exchange, until the event is indexed
+func pendingPkgAssetKey(id *keys.Uint256, kind PkgEventKind) []byte {
+	key := append(pendingPkgAssetPrefix, id[:]...)
+	return append(key, byte(kind))
+}
+
+// RevealPkg records the content of a package, attached to its close events.
+func (self *Exchange) RevealPkg(id keys.Uint256, asset assets.Asset) {
+	if self == nil {
+		return
+	}
+	self.putPkgAsset(pkgAssetKey(&id), &asset)
+}
+
+func (self *Exchange) putPkgAsset(key []byte, asset *assets.Asset) {
+	if bs, e := rlp.EncodeToBytes(asset); e == nil {
+		if e := self.db.Put(key, bs); e != nil {
+			log.Error("Exchange reveal pkg", "error", e)
+		}
+	} else {
+		log.Error("Exchange reveal pkg", "error", e)
+	}
+}
+
+// minedPkgAsset records in the batch the content revealed by the transaction of
+// the exchange creating or closing a package, once the event is indexed.
+func (self *Exchange) minedPkgAsset(batch serodb.Batch, id *keys.Uint256, kind PkgEventKind) *assets.Asset {
+	key := pendingPkgAssetKey(id, kind)
+	bs, e := self.db.Get(key)
+	if e != nil {
+		return nil
+	}
+	asset := assets.Asset{}
+	if e := rlp.DecodeBytes(bs, &asset); e != nil {
+		log.Error("Invalid pending pkg asset RLP", "id", id, "error", e)
+		return nil
+	}
+	if e := batch.Put(pkgAssetKey(id), bs); e != nil {
+		panic(e)
+	}
+	if e := batch.Delete(key); e != nil {
+		panic(e)
+	}
+	return &asset
+}
+
+func (self *Exchange) pkgAsset(id *keys.Uint256) *assets.Asset {
+	bs, e := self.db.Get(pkgAssetKey(id))
+	if e != nil {
+		return nil
+	}
+	asset := assets.Asset{}
+	if e := rlp.DecodeBytes(bs, &asset); e != nil {
+		log.Error("Invalid pkg asset RLP", "id", id, "error", e)
+		return nil
+	}
+	return &asset
+}
+
+func (self *Exchange) decodePkgEvent(bs []byte) (ev PkgEvent, e error) {
+	if e = rlp.DecodeBytes(bs, &ev); e != nil {
+		return
+	}
+	if ev.Kind == PkgClosed {
+		ev.Asset = self.pkgAsset(&ev.Id)
+	}
+	return
+}
+
+// GetPkgEvents returns the lifecycle of a package known to the exchange.
+func (self *Exchange) GetPkgEvents(id keys.Uint256) (events []PkgEvent, e error) {
+	iterator := self.db.NewIteratorWithPrefix(append(pkgEventPrefix, id[:]...))
+	defer iterator.Release()
+	for iterator.Next() {
+		var ev PkgEvent
+		if ev, e = self.decodePkgEvent(iterator.Value()); e != nil {
+			return
+		}
+		events = append(events, ev)
+	}
+	return
+}
+
+// GetPkgEventsByPk returns count events of the packages created, sent to or
+// owned by the PK, from the offset-th one.
+func (self *Exchange) GetPkgEventsByPk(pk keys.Uint512, offset, count uint64) (events []PkgEvent, e error) {
+	iterator := self.db.NewIteratorWithPrefix(append(pkPkgEventPrefix, pk[:]...))
+	defer iterator.Release()
+	for i := uint64(0); iterator.Next() && uint64(len(events)) < count; i++ {
+		if i < offset {
+			continue
+		}
+		var bs []byte
+		if bs, e = self.db.Get(iterator.Value()); e != nil {
+			return
+		}
+		var ev PkgEvent
+		if ev, e = self.decodePkgEvent(bs); e != nil {
+			return
+		}
+		events = append(events, ev)
+	}
+	return
+}
+
+// SubscribePkgNotify notifies the packages arriving to the tracked PKs and
+// closed by them.
+func (self *Exchange) SubscribePkgNotify(ch chan<- PkgNotify) event.Subscription {
+	return self.feed.Subscribe(ch)
+}
+
+// lastPkgEvent returns the last event of a package before the block num,
+// among the ones indexed and the pending ones of the batch. known tells
+// whether the exchange follows the package.
+func (self *Exchange) lastPkgEvent(id *keys.Uint256, num uint64, pending []PkgEvent) (last *PkgEvent, known bool) {
+	events, _ := self.GetPkgEvents(*id)
+	events = append(events, pending...)
+	for i := range events {
+		ev := &events[i]
+		if ev.Num >= num {
+			continue
+		}
+		if last == nil || ev.Num > last.Num || ev.Num == last.Num && ev.Kind > last.Kind {
+			last = ev
+		}
+	}
+	return last, len(events) > 0
+}
+
+// pkgEvents deduces the events of a package changed in the block num from its
+// state at the end of the block and its last event.
+func pkgEvents(pkg *localdb.ZPkg, num uint64, hash keys.Uint256, last *PkgEvent) (events []PkgEvent) {
+	ev := PkgEvent{Id: pkg.Pack.Id, Num: num, BlockHash: hash, To: pkg.Pack.PKr}
+	if pkg.High == num {
+		ev.Kind = PkgCreated
+		ev.From = pkg.From
+		events = append(events, ev)
+	} else if last != nil && last.To != pkg.Pack.PKr || last == nil && !pkg.Closed {
+		ev.Kind = PkgTransferred
+		if last != nil {
+			ev.From = last.To
+		}
+		events = append(events, ev)
+	}
+	if pkg.Closed {
+		ev.Kind = PkgClosed
+		ev.From = pkg.Pack.PKr
+		events = append(events, ev)
+	}
+	return
+}
+
+// indexPkgEvents records the events of the packages created by or sent to the
+// PKs, and of the ones already followed. It returns the notifications to send
+// once the batch is written.
+func (self *Exchange) indexPkgEvents(pks []keys.Uint512, batch serodb.Batch, blocks []txtool.Block) (notifies []PkgNotify) {
+	pending := map[keys.Uint256][]PkgEvent{}
+	indexed := map[string]bool{}
+	for _, block := range blocks {
+		num := uint64(block.Num)
+		for _, pkg := range block.Pkgs {
+			id := pkg.Pack.Id
+			last, known := self.lastPkgEvent(&id, num, pending[id])
+			if !known {
+				_, created := self.ownPkr(pks, pkg.From)
+				_, owned := self.ownPkr(pks, pkg.Pack.PKr)
+				if !created && !owned {
+					continue
+				}
+			}
+
+			for _, ev := range pkgEvents(&pkg, num, block.Hash, last) {
+				key := pkgEventKey(&id, num, ev.Kind)
+				if bs, e := rlp.EncodeToBytes(&ev); e == nil {
+					if e := batch.Put(key, bs); e != nil {
+						panic(e)
+					}
+				} else {
+					panic(e)
+				}
+				pending[id] = append(pending[id], ev)
+				var asset *assets.Asset
+				if ev.Kind != PkgTransferred {
+					asset = self.minedPkgAsset(batch, &id, ev.Kind)
+				}
+
+				for _, pkr := range []keys.PKr{ev.From, ev.To} {
+					if pkr == (keys.PKr{}) {
+						continue
+					}
+					account, ok := self.ownPkr(pks, pkr)
+					if !ok {
+						continue
+					}
+					if view := self.ViewOf(*account.pk); view != nil && !view.Allows(&pkr, num) {
+						continue
+					}
+					pkKey := pkPkgEventKey(account.pk, &ev)
+					if indexed[string(pkKey)] {
+						continue
+					}
+					indexed[string(pkKey)] = true
+					if has, _ := self.db.Has(pkKey); !has && (ev.Kind == PkgClosed || pkr == ev.To) {
+						notify := PkgNotify{PK: *account.pk, Event: ev}
+						if ev.Kind == PkgClosed {
+							if notify.Event.Asset = asset; asset == nil {
+								notify.Event.Asset = self.pkgAsset(&id)
+							}
+						}
+						notifies = append(notifies, notify)
+					}
+					if e := batch.Put(pkKey, key); e != nil {
+						panic(e)
+					}
+				}
+			}
+		}
+	}
+	return
+}
+
+// revealPkgCmds keeps the content of the packages created or closed by a
+// transaction of the exchange, recorded once the transaction is mined and its
+// event indexed.
+func (self *Exchange) revealPkgCmds(cmds *prepare.Cmds) {
+	if cmds.PkgCreate != nil {
+		self.putPkgAsset(pendingPkgAssetKey(&cmds.PkgCreate.Id, PkgCreated), &cmds.PkgCreate.Asset)
+	}
+	if cmds.PkgClose != nil {
+		if asset, e := cmds.PkgClose.Asset(); e == nil {
+			self.putPkgAsset(pendingPkgAssetKey(&cmds.PkgClose.Id, PkgClosed), &asset)
+		} else {
+			log.Error("Exchange reveal closed pkg", "error", e)
+		}
+	}
+}
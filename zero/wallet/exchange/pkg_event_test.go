@@ -0,0 +1,48 @@
+package exchange
+
+import (
+	"testing"
+
+	"github.com/sero-cash/go-czero-import/keys"
+	"github.com/sero-cash/go-sero/zero/localdb"
+)
+
+func TestPkgEvents(t *testing.T) {
+	creator, alice, bob := keys.PKr{1}, keys.PKr{2}, keys.PKr{3}
+	zpkg := func(high uint64, owner keys.PKr, closed bool) *localdb.ZPkg {
+		pkg := &localdb.ZPkg{High: high, From: creator, Closed: closed}
+		pkg.Pack.PKr = owner
+		return pkg
+	}
+	type step struct {
+		kind     PkgEventKind
+		from, to keys.PKr
+	}
+	tests := []struct {
+		pkg  *localdb.ZPkg
+		num  uint64
+		last *PkgEvent
+		want []step
+	}{
+		{zpkg(10, alice, false), 10, nil, []step{{PkgCreated, creator, alice}}},
+		{zpkg(10, alice, true), 10, nil, []step{{PkgCreated, creator, alice}, {PkgClosed, alice, alice}}},
+		{zpkg(10, bob, false), 12, &PkgEvent{To: alice}, []step{{PkgTransferred, alice, bob}}},
+		{zpkg(10, bob, false), 12, nil, []step{{PkgTransferred, keys.PKr{}, bob}}},
+		{zpkg(10, alice, true), 12, &PkgEvent{To: alice}, []step{{PkgClosed, alice, alice}}},
+		{zpkg(10, bob, true), 12, &PkgEvent{To: alice}, []step{{PkgTransferred, alice, bob}, {PkgClosed, bob, bob}}},
+		{zpkg(10, alice, true), 12, nil, []step{{PkgClosed, alice, alice}}},
+	}
+	for i, test := range tests {
+		events := pkgEvents(test.pkg, test.num, keys.Uint256{}, test.last)
+		if len(events) != len(test.want) {
+			t.Errorf("test %d: got %d events, want %d", i, len(events), len(test.want))
+			continue
+		}
+		for j, want := range test.want {
+			ev := events[j]
+			if ev.Kind != want.kind || ev.From != want.from || ev.To != want.to || ev.Num != test.num {
+				t.Errorf("test %d: event %d is %v %x -> %x, want %v %x -> %x", i, j, ev.Kind, ev.From[:1], ev.To[:1], want.kind, want.from[:1], want.to[:1])
+			}
+		}
+	}
+}
@@ -0,0 +1,160 @@
+package exchange
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/sero-cash/go-czero-import/keys"
+	"github.com/sero-cash/go-sero/common/hexutil"
+	"github.com/sero-cash/go-sero/rlp"
+	"github.com/sero-cash/go-sero/serodb"
+	"github.com/sero-cash/go-sero/zero/localdb"
+	"github.com/sero-cash/go-sero/zero/txs/assets"
+	"github.com/sero-cash/go-sero/zero/txtool"
+	"github.com/sero-cash/go-sero/zero/txtool/prepare"
+	"github.com/sero-cash/go-sero/zero/utils"
+)
+
+// newTestExchange returns an exchange tracking the accounts of the seeds 1 and
+// 2, their pks and a PKr of each.
+func newTestExchange(t *testing.T) (*Exchange, []keys.Uint512, []keys.PKr, func()) {
+	dir, err := ioutil.TempDir("", "exchange-pkg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := serodb.NewLDBDatabase(dir, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ex := &Exchange{db: db}
+	var (
+		pks  []keys.Uint512
+		pkrs []keys.PKr
+	)
+	for i := byte(1); i <= 2; i++ {
+		tk := keys.Seed2Tk(&keys.Uint256{i})
+		pk := keys.Tk2Pk(&tk)
+		ex.accounts.Store(pk, &Account{pk: &pk, tk: &tk})
+		pks = append(pks, pk)
+		pkrs = append(pkrs, keys.Addr2PKr(&pk, nil))
+	}
+	return ex, pks, pkrs, func() {
+		db.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+func testPkg(id keys.Uint256, high uint64, from, to keys.PKr, closed bool) localdb.ZPkg {
+	pkg := localdb.ZPkg{High: high, From: from, Closed: closed}
+	pkg.Pack.Id = id
+	pkg.Pack.PKr = to
+	return pkg
+}
+
+func checkPkgs(t *testing.T, ex *Exchange, pk keys.Uint512, from bool, want int) {
+	t.Helper()
+	pkgs := ex.FindPkgs(&pk, from)
+	if len(pkgs) != want {
+		t.Fatalf("pk %x indexes %d pkgs with from %v, want %d", pk[:2], len(pkgs), from, want)
+	}
+	for _, p := range pkgs {
+		if p.z.Pack.Id == (keys.Uint256{}) {
+			t.Fatalf("pk %x indexes an empty pkg", pk[:2])
+		}
+	}
+}
+
+func indexTestPkg(t *testing.T, ex *Exchange, pks []keys.Uint512, num uint64, pkg localdb.ZPkg) {
+	batch := ex.db.NewBatch()
+	ex.indexPkgs(pks, batch, []txtool.Block{{Num: hexutil.Uint64(num), Pkgs: []localdb.ZPkg{pkg}}})
+	if err := batch.Write(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestIndexPkgs(t *testing.T) {
+	ex, pks, pkrs, closeEx := newTestExchange(t)
+	defer closeEx()
+	alice, bob := pks[0], pks[1]
+	id := keys.Uint256{1}
+
+	// Sent by alice to bob
+	indexTestPkg(t, ex, pks, 1, testPkg(id, 1, pkrs[0], pkrs[1], false))
+	checkPkgs(t, ex, alice, true, 1)
+	checkPkgs(t, ex, alice, false, 0)
+	checkPkgs(t, ex, bob, true, 0)
+	checkPkgs(t, ex, bob, false, 1)
+
+	// Transferred by bob to a foreign PKr, then closed
+	indexTestPkg(t, ex, pks, 2, testPkg(id, 1, pkrs[0], keys.PKr{3}, false))
+	checkPkgs(t, ex, alice, true, 1)
+	checkPkgs(t, ex, bob, false, 0)
+	indexTestPkg(t, ex, pks, 3, testPkg(id, 1, pkrs[0], keys.PKr{3}, true))
+	checkPkgs(t, ex, alice, true, 0)
+	if ex.FindPkgById(&id) != nil {
+		t.Fatalf("closed pkg still recorded")
+	}
+}
+
+func TestMigratePkgIndex(t *testing.T) {
+	ex, pks, pkrs, closeEx := newTestExchange(t)
+	defer closeEx()
+	alice, bob := pks[0], pks[1]
+	sent, closed := keys.Uint256{1}, keys.Uint256{2}
+
+	// The older versions recorded empty pkgs, and indexed the ones sent by
+	// alice to bob under bob
+	yes, no := true, false
+	for _, id := range []keys.Uint256{sent, closed} {
+		ex.db.Put(id_2_pkg_key(&id), rlp.EmptyList)
+		ex.db.Put(pk_from_id_2_id_Key(&bob, &no, &id), id[:])
+		ex.db.Put(pk_from_id_2_id_Key(&bob, &yes, &id), id[:])
+	}
+	states := map[keys.Uint256]localdb.ZPkg{
+		sent:   testPkg(sent, 1, pkrs[0], pkrs[1], false),
+		closed: testPkg(closed, 1, pkrs[0], pkrs[1], true),
+	}
+	ex.migratePkgIndex(func(id *keys.Uint256) *localdb.ZPkg {
+		pkg := states[*id]
+		return &pkg
+	})
+	checkPkgs(t, ex, alice, true, 1)
+	checkPkgs(t, ex, alice, false, 0)
+	checkPkgs(t, ex, bob, true, 0)
+	checkPkgs(t, ex, bob, false, 1)
+	if ex.FindPkgById(&closed) != nil {
+		t.Fatalf("closed pkg still recorded")
+	}
+
+	// Migrated once
+	ex.migratePkgIndex(func(id *keys.Uint256) *localdb.ZPkg {
+		t.Fatalf("pkg index migrated twice")
+		return nil
+	})
+}
+
+// TestMinedPkgAsset checks that the content of a package created by the
+// exchange is only recorded once its creation is indexed.
+func TestMinedPkgAsset(t *testing.T) {
+	ex, pks, pkrs, closeEx := newTestExchange(t)
+	defer closeEx()
+	id := keys.Uint256{1}
+	asset := assets.Asset{Tkn: &assets.Token{Currency: keys.Uint256{'S'}, Value: utils.NewU256(7)}}
+
+	ex.revealPkgCmds(&prepare.Cmds{PkgCreate: &prepare.PkgCreateCmd{Id: id, PKr: keys.PKr{3}, Asset: asset}})
+	if ex.pkgAsset(&id) != nil {
+		t.Fatalf("content of a pkg recorded before its creation is mined")
+	}
+	batch := ex.db.NewBatch()
+	ex.indexPkgEvents(pks, batch, []txtool.Block{{Num: 5, Pkgs: []localdb.ZPkg{testPkg(id, 5, pkrs[0], keys.PKr{3}, false)}}})
+	if err := batch.Write(); err != nil {
+		t.Fatal(err)
+	}
+	if got := ex.pkgAsset(&id); got == nil || got.Tkn.Value.ToInt().Int64() != 7 {
+		t.Fatalf("content of the mined pkg: have %v, want 7", got)
+	}
+	if has, _ := ex.db.Has(pendingPkgAssetKey(&id, PkgCreated)); has {
+		t.Fatalf("pending content of the mined pkg kept")
+	}
+}
@@ -0,0 +1,93 @@
+package exchange
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/sero-cash/go-czero-import/keys"
+	"github.com/sero-cash/go-sero/accounts"
+	"github.com/sero-cash/go-sero/accounts/keystore"
+	"github.com/sero-cash/go-sero/common/address"
+	"github.com/sero-cash/go-sero/log"
+)
+
+// ImportViewBundle adds the watch-only account of a view bundle, whose outs
+// are indexed from the block of the bundle to its expiry and restricted to its
+// PKrs. Importing a new bundle of a viewed account replaces its scope.
+func (self *Exchange) ImportViewBundle(ks *keystore.KeyStore, bundle *keystore.ViewBundle) (accounts.Account, error) {
+	if err := bundle.Verify(); err != nil {
+		return accounts.Account{}, err
+	}
+	pk := keys.Tk2Pk(bundle.Tk.ToUint512())
+	var addr address.AccountAddress
+	copy(addr[:], pk[:])
+
+	previous, viewed := self.views.Load(pk)
+	if !viewed && ks.HasAddress(addr) {
+		return accounts.Account{}, errors.New("account already exists")
+	}
+	data, err := json.Marshal(bundle)
+	if err != nil {
+		return accounts.Account{}, err
+	}
+
+	// The scope is set before the account arrives, its outs are never
+	// indexed without it
+	self.views.Store(pk, bundle)
+	var account accounts.Account
+	if viewed {
+		account, err = ks.Find(accounts.Account{Address: addr})
+	} else {
+		account, err = ks.ImportViewBundle(bundle)
+	}
+	if err != nil {
+		if viewed {
+			self.views.Store(pk, previous)
+		} else {
+			self.views.Delete(pk)
+		}
+		return accounts.Account{}, err
+	}
+	if err := self.db.Put(viewKey(pk), data); err != nil {
+		return accounts.Account{}, err
+	}
+	log.Info("Exchange imported view bundle", "at", bundle.At, "expiry", bundle.Expiry, "pkrs", len(bundle.PKrs))
+	return account, nil
+}
+
+// ViewOf returns the scope of a watch-only account imported from a view
+// bundle, nil for the other accounts.
+func (self *Exchange) ViewOf(pk keys.Uint512) *keystore.ViewBundle {
+	if view, ok := self.views.Load(pk); ok {
+		return view.(*keystore.ViewBundle)
+	}
+	return nil
+}
+
+// loadView returns the view bundle of a watch-only account, nil for the other
+// accounts.
+func (self *Exchange) loadView(pk *keys.Uint512) *keystore.ViewBundle {
+	data, err := self.db.Get(viewKey(*pk))
+	if err != nil {
+		return nil
+	}
+	bundle := new(keystore.ViewBundle)
+	if err := json.Unmarshal(data, bundle); err != nil {
+		log.Error("Exchange invalid view bundle", "err", err)
+		return nil
+	}
+	return bundle
+}
+
+// viewExpired tells whether the indexing of an account reached the expiry of
+// its view.
+func (self *Exchange) viewExpired(pk keys.Uint512, num uint64) bool {
+	view := self.ViewOf(pk)
+	return view != nil && view.Expiry != 0 && num > view.Expiry
+}
+
+var viewPrefix = []byte("VIEW")
+
+func viewKey(pk keys.Uint512) []byte {
+	return append(viewPrefix, pk[:]...)
+}
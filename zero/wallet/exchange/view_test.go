@@ -0,0 +1,65 @@
+package exchange
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sero-cash/go-sero/accounts/keystore"
+	"github.com/sero-cash/go-sero/serodb"
+)
+
+// TestImportViewBundle checks that the scope of a bundle is only recorded
+// once its account is imported in the keystore.
+func TestImportViewBundle(t *testing.T) {
+	dir, err := ioutil.TempDir("", "exchange-view")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	db, err := serodb.NewLDBDatabase(filepath.Join(dir, "db"), 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	ex := &Exchange{db: db}
+
+	owner := keystore.NewKeyStore(filepath.Join(dir, "owner"), keystore.LightScryptN, keystore.LightScryptP)
+	account, err := owner.NewAccount("", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bundle, err := owner.ExportViewBundle(account, "", 10, nil, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pk := *account.Address.ToUint512()
+
+	// The keys of a keystore under a file can't be written
+	if err := ioutil.WriteFile(filepath.Join(dir, "file"), nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	broken := keystore.NewKeyStore(filepath.Join(dir, "file", "keys"), keystore.LightScryptN, keystore.LightScryptP)
+	if _, err := ex.ImportViewBundle(broken, bundle); err == nil {
+		t.Fatalf("bundle imported in a broken keystore")
+	}
+	if ex.ViewOf(pk) != nil || ex.loadView(&pk) != nil {
+		t.Fatalf("scope of a failed import recorded")
+	}
+
+	ks := keystore.NewKeyStore(filepath.Join(dir, "viewer"), keystore.LightScryptN, keystore.LightScryptP)
+	imported, err := ex.ImportViewBundle(ks, bundle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if imported.Address != account.Address {
+		t.Fatalf("imported account mismatch: have %v, want %v", imported.Address, account.Address)
+	}
+	if view := ex.loadView(&pk); view == nil || view.Hash() != bundle.Hash() {
+		t.Fatalf("scope of the imported bundle not recorded")
+	}
+	if !ex.viewExpired(pk, 21) || ex.viewExpired(pk, 20) {
+		t.Fatalf("expiry of the imported bundle not applied")
+	}
+}
@@ -2,7 +2,6 @@ package light
 
 import (
 	"encoding/binary"
-	"github.com/robfig/cron"
 	"github.com/sero-cash/go-czero-import/keys"
 	"github.com/sero-cash/go-czero-import/seroparam"
 	"github.com/sero-cash/go-sero/common"
@@ -11,10 +10,12 @@ import (
 	"github.com/sero-cash/go-sero/log"
 	"github.com/sero-cash/go-sero/rlp"
 	"github.com/sero-cash/go-sero/serodb"
+	"github.com/sero-cash/go-sero/zero/checkpoint"
+	"github.com/sero-cash/go-sero/zero/localdb"
 	"github.com/sero-cash/go-sero/zero/txtool"
 	"github.com/sero-cash/go-sero/zero/txtool/flight"
+	"github.com/sero-cash/go-sero/zero/utils"
 	"math/big"
-	"sync/atomic"
 )
 
 type LightNode struct {
@@ -47,7 +48,7 @@ func NewLightNode(dbPath string, txPool *core.TxPool, bcDB serodb.Database) (lig
 	}
 	current_light = lightNode
 
-	AddJob("0/10 * * * * ?", lightNode.fetchBlockInfo)
+	utils.AddJob("0/10 * * * * ?", lightNode.fetchBlockInfo)
 
 	log.Info("Init NewLightNode success")
 	return
@@ -87,6 +88,10 @@ func (self *LightNode) fetchBlockInfo() {
 		return
 	}
 	start := self.getLastNumber()
+	if err := localdb.CheckPruned(txtool.Ref_inst.Bc.GetDB(), start+1); err != nil {
+		log.Error("Light can't index pruned blocks, run it on a node keeping them", "err", err)
+		return
+	}
 	blocks, err := self.sri.GetBlocksInfo(start+1, fetchCount)
 	if err != nil {
 		log.Error("light GetBlocksInfo err:", err.Error())
@@ -97,6 +102,15 @@ func (self *LightNode) fetchBlockInfo() {
 	var count uint64 = 0
 	batch := self.db.NewBatch()
 	for _, block := range blocks {
+		blockHash := common.Hash{}
+		blockNum := uint64(block.Num)
+		copy(blockHash[:], block.Hash[:])
+		// Anchor the synced blocks to the checkpoints
+		if err := checkpoint.CheckHash(blockNum, blockHash); err != nil {
+			log.Error("light block doesn't match the checkpoint", "err", err)
+			return
+		}
+
 		// PKR -> Outs
 		outs := block.Outs
 		pkrMap := make(map[keys.PKr][]txtool.Out)
@@ -125,9 +139,6 @@ func (self *LightNode) fetchBlockInfo() {
 			batch.Put(pkrKey(pkr, uint64(block.Num)), data)
 		}
 
-		blockHash := common.Hash{}
-		blockNum := uint64(block.Num)
-		copy(blockHash[:], block.Hash[:])
 		body := rawdb.ReadBody(self.bcDB, blockHash, blockNum)
 		for _, tx := range body.Transactions {
 
@@ -203,33 +214,3 @@ func pkrKey(pkr keys.PKr, num uint64) []byte {
 	key := append(pkrPrefix, pkr[:]...)
 	return append(key, uint64ToBytes(num)...)
 }
-
-func AddJob(spec string, run RunFunc) *cron.Cron {
-	c := cron.New()
-	c.AddJob(spec, &RunJob{run: run})
-	c.Start()
-	return c
-}
-
-type (
-	RunFunc func()
-)
-
-type RunJob struct {
-	runing int32
-	run    RunFunc
-}
-
-func (r *RunJob) Run() {
-	x := atomic.LoadInt32(&r.runing)
-	if x == 1 {
-		return
-	}
-
-	atomic.StoreInt32(&r.runing, 1)
-	defer func() {
-		atomic.StoreInt32(&r.runing, 0)
-	}()
-
-	r.run()
-}
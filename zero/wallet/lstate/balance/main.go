@@ -76,6 +76,11 @@ func (self *Balance) Parse() (num uint64) {
 
 	target_num := txtool.Ref_inst.GetDelayedNum(seroparam.DefaultConfirmedBlock())
 
+	if err := localdb.CheckPruned(txtool.Ref_inst.Bc.GetDB(), next_num); err != nil && next_num <= target_num {
+		log.Error("BALANCE can't parse pruned blocks, run it on a node keeping them", "err", err)
+		return 0
+	}
+
 	i := 0
 	for ; (next_num <= target_num) && (i < 2000); i++ {
 		batch := leveldb.Batch{}
@@ -0,0 +1,285 @@
+package pending
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/sero-cash/go-czero-import/keys"
+	"github.com/sero-cash/go-sero/common"
+	"github.com/sero-cash/go-sero/common/hexutil"
+	"github.com/sero-cash/go-sero/core"
+	"github.com/sero-cash/go-sero/core/rawdb"
+	"github.com/sero-cash/go-sero/event"
+	"github.com/sero-cash/go-sero/log"
+	"github.com/sero-cash/go-sero/serodb"
+	"github.com/sero-cash/go-sero/zero/txtool"
+	"github.com/sero-cash/go-sero/zero/utils"
+)
+
+const (
+	StatePending   = "pending"
+	StateCommitted = "committed"
+)
+
+var DefaultExpiration = 30 * time.Minute
+
+var (
+	ErrTxNotFound    = errors.New("pending tx not found")
+	ErrTxCommitted   = errors.New("pending tx already committed")
+	ErrStoreNotReady = errors.New("pending tx store is not initialized")
+)
+
+type PendingTx struct {
+	Hash     keys.Uint256
+	Tx       txtool.GTx
+	Roots    []keys.Uint256
+	State    string
+	CreateAt hexutil.Uint64
+	ExpireAt hexutil.Uint64
+}
+
+func (self *PendingTx) isExpired(now time.Time) bool {
+	return uint64(now.Unix()) >= uint64(self.ExpireAt)
+}
+
+type PendingStore struct {
+	db         *serodb.LDBDatabase
+	expiration time.Duration
+
+	txs   sync.Map
+	roots sync.Map
+
+	lock sync.Mutex
+}
+
+var current_pendingStore *PendingStore
+
+func CurrentPendingStore() *PendingStore {
+	return current_pendingStore
+}
+
+func NewPendingStore(dbpath string, expiration time.Duration, txPool *core.TxPool) (store *PendingStore) {
+	db, err := serodb.NewLDBDatabase(dbpath, 16, 16)
+	if err != nil {
+		panic(err)
+	}
+	if expiration <= 0 {
+		expiration = DefaultExpiration
+	}
+	store = &PendingStore{
+		db:         db,
+		expiration: expiration,
+	}
+	current_pendingStore = store
+
+	store.load()
+
+	utils.AddJob("0/30 * * * * ?", store.expire)
+
+	if txPool != nil {
+		replace := make(chan core.TxReplacedEvent, 16)
+		go store.releaseReplaced(replace, txPool.SubscribeTxReplacedEvent(replace))
+	}
+
+	log.Info("Init PendingStore success", "expiration", expiration)
+	return
+}
+
+func (self *PendingStore) load() {
+	iterator := self.db.NewIteratorWithPrefix(pendingPrefix)
+	for iterator.Next() {
+		ptx := PendingTx{}
+		if err := json.Unmarshal(iterator.Value(), &ptx); err != nil {
+			log.Error("PendingStore invalid pending tx", "key", common.Bytes2Hex(iterator.Key()), "err", err)
+			continue
+		}
+		self.txs.Store(ptx.Hash, &ptx)
+		for _, root := range ptx.Roots {
+			self.roots.Store(root, ptx.Hash)
+		}
+	}
+}
+
+func (self *PendingStore) write(ptx *PendingTx) error {
+	data, err := json.Marshal(ptx)
+	if err != nil {
+		return err
+	}
+	return self.db.Put(pendingKey(ptx.Hash), data)
+}
+
+func (self *PendingStore) IsLocked(root keys.Uint256) bool {
+	_, ok := self.roots.Load(root)
+	return ok
+}
+
+func (self *PendingStore) Put(gtx *txtool.GTx, roots []keys.Uint256) (ptx *PendingTx, e error) {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+
+	for _, root := range roots {
+		if hash, ok := self.roots.Load(root); ok {
+			h := hash.(keys.Uint256)
+			e = fmt.Errorf("root %v is locked by pending tx %v", hexutil.Encode(root[:]), hexutil.Encode(h[:]))
+			return
+		}
+	}
+
+	now := time.Now()
+	ptx = &PendingTx{
+		Hash:     gtx.Hash,
+		Tx:       *gtx,
+		Roots:    roots,
+		State:    StatePending,
+		CreateAt: hexutil.Uint64(now.Unix()),
+		ExpireAt: hexutil.Uint64(now.Add(self.expiration).Unix()),
+	}
+	if e = self.write(ptx); e != nil {
+		return
+	}
+	stored := *ptx
+	self.txs.Store(ptx.Hash, &stored)
+	for _, root := range roots {
+		self.roots.Store(root, ptx.Hash)
+	}
+	return
+}
+
+// Get returns a copy of the pending tx, the stored ones are never modified
+// once shared with the indexers.
+func (self *PendingStore) Get(hash keys.Uint256) (ptx *PendingTx, e error) {
+	if value, ok := self.txs.Load(hash); ok {
+		c := *value.(*PendingTx)
+		ptx = &c
+	} else {
+		e = ErrTxNotFound
+	}
+	return
+}
+
+func (self *PendingStore) List() (ptxs []*PendingTx) {
+	self.txs.Range(func(key, value interface{}) bool {
+		c := *value.(*PendingTx)
+		ptxs = append(ptxs, &c)
+		return true
+	})
+	return
+}
+
+func (self *PendingStore) Committed(hash keys.Uint256) (e error) {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+
+	var ptx *PendingTx
+	if ptx, e = self.Get(hash); e != nil {
+		return
+	}
+	ptx.State = StateCommitted
+	if e = self.write(ptx); e != nil {
+		return
+	}
+	self.txs.Store(ptx.Hash, ptx)
+	return
+}
+
+func (self *PendingStore) Cancel(hash keys.Uint256) (e error) {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+
+	var ptx *PendingTx
+	if ptx, e = self.Get(hash); e != nil {
+		return
+	}
+	if ptx.State == StateCommitted {
+		e = ErrTxCommitted
+		return
+	}
+	return self.release(ptx)
+}
+
+func (self *PendingStore) release(ptx *PendingTx) (e error) {
+	if e = self.db.Delete(pendingKey(ptx.Hash)); e != nil {
+		return
+	}
+	self.txs.Delete(ptx.Hash)
+	for _, root := range ptx.Roots {
+		if hash, ok := self.roots.Load(root); ok && hash.(keys.Uint256) == ptx.Hash {
+			self.roots.Delete(root)
+		}
+	}
+	return
+}
+
+// Replaced releases the roots locked by a pending tx replaced in the tx pool,
+// the tx cannot be mined anymore even if it was committed.
+func (self *PendingStore) Replaced(hash keys.Uint256) (e error) {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+
+	var ptx *PendingTx
+	if ptx, e = self.Get(hash); e != nil {
+		return
+	}
+	return self.release(ptx)
+}
+
+// releaseReplaced releases the pending txs replaced in the tx pool until the
+// pool stops.
+func (self *PendingStore) releaseReplaced(replace chan core.TxReplacedEvent, sub event.Subscription) {
+	defer sub.Unsubscribe()
+	for {
+		select {
+		case ev := <-replace:
+			var hash keys.Uint256
+			copy(hash[:], ev.Old.Hash().Bytes())
+			if _, err := self.Get(hash); err != nil {
+				continue
+			}
+			if err := self.Replaced(hash); err != nil {
+				log.Error("PendingStore release replaced tx", "hash", hexutil.Encode(hash[:]), "err", err)
+			} else {
+				log.Info("PendingStore tx replaced", "hash", hexutil.Encode(hash[:]), "replacement", ev.New.Hash())
+			}
+		case <-sub.Err():
+			return
+		}
+	}
+}
+
+func (self *PendingStore) expire() {
+	if txtool.Ref_inst.Bc == nil || !txtool.Ref_inst.Bc.IsValid() {
+		return
+	}
+	self.lock.Lock()
+	defer self.lock.Unlock()
+
+	now := time.Now()
+	for _, ptx := range self.List() {
+		if ptx.State == StateCommitted {
+			hash := common.Hash{}
+			copy(hash[:], ptx.Hash[:])
+			if tx, _, _, _ := rawdb.ReadTransaction(txtool.Ref_inst.Bc.GetDB(), hash); tx != nil {
+				if err := self.release(ptx); err != nil {
+					log.Error("PendingStore release mined tx", "hash", hexutil.Encode(ptx.Hash[:]), "err", err)
+				}
+				continue
+			}
+		}
+		if ptx.isExpired(now) {
+			if err := self.release(ptx); err != nil {
+				log.Error("PendingStore release expired tx", "hash", hexutil.Encode(ptx.Hash[:]), "err", err)
+			} else {
+				log.Info("PendingStore tx expired", "hash", hexutil.Encode(ptx.Hash[:]), "state", ptx.State, "roots", len(ptx.Roots))
+			}
+		}
+	}
+}
+
+var pendingPrefix = []byte("PENDINGTX")
+
+func pendingKey(hash keys.Uint256) []byte {
+	return append(pendingPrefix, hash[:]...)
+}
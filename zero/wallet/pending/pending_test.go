@@ -0,0 +1,146 @@
+package pending
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/sero-cash/go-czero-import/keys"
+	"github.com/sero-cash/go-sero/serodb"
+	"github.com/sero-cash/go-sero/zero/txtool"
+)
+
+func newTestStore(t *testing.T) (*PendingStore, func()) {
+	dir, err := ioutil.TempDir("", "pending")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := serodb.NewLDBDatabase(dir, 16, 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := &PendingStore{db: db, expiration: time.Minute}
+	return store, func() {
+		db.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+func testTx(b byte) *txtool.GTx {
+	return &txtool.GTx{Hash: keys.Uint256{b}}
+}
+
+func TestPendingLocks(t *testing.T) {
+	store, done := newTestStore(t)
+	defer done()
+
+	r1, r2, r3 := keys.Uint256{1}, keys.Uint256{2}, keys.Uint256{3}
+	if _, err := store.Put(testTx(1), []keys.Uint256{r1, r2}); err != nil {
+		t.Fatal(err)
+	}
+	if !store.IsLocked(r1) || !store.IsLocked(r2) || store.IsLocked(r3) {
+		t.Fatalf("roots not locked by the pending tx")
+	}
+	if _, err := store.Put(testTx(2), []keys.Uint256{r2, r3}); err == nil {
+		t.Fatalf("put a tx spending a locked root")
+	}
+	if store.IsLocked(r3) {
+		t.Fatalf("rejected tx locked a root")
+	}
+	if err := store.Cancel(keys.Uint256{1}); err != nil {
+		t.Fatal(err)
+	}
+	if store.IsLocked(r1) || store.IsLocked(r2) {
+		t.Fatalf("canceled tx still locks its roots")
+	}
+	if _, err := store.Put(testTx(2), []keys.Uint256{r2, r3}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPendingCommitted(t *testing.T) {
+	store, done := newTestStore(t)
+	defer done()
+
+	hash := keys.Uint256{1}
+	if _, err := store.Put(testTx(1), []keys.Uint256{{1}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Committed(hash); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Cancel(hash); err != ErrTxCommitted {
+		t.Fatalf("canceled a committed tx: %v", err)
+	}
+	if err := store.Replaced(hash); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Get(hash); err != ErrTxNotFound {
+		t.Fatalf("replaced tx still stored: %v", err)
+	}
+	if store.IsLocked(keys.Uint256{1}) {
+		t.Fatalf("replaced tx still locks its roots")
+	}
+}
+
+func TestPendingCopies(t *testing.T) {
+	store, done := newTestStore(t)
+	defer done()
+
+	hash := keys.Uint256{1}
+	ptx, err := store.Put(testTx(1), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ptx.State = StateCommitted
+	got, _ := store.Get(hash)
+	if got.State != StatePending {
+		t.Fatalf("the tx returned by put is shared with the store")
+	}
+	got.State = StateCommitted
+	for _, ptx := range store.List() {
+		if ptx.State != StatePending {
+			t.Fatalf("the tx returned by get is shared with the store")
+		}
+	}
+}
+
+func TestPendingReload(t *testing.T) {
+	store, done := newTestStore(t)
+	defer done()
+
+	if _, err := store.Put(testTx(1), []keys.Uint256{{1}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Put(testTx(2), []keys.Uint256{{2}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Committed(keys.Uint256{2}); err != nil {
+		t.Fatal(err)
+	}
+
+	reloaded := &PendingStore{db: store.db, expiration: time.Minute}
+	reloaded.load()
+	if len(reloaded.List()) != 2 {
+		t.Fatalf("reloaded %d txs, want 2", len(reloaded.List()))
+	}
+	if ptx, err := reloaded.Get(keys.Uint256{2}); err != nil || ptx.State != StateCommitted {
+		t.Fatalf("reloaded tx %v, %v", ptx, err)
+	}
+	if !reloaded.IsLocked(keys.Uint256{1}) || !reloaded.IsLocked(keys.Uint256{2}) {
+		t.Fatalf("reloaded txs don't lock their roots")
+	}
+}
+
+func TestPendingExpired(t *testing.T) {
+	now := time.Now()
+	ptx := PendingTx{ExpireAt: 1}
+	if !ptx.isExpired(now) {
+		t.Fatalf("tx expired in 1970 isn't expired")
+	}
+	ptx.ExpireAt = 1 << 40
+	if ptx.isExpired(now) {
+		t.Fatalf("tx expiring in the future is expired")
+	}
+}
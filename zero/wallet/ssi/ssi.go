@@ -5,9 +5,9 @@ import (
 	"log"
 	"math/big"
 	"strings"
-	"sync"
 
 	"github.com/sero-cash/go-sero/zero/txtool/flight"
+	"github.com/sero-cash/go-sero/zero/wallet/pending"
 
 	"github.com/sero-cash/go-czero-import/cpt"
 
@@ -92,8 +92,6 @@ func (self *SSI) Detail(roots []keys.Uint256, skr *keys.PKr) (douts []txtool.DOu
 	return
 }
 
-var txMap sync.Map
-
 func (self *SSI) GenTxParam(param *PreTxParam) (p txtool.GTxParam, e error) {
 	log.Printf("genTx start")
 	p.Gas = param.Gas
@@ -207,6 +205,19 @@ func (self *SSI) GenTxParam(param *PreTxParam) (p txtool.GTxParam, e error) {
 }
 
 func (self *SSI) GenTx(param *PreTxParam) (hash keys.Uint256, e error) {
+	store := pending.CurrentPendingStore()
+	if store == nil {
+		e = pending.ErrStoreNotReady
+		return
+	}
+	roots := []keys.Uint256{}
+	for _, in := range param.Ins {
+		if store.IsLocked(in.Root) {
+			e = fmt.Errorf("SSI GenTx Error: root %v is used by a pending tx", in.Root)
+			return
+		}
+		roots = append(roots, in.Root)
+	}
 	if p, err := self.GenTxParam(param); err != nil {
 		e = err
 		return
@@ -216,8 +227,12 @@ func (self *SSI) GenTx(param *PreTxParam) (hash keys.Uint256, e error) {
 			log.Printf("genTx error : %v", err)
 			return
 		} else {
-			hash = gtx.Tx.ToHash()
-			txMap.Store(hash, &gtx)
+			if _, err := store.Put(&gtx, roots); err != nil {
+				e = err
+				log.Printf("genTx store error : %v", err)
+				return
+			}
+			hash = gtx.Hash
 			log.Printf("genTx success hash: %s", common.Bytes2Hex(hash[:]))
 			return
 		}
@@ -225,14 +240,43 @@ func (self *SSI) GenTx(param *PreTxParam) (hash keys.Uint256, e error) {
 }
 
 func (self *SSI) GetTx(txhash keys.Uint256) (tx *txtool.GTx, e error) {
-	if ld, ok := txMap.Load(txhash); !ok {
+	store := pending.CurrentPendingStore()
+	if store == nil {
+		e = pending.ErrStoreNotReady
+		return
+	}
+	if ptx, err := store.Get(txhash); err != nil {
 		e = fmt.Errorf("SSI GetTx Failed : %v", txhash)
 	} else {
-		if ld == nil {
-			e = fmt.Errorf("SSI GetTx Nil : %v", txhash)
-		} else {
-			tx = ld.(*txtool.GTx)
-		}
+		tx = &ptx.Tx
 	}
 	return
 }
+
+func (self *SSI) ListTx() (txs []*pending.PendingTx, e error) {
+	store := pending.CurrentPendingStore()
+	if store == nil {
+		e = pending.ErrStoreNotReady
+		return
+	}
+	txs = store.List()
+	return
+}
+
+func (self *SSI) CancelTx(txhash keys.Uint256) (e error) {
+	store := pending.CurrentPendingStore()
+	if store == nil {
+		e = pending.ErrStoreNotReady
+		return
+	}
+	return store.Cancel(txhash)
+}
+
+func (self *SSI) CommittedTx(txhash keys.Uint256) (e error) {
+	store := pending.CurrentPendingStore()
+	if store == nil {
+		e = pending.ErrStoreNotReady
+		return
+	}
+	return store.Committed(txhash)
+}
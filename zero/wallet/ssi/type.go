@@ -4,6 +4,7 @@ import (
 	"github.com/sero-cash/go-czero-import/keys"
 	"github.com/sero-cash/go-sero/common/hexutil"
 	"github.com/sero-cash/go-sero/zero/txtool"
+	"github.com/sero-cash/go-sero/zero/wallet/pending"
 )
 
 type Out struct {
@@ -35,5 +36,8 @@ type ISSI interface {
 	GetBlocksInfo(start uint64, count uint64) ([]Block, error)
 	Detail(root []keys.Uint256, skr *keys.PKr) ([]txtool.DOut, error)
 	GenTx(param *PreTxParam) (keys.Uint256, error)
+	GetTx(txhash keys.Uint256) (*txtool.GTx, error)
+	ListTx() ([]*pending.PendingTx, error)
+	CancelTx(txhash keys.Uint256) error
 	CommitTx(txhash *keys.Uint256) error
 }
@@ -0,0 +1,31 @@
+package stakeservice
+
+import (
+	"github.com/sero-cash/go-czero-import/keys"
+	"github.com/sero-cash/go-sero/accounts"
+	"github.com/sero-cash/go-sero/accounts/keystore"
+)
+
+// ScanDerived adds to the keystore the accounts derived from master up to gap
+// consecutive accounts owning no share indexed by the service.
+func (self *StakeService) ScanDerived(ks *keystore.KeyStore, master accounts.Account, passphrase string, gap int) ([]accounts.Account, error) {
+	return ks.ScanDerived(master, passphrase, gap, self.ownShares)
+}
+
+// ownShares tells which accounts own a share, the shares are read once for all
+// the accounts.
+func (self *StakeService) ownShares(candidates []accounts.Account) ([]bool, error) {
+	used := make([]bool, len(candidates))
+	tks := make([]*keys.Uint512, len(candidates))
+	for i, candidate := range candidates {
+		tks[i] = candidate.Tk.ToUint512()
+	}
+	for _, share := range self.Shares() {
+		for i, tk := range tks {
+			if !used[i] && keys.IsMyPKr(tk, &share.PKr) {
+				used[i] = true
+			}
+		}
+	}
+	return used, nil
+}
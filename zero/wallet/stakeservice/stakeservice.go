@@ -1,12 +1,10 @@
 package stakeservice
 
 import (
-	"github.com/robfig/cron"
 	"github.com/sero-cash/go-sero/common"
 	"github.com/sero-cash/go-sero/common/math"
 	"github.com/sero-cash/go-sero/zero/utils"
 	"sync"
-	"sync/atomic"
 
 	"github.com/sero-cash/go-czero-import/keys"
 	"github.com/sero-cash/go-czero-import/seroparam"
@@ -16,6 +14,7 @@ import (
 	"github.com/sero-cash/go-sero/log"
 	"github.com/sero-cash/go-sero/serodb"
 	"github.com/sero-cash/go-sero/zero/stake"
+	"github.com/sero-cash/go-sero/zero/wallet/exchange"
 )
 
 type Account struct {
@@ -68,7 +67,7 @@ func NewStakeService(dbpath string, bc *core.BlockChain, accountManager *account
 		stakeService.initWallet(w)
 	}
 
-	AddJob("0/10 * * * * ?", stakeService.stakeIndex)
+	utils.AddJob("0/10 * * * * ?", stakeService.stakeIndex)
 	go stakeService.updateAccount()
 	return stakeService
 }
@@ -161,7 +160,7 @@ func (self *StakeService) stakeIndex() {
 		for _, share := range shares {
 			batch.Put(sharekey(share.Id()), share.State())
 			batch.Put(pkrShareKey(share.PKr, share.Id()), share.State())
-			if pk, ok := self.ownPkr(share.PKr); ok {
+			if pk, ok := self.ownPkr(share.PKr, share.BlockNumber); ok {
 				batch.Put(pkShareKey(pk, share.Id()), share.State())
 			}
 		}
@@ -196,7 +195,9 @@ func (self *StakeService) stakeIndex() {
 	}
 }
 
-func (self *StakeService) ownPkr(pkr keys.PKr) (pk *keys.Uint512, ok bool) {
+// ownPkr returns the account owning the shares bought to pkr in the block num,
+// the watch-only accounts only own the ones within the scope of their view.
+func (self *StakeService) ownPkr(pkr keys.PKr, num uint64) (pk *keys.Uint512, ok bool) {
 	var account *Account
 	self.accounts.Range(func(key, value interface{}) bool {
 		a := value.(*Account)
@@ -206,10 +207,16 @@ func (self *StakeService) ownPkr(pkr keys.PKr) (pk *keys.Uint512, ok bool) {
 		}
 		return true
 	})
-	if account != nil {
-		return account.pk, true
+	if account == nil {
+		return
 	}
-	return
+	// The scopes are kept by the exchange, which imports the view bundles
+	if ex := exchange.CurrentExchange(); ex != nil {
+		if view := ex.ViewOf(*account.pk); view != nil && !view.Allows(&pkr, num) {
+			return
+		}
+	}
+	return account.pk, true
 }
 
 func (self *StakeService) updateAccount() {
@@ -293,33 +300,3 @@ func poolKey(key []byte) []byte {
 func numKey(pk keys.Uint512) []byte {
 	return append(numPrefix, pk[:]...)
 }
-
-func AddJob(spec string, run RunFunc) *cron.Cron {
-	c := cron.New()
-	c.AddJob(spec, &RunJob{run: run})
-	c.Start()
-	return c
-}
-
-type (
-	RunFunc func()
-)
-
-type RunJob struct {
-	runing int32
-	run    RunFunc
-}
-
-func (r *RunJob) Run() {
-	x := atomic.LoadInt32(&r.runing)
-	if x == 1 {
-		return
-	}
-
-	atomic.StoreInt32(&r.runing, 1)
-	defer func() {
-		atomic.StoreInt32(&r.runing, 0)
-	}()
-
-	r.run()
-}
@@ -0,0 +1,439 @@
+package witness
+
+import (
+	"errors"
+	"fmt"
+	"math/bits"
+	"sync"
+
+	"github.com/sero-cash/go-czero-import/cpt"
+	"github.com/sero-cash/go-czero-import/keys"
+	"github.com/sero-cash/go-czero-import/seroparam"
+	"github.com/sero-cash/go-sero/common"
+	"github.com/sero-cash/go-sero/common/hexutil"
+	"github.com/sero-cash/go-sero/log"
+	"github.com/sero-cash/go-sero/rlp"
+	"github.com/sero-cash/go-sero/serodb"
+	"github.com/sero-cash/go-sero/zero/localdb"
+	"github.com/sero-cash/go-sero/zero/txs/zstate/txstate"
+	"github.com/sero-cash/go-sero/zero/txtool"
+	"github.com/sero-cash/go-sero/zero/utils"
+)
+
+const leafcap = uint64(1) << cpt.DEPTH
+
+const (
+	maxRegisterRoots = 256     // Maximum number of roots registered at once
+	maxWitnesses     = 1 << 16 // Maximum number of roots maintained or queued
+)
+
+var (
+	frontierKey   = []byte("WITNESS$FRONTIER")
+	witnessPrefix = []byte("WITNESS$ROOT$")
+	pendingPrefix = []byte("WITNESS$PENDING$")
+
+	ErrNotRegistered = errors.New("root is not registered")
+	ErrTooManyRoots  = errors.New("too many roots registered")
+)
+
+// frontier is the right edge of the commitment tree at a block, enough to
+// append the leaves of the next blocks.
+type frontier struct {
+	Num  uint64
+	Hash common.Hash
+	Tree uint64                  // Index of the current tree
+	Size uint64                  // Number of leaves of the current tree
+	Left [cpt.DEPTH]keys.Uint256 // Latest left node of each depth
+	Root keys.Uint256            // Root of the current tree
+}
+
+// witness is the merkle path of a registered out, kept up to date with the
+// leaves appended after it.
+type witness struct {
+	Root   keys.Uint256
+	Tree   uint64
+	Pos    uint64
+	Paths  [cpt.DEPTH]keys.Uint256
+	Anchor keys.Uint256
+}
+
+func (self *witness) toWitness() txtool.Witness {
+	return txtool.Witness{Pos: hexutil.Uint64(self.Pos), Paths: self.Paths, Anchor: self.Anchor}
+}
+
+// Witnesses maintains the witnesses of a set of roots as the confirmed blocks
+// append leaves to the commitment tree, so that the anchor of a root is served
+// without walking the tree of the state. The roots registered before their
+// block is confirmed are queued until it is.
+type Witnesses struct {
+	db *serodb.LDBDatabase
+	mu sync.RWMutex
+
+	frontier frontier
+	wits     map[keys.Uint256]*witness
+	pending  map[keys.Uint256]bool
+	dirty    map[keys.Uint256]bool
+}
+
+var current_witnesses *Witnesses
+
+// Current returns the running witness service, nil if there is none.
+func Current() *Witnesses {
+	return current_witnesses
+}
+
+func NewWitnesses(dbpath string) (ws *Witnesses) {
+	db, err := serodb.NewLDBDatabase(dbpath, 1024, 1024)
+	if err != nil {
+		panic(err)
+	}
+	ws = &Witnesses{
+		db:      db,
+		wits:    make(map[keys.Uint256]*witness),
+		pending: make(map[keys.Uint256]bool),
+		dirty:   make(map[keys.Uint256]bool),
+	}
+	if data, err := db.Get(frontierKey); err == nil {
+		if err := rlp.DecodeBytes(data, &ws.frontier); err != nil {
+			log.Error("Witnesses invalid frontier", "err", err)
+		}
+	}
+	iterator := db.NewIteratorWithPrefix(witnessPrefix)
+	for iterator.Next() {
+		wit := witness{}
+		if err := rlp.DecodeBytes(iterator.Value(), &wit); err != nil {
+			log.Error("Witnesses invalid witness", "key", hexutil.Encode(iterator.Key()), "err", err)
+			continue
+		}
+		ws.wits[wit.Root] = &wit
+	}
+	iterator.Release()
+	iterator = db.NewIteratorWithPrefix(pendingPrefix)
+	for iterator.Next() {
+		root := keys.Uint256{}
+		copy(root[:], iterator.Key()[len(pendingPrefix):])
+		ws.pending[root] = true
+	}
+	iterator.Release()
+	current_witnesses = ws
+
+	utils.AddJob("0/10 * * * * ?", ws.update)
+
+	log.Info("Init NewWitnesses success", "witnesses", len(ws.wits), "num", ws.frontier.Num)
+	return
+}
+
+func witnessKey(root *keys.Uint256) []byte {
+	return append(append([]byte{}, witnessPrefix...), root[:]...)
+}
+
+func pendingKey(root *keys.Uint256) []byte {
+	return append(append([]byte{}, pendingPrefix...), root[:]...)
+}
+
+// Register starts maintaining the witnesses of roots, their current witness
+// is read from the state of the last processed block. The roots of the blocks
+// not confirmed yet are queued, their witness is built as their block gets
+// processed.
+func (self *Witnesses) Register(roots []keys.Uint256) (e error) {
+	if len(roots) > maxRegisterRoots {
+		return fmt.Errorf("%v: %d roots at once, max %d", ErrTooManyRoots, len(roots), maxRegisterRoots)
+	}
+	self.mu.Lock()
+	defer self.mu.Unlock()
+
+	if self.frontier.Hash == (common.Hash{}) {
+		return errors.New("witnesses not synced yet")
+	}
+	db := txtool.Ref_inst.Bc.GetDB()
+	var tree *txstate.MerkleTree
+	for _, root := range roots {
+		if _, ok := self.wits[root]; ok || self.pending[root] {
+			continue
+		}
+		if len(self.wits)+len(self.pending) >= maxWitnesses {
+			e = fmt.Errorf("%v: max %d", ErrTooManyRoots, maxWitnesses)
+			break
+		}
+		rs := localdb.GetRoot(db, &root)
+		if rs == nil {
+			e = fmt.Errorf("root %v not found", hexutil.Encode(root[:]))
+			break
+		}
+		if rs.Num > self.frontier.Num {
+			self.pending[root] = true
+			self.dirty[root] = true
+			continue
+		}
+		if tree == nil {
+			state := txtool.Ref_inst.Bc.CurrentState(&self.frontier.Hash)
+			if state == nil {
+				e = fmt.Errorf("state of block %d not found", self.frontier.Num)
+				break
+			}
+			tree = &state.State.MTree
+		}
+		self.wits[root] = newWitness(tree, root, *rs.OS.ToRootCM())
+		self.dirty[root] = true
+	}
+	if err := self.flush(); err != nil {
+		return err
+	}
+	return e
+}
+
+func newWitness(tree *txstate.MerkleTree, root keys.Uint256, leaf keys.Uint256) *witness {
+	pos, paths, anchor := tree.GetPaths(leaf)
+	return &witness{
+		Root:   root,
+		Tree:   tree.GetTreeIndex(leaf),
+		Pos:    pos,
+		Paths:  paths,
+		Anchor: anchor,
+	}
+}
+
+// Unregister stops maintaining the witnesses of roots.
+func (self *Witnesses) Unregister(roots []keys.Uint256) error {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+
+	batch := self.db.NewBatch()
+	for _, root := range roots {
+		delete(self.wits, root)
+		delete(self.pending, root)
+		delete(self.dirty, root)
+		batch.Delete(witnessKey(&root))
+		batch.Delete(pendingKey(&root))
+	}
+	return batch.Write()
+}
+
+// GetAnchor returns the witnesses of registered roots as of the last processed
+// block.
+func (self *Witnesses) GetAnchor(roots []keys.Uint256) (wits []txtool.Witness, e error) {
+	self.mu.RLock()
+	defer self.mu.RUnlock()
+
+	for _, root := range roots {
+		wit, ok := self.wits[root]
+		if !ok {
+			e = fmt.Errorf("%v: %v", ErrNotRegistered, hexutil.Encode(root[:]))
+			return
+		}
+		wits = append(wits, wit.toWitness())
+	}
+	return
+}
+
+// Has returns whether all the roots are registered.
+func (self *Witnesses) Has(roots []keys.Uint256) bool {
+	self.mu.RLock()
+	defer self.mu.RUnlock()
+
+	for _, root := range roots {
+		if _, ok := self.wits[root]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+var fetchCount = uint64(5000)
+
+func (self *Witnesses) update() {
+	if txtool.Ref_inst.Bc == nil || !txtool.Ref_inst.Bc.IsValid() {
+		return
+	}
+	self.mu.Lock()
+	defer self.mu.Unlock()
+
+	target := txtool.Ref_inst.GetDelayedNum(seroparam.DefaultConfirmedBlock())
+	if self.frontier.Hash == (common.Hash{}) {
+		self.reset(target)
+		return
+	}
+	if target > self.frontier.Num+fetchCount {
+		target = self.frontier.Num + fetchCount
+	}
+	for num := self.frontier.Num + 1; num <= target; num++ {
+		block := txtool.Ref_inst.Bc.GetBlockByNumber(num)
+		if block == nil {
+			break
+		}
+		if block.ParentHash() != self.frontier.Hash {
+			log.Info("Witnesses chain reorganised", "num", num)
+			self.reset(target)
+			return
+		}
+		hash := block.Hash()
+		if e := self.appendBlock(num, &hash); e != nil {
+			log.Error("Witnesses append block failed", "num", num, "err", e)
+			self.reset(target)
+			return
+		}
+		self.frontier.Num = num
+		self.frontier.Hash = hash
+	}
+	self.dropStale()
+	if e := self.flush(); e != nil {
+		log.Error("Witnesses flush failed", "err", e)
+	}
+}
+
+// reset rebuilds the frontier and the witnesses from the state of the block num.
+func (self *Witnesses) reset(num uint64) {
+	header := txtool.Ref_inst.Bc.GetHeaderByNumber(num)
+	if header == nil {
+		return
+	}
+	hash := header.Hash()
+	state := txtool.Ref_inst.Bc.CurrentState(&hash)
+	if state == nil {
+		log.Error("Witnesses reset failed, state not found", "num", num)
+		return
+	}
+	tree := &state.State.MTree
+	self.frontier = frontier{Num: num, Hash: hash, Root: tree.CurrentRoot()}
+	self.frontier.Tree, self.frontier.Size, self.frontier.Left = tree.Frontier()
+
+	db := txtool.Ref_inst.Bc.GetDB()
+	for root := range self.wits {
+		if rs := localdb.GetRoot(db, &root); rs == nil {
+			log.Error("Witnesses reset drops unknown root", "root", hexutil.Encode(root[:]))
+			delete(self.wits, root)
+		} else if rs.Num > num {
+			delete(self.wits, root)
+			self.pending[root] = true
+		} else {
+			self.wits[root] = newWitness(tree, root, *rs.OS.ToRootCM())
+		}
+		self.dirty[root] = true
+	}
+	for root := range self.pending {
+		if rs := localdb.GetRoot(db, &root); rs != nil && rs.Num <= num {
+			delete(self.pending, root)
+			self.wits[root] = newWitness(tree, root, *rs.OS.ToRootCM())
+			self.dirty[root] = true
+		}
+	}
+	self.dropStale()
+	if e := self.flush(); e != nil {
+		log.Error("Witnesses flush failed", "err", e)
+	}
+	log.Info("Witnesses reset", "num", num, "witnesses", len(self.wits))
+}
+
+func (self *Witnesses) appendBlock(num uint64, hash *common.Hash) error {
+	db := txtool.Ref_inst.Bc.GetDB()
+	if e := localdb.CheckPruned(db, num); e != nil {
+		return e
+	}
+	block := localdb.GetBlock(db, num, hash.HashToUint256())
+	if block == nil {
+		return nil
+	}
+	for _, root := range block.Roots {
+		rs := localdb.GetRoot(db, &root)
+		if rs == nil {
+			return fmt.Errorf("root %v not found", hexutil.Encode(root[:]))
+		}
+		// The root of an out is the root of its tree once it is appended
+		wit := self.appendLeaf(root, *rs.OS.ToRootCM())
+		if wit.Anchor != root {
+			return fmt.Errorf("root %v mismatch, have %v", hexutil.Encode(root[:]), hexutil.Encode(wit.Anchor[:]))
+		}
+		if self.pending[root] {
+			delete(self.pending, root)
+			self.wits[root] = wit
+			self.dirty[root] = true
+		}
+	}
+	return nil
+}
+
+// dropStale drops the queued roots the processed blocks went past, their block
+// was reorganised away.
+func (self *Witnesses) dropStale() {
+	db := txtool.Ref_inst.Bc.GetDB()
+	for root := range self.pending {
+		if rs := localdb.GetRoot(db, &root); rs == nil || rs.Num <= self.frontier.Num {
+			log.Info("Witnesses drops queued root", "root", hexutil.Encode(root[:]))
+			delete(self.pending, root)
+			self.dirty[root] = true
+		}
+	}
+}
+
+// appendLeaf appends a leaf to the frontier and updates the path of every
+// witness of the current tree, only the node of the path the new leaf falls
+// under changes. It returns the witness of the new leaf.
+func (self *Witnesses) appendLeaf(root keys.Uint256, leaf keys.Uint256) *witness {
+	if self.frontier.Size == leafcap {
+		self.frontier.Tree++
+		self.frontier.Size = 0
+		self.frontier.Left = [cpt.DEPTH]keys.Uint256{}
+	}
+	pos := self.frontier.Size
+	added := &witness{Root: root, Tree: self.frontier.Tree, Pos: pos}
+	var nodes [cpt.DEPTH]keys.Uint256
+	current := leaf
+	index := pos
+	for depth := 0; depth < cpt.DEPTH; depth++ {
+		nodes[depth] = current
+		if index%2 == 0 {
+			self.frontier.Left[depth] = current
+			empty := cpt.EmptyRoots()[depth]
+			added.Paths[depth] = empty
+			current = txstate.Combine(&current, &empty)
+		} else {
+			added.Paths[depth] = self.frontier.Left[depth]
+			current = txstate.Combine(&self.frontier.Left[depth], &current)
+		}
+		index >>= 1
+	}
+	self.frontier.Size++
+	self.frontier.Root = current
+	added.Anchor = current
+
+	for root, wit := range self.wits {
+		if wit.Tree != self.frontier.Tree || wit.Pos >= pos {
+			continue
+		}
+		depth := bits.Len64(wit.Pos^pos) - 1
+		wit.Paths[depth] = nodes[depth]
+		wit.Anchor = current
+		self.dirty[root] = true
+	}
+	return added
+}
+
+func (self *Witnesses) flush() error {
+	batch := self.db.NewBatch()
+	for root := range self.dirty {
+		if wit, ok := self.wits[root]; ok {
+			data, err := rlp.EncodeToBytes(wit)
+			if err != nil {
+				return err
+			}
+			batch.Put(witnessKey(&root), data)
+		} else {
+			batch.Delete(witnessKey(&root))
+		}
+		if self.pending[root] {
+			batch.Put(pendingKey(&root), []byte{1})
+		} else {
+			batch.Delete(pendingKey(&root))
+		}
+	}
+	data, err := rlp.EncodeToBytes(&self.frontier)
+	if err != nil {
+		return err
+	}
+	batch.Put(frontierKey, data)
+	if err := batch.Write(); err != nil {
+		return err
+	}
+	self.dirty = make(map[keys.Uint256]bool)
+	return nil
+}
@@ -0,0 +1,108 @@
+package witness
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/sero-cash/go-czero-import/cpt"
+	"github.com/sero-cash/go-czero-import/keys"
+	"github.com/sero-cash/go-sero/serodb"
+	"github.com/sero-cash/go-sero/zero/consensus"
+	"github.com/sero-cash/go-sero/zero/txs/zstate/txstate"
+)
+
+type treeState struct {
+	consensus.FakeTri
+}
+
+func (self *treeState) SetState(key *keys.Uint256, value *keys.Uint256) {
+	self.TryUpdate(key[:], value[:])
+}
+
+func (self *treeState) GetState(key *keys.Uint256) (ret keys.Uint256) {
+	if value, err := self.TryGet(key[:]); err == nil {
+		copy(ret[:], value)
+	}
+	return
+}
+
+func (self *treeState) GlobalGetter() serodb.Getter {
+	return nil
+}
+
+func randLeaf(rnd *rand.Rand) (leaf keys.Uint256) {
+	rnd.Read(leaf[:])
+	return
+}
+
+// checkWitnesses checks the witnesses maintained from the frontier against the
+// paths walked in the tree.
+func checkWitnesses(t *testing.T, ws *Witnesses, tree *txstate.MerkleTree, leaves map[keys.Uint256]keys.Uint256) {
+	t.Helper()
+	if root := tree.CurrentRoot(); ws.frontier.Root != root {
+		t.Fatalf("frontier root mismatch: have %x, want %x", ws.frontier.Root, root)
+	}
+	for root, wit := range ws.wits {
+		pos, paths, anchor := tree.GetPaths(leaves[root])
+		if wit.Pos != pos || wit.Paths != paths || wit.Anchor != anchor {
+			t.Fatalf("witness of leaf %d mismatch: have %d %x %x, want %d %x %x", pos, wit.Pos, wit.Paths, wit.Anchor, pos, paths, anchor)
+		}
+		leaf := leaves[root]
+		if calc := txstate.CalcRoot(&leaf, wit.Pos, &wit.Paths); calc != wit.Anchor {
+			t.Fatalf("witness of leaf %d doesn't lead to its anchor", pos)
+		}
+	}
+}
+
+func TestAppendLeafMatchesTree(t *testing.T) {
+	cpt.ZeroInit("", cpt.NET_Dev)
+	for seed := int64(0); seed < 8; seed++ {
+		rnd := rand.New(rand.NewSource(seed))
+		tree := txstate.NewMerkleTree(&treeState{consensus.NewFakeTri()})
+		leaves := make(map[keys.Uint256]keys.Uint256)
+
+		// Start from the frontier of a tree already holding leaves, some of
+		// them registered
+		ws := &Witnesses{wits: make(map[keys.Uint256]*witness), dirty: make(map[keys.Uint256]bool)}
+		roots := []keys.Uint256{}
+		for i := rnd.Intn(40); i > 0; i-- {
+			leaf := randLeaf(rnd)
+			root := tree.AppendLeaf(leaf)
+			leaves[root] = leaf
+			roots = append(roots, root)
+		}
+		for _, root := range roots {
+			if rnd.Intn(3) == 0 {
+				ws.wits[root] = newWitness(&tree, root, leaves[root])
+			}
+		}
+		ws.frontier.Tree, ws.frontier.Size, ws.frontier.Left = tree.Frontier()
+		ws.frontier.Root = tree.CurrentRoot()
+		checkWitnesses(t, ws, &tree, leaves)
+
+		// Then append to both, registering some of the appended leaves
+		for i := 0; i < 200; i++ {
+			leaf := randLeaf(rnd)
+			root := tree.AppendLeaf(leaf)
+			leaves[root] = leaf
+			wit := ws.appendLeaf(root, leaf)
+			if wit.Anchor != root {
+				t.Fatalf("seed %d: root of leaf %d mismatch: have %x, want %x", seed, i, wit.Anchor, root)
+			}
+			if rnd.Intn(4) == 0 {
+				ws.wits[root] = wit
+			}
+			if rnd.Intn(10) == 0 {
+				checkWitnesses(t, ws, &tree, leaves)
+			}
+		}
+		checkWitnesses(t, ws, &tree, leaves)
+	}
+}
+
+func TestRegisterBounded(t *testing.T) {
+	ws := &Witnesses{wits: make(map[keys.Uint256]*witness), pending: make(map[keys.Uint256]bool)}
+	if err := ws.Register(make([]keys.Uint256, maxRegisterRoots+1)); err == nil {
+		t.Fatalf("registered %d roots at once", maxRegisterRoots+1)
+	}
+}
@@ -0,0 +1,7 @@
+package zconfig
+
+import "path/filepath"
+
+func Pending_dir() string {
+	return filepath.Join(dir, "pending")
+}